@@ -0,0 +1,17 @@
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS image_revision (
+	id TEXT PRIMARY KEY,
+	image_id TEXT NOT NULL,
+	file_name TEXT NOT NULL DEFAULT '',
+	file_path TEXT NOT NULL,
+	backup_path TEXT NOT NULL,
+	width INTEGER,
+	height INTEGER,
+	created_at TIMESTAMP,
+	FOREIGN KEY (image_id) REFERENCES image(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_image_revision_image_id ON image_revision(image_id);
+
+-- +migrate Down
+DROP TABLE IF EXISTS image_revision;
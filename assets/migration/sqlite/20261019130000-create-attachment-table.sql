@@ -0,0 +1,37 @@
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS attachment (
+	id TEXT PRIMARY KEY,
+	site_id TEXT NOT NULL,
+	short_id TEXT,
+	file_name TEXT NOT NULL,
+	file_path TEXT NOT NULL,
+	mime_type TEXT,
+	size_bytes INTEGER DEFAULT 0,
+	title TEXT,
+	created_by TEXT,
+	updated_by TEXT,
+	created_at TIMESTAMP,
+	updated_at TIMESTAMP,
+	FOREIGN KEY (site_id) REFERENCES site(id) ON DELETE CASCADE,
+	UNIQUE(site_id, file_path)
+);
+
+CREATE INDEX IF NOT EXISTS idx_attachment_site_id ON attachment(site_id);
+
+CREATE TABLE IF NOT EXISTS content_attachments (
+	id TEXT PRIMARY KEY,
+	content_id TEXT NOT NULL,
+	attachment_id TEXT NOT NULL,
+	order_num INTEGER DEFAULT 0,
+	created_at TIMESTAMP,
+	FOREIGN KEY (content_id) REFERENCES content(id) ON DELETE CASCADE,
+	FOREIGN KEY (attachment_id) REFERENCES attachment(id) ON DELETE CASCADE,
+	UNIQUE(content_id, attachment_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_content_attachments_content_id ON content_attachments(content_id);
+CREATE INDEX IF NOT EXISTS idx_content_attachments_attachment_id ON content_attachments(attachment_id);
+
+-- +migrate Down
+DROP TABLE IF EXISTS content_attachments;
+DROP TABLE IF EXISTS attachment;
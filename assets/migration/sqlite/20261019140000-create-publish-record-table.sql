@@ -0,0 +1,22 @@
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS publish_record (
+	id TEXT PRIMARY KEY,
+	site_id TEXT NOT NULL,
+	target TEXT NOT NULL DEFAULT '',
+	status TEXT NOT NULL,
+	reference TEXT NOT NULL DEFAULT '',
+	commit_hash TEXT NOT NULL DEFAULT '',
+	summary TEXT NOT NULL DEFAULT '',
+	error TEXT NOT NULL DEFAULT '',
+	duration_ms INTEGER DEFAULT 0,
+	snapshot_path TEXT NOT NULL DEFAULT '',
+	rollback_of TEXT NOT NULL DEFAULT '',
+	published_by TEXT NOT NULL DEFAULT '',
+	created_at TIMESTAMP,
+	FOREIGN KEY (site_id) REFERENCES site(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_publish_record_site_id ON publish_record(site_id);
+
+-- +migrate Down
+DROP TABLE IF EXISTS publish_record;
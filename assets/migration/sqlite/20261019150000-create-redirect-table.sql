@@ -0,0 +1,17 @@
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS redirect (
+	id TEXT PRIMARY KEY,
+	site_id TEXT NOT NULL,
+	from_path TEXT NOT NULL,
+	content_id TEXT NOT NULL DEFAULT '',
+	to_path TEXT NOT NULL DEFAULT '',
+	source TEXT NOT NULL DEFAULT '',
+	created_at TIMESTAMP,
+	FOREIGN KEY (site_id) REFERENCES site(id) ON DELETE CASCADE,
+	UNIQUE(site_id, from_path)
+);
+
+CREATE INDEX IF NOT EXISTS idx_redirect_site_id ON redirect(site_id);
+
+-- +migrate Down
+DROP TABLE IF EXISTS redirect;
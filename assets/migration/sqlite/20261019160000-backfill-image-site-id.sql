@@ -0,0 +1,37 @@
+-- +migrate Up
+-- Images uploaded before they were given the site of the upload belong to the
+-- site of the first content, or else section, that links them. Images whose
+-- path that site already uses are left as they are.
+UPDATE image
+SET site_id = (
+	SELECT c.site_id FROM content_images ci JOIN content c ON c.id = ci.content_id
+	WHERE ci.image_id = image.id
+		AND NOT EXISTS (SELECT 1 FROM image o WHERE o.site_id = c.site_id AND o.file_path = image.file_path)
+	ORDER BY ci.created_at
+	LIMIT 1
+)
+WHERE site_id IN ('', '00000000-0000-0000-0000-000000000000')
+	AND EXISTS (
+		SELECT 1 FROM content_images ci JOIN content c ON c.id = ci.content_id
+		WHERE ci.image_id = image.id
+			AND NOT EXISTS (SELECT 1 FROM image o WHERE o.site_id = c.site_id AND o.file_path = image.file_path)
+	);
+
+UPDATE image
+SET site_id = (
+	SELECT s.site_id FROM section_images si JOIN section s ON s.id = si.section_id
+	WHERE si.image_id = image.id
+		AND NOT EXISTS (SELECT 1 FROM image o WHERE o.site_id = s.site_id AND o.file_path = image.file_path)
+	ORDER BY si.created_at
+	LIMIT 1
+)
+WHERE site_id IN ('', '00000000-0000-0000-0000-000000000000')
+	AND EXISTS (
+		SELECT 1 FROM section_images si JOIN section s ON s.id = si.section_id
+		WHERE si.image_id = image.id
+			AND NOT EXISTS (SELECT 1 FROM image o WHERE o.site_id = s.site_id AND o.file_path = image.file_path)
+	);
+
+-- +migrate Down
+-- The images are not given back an empty site.
+SELECT 1;
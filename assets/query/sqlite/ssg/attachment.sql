@@ -0,0 +1,33 @@
+-- Res: ssg
+-- Table: attachment
+-- Create
+INSERT INTO attachment (id, site_id, short_id, file_name, file_path, mime_type, size_bytes, title, created_by, updated_by, created_at, updated_at)
+VALUES (:id, :site_id, :short_id, :file_name, :file_path, :mime_type, :size_bytes, :title, :created_by, :updated_by, :created_at, :updated_at);
+
+-- Res: ssg
+-- Table: attachment
+-- Get
+SELECT id, site_id, short_id, file_name, file_path, mime_type, size_bytes, title, created_by, updated_by, created_at, updated_at
+FROM attachment
+WHERE id = ?;
+
+-- Res: ssg
+-- Table: attachment
+-- Update
+UPDATE attachment
+SET file_name = :file_name, file_path = :file_path, mime_type = :mime_type, size_bytes = :size_bytes, title = :title, updated_by = :updated_by, updated_at = :updated_at
+WHERE id = :id;
+
+-- Res: ssg
+-- Table: attachment
+-- Delete
+DELETE FROM attachment
+WHERE id = ?;
+
+-- Res: ssg
+-- Table: attachment
+-- List
+SELECT id, site_id, short_id, file_name, file_path, mime_type, size_bytes, title, created_by, updated_by, created_at, updated_at
+FROM attachment
+WHERE site_id = ?
+ORDER BY created_at DESC;
@@ -18,10 +18,14 @@ SELECT id, user_id, section_id, heading, body, draft, featured, published_at, sh
 UPDATE content SET
     user_id = :user_id,
     section_id = :section_id,
+    kind = :kind,
     heading = :heading,
+    summary = :summary,
     body = :body,
     draft = :draft,
     featured = :featured,
+    series = :series,
+    series_order = :series_order,
     published_at = :published_at,
     updated_by = :updated_by,
     updated_at = :updated_at
@@ -33,6 +37,7 @@ DELETE FROM content WHERE id = :id;
 -- GetAllContentWithMeta
 SELECT
     c.id, c.user_id, c.section_id, c.kind, c.heading, c.body, c.draft, c.featured, c.published_at, c.short_id,
+    COALESCE(c.summary, '') AS summary, COALESCE(c.series, '') AS series, COALESCE(c.series_order, 0) AS series_order,
     c.created_by, c.updated_by, c.created_at, c.updated_at,
     COALESCE(s.path, '') AS section_path, COALESCE(s.name, '') AS section_name,
     COALESCE(m.id, '') AS meta_id, COALESCE(m.description, '') AS description, COALESCE(m.keywords, '') AS keywords,
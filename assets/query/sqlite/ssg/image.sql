@@ -43,3 +43,51 @@ WHERE id = ?;
 -- List
 SELECT id, site_id, short_id, file_name, file_path, alt_text, title, width, height, created_by, updated_by, created_at, updated_at
 FROM image;
+
+-- Res: ssg
+-- Table: image
+-- ListSiteImages
+SELECT i.id, i.site_id, i.short_id, i.file_name, i.file_path, i.alt_text, i.title, i.width, i.height, i.created_by, i.updated_by, i.created_at, i.updated_at
+FROM image i
+WHERE
+    (i.site_id = ?1
+        OR EXISTS (SELECT 1 FROM content_images ci JOIN content c ON c.id = ci.content_id WHERE ci.image_id = i.id AND c.site_id = ?1)
+        OR EXISTS (SELECT 1 FROM section_images si JOIN section s ON s.id = si.section_id WHERE si.image_id = i.id AND s.site_id = ?1))
+    AND (?2 = ''
+        OR i.file_name LIKE '%' || ?2 || '%'
+        OR i.file_path LIKE '%' || ?2 || '%'
+        OR i.title LIKE '%' || ?2 || '%'
+        OR i.alt_text LIKE '%' || ?2 || '%'
+        OR EXISTS (
+            SELECT 1 FROM content c
+            WHERE c.site_id = ?1 AND c.heading LIKE '%' || ?2 || '%'
+                AND (c.body LIKE '%/static/images/' || i.file_path || '%'
+                    OR EXISTS (SELECT 1 FROM content_images ci WHERE ci.content_id = c.id AND ci.image_id = i.id)))
+        OR EXISTS (
+            SELECT 1 FROM section_images si JOIN section s ON s.id = si.section_id
+            WHERE si.image_id = i.id AND s.site_id = ?1 AND s.name LIKE '%' || ?2 || '%'))
+ORDER BY i.created_at DESC, i.id ASC
+LIMIT ?3 OFFSET ?4;
+
+-- Res: ssg
+-- Table: image
+-- CountSiteImages
+SELECT COUNT(*)
+FROM image i
+WHERE
+    (i.site_id = ?1
+        OR EXISTS (SELECT 1 FROM content_images ci JOIN content c ON c.id = ci.content_id WHERE ci.image_id = i.id AND c.site_id = ?1)
+        OR EXISTS (SELECT 1 FROM section_images si JOIN section s ON s.id = si.section_id WHERE si.image_id = i.id AND s.site_id = ?1))
+    AND (?2 = ''
+        OR i.file_name LIKE '%' || ?2 || '%'
+        OR i.file_path LIKE '%' || ?2 || '%'
+        OR i.title LIKE '%' || ?2 || '%'
+        OR i.alt_text LIKE '%' || ?2 || '%'
+        OR EXISTS (
+            SELECT 1 FROM content c
+            WHERE c.site_id = ?1 AND c.heading LIKE '%' || ?2 || '%'
+                AND (c.body LIKE '%/static/images/' || i.file_path || '%'
+                    OR EXISTS (SELECT 1 FROM content_images ci WHERE ci.content_id = c.id AND ci.image_id = i.id)))
+        OR EXISTS (
+            SELECT 1 FROM section_images si JOIN section s ON s.id = si.section_id
+            WHERE si.image_id = i.id AND s.site_id = ?1 AND s.name LIKE '%' || ?2 || '%'));
@@ -16,26 +16,27 @@ WHERE id = ?;
 -- GetByName
 SELECT id, site_id, name, description, value, ref_key, system, created_by, updated_by, created_at, updated_at
 FROM param
-WHERE name = ?;
+WHERE site_id = ? AND name = ?;
 
 -- Res: ssg
 -- Table: param
 -- GetByRefKey
 SELECT id, site_id, name, description, value, ref_key, system, created_by, updated_by, created_at, updated_at
 FROM param
-WHERE ref_key = ?;
+WHERE site_id = ? AND ref_key = ?;
 
 -- Res: ssg
 -- Table: param
 -- List
 SELECT id, site_id, name, description, value, ref_key, system, created_by, updated_by, created_at, updated_at
-FROM param;
+FROM param
+WHERE site_id = ?;
 
 -- Res: ssg
 -- Table: param
 -- Update
 UPDATE param
-SET site_id = :site_id, name = :name, description = :description, value = :value, ref_key = :ref_key, updated_by = :updated_by, updated_at = :updated_at
+SET name = :name, description = :description, value = :value, ref_key = :ref_key, updated_by = :updated_by, updated_at = :updated_at
 WHERE id = :id;
 
 -- Res: ssg
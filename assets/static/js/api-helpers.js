@@ -89,3 +89,45 @@ function getSiteSlug() {
 function getAPIBaseURL() {
   return 'http://localhost:8081/api/v1';
 }
+
+/**
+ * Run a background job and follow its progress
+ * Starts the job through the jobs API and listens to its event stream.
+ * EventSource cannot send headers, so the site goes in the query string.
+ * @param {string} kind - Job kind: generate-markdown, generate-html, plan or publish
+ * @param {Object} params - Extra job parameters, e.g. { message: '...' }
+ * @param {function(string)} onLog - Called with each progress message
+ * @returns {Promise<Object>} Resolves with the finished job event, rejects if the job fails
+ */
+async function runJob(kind, params = {}, onLog = () => {}) {
+  const response = await apiFetch(`${getAPIBaseURL()}/ssg/jobs`, {
+    method: 'POST',
+    headers: { 'Content-Type': 'application/json' },
+    body: JSON.stringify({ kind, ...params })
+  });
+  const body = await response.json();
+  if (!response.ok) {
+    throw new Error(body.error || body.message || `Server error (${response.status})`);
+  }
+
+  const jobID = body.data.job.id;
+  const url = `${getAPIBaseURL()}/ssg/jobs/${jobID}/events?site=${encodeURIComponent(getSiteSlug())}`;
+
+  return new Promise((resolve, reject) => {
+    const source = new EventSource(url);
+    source.addEventListener('log', (e) => onLog(JSON.parse(e.data).message));
+    source.addEventListener('done', (e) => {
+      source.close();
+      const done = JSON.parse(e.data);
+      if (done.status === 'failed') {
+        reject(new Error(done.error));
+        return;
+      }
+      resolve(done);
+    });
+    source.onerror = () => {
+      source.close();
+      reject(new Error('Lost connection to the job progress stream'));
+    };
+  });
+}
@@ -26,6 +26,15 @@ func (s *AdminFileServer) Setup(ctx context.Context) error {
 }
 
 func (s *AdminFileServer) Handler() http.HandlerFunc {
+	return s.siteFileHandler("/static/images/", ssg.GetSiteImagesPath)
+}
+
+// AttachmentsHandler serves the attachments of the current site.
+func (s *AdminFileServer) AttachmentsHandler() http.HandlerFunc {
+	return s.siteFileHandler("/static/attachments/", ssg.GetSiteAttachmentsPath)
+}
+
+func (s *AdminFileServer) siteFileHandler(prefix string, sitePath func(sitesBasePath, siteSlug string) string) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		siteSlug, ok := ssg.GetSiteSlugFromContext(r.Context())
 		if !ok || siteSlug == "" {
@@ -33,10 +42,10 @@ func (s *AdminFileServer) Handler() http.HandlerFunc {
 		}
 
 		sitesBasePath := s.Cfg().StrValOrDef(ssg.SSGKey.SitesBasePath, "_workspace/sites")
-		imagesPath := ssg.GetSiteImagesPath(sitesBasePath, siteSlug)
+		basePath := sitePath(sitesBasePath, siteSlug)
 
-		requestPath := strings.TrimPrefix(r.URL.Path, "/static/images/")
-		fullPath := filepath.Join(imagesPath, requestPath)
+		requestPath := strings.TrimPrefix(r.URL.Path, prefix)
+		fullPath := filepath.Join(basePath, requestPath)
 
 		http.ServeFile(w, r, fullPath)
 	}
@@ -10,7 +10,7 @@ import (
 type SSGPublisher struct {
 	// Expected results
 	ValidateFn func(cfg ssg.PublisherConfig) error
-	PublishFn  func(ctx context.Context, cfg ssg.PublisherConfig, sourceDir string) (string, error)
+	PublishFn  func(ctx context.Context, cfg ssg.PublisherConfig, sourceDir string) (string, ssg.PlanReport, error)
 	PlanFn     func(ctx context.Context, cfg ssg.PublisherConfig, sourceDir string) (ssg.PlanReport, error)
 
 	// Captured arguments
@@ -40,7 +40,7 @@ func (f *SSGPublisher) Validate(cfg ssg.PublisherConfig) error {
 	return nil
 }
 
-func (f *SSGPublisher) Publish(ctx context.Context, cfg ssg.PublisherConfig, sourceDir string) (string, error) {
+func (f *SSGPublisher) Publish(ctx context.Context, cfg ssg.PublisherConfig, sourceDir string) (string, ssg.PlanReport, error) {
 	f.PublishCalls = append(f.PublishCalls, struct {
 		Ctx       context.Context
 		Cfg       ssg.PublisherConfig
@@ -49,7 +49,7 @@ func (f *SSGPublisher) Publish(ctx context.Context, cfg ssg.PublisherConfig, sou
 	if f.PublishFn != nil {
 		return f.PublishFn(ctx, cfg, sourceDir)
 	}
-	return "fake-commit-url", nil
+	return "fake-commit-url", ssg.PlanReport{Summary: "fake publish"}, nil
 }
 
 func (f *SSGPublisher) Plan(ctx context.Context, cfg ssg.PublisherConfig, sourceDir string) (ssg.PlanReport, error) {
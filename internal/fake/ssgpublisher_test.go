@@ -33,8 +33,8 @@ func TestSSGPublisherPublish(t *testing.T) {
 		{
 			name: "publish returns error",
 			setupFake: func(f *fake.SSGPublisher) {
-				f.PublishFn = func(ctx context.Context, cfg ssg.PublisherConfig, sourceDir string) (string, error) {
-					return "", errors.New("publish failed")
+				f.PublishFn = func(ctx context.Context, cfg ssg.PublisherConfig, sourceDir string) (string, ssg.PlanReport, error) {
+					return "", ssg.PlanReport{}, errors.New("publish failed")
 				}
 			},
 			ctx:         context.Background(),
@@ -51,7 +51,7 @@ func TestSSGPublisherPublish(t *testing.T) {
 			f := fake.NewSSGPublisher()
 			tt.setupFake(f)
 
-			url, err := f.Publish(tt.ctx, tt.cfg, tt.sourceDir)
+			url, _, err := f.Publish(tt.ctx, tt.cfg, tt.sourceDir)
 
 			if tt.expectedErr != nil {
 				if err == nil || err.Error() != tt.expectedErr.Error() {
@@ -2,6 +2,7 @@ package ssg
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -17,19 +18,25 @@ const (
 	resParamName        = "param"
 	resImageName        = "image"
 	resImageVariantName = "image variant"
+	resAttachmentName   = "attachment"
+	resRedirectName     = "redirect"
 )
 
+const errCodeQualityGate = "QUALITY_GATE"
+
 type APIHandler struct {
 	*hm.APIHandler
 	svc         Service
 	siteManager *SiteManager
+	jobs        *JobRunner
 }
 
-func NewAPIHandler(name string, service Service, siteManager *SiteManager, params hm.XParams) *APIHandler {
+func NewAPIHandler(name string, service Service, siteManager *SiteManager, jobs *JobRunner, params hm.XParams) *APIHandler {
 	return &APIHandler{
 		APIHandler:  hm.NewAPIHandler(name, params),
 		svc:         service,
 		siteManager: siteManager,
+		jobs:        jobs,
 	}
 }
 
@@ -62,6 +69,8 @@ func (h *APIHandler) wrapData(data interface{}) interface{} {
 		return map[string]interface{}{"image": v}
 	case ImageVariant:
 		return map[string]interface{}{"image_variant": v}
+	case Attachment:
+		return map[string]interface{}{"attachment": v}
 
 	// Slices of entities
 	case []Site:
@@ -80,6 +89,8 @@ func (h *APIHandler) wrapData(data interface{}) interface{} {
 		return map[string]interface{}{"images": v}
 	case []ImageVariant:
 		return map[string]interface{}{"image_variants": v}
+	case []Attachment:
+		return map[string]interface{}{"attachments": v}
 
 	// Default case for nil, maps, or other types
 	default:
@@ -106,7 +117,12 @@ func (h *APIHandler) Publish(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Run the publish process
-	commitURL, err := h.svc.Publish(r.Context(), data.Message)
+	commitURL, err := h.svc.Publish(r.Context(), data.Message, data.Force)
+	var gateErr *QualityGateError
+	if errors.As(err, &gateErr) {
+		h.qualityGateErr(w, gateErr)
+		return
+	}
 	if err != nil {
 		msg := fmt.Sprintf("Cannot publish: %v", err)
 		h.Err(w, http.StatusInternalServerError, msg, err)
@@ -118,6 +134,76 @@ func (h *APIHandler) Publish(w http.ResponseWriter, r *http.Request) {
 	h.OK(w, msg, result)
 }
 
+// PlanPublish returns the changes a publish would make without making them.
+func (h *APIHandler) PlanPublish(w http.ResponseWriter, r *http.Request) {
+	h.Log().Debugf("%s: Handling PlanPublish", h.Name())
+
+	report, err := h.svc.Plan(r.Context())
+	if err != nil {
+		msg := fmt.Sprintf("Cannot plan publish: %v", err)
+		h.Err(w, http.StatusInternalServerError, msg, err)
+		return
+	}
+
+	h.OK(w, "Publish plan created successfully", map[string]interface{}{"plan": report})
+}
+
+// CheckQuality runs the pre-publish quality checks on the generated site.
+func (h *APIHandler) CheckQuality(w http.ResponseWriter, r *http.Request) {
+	h.Log().Debugf("%s: Handling CheckQuality", h.Name())
+
+	report, err := h.svc.CheckQuality(r.Context())
+	if err != nil {
+		msg := fmt.Sprintf("Cannot check quality: %v", err)
+		h.Err(w, http.StatusInternalServerError, msg, err)
+		return
+	}
+
+	h.OK(w, "Quality checks completed", map[string]interface{}{"report": report})
+}
+
+// qualityGateErr responds to a publish blocked by the quality gate with the
+// report, so the caller can show the issues and decide to force it.
+func (h *APIHandler) qualityGateErr(w http.ResponseWriter, err *QualityGateError) {
+	h.Log().Info("Publish blocked by quality checks", "summary", err.Report.Summary)
+	res := hm.NewErrorResponse(err.Error(), errCodeQualityGate, err.Report.Summary)
+	res.Data = map[string]interface{}{"report": err.Report}
+	hm.Respond(w, http.StatusUnprocessableEntity, res)
+}
+
+// ListPublishRecords returns the publish history of the site.
+func (h *APIHandler) ListPublishRecords(w http.ResponseWriter, r *http.Request) {
+	h.Log().Debugf("%s: Handling ListPublishRecords", h.Name())
+
+	records, err := h.svc.ListPublishRecords(r.Context())
+	if err != nil {
+		h.Err(w, http.StatusInternalServerError, "Cannot get publish history", err)
+		return
+	}
+
+	h.OK(w, "Publish history retrieved", map[string]interface{}{"records": records})
+}
+
+// RollbackPublish publishes again the output of a previous publish.
+func (h *APIHandler) RollbackPublish(w http.ResponseWriter, r *http.Request) {
+	h.Log().Debugf("%s: Handling RollbackPublish", h.Name())
+
+	id, err := h.ID(w, r)
+	if err != nil {
+		h.Err(w, http.StatusBadRequest, "Invalid publish record ID", err)
+		return
+	}
+
+	record, err := h.svc.RollbackPublish(r.Context(), id)
+	if err != nil {
+		msg := fmt.Sprintf("Cannot roll back publish: %v", err)
+		h.Err(w, http.StatusInternalServerError, msg, err)
+		return
+	}
+
+	h.OK(w, "Publish rolled back successfully", map[string]interface{}{"record": record})
+}
+
 func (h *APIHandler) GenerateMarkdown(w http.ResponseWriter, r *http.Request) {
 	h.Log().Debugf("%s: Handling GenerateMarkdown", h.Name())
 
@@ -133,6 +219,122 @@ func (h *APIHandler) GenerateMarkdown(w http.ResponseWriter, r *http.Request) {
 	h.OK(w, msg, nil)
 }
 
+// ImportMarkdown imports a directory of Markdown files with front matter into
+// the site and returns the import report.
+func (h *APIHandler) ImportMarkdown(w http.ResponseWriter, r *http.Request) {
+	h.Log().Debugf("%s: Handling ImportMarkdown", h.Name())
+
+	var req ImportMarkdownRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		h.Err(w, http.StatusBadRequest, hm.ErrInvalidBody, err)
+		return
+	}
+
+	report, err := h.svc.ImportMarkdown(r.Context(), req.Dir)
+	if err != nil {
+		msg := fmt.Sprintf("Cannot import markdown: %v", err)
+		h.Err(w, http.StatusInternalServerError, msg, err)
+		return
+	}
+
+	h.OK(w, "Markdown imported", map[string]interface{}{"report": report})
+}
+
+// MigrateSite imports the contents and images of a Hugo or Jekyll site into
+// the site and returns the import report.
+func (h *APIHandler) MigrateSite(w http.ResponseWriter, r *http.Request) {
+	h.Log().Debugf("%s: Handling MigrateSite", h.Name())
+
+	var req MigrateSiteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.Err(w, http.StatusBadRequest, hm.ErrInvalidBody, err)
+		return
+	}
+	if req.Dir == "" {
+		h.Err(w, http.StatusBadRequest, "Dir is required", nil)
+		return
+	}
+	if req.Source != "" && req.Source != MigrateHugo && req.Source != MigrateJekyll {
+		msg := fmt.Sprintf("Source must be %q or %q", MigrateHugo, MigrateJekyll)
+		h.Err(w, http.StatusBadRequest, msg, nil)
+		return
+	}
+
+	report, err := h.svc.MigrateSite(r.Context(), req.Dir, req.Source)
+	if err != nil {
+		msg := fmt.Sprintf("Cannot migrate site: %v", err)
+		h.Err(w, http.StatusInternalServerError, msg, err)
+		return
+	}
+
+	h.OK(w, "Site migrated", map[string]interface{}{"report": report})
+}
+
+// ImportWordPress imports the posts and pages of a WordPress export file into
+// the site and returns the import report.
+func (h *APIHandler) ImportWordPress(w http.ResponseWriter, r *http.Request) {
+	h.Log().Debugf("%s: Handling ImportWordPress", h.Name())
+
+	var req ImportWordPressRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.Err(w, http.StatusBadRequest, hm.ErrInvalidBody, err)
+		return
+	}
+	if req.File == "" {
+		h.Err(w, http.StatusBadRequest, "File is required", nil)
+		return
+	}
+
+	report, err := h.svc.ImportWordPress(r.Context(), req.File, req.UploadsDir, req.CategoriesAs)
+	if err != nil {
+		msg := fmt.Sprintf("Cannot import WordPress export: %v", err)
+		h.Err(w, http.StatusInternalServerError, msg, err)
+		return
+	}
+
+	h.OK(w, "WordPress export imported", map[string]interface{}{"report": report})
+}
+
+// WatchStatus returns the watched drafts status of the site.
+func (h *APIHandler) WatchStatus(w http.ResponseWriter, r *http.Request) {
+	h.Log().Debugf("%s: Handling WatchStatus", h.Name())
+
+	status, err := h.svc.WatchStatus(r.Context())
+	if err != nil {
+		msg := fmt.Sprintf("Cannot get watch status: %v", err)
+		h.Err(w, http.StatusInternalServerError, msg, err)
+		return
+	}
+
+	h.OK(w, "Watch status retrieved", map[string]interface{}{"status": status})
+}
+
+// ResolveWatchConflict keeps either the watched file or the admin version of
+// a content in conflict.
+func (h *APIHandler) ResolveWatchConflict(w http.ResponseWriter, r *http.Request) {
+	h.Log().Debugf("%s: Handling ResolveWatchConflict", h.Name())
+
+	var req ResolveWatchConflictRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.Err(w, http.StatusBadRequest, hm.ErrInvalidBody, err)
+		return
+	}
+	if req.Path == "" || (req.Keep != WatchKeepFile && req.Keep != WatchKeepAdmin) {
+		msg := fmt.Sprintf("Path and keep (%q or %q) are required", WatchKeepFile, WatchKeepAdmin)
+		h.Err(w, http.StatusBadRequest, msg, nil)
+		return
+	}
+
+	file, err := h.svc.ResolveWatchConflict(r.Context(), req.Path, req.Keep)
+	if err != nil {
+		msg := fmt.Sprintf("Cannot resolve watch conflict: %v", err)
+		h.Err(w, http.StatusInternalServerError, msg, err)
+		return
+	}
+
+	h.OK(w, "Watch conflict resolved", map[string]interface{}{"file": file})
+}
+
 func (h *APIHandler) GenerateHTML(w http.ResponseWriter, r *http.Request) {
 	h.Log().Debugf("%s: Handling GenerateHTML", h.Name())
 
@@ -148,9 +350,36 @@ func (h *APIHandler) GenerateHTML(w http.ResponseWriter, r *http.Request) {
 	h.OK(w, msg, nil)
 }
 
-// PublishRequest represents the data for a publish request.
+// PublishRequest represents the data for a publish request. Force publishes
+// even if quality checks report errors.
 type PublishRequest struct {
 	Message string `json:"message"`
+	Force   bool   `json:"force"`
+}
+
+// ImportMarkdownRequest represents a request to import Markdown files.
+type ImportMarkdownRequest struct {
+	Dir string `json:"dir"` // Defaults to the Markdown export of the site
+}
+
+// MigrateSiteRequest represents a request to migrate a Hugo or Jekyll site.
+type MigrateSiteRequest struct {
+	Dir    string `json:"dir"`
+	Source string `json:"source"` // MigrateHugo or MigrateJekyll, detected if empty
+}
+
+// ImportWordPressRequest represents a request to import a WordPress export.
+type ImportWordPressRequest struct {
+	File         string `json:"file"`
+	UploadsDir   string `json:"uploads_dir"`
+	CategoriesAs string `json:"categories_as"` // WordPressCategoriesAsTags or WordPressCategoriesAsSections
+}
+
+// ResolveWatchConflictRequest represents a request to resolve a conflict of
+// the watched drafts directory.
+type ResolveWatchConflictRequest struct {
+	Path string `json:"path"`
+	Keep string `json:"keep"` // WatchKeepFile or WatchKeepAdmin
 }
 
 // AddTagToContentForm represents the data for adding a tag to content.
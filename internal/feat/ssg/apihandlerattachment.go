@@ -0,0 +1,215 @@
+package ssg
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/hermesgen/hm"
+)
+
+// AddAttachmentRequest represents a request to link an existing attachment to a content.
+type AddAttachmentRequest struct {
+	AttachmentID uuid.UUID `json:"attachment_id"`
+}
+
+// ListAttachments returns the attachments of the current site.
+func (h *APIHandler) ListAttachments(w http.ResponseWriter, r *http.Request) {
+	h.Log().Debugf("%s: Handling ListAttachments", h.Name())
+
+	attachments, err := h.svc.ListAttachments(r.Context())
+	if err != nil {
+		msg := fmt.Sprintf(hm.ErrCannotGetResources, resAttachmentName)
+		h.Err(w, http.StatusInternalServerError, msg, err)
+		return
+	}
+
+	msg := fmt.Sprintf(hm.MsgGetAllItems, hm.Cap(resAttachmentName))
+	h.OK(w, msg, attachments)
+}
+
+// UploadAttachment stores an uploaded file as a site attachment.
+func (h *APIHandler) UploadAttachment(w http.ResponseWriter, r *http.Request) {
+	h.Log().Debugf("%s: Handling UploadAttachment", h.Name())
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		h.Err(w, http.StatusBadRequest, "Failed to parse uploaded file", err)
+		return
+	}
+	defer file.Close()
+
+	attachment, err := h.svc.UploadAttachment(r.Context(), file, header, r.FormValue("title"))
+	if err != nil {
+		h.Err(w, http.StatusInternalServerError, "Failed to upload attachment", err)
+		return
+	}
+
+	msg := fmt.Sprintf("Attachment uploaded successfully: %s", attachment.FileName)
+	h.Created(w, msg, attachment)
+}
+
+// GetAttachment returns a single attachment.
+func (h *APIHandler) GetAttachment(w http.ResponseWriter, r *http.Request) {
+	h.Log().Debugf("%s: Handling GetAttachment", h.Name())
+
+	id, err := h.ID(w, r)
+	if err != nil {
+		msg := fmt.Sprintf(hm.ErrInvalidID, hm.Cap(resAttachmentName))
+		h.Err(w, http.StatusBadRequest, msg, err)
+		return
+	}
+
+	attachment, err := h.svc.GetAttachment(r.Context(), id)
+	if err != nil {
+		msg := fmt.Sprintf(hm.ErrCannotGetResource, resAttachmentName)
+		h.Err(w, http.StatusInternalServerError, msg, err)
+		return
+	}
+
+	msg := fmt.Sprintf(hm.MsgGetItem, hm.Cap(resAttachmentName))
+	h.OK(w, msg, attachment)
+}
+
+// DeleteAttachment removes an attachment and its file.
+func (h *APIHandler) DeleteAttachment(w http.ResponseWriter, r *http.Request) {
+	h.Log().Debugf("%s: Handling DeleteAttachment", h.Name())
+
+	id, err := h.ID(w, r)
+	if err != nil {
+		msg := fmt.Sprintf(hm.ErrInvalidID, hm.Cap(resAttachmentName))
+		h.Err(w, http.StatusBadRequest, msg, err)
+		return
+	}
+
+	err = h.svc.DeleteAttachment(r.Context(), id)
+	if err != nil {
+		msg := fmt.Sprintf(hm.ErrCannotDeleteResource, resAttachmentName)
+		h.Err(w, http.StatusInternalServerError, msg, err)
+		return
+	}
+
+	msg := fmt.Sprintf(hm.MsgDeleteItem, hm.Cap(resAttachmentName))
+	h.OK(w, msg, json.RawMessage("null"))
+}
+
+// GetContentAttachments returns the attachments linked to a content.
+func (h *APIHandler) GetContentAttachments(w http.ResponseWriter, r *http.Request) {
+	h.Log().Debugf("%s: Handling GetContentAttachments", h.Name())
+
+	contentID, ok := h.contentIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	attachments, err := h.svc.GetContentAttachments(r.Context(), contentID)
+	if err != nil {
+		msg := fmt.Sprintf(hm.ErrCannotGetResources, resAttachmentName)
+		h.Err(w, http.StatusInternalServerError, msg, err)
+		return
+	}
+
+	msg := fmt.Sprintf(hm.MsgGetAllItems, hm.Cap(resAttachmentName))
+	h.OK(w, msg, attachments)
+}
+
+// AddAttachmentToContent links an attachment to a content and returns the
+// Markdown link to insert in its body. It accepts either a JSON body with an
+// existing attachment ID or a multipart upload of a new file.
+func (h *APIHandler) AddAttachmentToContent(w http.ResponseWriter, r *http.Request) {
+	h.Log().Debugf("%s: Handling AddAttachmentToContent", h.Name())
+
+	contentID, ok := h.contentIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	var attachmentID uuid.UUID
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			h.Err(w, http.StatusBadRequest, "Failed to parse uploaded file", err)
+			return
+		}
+		defer file.Close()
+
+		attachment, err := h.svc.UploadAttachment(r.Context(), file, header, r.FormValue("title"))
+		if err != nil {
+			h.Err(w, http.StatusInternalServerError, "Failed to upload attachment", err)
+			return
+		}
+		attachmentID = attachment.ID
+	} else {
+		var req AddAttachmentRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			h.Err(w, http.StatusBadRequest, hm.ErrInvalidBody, err)
+			return
+		}
+		attachmentID = req.AttachmentID
+	}
+
+	if attachmentID == uuid.Nil {
+		h.Err(w, http.StatusBadRequest, "Missing attachment_id", nil)
+		return
+	}
+
+	markdown, err := h.svc.AddAttachmentToContent(r.Context(), contentID, attachmentID)
+	if err != nil {
+		h.Err(w, http.StatusInternalServerError, "Failed to add attachment to content", err)
+		return
+	}
+
+	h.OK(w, "Attachment added to content", map[string]interface{}{
+		"attachment_id": attachmentID,
+		"markdown":      markdown,
+	})
+}
+
+// RemoveAttachmentFromContent unlinks an attachment from a content.
+func (h *APIHandler) RemoveAttachmentFromContent(w http.ResponseWriter, r *http.Request) {
+	h.Log().Debugf("%s: Handling RemoveAttachmentFromContent", h.Name())
+
+	contentID, ok := h.contentIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	attachmentIDStr, err := h.Param(w, r, "attachment_id")
+	if err != nil {
+		h.Err(w, http.StatusBadRequest, "Invalid attachment ID", err)
+		return
+	}
+
+	attachmentID, err := uuid.Parse(attachmentIDStr)
+	if err != nil {
+		h.Err(w, http.StatusBadRequest, "Invalid attachment ID format", err)
+		return
+	}
+
+	err = h.svc.RemoveAttachmentFromContent(r.Context(), contentID, attachmentID)
+	if err != nil {
+		h.Err(w, http.StatusInternalServerError, "Failed to remove attachment from content", err)
+		return
+	}
+
+	h.OK(w, "Attachment removed from content", json.RawMessage("null"))
+}
+
+// contentIDParam reads the content_id URL parameter, writing the error response when invalid.
+func (h *APIHandler) contentIDParam(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	contentIDStr, err := h.Param(w, r, "content_id")
+	if err != nil {
+		h.Err(w, http.StatusBadRequest, "Invalid content ID", err)
+		return uuid.Nil, false
+	}
+
+	contentID, err := uuid.Parse(contentIDStr)
+	if err != nil {
+		h.Err(w, http.StatusBadRequest, "Invalid content ID format", err)
+		return uuid.Nil, false
+	}
+
+	return contentID, true
+}
@@ -335,6 +335,7 @@ func (h *APIHandler) UploadContentImage(w http.ResponseWriter, r *http.Request)
 		"filename":      result.Filename,
 		"relative_path": result.RelativePath,
 		"metadata":      result.Metadata,
+		"stripped":      result.Stripped,
 	})
 }
 
@@ -330,3 +330,117 @@ func (h *APIHandler) DeleteImageVariant(w http.ResponseWriter, r *http.Request)
 	msg := fmt.Sprintf(hm.MsgDeleteItem, hm.Cap(resImageVariantName))
 	h.OK(w, msg, json.RawMessage("null"))
 }
+
+// PurgeOrphanedImagesRequest represents the body of an orphaned images purge request.
+type PurgeOrphanedImagesRequest struct {
+	Confirm bool `json:"confirm"`
+}
+
+// GetOrphanedImages reports orphaned image files and records without purging them (dry run).
+func (h *APIHandler) GetOrphanedImages(w http.ResponseWriter, r *http.Request) {
+	h.Log().Debugf("%s: Handling GetOrphanedImages", h.Name())
+
+	report, err := h.svc.CleanupOrphanedImages(r.Context(), false)
+	if err != nil {
+		h.Err(w, http.StatusInternalServerError, "Cannot detect orphaned images", err)
+		return
+	}
+
+	msg := fmt.Sprintf("Found %d orphan files and %d images without file", len(report.OrphanFiles), len(report.MissingFiles))
+	h.OK(w, msg, map[string]interface{}{"report": report})
+}
+
+// PurgeOrphanedImages removes orphaned image files and records once confirmed.
+func (h *APIHandler) PurgeOrphanedImages(w http.ResponseWriter, r *http.Request) {
+	h.Log().Debugf("%s: Handling PurgeOrphanedImages", h.Name())
+
+	var req PurgeOrphanedImagesRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		h.Err(w, http.StatusBadRequest, hm.ErrInvalidBody, err)
+		return
+	}
+
+	if !req.Confirm {
+		h.Err(w, http.StatusBadRequest, "Purge must be confirmed", nil)
+		return
+	}
+
+	report, err := h.svc.CleanupOrphanedImages(r.Context(), true)
+	if err != nil {
+		h.Err(w, http.StatusInternalServerError, "Cannot purge orphaned images", err)
+		return
+	}
+
+	msg := fmt.Sprintf("Removed %d orphan files and %d images without file", len(report.OrphanFiles), len(report.MissingFiles))
+	h.OK(w, msg, map[string]interface{}{"report": report})
+}
+
+// ReplaceImageFile swaps the file of an image keeping its ID and references.
+func (h *APIHandler) ReplaceImageFile(w http.ResponseWriter, r *http.Request) {
+	h.Log().Debugf("%s: Handling ReplaceImageFile", h.Name())
+
+	id, err := h.ID(w, r)
+	if err != nil {
+		msg := fmt.Sprintf(hm.ErrInvalidID, hm.Cap(resImageName))
+		h.Err(w, http.StatusBadRequest, msg, err)
+		return
+	}
+
+	file, header, err := r.FormFile("image")
+	if err != nil {
+		h.Err(w, http.StatusBadRequest, "Failed to parse uploaded file", err)
+		return
+	}
+	defer file.Close()
+
+	replacement, err := h.svc.ReplaceImageFile(r.Context(), id, file, header)
+	if err != nil {
+		h.Err(w, http.StatusInternalServerError, "Failed to replace image file", err)
+		return
+	}
+
+	msg := fmt.Sprintf("Image file replaced, %d contents updated", len(replacement.UpdatedContents))
+	h.OK(w, msg, map[string]interface{}{"replacement": replacement})
+}
+
+// ListImageRevisions returns the previous files kept for an image.
+func (h *APIHandler) ListImageRevisions(w http.ResponseWriter, r *http.Request) {
+	h.Log().Debugf("%s: Handling ListImageRevisions", h.Name())
+
+	id, err := h.ID(w, r)
+	if err != nil {
+		msg := fmt.Sprintf(hm.ErrInvalidID, hm.Cap(resImageName))
+		h.Err(w, http.StatusBadRequest, msg, err)
+		return
+	}
+
+	revisions, err := h.svc.ListImageRevisions(r.Context(), id)
+	if err != nil {
+		h.Err(w, http.StatusInternalServerError, "Cannot get image revisions", err)
+		return
+	}
+
+	h.OK(w, "Image revisions retrieved", map[string]interface{}{"revisions": revisions})
+}
+
+// RollbackImageFile restores the previous file of an image.
+func (h *APIHandler) RollbackImageFile(w http.ResponseWriter, r *http.Request) {
+	h.Log().Debugf("%s: Handling RollbackImageFile", h.Name())
+
+	id, err := h.ID(w, r)
+	if err != nil {
+		msg := fmt.Sprintf(hm.ErrInvalidID, hm.Cap(resImageName))
+		h.Err(w, http.StatusBadRequest, msg, err)
+		return
+	}
+
+	replacement, err := h.svc.RollbackImageFile(r.Context(), id)
+	if err != nil {
+		h.Err(w, http.StatusInternalServerError, "Failed to roll back image file", err)
+		return
+	}
+
+	msg := fmt.Sprintf("Previous image file restored, %d contents updated", len(replacement.UpdatedContents))
+	h.OK(w, msg, map[string]interface{}{"replacement": replacement})
+}
@@ -0,0 +1,230 @@
+package ssg
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/hermesgen/hm"
+)
+
+// jobHeartbeat is how often an idle event stream sends a keep-alive comment.
+const jobHeartbeat = 15 * time.Second
+
+// SubmitJobRequest represents a request to run a job in the background.
+type SubmitJobRequest struct {
+	Kind         string `json:"kind"`
+	Message      string `json:"message"`
+	Force        bool   `json:"force"`
+	Dir          string `json:"dir"`
+	Source       string `json:"source"`
+	File         string `json:"file"`
+	UploadsDir   string `json:"uploads_dir"`
+	CategoriesAs string `json:"categories_as"`
+}
+
+// SubmitJob starts a generate, plan, publish or import job for the site and returns
+// it right away. Progress can be followed through StreamJobEvents.
+func (h *APIHandler) SubmitJob(w http.ResponseWriter, r *http.Request) {
+	h.Log().Debugf("%s: Handling SubmitJob", h.Name())
+
+	var req SubmitJobRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.Err(w, http.StatusBadRequest, hm.ErrInvalidBody, err)
+		return
+	}
+
+	var fn JobFunc
+	switch req.Kind {
+	case JobGenerateMarkdown:
+		fn = func(ctx context.Context) (string, error) {
+			return "", h.svc.GenerateMarkdown(ctx)
+		}
+	case JobGenerateHTML:
+		fn = func(ctx context.Context) (string, error) {
+			return "", h.svc.GenerateHTMLFromContent(ctx)
+		}
+	case JobPlan:
+		fn = func(ctx context.Context) (string, error) {
+			report, err := h.svc.Plan(ctx)
+			return report.Summary, err
+		}
+	case JobPublish:
+		fn = func(ctx context.Context) (string, error) {
+			return h.svc.Publish(ctx, req.Message, req.Force)
+		}
+	case JobImportMarkdown:
+		fn = func(ctx context.Context) (string, error) {
+			report, err := h.svc.ImportMarkdown(ctx, req.Dir)
+			return report.Summary, err
+		}
+	case JobMigrateSite:
+		if req.Dir == "" {
+			h.Err(w, http.StatusBadRequest, "Dir is required", nil)
+			return
+		}
+		fn = func(ctx context.Context) (string, error) {
+			report, err := h.svc.MigrateSite(ctx, req.Dir, req.Source)
+			return report.Summary, err
+		}
+	case JobImportWordPress:
+		if req.File == "" {
+			h.Err(w, http.StatusBadRequest, "File is required", nil)
+			return
+		}
+		fn = func(ctx context.Context) (string, error) {
+			report, err := h.svc.ImportWordPress(ctx, req.File, req.UploadsDir, req.CategoriesAs)
+			return report.Summary, err
+		}
+	case JobImportWatched:
+		fn = func(ctx context.Context) (string, error) {
+			report, err := h.svc.ImportWatched(ctx)
+			return report.Summary, err
+		}
+	default:
+		h.Err(w, http.StatusBadRequest, fmt.Sprintf("Unknown job kind: %q", req.Kind), nil)
+		return
+	}
+
+	job, err := h.jobs.Submit(r.Context(), req.Kind, fn)
+	if errors.Is(err, ErrSiteBusy) {
+		h.Err(w, http.StatusConflict, "Cannot start job", err)
+		return
+	}
+	if err != nil {
+		h.Err(w, http.StatusInternalServerError, "Cannot start job", err)
+		return
+	}
+
+	hm.Respond(w, http.StatusAccepted, hm.NewSuccessResponse("Job started", map[string]interface{}{"job": job}))
+}
+
+// siteLocked runs next holding the lock of the site in context, so it does not
+// run along a job, another locked request, a rename or a purge of the site.
+// It answers 409 when the site is busy.
+func (h *APIHandler) siteLocked(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		siteSlug, err := RequireSiteSlug(r.Context())
+		if err != nil {
+			h.Err(w, http.StatusBadRequest, "Missing site", err)
+			return
+		}
+
+		unlock, err := h.jobs.LockSite(siteSlug)
+		if err != nil {
+			h.Err(w, http.StatusConflict, "Site is busy", err)
+			return
+		}
+		defer unlock()
+
+		next(w, r)
+	}
+}
+
+// ListJobs returns the recent jobs of the site, newest first.
+func (h *APIHandler) ListJobs(w http.ResponseWriter, r *http.Request) {
+	h.Log().Debugf("%s: Handling ListJobs", h.Name())
+
+	siteSlug, err := RequireSiteSlug(r.Context())
+	if err != nil {
+		h.Err(w, http.StatusBadRequest, "Missing site", err)
+		return
+	}
+
+	h.OK(w, "Jobs retrieved", map[string]interface{}{"jobs": h.jobs.List(siteSlug)})
+}
+
+// GetJob returns a job with its logs.
+func (h *APIHandler) GetJob(w http.ResponseWriter, r *http.Request) {
+	h.Log().Debugf("%s: Handling GetJob", h.Name())
+
+	job, ok := h.siteJob(w, r)
+	if !ok {
+		return
+	}
+
+	h.OK(w, "Job retrieved", map[string]interface{}{"job": job})
+}
+
+// StreamJobEvents streams the progress of a job as Server-Sent Events. Past
+// log messages are sent first; the stream ends after the done event.
+func (h *APIHandler) StreamJobEvents(w http.ResponseWriter, r *http.Request) {
+	h.Log().Debugf("%s: Handling StreamJobEvents", h.Name())
+
+	current, ok := h.siteJob(w, r)
+	if !ok {
+		return
+	}
+
+	job, events, unsubscribe, err := h.jobs.Subscribe(current.ID)
+	if err != nil {
+		h.Err(w, http.StatusNotFound, "Job not found", err)
+		return
+	}
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	rc := http.NewResponseController(w)
+
+	for _, entry := range job.Logs {
+		writeJobEvent(w, JobEvent{Type: JobEventLog, JobID: job.ID, Time: entry.Time, Message: entry.Message, Status: JobRunning})
+	}
+
+	if events == nil {
+		writeJobEvent(w, JobEvent{Type: JobEventDone, JobID: job.ID, Time: time.Now(), Status: job.Status, Result: job.Result, Error: job.Error})
+		rc.Flush()
+		return
+	}
+	rc.Flush()
+
+	heartbeat := time.NewTicker(jobHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+			rc.Flush()
+		case ev, open := <-events:
+			if !open {
+				return
+			}
+			writeJobEvent(w, ev)
+			rc.Flush()
+		}
+	}
+}
+
+// siteJob returns the job in the path if it belongs to the site of the request.
+func (h *APIHandler) siteJob(w http.ResponseWriter, r *http.Request) (Job, bool) {
+	id, err := h.ID(w, r)
+	if err != nil {
+		h.Err(w, http.StatusBadRequest, "Invalid job ID", err)
+		return Job{}, false
+	}
+
+	siteSlug, _ := GetSiteSlugFromContext(r.Context())
+	job, ok := h.jobs.Get(id)
+	if !ok || job.SiteSlug != siteSlug {
+		h.Err(w, http.StatusNotFound, "Job not found", nil)
+		return Job{}, false
+	}
+	return job, true
+}
+
+func writeJobEvent(w http.ResponseWriter, ev JobEvent) {
+	data, err := json.Marshal(ev)
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev.Type, data)
+}
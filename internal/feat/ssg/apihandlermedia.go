@@ -0,0 +1,182 @@
+package ssg
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/hermesgen/hm"
+)
+
+// UpdateImageMetadataRequest represents the editable metadata of an image.
+type UpdateImageMetadataRequest struct {
+	Title   string `json:"title"`
+	AltText string `json:"alt_text"`
+}
+
+// AddLibraryImageRequest represents a request to use a library image in a content.
+type AddLibraryImageRequest struct {
+	ImageID uuid.UUID `json:"image_id"`
+}
+
+// ListLibraryImages returns a page of the media library, optionally filtered by search.
+func (h *APIHandler) ListLibraryImages(w http.ResponseWriter, r *http.Request) {
+	h.Log().Debugf("%s: Handling ListLibraryImages", h.Name())
+
+	query := r.URL.Query()
+	searchQuery := query.Get("search")
+	pageStr := query.Get("page")
+
+	page := 1
+	if pageStr != "" {
+		if p, err := strconv.Atoi(pageStr); err == nil && p > 0 {
+			page = p
+		}
+	}
+
+	const itemsPerPage = 25
+	offset := (page - 1) * itemsPerPage
+
+	images, totalCount, err := h.svc.ListLibraryImages(r.Context(), offset, itemsPerPage, searchQuery)
+	if err != nil {
+		msg := fmt.Sprintf(hm.ErrCannotGetResources, resImageName)
+		h.Err(w, http.StatusInternalServerError, msg, err)
+		return
+	}
+
+	totalPages := (totalCount + itemsPerPage - 1) / itemsPerPage
+
+	response := struct {
+		Images     []LibraryImage `json:"images"`
+		Page       int            `json:"page"`
+		TotalPages int            `json:"total_pages"`
+		TotalCount int            `json:"total_count"`
+		Search     string         `json:"search"`
+	}{
+		Images:     images,
+		Page:       page,
+		TotalPages: totalPages,
+		TotalCount: totalCount,
+		Search:     searchQuery,
+	}
+
+	msg := fmt.Sprintf(hm.MsgGetAllItems, hm.Cap(resImageName))
+	h.OK(w, msg, response)
+}
+
+// UploadLibraryImage stores an uploaded image in the media library.
+func (h *APIHandler) UploadLibraryImage(w http.ResponseWriter, r *http.Request) {
+	h.Log().Debugf("%s: Handling UploadLibraryImage", h.Name())
+
+	altText := r.FormValue("alt_text")
+	title := r.FormValue("title")
+
+	file, header, err := r.FormFile("image")
+	if err != nil {
+		h.Err(w, http.StatusBadRequest, "Failed to parse uploaded file", err)
+		return
+	}
+	defer file.Close()
+
+	image, err := h.svc.UploadLibraryImage(r.Context(), file, header, altText, title)
+	if err != nil {
+		h.Err(w, http.StatusInternalServerError, "Failed to upload image", err)
+		return
+	}
+
+	msg := fmt.Sprintf("Image uploaded successfully: %s", image.FileName)
+	h.Created(w, msg, image)
+}
+
+// GetImageUsage returns an image along with the contents and sections using it.
+func (h *APIHandler) GetImageUsage(w http.ResponseWriter, r *http.Request) {
+	h.Log().Debugf("%s: Handling GetImageUsage", h.Name())
+
+	id, err := h.ID(w, r)
+	if err != nil {
+		msg := fmt.Sprintf(hm.ErrInvalidID, hm.Cap(resImageName))
+		h.Err(w, http.StatusBadRequest, msg, err)
+		return
+	}
+
+	image, err := h.svc.GetLibraryImage(r.Context(), id)
+	if err != nil {
+		msg := fmt.Sprintf(hm.ErrCannotGetResource, resImageName)
+		h.Err(w, http.StatusInternalServerError, msg, err)
+		return
+	}
+
+	msg := fmt.Sprintf(hm.MsgGetItem, hm.Cap(resImageName))
+	h.OK(w, msg, map[string]interface{}{"image": image})
+}
+
+// UpdateImageMetadata changes the title and alt text of an image without touching its file.
+func (h *APIHandler) UpdateImageMetadata(w http.ResponseWriter, r *http.Request) {
+	h.Log().Debugf("%s: Handling UpdateImageMetadata", h.Name())
+
+	id, err := h.ID(w, r)
+	if err != nil {
+		msg := fmt.Sprintf(hm.ErrInvalidID, hm.Cap(resImageName))
+		h.Err(w, http.StatusBadRequest, msg, err)
+		return
+	}
+
+	var req UpdateImageMetadataRequest
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		h.Err(w, http.StatusBadRequest, hm.ErrInvalidBody, err)
+		return
+	}
+
+	image, err := h.svc.UpdateImageMetadata(r.Context(), id, req.Title, req.AltText)
+	if err != nil {
+		msg := fmt.Sprintf(hm.ErrCannotUpdateResource, resImageName)
+		h.Err(w, http.StatusInternalServerError, msg, err)
+		return
+	}
+
+	msg := fmt.Sprintf(hm.MsgUpdateItem, hm.Cap(resImageName))
+	h.OK(w, msg, image)
+}
+
+// AddLibraryImageToContent links a library image to a content and returns the
+// Markdown snippet to insert in its body.
+func (h *APIHandler) AddLibraryImageToContent(w http.ResponseWriter, r *http.Request) {
+	h.Log().Debugf("%s: Handling AddLibraryImageToContent", h.Name())
+
+	contentIDStr, err := h.Param(w, r, "content_id")
+	if err != nil {
+		h.Err(w, http.StatusBadRequest, "Invalid content ID", err)
+		return
+	}
+
+	contentID, err := uuid.Parse(contentIDStr)
+	if err != nil {
+		h.Err(w, http.StatusBadRequest, "Invalid content ID format", err)
+		return
+	}
+
+	var req AddLibraryImageRequest
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		h.Err(w, http.StatusBadRequest, hm.ErrInvalidBody, err)
+		return
+	}
+
+	if req.ImageID == uuid.Nil {
+		h.Err(w, http.StatusBadRequest, "Missing image_id", nil)
+		return
+	}
+
+	markdown, err := h.svc.AddLibraryImageToContent(r.Context(), contentID, req.ImageID)
+	if err != nil {
+		h.Err(w, http.StatusInternalServerError, "Failed to add library image to content", err)
+		return
+	}
+
+	h.OK(w, "Library image added to content", map[string]interface{}{
+		"markdown": markdown,
+	})
+}
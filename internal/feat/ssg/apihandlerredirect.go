@@ -0,0 +1,79 @@
+package ssg
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/hermesgen/hm"
+)
+
+// CreateRedirectRequest represents a request to redirect an old URL to a
+// content or to a path of the site.
+type CreateRedirectRequest struct {
+	FromPath  string    `json:"from_path"`
+	ContentID uuid.UUID `json:"content_id"`
+	ToPath    string    `json:"to_path"`
+}
+
+// ListRedirects returns the redirects of the current site.
+func (h *APIHandler) ListRedirects(w http.ResponseWriter, r *http.Request) {
+	h.Log().Debugf("%s: Handling ListRedirects", h.Name())
+
+	redirects, err := h.svc.ListRedirects(r.Context())
+	if err != nil {
+		msg := fmt.Sprintf(hm.ErrCannotGetResources, resRedirectName)
+		h.Err(w, http.StatusInternalServerError, msg, err)
+		return
+	}
+
+	msg := fmt.Sprintf(hm.MsgGetAllItems, hm.Cap(resRedirectName))
+	h.OK(w, msg, redirects)
+}
+
+// CreateRedirect adds a redirect, replacing the one from the same path.
+func (h *APIHandler) CreateRedirect(w http.ResponseWriter, r *http.Request) {
+	h.Log().Debugf("%s: Handling CreateRedirect", h.Name())
+
+	var req CreateRedirectRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.Err(w, http.StatusBadRequest, hm.ErrInvalidBody, err)
+		return
+	}
+	if req.FromPath == "" {
+		h.Err(w, http.StatusBadRequest, "From path is required", nil)
+		return
+	}
+
+	redirect, err := h.svc.CreateRedirect(r.Context(), req.FromPath, req.ContentID, req.ToPath)
+	if err != nil {
+		msg := fmt.Sprintf("Cannot create redirect: %v", err)
+		h.Err(w, http.StatusBadRequest, msg, err)
+		return
+	}
+
+	msg := fmt.Sprintf(hm.MsgCreateItem, hm.Cap(resRedirectName))
+	h.Created(w, msg, redirect)
+}
+
+// DeleteRedirect removes a redirect.
+func (h *APIHandler) DeleteRedirect(w http.ResponseWriter, r *http.Request) {
+	h.Log().Debugf("%s: Handling DeleteRedirect", h.Name())
+
+	id, err := h.ID(w, r)
+	if err != nil {
+		msg := fmt.Sprintf(hm.ErrInvalidID, hm.Cap(resRedirectName))
+		h.Err(w, http.StatusBadRequest, msg, err)
+		return
+	}
+
+	if err := h.svc.DeleteRedirect(r.Context(), id); err != nil {
+		msg := fmt.Sprintf(hm.ErrCannotDeleteResource, resRedirectName)
+		h.Err(w, http.StatusInternalServerError, msg, err)
+		return
+	}
+
+	msg := fmt.Sprintf(hm.MsgDeleteItem, hm.Cap(resRedirectName))
+	h.OK(w, msg, json.RawMessage("null"))
+}
@@ -181,6 +181,7 @@ func (h *APIHandler) UploadSectionImage(w http.ResponseWriter, r *http.Request)
 		"filename":      result.Filename,
 		"relative_path": result.RelativePath,
 		"metadata":      result.Metadata,
+		"stripped":      result.Stripped,
 	})
 }
 
@@ -1,6 +1,7 @@
 package ssg
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -40,10 +41,13 @@ func (h *APIHandler) CreateSite(w http.ResponseWriter, r *http.Request) {
 	h.Created(w, msg, site)
 }
 
+// ListSites returns the active sites, or every site with the archived query
+// value set.
 func (h *APIHandler) ListSites(w http.ResponseWriter, r *http.Request) {
 	h.Log().Debugf("%s: Handling ListSites", h.Name())
 
-	sites, err := h.siteManager.ListSites(r.Context(), true)
+	activeOnly := r.URL.Query().Get("archived") != "true"
+	sites, err := h.siteManager.ListSites(r.Context(), activeOnly)
 	if err != nil {
 		msg := "Cannot list sites"
 		h.Err(w, http.StatusInternalServerError, msg, err)
@@ -55,3 +59,388 @@ func (h *APIHandler) ListSites(w http.ResponseWriter, r *http.Request) {
 	}
 	h.OK(w, "Sites retrieved successfully", response)
 }
+
+// CloneSite creates a site from the site in the path, copying its structure
+// and, if requested, its contents and images.
+func (h *APIHandler) CloneSite(w http.ResponseWriter, r *http.Request) {
+	h.Log().Debugf("%s: Handling CloneSite", h.Name())
+
+	id, err := h.ID(w, r)
+	if err != nil {
+		h.Err(w, http.StatusBadRequest, "Invalid site ID", err)
+		return
+	}
+
+	var req struct {
+		Name    string `json:"name"`
+		Slug    string `json:"slug"`
+		Content bool   `json:"content"`
+		Images  bool   `json:"images"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.Err(w, http.StatusBadRequest, hm.ErrInvalidBody, err)
+		return
+	}
+
+	if req.Slug == "" {
+		h.Err(w, http.StatusBadRequest, "slug is required", nil)
+		return
+	}
+
+	userID := uuid.New()
+	opts := CloneOptions{Content: req.Content, Images: req.Images}
+	site, err := h.siteManager.CloneSite(r.Context(), id, req.Name, req.Slug, opts, userID)
+	if err != nil {
+		msg := fmt.Sprintf("Cannot clone site: %v", err)
+		h.Err(w, http.StatusInternalServerError, msg, err)
+		return
+	}
+
+	h.Created(w, "Site cloned successfully", site)
+}
+
+// ExportSite sends the site as a bundle to restore in another instance.
+func (h *APIHandler) ExportSite(w http.ResponseWriter, r *http.Request) {
+	h.Log().Debugf("%s: Handling ExportSite", h.Name())
+
+	id, err := h.ID(w, r)
+	if err != nil {
+		h.Err(w, http.StatusBadRequest, "Invalid site ID", err)
+		return
+	}
+
+	bundle, err := h.siteManager.ExportSite(r.Context(), id)
+	if err != nil {
+		msg := fmt.Sprintf("Cannot export site: %v", err)
+		h.Err(w, http.StatusInternalServerError, msg, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", bundle.FileName()))
+	if err := bundle.Write(w); err != nil {
+		h.Log().Error("Cannot write site bundle", "id", id, "error", err)
+	}
+}
+
+// RestoreSite creates a site from an uploaded bundle. The slug and name form
+// values, when given, replace those of the exported site.
+func (h *APIHandler) RestoreSite(w http.ResponseWriter, r *http.Request) {
+	h.Log().Debugf("%s: Handling RestoreSite", h.Name())
+
+	file, _, err := r.FormFile("bundle")
+	if err != nil {
+		h.Err(w, http.StatusBadRequest, "Failed to parse uploaded bundle", err)
+		return
+	}
+	defer file.Close()
+
+	site, err := h.siteManager.RestoreSite(r.Context(), file, r.FormValue("slug"), r.FormValue("name"))
+	if err != nil {
+		msg := fmt.Sprintf("Cannot restore site: %v", err)
+		h.Err(w, http.StatusBadRequest, msg, err)
+		return
+	}
+
+	h.Created(w, "Site restored successfully", site)
+}
+
+// GetSite returns the site in the path.
+func (h *APIHandler) GetSite(w http.ResponseWriter, r *http.Request) {
+	h.Log().Debugf("%s: Handling GetSite", h.Name())
+
+	id, err := h.ID(w, r)
+	if err != nil {
+		h.Err(w, http.StatusBadRequest, "Invalid site ID", err)
+		return
+	}
+
+	site, err := h.siteManager.GetSite(r.Context(), id)
+	if err != nil {
+		h.Err(w, http.StatusNotFound, "Site not found", err)
+		return
+	}
+
+	h.OK(w, "Site retrieved successfully", site)
+}
+
+// UpdateSite changes the name, slug and mode of the site in the path; empty
+// values keep the current ones. A new mode is switched to as SwitchMode does,
+// with redirects from the URLs that move. If the switch fails the site keeps
+// its name and slug.
+func (h *APIHandler) UpdateSite(w http.ResponseWriter, r *http.Request) {
+	h.Log().Debugf("%s: Handling UpdateSite", h.Name())
+
+	id, err := h.ID(w, r)
+	if err != nil {
+		h.Err(w, http.StatusBadRequest, "Invalid site ID", err)
+		return
+	}
+
+	var req struct {
+		Name string `json:"name"`
+		Slug string `json:"slug"`
+		Mode string `json:"mode"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.Err(w, http.StatusBadRequest, hm.ErrInvalidBody, err)
+		return
+	}
+
+	if req.Mode != "" {
+		if err := ValidateSiteMode(req.Mode); err != nil {
+			h.Err(w, http.StatusBadRequest, err.Error(), err)
+			return
+		}
+	}
+
+	site, err := h.siteManager.GetSite(r.Context(), id)
+	if err != nil {
+		h.Err(w, http.StatusNotFound, "Site not found", err)
+		return
+	}
+	oldName, oldSlug := site.Name, site.Slug()
+	if req.Slug == "" {
+		req.Slug = oldSlug
+	}
+
+	// The mode switch is planned before the rename, so an invalid switch
+	// leaves the site as it was.
+	opts := ModeSwitchOptions{Mode: req.Mode, Redirects: true}
+	switchMode := false
+	if req.Mode != "" {
+		plan, err := h.svc.PlanModeSwitch(siteContext(r.Context(), site), opts)
+		if err != nil {
+			msg := fmt.Sprintf("Cannot switch mode: %v", err)
+			h.Err(w, http.StatusBadRequest, msg, err)
+			return
+		}
+		switchMode = plan.From != plan.To
+	}
+
+	userID := uuid.New()
+	site, err = h.siteManager.RenameSite(r.Context(), id, req.Name, req.Slug, userID)
+	if err != nil {
+		msg := fmt.Sprintf("Cannot update site: %v", err)
+		h.Err(w, http.StatusBadRequest, msg, err)
+		return
+	}
+
+	if switchMode {
+		if _, err := h.svc.SwitchMode(siteContext(r.Context(), site), opts); err != nil {
+			if _, rerr := h.siteManager.RenameSite(r.Context(), id, oldName, oldSlug, userID); rerr != nil {
+				h.Log().Error("Cannot undo site rename", "slug", site.Slug(), "to", oldSlug, "error", rerr)
+			}
+			msg := fmt.Sprintf("Cannot switch mode: %v", err)
+			h.Err(w, http.StatusBadRequest, msg, err)
+			return
+		}
+	}
+	if cookie, err := r.Cookie(lastSiteCookie); err == nil && cookie.Value == oldSlug {
+		SetLastSiteCookie(w, site.Slug())
+	}
+
+	if req.Mode != "" && site.Mode != req.Mode {
+		updated, err := h.siteManager.SetSiteMode(r.Context(), id, req.Mode, userID)
+		if err != nil {
+			h.Log().Error("Cannot record site mode", "site", id, "error", err)
+		} else {
+			site = updated
+		}
+	}
+
+	h.OK(w, "Site updated successfully", site)
+}
+
+// ArchiveSite archives the site in the path. Its data and files are kept.
+func (h *APIHandler) ArchiveSite(w http.ResponseWriter, r *http.Request) {
+	h.Log().Debugf("%s: Handling ArchiveSite", h.Name())
+	h.setSiteActive(w, r, false)
+}
+
+// UnarchiveSite makes the archived site in the path active again.
+func (h *APIHandler) UnarchiveSite(w http.ResponseWriter, r *http.Request) {
+	h.Log().Debugf("%s: Handling UnarchiveSite", h.Name())
+	h.setSiteActive(w, r, true)
+}
+
+func (h *APIHandler) setSiteActive(w http.ResponseWriter, r *http.Request, active bool) {
+	id, err := h.ID(w, r)
+	if err != nil {
+		h.Err(w, http.StatusBadRequest, "Invalid site ID", err)
+		return
+	}
+
+	userID := uuid.New()
+	var site Site
+	if active {
+		site, err = h.siteManager.UnarchiveSite(r.Context(), id, userID)
+	} else {
+		site, err = h.siteManager.ArchiveSite(r.Context(), id, userID)
+	}
+	if err != nil {
+		msg := fmt.Sprintf("Cannot update site: %v", err)
+		h.Err(w, http.StatusInternalServerError, msg, err)
+		return
+	}
+
+	h.OK(w, "Site updated successfully", site)
+}
+
+// DeleteSite purges the archived site in the path for good. The confirm query
+// value must be its slug.
+func (h *APIHandler) DeleteSite(w http.ResponseWriter, r *http.Request) {
+	h.Log().Debugf("%s: Handling DeleteSite", h.Name())
+
+	id, err := h.ID(w, r)
+	if err != nil {
+		h.Err(w, http.StatusBadRequest, "Invalid site ID", err)
+		return
+	}
+
+	slug, err := h.siteManager.PurgeSite(r.Context(), id, r.URL.Query().Get("confirm"))
+	if err != nil {
+		msg := fmt.Sprintf("Cannot delete site: %v", err)
+		h.Err(w, http.StatusBadRequest, msg, err)
+		return
+	}
+	h.svc.InvalidateParams(id)
+
+	h.OK(w, fmt.Sprintf("Site '%s' deleted successfully", slug), json.RawMessage("null"))
+}
+
+// ListOrphanedSites returns the site directories without a site.
+func (h *APIHandler) ListOrphanedSites(w http.ResponseWriter, r *http.Request) {
+	h.Log().Debugf("%s: Handling ListOrphanedSites", h.Name())
+
+	orphans, err := h.siteManager.OrphanedSites(r.Context())
+	if err != nil {
+		h.Err(w, http.StatusInternalServerError, "Cannot list orphaned sites", err)
+		return
+	}
+
+	h.OK(w, "Orphaned sites retrieved successfully", map[string]interface{}{"orphans": orphans})
+}
+
+// AttachSite creates the site of an orphaned site directory.
+func (h *APIHandler) AttachSite(w http.ResponseWriter, r *http.Request) {
+	h.Log().Debugf("%s: Handling AttachSite", h.Name())
+
+	var req struct {
+		Slug string `json:"slug"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.Err(w, http.StatusBadRequest, hm.ErrInvalidBody, err)
+		return
+	}
+
+	userID := uuid.New()
+	site, err := h.siteManager.AttachSite(r.Context(), req.Slug, userID)
+	if err != nil {
+		msg := fmt.Sprintf("Cannot attach site: %v", err)
+		h.Err(w, http.StatusBadRequest, msg, err)
+		return
+	}
+
+	h.Created(w, "Site attached successfully", site)
+}
+
+// SwitchSite makes the site in the path the one used by the requests of this
+// client that carry no X-Site-Slug header.
+func (h *APIHandler) SwitchSite(w http.ResponseWriter, r *http.Request) {
+	h.Log().Debugf("%s: Handling SwitchSite", h.Name())
+
+	site, ok := h.activeSite(w, r)
+	if !ok {
+		return
+	}
+
+	SetLastSiteCookie(w, site.Slug())
+	h.OK(w, "Site switched successfully", site)
+}
+
+// GetSiteSettings returns the settings of the site in the path with their
+// resolved values.
+func (h *APIHandler) GetSiteSettings(w http.ResponseWriter, r *http.Request) {
+	h.Log().Debugf("%s: Handling GetSiteSettings", h.Name())
+
+	site, ok := h.activeSite(w, r)
+	if !ok {
+		return
+	}
+
+	settings, err := h.svc.ListSettings(siteContext(r.Context(), site))
+	if err != nil {
+		msg := fmt.Sprintf(hm.ErrCannotGetResources, "settings")
+		h.Err(w, http.StatusInternalServerError, msg, err)
+		return
+	}
+
+	h.OK(w, "Site settings retrieved successfully", map[string]interface{}{"settings": settings})
+}
+
+// UpdateSiteSettings sets the values of params of the site in the path, given
+// as an object of values by ref key, and returns the settings of the site with
+// their resolved values.
+func (h *APIHandler) UpdateSiteSettings(w http.ResponseWriter, r *http.Request) {
+	h.Log().Debugf("%s: Handling UpdateSiteSettings", h.Name())
+
+	site, ok := h.activeSite(w, r)
+	if !ok {
+		return
+	}
+
+	var values map[string]string
+	if err := json.NewDecoder(r.Body).Decode(&values); err != nil {
+		h.Err(w, http.StatusBadRequest, hm.ErrInvalidBody, err)
+		return
+	}
+
+	ctx := siteContext(r.Context(), site)
+	if _, err := h.svc.UpdateSettings(ctx, values); err != nil {
+		msg := fmt.Sprintf("Cannot update site settings: %v", err)
+		h.Err(w, http.StatusBadRequest, msg, err)
+		return
+	}
+
+	settings, err := h.svc.ListSettings(ctx)
+	if err != nil {
+		msg := fmt.Sprintf(hm.ErrCannotGetResources, "settings")
+		h.Err(w, http.StatusInternalServerError, msg, err)
+		return
+	}
+
+	h.OK(w, "Site settings updated successfully", map[string]interface{}{"settings": settings})
+}
+
+// activeSite returns the site in the path, responding with an error if it
+// does not exist or is archived.
+func (h *APIHandler) activeSite(w http.ResponseWriter, r *http.Request) (Site, bool) {
+	id, err := h.ID(w, r)
+	if err != nil {
+		h.Err(w, http.StatusBadRequest, "Invalid site ID", err)
+		return Site{}, false
+	}
+
+	site, err := h.siteManager.GetSite(r.Context(), id)
+	if err != nil {
+		h.Err(w, http.StatusNotFound, "Site not found", err)
+		return Site{}, false
+	}
+	if site.Active == 0 {
+		h.Err(w, http.StatusConflict, "Site is archived", nil)
+		return Site{}, false
+	}
+
+	return site, true
+}
+
+// siteContext returns ctx for requests on site, as SiteContextMw sets it for
+// requests on the selected site.
+func siteContext(ctx context.Context, site Site) context.Context {
+	ctx = context.WithValue(ctx, siteSlugKey, site.Slug())
+	return context.WithValue(ctx, siteIDKey, site.ID)
+}
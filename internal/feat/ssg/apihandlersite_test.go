@@ -0,0 +1,136 @@
+package ssg
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/hermesgen/hm"
+)
+
+func TestUpdateSiteKeepsSlugWhenModeSwitchFails(t *testing.T) {
+	site := NewSite("Blog", "blog", ModeStructured)
+	site.GenID()
+	sm, siteRepo, _, base := newTestSiteManager(t, site)
+	writeSiteFiles(t, base, map[string]string{"blog/documents/markdown/post.md": "# Post"})
+
+	rootID, techID := uuid.New(), uuid.New()
+	repo := &fakeModeSwitchRepo{
+		fakeParamRepo: fakeParamRepo{params: map[string]Param{
+			siteModeRefKey: {ID: uuid.New(), SiteID: site.ID, RefKey: siteModeRefKey, Value: ModeStructured},
+		}},
+		sections: []Section{
+			{ID: rootID, Name: "root", Path: "/"},
+			{ID: techID, Name: "tech", Path: "/tech/"},
+		},
+		contents: []Content{
+			{ID: uuid.New(), ShortID: "b2", SectionID: techID, SectionPath: "/tech/", Kind: "article", Heading: "Tech Article"},
+		},
+	}
+	params := hm.XParams{Cfg: hm.NewConfig(), Log: hm.NewLogger("error")}
+	svc := &BaseService{Service: hm.NewService("ssg-svc", params), repo: repo, pm: NewParamManager(repo, params)}
+	h := NewAPIHandler("ssg-api", svc, sm, sm.jobs, params)
+
+	update := func(body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPut, "/api/v1/ssg/sites/"+site.ID.String(), strings.NewReader(body))
+		r.SetPathValue("id", site.ID.String())
+		w := httptest.NewRecorder()
+		h.UpdateSite(w, r)
+		return w
+	}
+
+	if w := update(`{"slug": "journal", "mode": "gallery"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("update with an invalid mode status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+
+	if w := update(`{"name": "Journal", "slug": "journal", "mode": "blog"}`); w.Code != http.StatusBadRequest {
+		t.Fatalf("update with a failing switch status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+	if stored := siteRepo.sites[site.ID]; stored.Slug() != "blog" || stored.Name != "Blog" || stored.Mode != ModeStructured {
+		t.Errorf("site = %s %s %s, want it unchanged", stored.Name, stored.Slug(), stored.Mode)
+	}
+	if _, err := os.Stat(filepath.Join(base, "blog", "documents", "markdown", "post.md")); err != nil {
+		t.Errorf("site files not moved back: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(base, "journal")); !os.IsNotExist(err) {
+		t.Errorf("renamed site directory still there: %v", err)
+	}
+}
+
+// fakeModeFailSiteRepo fails to store a site whose mode changed.
+type fakeModeFailSiteRepo struct {
+	*fakeSiteRepo
+}
+
+func (f fakeModeFailSiteRepo) UpdateSite(ctx context.Context, site *Site) error {
+	if site.Mode != f.sites[site.ID].Mode {
+		return errors.New("disk full")
+	}
+	return f.fakeSiteRepo.UpdateSite(ctx, site)
+}
+
+func TestUpdateSiteIgnoresModeRecordFailure(t *testing.T) {
+	site := NewSite("Blog", "blog", ModeStructured)
+	site.GenID()
+	sm, siteRepo, _, _ := newTestSiteManager(t, site)
+	sm.siteRepo = fakeModeFailSiteRepo{siteRepo}
+
+	repo := &fakeModeSwitchRepo{
+		fakeParamRepo: fakeParamRepo{params: map[string]Param{
+			siteModeRefKey: {ID: uuid.New(), SiteID: site.ID, RefKey: siteModeRefKey, Value: ModeStructured},
+		}},
+		sections: []Section{{ID: uuid.New(), Name: "root", Path: "/"}},
+	}
+	params := hm.XParams{Cfg: hm.NewConfig(), Log: hm.NewLogger("error")}
+	svc := &BaseService{Service: hm.NewService("ssg-svc", params), repo: repo, pm: NewParamManager(repo, params)}
+	h := NewAPIHandler("ssg-api", svc, sm, sm.jobs, params)
+
+	body := `{"name": "Journal", "slug": "journal", "mode": "blog"}`
+	r := httptest.NewRequest(http.MethodPut, "/api/v1/ssg/sites/"+site.ID.String(), strings.NewReader(body))
+	r.SetPathValue("id", site.ID.String())
+	w := httptest.NewRecorder()
+	h.UpdateSite(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body)
+	}
+	if mode := repo.params[siteModeRefKey].Value; mode != ModeBlog {
+		t.Errorf("mode param = %s, want %s", mode, ModeBlog)
+	}
+	if stored := siteRepo.sites[site.ID]; stored.Slug() != "journal" {
+		t.Errorf("slug = %s, want journal", stored.Slug())
+	}
+}
+
+func TestUpdateSiteSettingsHidesSecrets(t *testing.T) {
+	site := NewSite("Blog", "blog", ModeStructured)
+	site.GenID()
+	sm, _, _, _ := newTestSiteManager(t, site)
+
+	repo := &fakeParamRepo{params: map[string]Param{}}
+	params := hm.XParams{Cfg: hm.NewConfig(), Log: hm.NewLogger("error")}
+	svc := &BaseService{Service: hm.NewService("ssg-svc", params), repo: repo, pm: NewParamManager(repo, params)}
+	h := NewAPIHandler("ssg-api", svc, sm, sm.jobs, params)
+
+	body := `{"` + SSGKey.PublishAuthToken + `": "s3cr3t-token"}`
+	r := httptest.NewRequest(http.MethodPut, "/api/v1/ssg/sites/"+site.ID.String()+"/settings", strings.NewReader(body))
+	r.SetPathValue("id", site.ID.String())
+	w := httptest.NewRecorder()
+	h.UpdateSiteSettings(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body)
+	}
+	if repo.params[SSGKey.PublishAuthToken].Value != "s3cr3t-token" {
+		t.Errorf("token not saved: %+v", repo.params[SSGKey.PublishAuthToken])
+	}
+	if strings.Contains(w.Body.String(), "s3cr3t-token") {
+		t.Errorf("response echoes the token: %s", w.Body)
+	}
+}
@@ -0,0 +1,75 @@
+package ssg
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/hermesgen/hm"
+)
+
+// PlanModeSwitch returns what switching the current site to the mode in the
+// query would change. The kind and section_id query values reassign the
+// contents that would no longer be listed.
+func (h *APIHandler) PlanModeSwitch(w http.ResponseWriter, r *http.Request) {
+	h.Log().Debugf("%s: Handling PlanModeSwitch", h.Name())
+
+	opts, err := modeSwitchQuery(r)
+	if err != nil {
+		h.Err(w, http.StatusBadRequest, "Invalid section ID", err)
+		return
+	}
+
+	plan, err := h.svc.PlanModeSwitch(r.Context(), opts)
+	if err != nil {
+		msg := fmt.Sprintf("Cannot plan mode switch: %v", err)
+		h.Err(w, http.StatusBadRequest, msg, err)
+		return
+	}
+
+	h.OK(w, "Mode switch plan created successfully", map[string]interface{}{"plan": plan})
+}
+
+// SwitchMode switches the current site to another mode.
+func (h *APIHandler) SwitchMode(w http.ResponseWriter, r *http.Request) {
+	h.Log().Debugf("%s: Handling SwitchMode", h.Name())
+
+	var opts ModeSwitchOptions
+	if err := json.NewDecoder(r.Body).Decode(&opts); err != nil {
+		h.Err(w, http.StatusBadRequest, hm.ErrInvalidBody, err)
+		return
+	}
+
+	plan, err := h.svc.SwitchMode(r.Context(), opts)
+	if err != nil {
+		msg := fmt.Sprintf("Cannot switch mode: %v", err)
+		h.Err(w, http.StatusBadRequest, msg, err)
+		return
+	}
+
+	if siteID, ok := GetSiteIDFromContext(r.Context()); ok {
+		userID := uuid.New()
+		if _, err := h.siteManager.SetSiteMode(r.Context(), siteID, plan.To, userID); err != nil {
+			h.Log().Error("Cannot record site mode", "site", siteID, "error", err)
+		}
+	}
+
+	h.OK(w, "Site mode switched successfully", map[string]interface{}{"plan": plan})
+}
+
+func modeSwitchQuery(r *http.Request) (ModeSwitchOptions, error) {
+	query := r.URL.Query()
+	opts := ModeSwitchOptions{
+		Mode: query.Get("mode"),
+		Kind: query.Get("kind"),
+	}
+	if s := query.Get("section_id"); s != "" {
+		id, err := uuid.Parse(s)
+		if err != nil {
+			return ModeSwitchOptions{}, err
+		}
+		opts.SectionID = id
+	}
+	return opts, nil
+}
@@ -0,0 +1,65 @@
+package ssg
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/hermesgen/hm"
+)
+
+// GetSettings returns the settings of the current site with their resolved
+// values and where each one comes from.
+func (h *APIHandler) GetSettings(w http.ResponseWriter, r *http.Request) {
+	h.Log().Debugf("%s: Handling GetSettings", h.Name())
+
+	settings, err := h.svc.ListSettings(r.Context())
+	if err != nil {
+		msg := fmt.Sprintf(hm.ErrCannotGetResources, "settings")
+		h.Err(w, http.StatusInternalServerError, msg, err)
+		return
+	}
+
+	h.OK(w, "Settings retrieved successfully", map[string]interface{}{"settings": settings})
+}
+
+// UpdateSettings sets settings of the current site, given as an object of
+// values by key, and returns the settings with their resolved values.
+func (h *APIHandler) UpdateSettings(w http.ResponseWriter, r *http.Request) {
+	h.Log().Debugf("%s: Handling UpdateSettings", h.Name())
+
+	var values map[string]string
+	if err := json.NewDecoder(r.Body).Decode(&values); err != nil {
+		h.Err(w, http.StatusBadRequest, hm.ErrInvalidBody, err)
+		return
+	}
+
+	if _, err := h.svc.UpdateSettings(r.Context(), values); err != nil {
+		msg := fmt.Sprintf("Cannot update settings: %v", err)
+		h.Err(w, http.StatusBadRequest, msg, err)
+		return
+	}
+
+	h.GetSettings(w, r)
+}
+
+// ResetSetting drops the value the current site has for a setting, so it is
+// inherited from the configuration again.
+func (h *APIHandler) ResetSetting(w http.ResponseWriter, r *http.Request) {
+	h.Log().Debugf("%s: Handling ResetSetting", h.Name())
+
+	key, err := h.Param(w, r, "key")
+	if err != nil {
+		msg := fmt.Sprintf("%s: %s", hm.ErrInvalidParam, "key")
+		h.Err(w, http.StatusBadRequest, msg, err)
+		return
+	}
+
+	if err := h.svc.ResetSetting(r.Context(), key); err != nil {
+		msg := fmt.Sprintf("Cannot reset setting: %v", err)
+		h.Err(w, http.StatusBadRequest, msg, err)
+		return
+	}
+
+	h.GetSettings(w, r)
+}
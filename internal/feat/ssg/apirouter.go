@@ -11,13 +11,58 @@ func NewAPIRouter(handler *APIHandler, mw []hm.Middleware, params hm.XParams) *h
 	// Site API routes
 	core.Get("/sites", handler.ListSites)
 	core.Post("/sites", handler.CreateSite)
-
-	// SSG API routes
-	core.Post("/generate-markdown", handler.GenerateMarkdown)
-	core.Post("/generate-html", handler.GenerateHTML)
+	core.Post("/sites/restore", handler.RestoreSite)
+	core.Get("/sites/orphans", handler.ListOrphanedSites)
+	core.Post("/sites/attach", handler.AttachSite)
+	core.Get("/sites/{id}", handler.GetSite)
+	core.Put("/sites/{id}", handler.UpdateSite)
+	core.Delete("/sites/{id}", handler.DeleteSite)
+	core.Post("/sites/{id}/archive", handler.ArchiveSite)
+	core.Post("/sites/{id}/unarchive", handler.UnarchiveSite)
+	core.Post("/sites/{id}/switch", handler.SwitchSite)
+	core.Get("/sites/{id}/settings", handler.GetSiteSettings)
+	core.Put("/sites/{id}/settings", handler.UpdateSiteSettings)
+	core.Post("/sites/{id}/clone", handler.CloneSite)
+	core.Get("/sites/{id}/export", handler.ExportSite)
+
+	// SSG API routes. Builds, imports and publishes hold the site lock, so
+	// they do not run along each other or along a job of the site.
+	core.Post("/generate-markdown", handler.siteLocked(handler.GenerateMarkdown))
+	core.Post("/generate-html", handler.siteLocked(handler.GenerateHTML))
+
+	// Import API routes
+	core.Post("/import/markdown", handler.siteLocked(handler.ImportMarkdown))
+	core.Post("/import/migrate", handler.siteLocked(handler.MigrateSite))
+	core.Post("/import/wordpress", handler.siteLocked(handler.ImportWordPress))
+	core.Get("/watch", handler.WatchStatus)
+	core.Post("/watch/resolve", handler.siteLocked(handler.ResolveWatchConflict))
+
+	// Redirect API routes
+	core.Get("/redirects", handler.ListRedirects)
+	core.Post("/redirects", handler.CreateRedirect)
+	core.Delete("/redirects/{id}", handler.DeleteRedirect)
+
+	// Settings API routes
+	core.Get("/settings", handler.GetSettings)
+	core.Put("/settings", handler.UpdateSettings)
+	core.Delete("/settings/{key}", handler.ResetSetting)
+
+	// Site mode API routes
+	core.Get("/site-mode/plan", handler.PlanModeSwitch)
+	core.Post("/site-mode", handler.SwitchMode)
 
 	// Publish API routes
-	core.Post("/publish", handler.Publish)
+	core.Post("/publish", handler.siteLocked(handler.Publish))
+	core.Get("/publish/plan", handler.PlanPublish)
+	core.Get("/publish/history", handler.ListPublishRecords)
+	core.Post("/publish/history/{id}/rollback", handler.siteLocked(handler.RollbackPublish))
+	core.Get("/publish/quality", handler.CheckQuality)
+
+	// Background job routes
+	core.Get("/jobs", handler.ListJobs)
+	core.Post("/jobs", handler.SubmitJob)
+	core.Get("/jobs/{id}", handler.GetJob)
+	core.Get("/jobs/{id}/events", handler.StreamJobEvents)
 
 	// Layout API routes
 	core.Get("/layouts", handler.GetAllLayouts)
@@ -49,6 +94,12 @@ func NewAPIRouter(handler *APIHandler, mw []hm.Middleware, params hm.XParams) *h
 	core.Post("/contents/{content_id}/images", handler.UploadContentImage)
 	core.Get("/contents/{content_id}/images", handler.GetContentImages)
 	core.Delete("/contents/{content_id}/images/delete", handler.DeleteContentImage)
+	core.Post("/contents/{content_id}/images/library", handler.AddLibraryImageToContent)
+
+	// Content attachment routes
+	core.Get("/contents/{content_id}/attachments", handler.GetContentAttachments)
+	core.Post("/contents/{content_id}/attachments", handler.AddAttachmentToContent)
+	core.Delete("/contents/{content_id}/attachments/{attachment_id}", handler.RemoveAttachmentFromContent)
 
 	// Section Image Upload API routes
 	core.Post("/sections/{section_id}/images", handler.UploadSectionImage)
@@ -71,14 +122,31 @@ func NewAPIRouter(handler *APIHandler, mw []hm.Middleware, params hm.XParams) *h
 	core.Put("/params/{id}", handler.UpdateParam)
 	core.Delete("/params/{id}", handler.DeleteParam)
 
+	// Media Library API routes
+	core.Get("/library/images", handler.ListLibraryImages)
+	core.Post("/library/images", handler.UploadLibraryImage)
+
 	// Image API routes
 	core.Get("/images", handler.ListImages)
+	core.Get("/images/orphans", handler.GetOrphanedImages)
+	core.Post("/images/orphans/purge", handler.PurgeOrphanedImages)
+	core.Get("/images/{id}/usage", handler.GetImageUsage)
+	core.Put("/images/{id}/metadata", handler.UpdateImageMetadata)
+	core.Post("/images/{id}/file", handler.ReplaceImageFile)
+	core.Get("/images/{id}/revisions", handler.ListImageRevisions)
+	core.Post("/images/{id}/rollback", handler.RollbackImageFile)
 	core.Get("/images/{id}", handler.GetImage)
 	core.Get("/images/short/{short_id}", handler.GetImageByShortID)
 	core.Post("/images", handler.CreateImage)
 	core.Put("/images/{id}", handler.UpdateImage)
 	core.Delete("/images/{id}", handler.DeleteImage)
 
+	// Attachment API routes
+	core.Get("/attachments", handler.ListAttachments)
+	core.Post("/attachments", handler.UploadAttachment)
+	core.Get("/attachments/{id}", handler.GetAttachment)
+	core.Delete("/attachments/{id}", handler.DeleteAttachment)
+
 	// Image Variant API routes
 	core.Get("/images/{image_id}/variants", handler.ListImageVariantsByImageID)
 	core.Get("/images/{image_id}/variants/{id}", handler.GetImageVariant)
@@ -7,6 +7,7 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
+	"strings"
 )
 
 func CopyStaticAssets(assetsFS embed.FS, targetDir string) error {
@@ -31,28 +32,49 @@ func CopyStaticAssets(assetsFS embed.FS, targetDir string) error {
 
 // CopyDynamicImages copies all dynamic images from assets/images to html/static/images
 func CopyDynamicImages(sourceDir, targetDir string) error {
-	sourceImagesDir := filepath.Join(sourceDir, "assets", "images")
-	targetImagesDir := filepath.Join(targetDir, "static", "images")
+	return copyDynamicDir(
+		filepath.Join(sourceDir, "assets", "images"),
+		filepath.Join(targetDir, "static", "images"),
+	)
+}
+
+// CopyDynamicAttachments copies all attachments from assets/attachments to html/static/attachments
+func CopyDynamicAttachments(sourceDir, targetDir string) error {
+	return copyDynamicDir(
+		filepath.Join(sourceDir, "assets", "attachments"),
+		filepath.Join(targetDir, "static", "attachments"),
+	)
+}
 
-	if _, err := os.Stat(sourceImagesDir); os.IsNotExist(err) {
+// copyDynamicDir mirrors sourceDir into targetDir. Hidden files and directories
+// (e.g. the replaced image history) are internal and not published.
+func copyDynamicDir(sourceDir, targetDir string) error {
+	if _, err := os.Stat(sourceDir); os.IsNotExist(err) {
 		return nil
 	}
 
-	if err := os.MkdirAll(targetImagesDir, 0755); err != nil {
-		return fmt.Errorf("cannot create images directory: %w", err)
+	if err := os.MkdirAll(targetDir, 0755); err != nil {
+		return fmt.Errorf("cannot create directory: %w", err)
 	}
 
-	return filepath.Walk(sourceImagesDir, func(srcPath string, info os.FileInfo, err error) error {
+	return filepath.Walk(sourceDir, func(srcPath string, info os.FileInfo, err error) error {
 		if err != nil {
 			return fmt.Errorf("error walking source directory: %w", err)
 		}
 
-		relPath, err := filepath.Rel(sourceImagesDir, srcPath)
+		if srcPath != sourceDir && strings.HasPrefix(info.Name(), ".") {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		relPath, err := filepath.Rel(sourceDir, srcPath)
 		if err != nil {
 			return fmt.Errorf("cannot get relative path: %w", err)
 		}
 
-		dstPath := filepath.Join(targetImagesDir, relPath)
+		dstPath := filepath.Join(targetDir, relPath)
 
 		if info.IsDir() {
 			if err := os.MkdirAll(dstPath, 0755); err != nil {
@@ -0,0 +1,216 @@
+package ssg
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hermesgen/hm"
+)
+
+// Attachment represents a downloadable file (PDF, archive, audio, etc.) linked from content.
+type Attachment struct {
+	// Common
+	ID      uuid.UUID `json:"id" db:"id"`
+	ShortID string    `json:"-" db:"short_id"`
+	ref     string    `json:"-"`
+
+	// Site relationship
+	SiteID uuid.UUID `json:"site_id" db:"site_id"`
+
+	// File information
+	FileName  string `json:"file_name" db:"file_name"`
+	FilePath  string `json:"file_path" db:"file_path"`
+	MimeType  string `json:"mime_type" db:"mime_type"`
+	SizeBytes int64  `json:"size_bytes" db:"size_bytes"`
+
+	Title string `json:"title" db:"title"`
+
+	// Audit
+	CreatedBy uuid.UUID `json:"-" db:"created_by"`
+	UpdatedBy uuid.UUID `json:"-" db:"updated_by"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"-" db:"updated_at"`
+}
+
+// NewAttachment creates a new Attachment instance with default values.
+func NewAttachment() Attachment {
+	return Attachment{
+		ID: uuid.New(),
+	}
+}
+
+// Type returns the type of the entity.
+func (a *Attachment) Type() string {
+	return "attachment"
+}
+
+// GetID returns the unique identifier of the entity.
+func (a Attachment) GetID() uuid.UUID {
+	return a.ID
+}
+
+// GenID delegates to the functional helper.
+func (a *Attachment) GenID() {
+	hm.GenID(a)
+}
+
+// SetID sets the unique identifier of the entity.
+func (a *Attachment) SetID(id uuid.UUID, force ...bool) {
+	shouldForce := len(force) > 0 && force[0]
+	if a.ID == uuid.Nil || (shouldForce && id != uuid.Nil) {
+		a.ID = id
+	}
+}
+
+// GetShortID returns the short ID portion of the slug.
+func (a *Attachment) GetShortID() string {
+	return a.ShortID
+}
+
+// GenShortID delegates to the functional helper.
+func (a *Attachment) GenShortID() {
+	hm.GenShortID(a)
+}
+
+// SetShortID sets the short ID of the entity.
+func (a *Attachment) SetShortID(shortID string, force ...bool) {
+	shouldForce := len(force) > 0 && force[0]
+	if a.ShortID == "" || shouldForce {
+		a.ShortID = shortID
+	}
+}
+
+// GenCreateValues delegates to the functional helper.
+func (a *Attachment) GenCreateValues(userID ...uuid.UUID) {
+	hm.SetCreateValues(a, userID...)
+}
+
+// GenUpdateValues delegates to the functional helper.
+func (a *Attachment) GenUpdateValues(userID ...uuid.UUID) {
+	hm.SetUpdateValues(a, userID...)
+}
+
+// GetCreatedBy returns the UUID of the user who created the entity.
+func (a *Attachment) GetCreatedBy() uuid.UUID {
+	return a.CreatedBy
+}
+
+// GetUpdatedBy returns the UUID of the user who last updated the entity.
+func (a *Attachment) GetUpdatedBy() uuid.UUID {
+	return a.UpdatedBy
+}
+
+// GetCreatedAt returns the creation time of the entity.
+func (a *Attachment) GetCreatedAt() time.Time {
+	return a.CreatedAt
+}
+
+// GetUpdatedAt returns the last update time of the entity.
+func (a *Attachment) GetUpdatedAt() time.Time {
+	return a.UpdatedAt
+}
+
+// SetCreatedAt implements the Auditable interface.
+func (a *Attachment) SetCreatedAt(t time.Time) {
+	a.CreatedAt = t
+}
+
+// SetUpdatedAt implements the Auditable interface.
+func (a *Attachment) SetUpdatedAt(t time.Time) {
+	a.UpdatedAt = t
+}
+
+// SetCreatedBy implements the Auditable interface.
+func (a *Attachment) SetCreatedBy(id uuid.UUID) {
+	a.CreatedBy = id
+}
+
+// SetUpdatedBy implements the Auditable interface.
+func (a *Attachment) SetUpdatedBy(id uuid.UUID) {
+	a.UpdatedBy = id
+}
+
+// IsZero returns true if the Attachment is uninitialized.
+func (a *Attachment) IsZero() bool {
+	return a.ID == uuid.Nil
+}
+
+// Slug returns a slug for the attachment.
+func (a *Attachment) Slug() string {
+	if a.Title != "" {
+		return hm.Normalize(a.Title) + "-" + a.GetShortID()
+	}
+	return hm.Normalize(a.FileName) + "-" + a.GetShortID()
+}
+
+func (a *Attachment) Ref() string {
+	return a.ref
+}
+
+func (a *Attachment) SetRef(ref string) {
+	a.ref = ref
+}
+
+// URL returns the path used to link the attachment from content.
+func (a Attachment) URL() string {
+	return attachmentURLPrefix + a.FilePath
+}
+
+// Kind returns a short, human readable label of the file type (e.g. PDF, ZIP).
+func (a Attachment) Kind() string {
+	ext := strings.TrimPrefix(filepath.Ext(a.FilePath), ".")
+	if ext == "" {
+		return "File"
+	}
+	return strings.ToUpper(ext)
+}
+
+// Size returns the file size in a human readable form.
+func (a Attachment) Size() string {
+	return HumanSize(a.SizeBytes)
+}
+
+// Markdown returns the Markdown link to insert the attachment in a content body.
+func (a Attachment) Markdown() string {
+	text := a.Title
+	if text == "" {
+		text = a.FileName
+	}
+	return fmt.Sprintf("[%s](%s)", text, a.URL())
+}
+
+// HumanSize formats a size in bytes using binary units.
+func HumanSize(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(bytes)/float64(div), "KMGTPE"[exp])
+}
+
+// ContentAttachment represents the relationship between content and attachments.
+type ContentAttachment struct {
+	ID           uuid.UUID `json:"id" db:"id"`
+	ContentID    uuid.UUID `json:"content_id" db:"content_id"`
+	AttachmentID uuid.UUID `json:"attachment_id" db:"attachment_id"`
+	OrderNum     int       `json:"order_num" db:"order_num"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+}
+
+// NewContentAttachment creates a new ContentAttachment.
+func NewContentAttachment(contentID, attachmentID uuid.UUID) *ContentAttachment {
+	return &ContentAttachment{
+		ID:           uuid.New(),
+		ContentID:    contentID,
+		AttachmentID: attachmentID,
+		CreatedAt:    time.Now(),
+	}
+}
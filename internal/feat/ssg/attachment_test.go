@@ -0,0 +1,124 @@
+package ssg
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestNewAttachment(t *testing.T) {
+	a := NewAttachment()
+
+	if a.ID == uuid.Nil {
+		t.Error("NewAttachment() did not generate UUID")
+	}
+}
+
+func TestAttachmentType(t *testing.T) {
+	a := Attachment{}
+	if got := a.Type(); got != "attachment" {
+		t.Errorf("Type() = %v, want %v", got, "attachment")
+	}
+}
+
+func TestAttachmentSlug(t *testing.T) {
+	tests := []struct {
+		name     string
+		title    string
+		fileName string
+		shortID  string
+		want     string
+	}{
+		{
+			name:     "uses title when available",
+			title:    "Annual Report",
+			fileName: "report.pdf",
+			shortID:  "abc123",
+			want:     "annual-report-abc123",
+		},
+		{
+			name:     "uses filename when title is empty",
+			fileName: "report.pdf",
+			shortID:  "xyz789",
+			want:     "report.pdf-xyz789",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := Attachment{Title: tt.title, FileName: tt.fileName, ShortID: tt.shortID}
+			if got := a.Slug(); got != tt.want {
+				t.Errorf("Slug() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAttachmentKind(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{path: "report_1.pdf", want: "PDF"},
+		{path: "episode_2.mp3", want: "MP3"},
+		{path: "noext", want: "File"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			a := Attachment{FilePath: tt.path}
+			if got := a.Kind(); got != tt.want {
+				t.Errorf("Kind() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAttachmentMarkdown(t *testing.T) {
+	tests := []struct {
+		name       string
+		attachment Attachment
+		want       string
+	}{
+		{
+			name:       "uses title when available",
+			attachment: Attachment{Title: "Slides", FileName: "talk.pdf", FilePath: "talk_1.pdf"},
+			want:       "[Slides](/static/attachments/talk_1.pdf)",
+		},
+		{
+			name:       "falls back to file name",
+			attachment: Attachment{FileName: "talk.pdf", FilePath: "talk_1.pdf"},
+			want:       "[talk.pdf](/static/attachments/talk_1.pdf)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.attachment.Markdown(); got != tt.want {
+				t.Errorf("Markdown() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHumanSize(t *testing.T) {
+	tests := []struct {
+		bytes int64
+		want  string
+	}{
+		{bytes: 0, want: "0 B"},
+		{bytes: 1023, want: "1023 B"},
+		{bytes: 1024, want: "1.0 KB"},
+		{bytes: 1536, want: "1.5 KB"},
+		{bytes: 5 * 1024 * 1024, want: "5.0 MB"},
+		{bytes: 3 * 1024 * 1024 * 1024, want: "3.0 GB"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := HumanSize(tt.bytes); got != tt.want {
+				t.Errorf("HumanSize(%d) = %v, want %v", tt.bytes, got, tt.want)
+			}
+		})
+	}
+}
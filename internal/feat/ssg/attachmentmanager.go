@@ -0,0 +1,215 @@
+package ssg
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/hermesgen/hm"
+)
+
+// attachmentURLPrefix is the URL prefix used to link site attachments from content.
+const attachmentURLPrefix = "/static/attachments/"
+
+// attachmentExtensions lists the file types accepted as attachments.
+var attachmentExtensions = map[string]bool{
+	".pdf":  true,
+	".epub": true,
+	".txt":  true,
+	".csv":  true,
+	".md":   true,
+	".doc":  true,
+	".docx": true,
+	".xls":  true,
+	".xlsx": true,
+	".ppt":  true,
+	".pptx": true,
+	".odt":  true,
+	".ods":  true,
+	".odp":  true,
+	".zip":  true,
+	".gz":   true,
+	".tgz":  true,
+	".tar":  true,
+	".7z":   true,
+	".mp3":  true,
+	".ogg":  true,
+	".oga":  true,
+	".wav":  true,
+	".flac": true,
+	".m4a":  true,
+	".mp4":  true,
+	".webm": true,
+}
+
+// AttachmentFile contains the result of storing an attachment file.
+type AttachmentFile struct {
+	RelativePath string // Path relative to the site attachments directory
+	Filename     string // Generated filename
+	MimeType     string // Detected MIME type
+	SizeBytes    int64  // File size in bytes
+}
+
+// AttachmentManager handles the files of downloadable attachments.
+type AttachmentManager struct {
+	hm.Core
+}
+
+// NewAttachmentManager creates a new AttachmentManager instance.
+func NewAttachmentManager(params hm.XParams) *AttachmentManager {
+	return &AttachmentManager{
+		Core: hm.NewCore("attachment-manager", params),
+	}
+}
+
+// IsAllowedAttachment reports whether a file name has an accepted attachment extension.
+func IsAllowedAttachment(filename string) bool {
+	return attachmentExtensions[strings.ToLower(filepath.Ext(filename))]
+}
+
+// Save stores an uploaded file in the attachments directory of the site in context.
+func (am *AttachmentManager) Save(ctx context.Context, file multipart.File, header *multipart.FileHeader) (*AttachmentFile, error) {
+	siteSlug, ok := GetSiteSlugFromContext(ctx)
+	if !ok || siteSlug == "" {
+		return nil, fmt.Errorf("site slug not found in context")
+	}
+
+	if !IsAllowedAttachment(header.Filename) {
+		return nil, fmt.Errorf("file type not allowed for attachments: %s", filepath.Ext(header.Filename))
+	}
+
+	basePath := am.siteAttachmentsPath(siteSlug)
+	if err := os.MkdirAll(basePath, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	if _, err := file.Seek(0, 0); err != nil {
+		return nil, fmt.Errorf("failed to reset file pointer: %w", err)
+	}
+
+	dst, filename, err := createAttachmentFile(basePath, attachmentFilename(header.Filename, time.Now()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create destination file: %w", err)
+	}
+	fullPath := filepath.Join(basePath, filename)
+
+	size, err := io.Copy(dst, file)
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(fullPath)
+		return nil, fmt.Errorf("failed to copy file content: %w", err)
+	}
+
+	mimeType, err := detectMimeType(fullPath)
+	if err != nil {
+		os.Remove(fullPath)
+		return nil, err
+	}
+
+	am.Log().Debugf("Attachment stored: %s", filename)
+	return &AttachmentFile{
+		RelativePath: filename,
+		Filename:     filename,
+		MimeType:     mimeType,
+		SizeBytes:    size,
+	}, nil
+}
+
+// Delete removes an attachment file by its relative path.
+func (am *AttachmentManager) Delete(ctx context.Context, relativePath string) error {
+	if relativePath == "" {
+		return nil
+	}
+
+	siteSlug, ok := GetSiteSlugFromContext(ctx)
+	if !ok || siteSlug == "" {
+		return fmt.Errorf("site slug not found in context")
+	}
+
+	fullPath := filepath.Join(am.siteAttachmentsPath(siteSlug), relativePath)
+	if err := os.Remove(fullPath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete attachment file %s: %w", fullPath, err)
+	}
+
+	return nil
+}
+
+// siteAttachmentsPath returns the attachments directory of a site.
+func (am *AttachmentManager) siteAttachmentsPath(siteSlug string) string {
+	sitesBasePath := am.Cfg().StrValOrDef(SSGKey.SitesBasePath, "_workspace/sites")
+	return GetSiteAttachmentsPath(sitesBasePath, siteSlug)
+}
+
+// attachmentFilename builds a URL safe name keeping the original extension.
+// Files uploaded with the same name in the same second get the same name, Save
+// makes it unique when the file is created.
+func attachmentFilename(original string, now time.Time) string {
+	ext := strings.ToLower(filepath.Ext(original))
+	base := strings.TrimSuffix(filepath.Base(original), filepath.Ext(original))
+
+	base = regexp.MustCompile(`[^a-zA-Z0-9\-_]`).ReplaceAllString(base, "-")
+	base = regexp.MustCompile(`-+`).ReplaceAllString(base, "-")
+	base = strings.ToLower(strings.Trim(base, "-"))
+	if base == "" {
+		base = "file"
+	}
+
+	return fmt.Sprintf("%s_%d%s", base, now.Unix(), ext)
+}
+
+// maxAttachmentNameTries bounds the names tried when the generated one is taken.
+const maxAttachmentNameTries = 100
+
+// createAttachmentFile creates a new file named filename in dir. If the name
+// is taken, a numeric suffix is added before the extension, so an existing
+// attachment is never overwritten. It returns the file and the name used.
+func createAttachmentFile(dir, filename string) (*os.File, string, error) {
+	ext := filepath.Ext(filename)
+	base := strings.TrimSuffix(filename, ext)
+
+	name := filename
+	for i := 2; i <= maxAttachmentNameTries+1; i++ {
+		f, err := os.OpenFile(filepath.Join(dir, name), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			return f, name, nil
+		}
+		if !os.IsExist(err) {
+			return nil, "", err
+		}
+		name = fmt.Sprintf("%s-%d%s", base, i, ext)
+	}
+
+	return nil, "", fmt.Errorf("no free name for %s", filename)
+}
+
+// detectMimeType returns the MIME type of a stored file from its extension,
+// falling back to sniffing the content.
+func detectMimeType(path string) (string, error) {
+	if mimeType := mime.TypeByExtension(strings.ToLower(filepath.Ext(path))); mimeType != "" {
+		return mimeType, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open attachment: %w", err)
+	}
+	defer f.Close()
+
+	buf := make([]byte, 512)
+	n, err := f.Read(buf)
+	if err != nil && err != io.EOF {
+		return "", fmt.Errorf("failed to read attachment: %w", err)
+	}
+
+	return http.DetectContentType(buf[:n]), nil
+}
@@ -0,0 +1,215 @@
+package ssg
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hermesgen/hm"
+)
+
+func TestAttachmentFilename(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+
+	tests := []struct {
+		name     string
+		original string
+		want     string
+	}{
+		{name: "sanitizes base name", original: "My Report (final).PDF", want: "my-report-final_1700000000.pdf"},
+		{name: "falls back when empty", original: "###.zip", want: "file_1700000000.zip"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := attachmentFilename(tt.original, now); got != tt.want {
+				t.Errorf("attachmentFilename() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAttachmentManagerSave(t *testing.T) {
+	tests := []struct {
+		name     string
+		filename string
+		data     []byte
+		wantErr  bool
+		wantMime string
+	}{
+		{
+			name:     "stores pdf",
+			filename: "guide.pdf",
+			data:     []byte("%PDF-1.4 test"),
+			wantMime: "application/pdf",
+		},
+		{
+			name:     "rejects executables",
+			filename: "tool.exe",
+			data:     []byte("MZ"),
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sitesBase := t.TempDir()
+			cfg := hm.NewConfig()
+			cfg.Set(SSGKey.SitesBasePath, sitesBase)
+			am := NewAttachmentManager(hm.XParams{Cfg: cfg, Log: hm.NewLogger("error")})
+			ctx := context.WithValue(context.Background(), siteSlugKey, "test")
+
+			file, header := testMultipartFile(t, tt.filename, tt.data)
+			got, err := am.Save(ctx, file, header)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Save() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if got.SizeBytes != int64(len(tt.data)) {
+				t.Errorf("SizeBytes = %d, want %d", got.SizeBytes, len(tt.data))
+			}
+			if !strings.HasPrefix(got.MimeType, tt.wantMime) {
+				t.Errorf("MimeType = %v, want %v", got.MimeType, tt.wantMime)
+			}
+
+			path := filepath.Join(GetSiteAttachmentsPath(sitesBase, "test"), got.RelativePath)
+			if readTestFile(t, path) != string(tt.data) {
+				t.Errorf("stored content does not match upload")
+			}
+
+			if err := am.Delete(ctx, got.RelativePath); err != nil {
+				t.Fatalf("Delete() error = %v", err)
+			}
+			if _, err := os.Stat(path); !os.IsNotExist(err) {
+				t.Errorf("file still exists after Delete()")
+			}
+		})
+	}
+}
+
+func TestAttachmentManagerSaveSameName(t *testing.T) {
+	sitesBase := t.TempDir()
+	cfg := hm.NewConfig()
+	cfg.Set(SSGKey.SitesBasePath, sitesBase)
+	am := NewAttachmentManager(hm.XParams{Cfg: cfg, Log: hm.NewLogger("error")})
+	ctx := context.WithValue(context.Background(), siteSlugKey, "test")
+	dir := GetSiteAttachmentsPath(sitesBase, "test")
+
+	first, header := testMultipartFile(t, "guide.pdf", []byte("first"))
+	stored1, err := am.Save(ctx, first, header)
+	if err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	second, header := testMultipartFile(t, "guide.pdf", []byte("second"))
+	stored2, err := am.Save(ctx, second, header)
+	if err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	if stored1.RelativePath == stored2.RelativePath {
+		t.Fatalf("both uploads stored as %s", stored1.RelativePath)
+	}
+	if got := readTestFile(t, filepath.Join(dir, stored1.RelativePath)); got != "first" {
+		t.Errorf("first attachment content = %q, want %q", got, "first")
+	}
+
+	// A failed record of the second upload only removes its own file.
+	if err := am.Delete(ctx, stored2.RelativePath); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if got := readTestFile(t, filepath.Join(dir, stored1.RelativePath)); got != "first" {
+		t.Errorf("first attachment content = %q after deleting the second, want %q", got, "first")
+	}
+}
+
+func TestCreateAttachmentFileTakenName(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "guide_1700000000.pdf"), []byte("taken"), 0644); err != nil {
+		t.Fatalf("cannot write file: %v", err)
+	}
+
+	f, name, err := createAttachmentFile(dir, "guide_1700000000.pdf")
+	if err != nil {
+		t.Fatalf("createAttachmentFile() error = %v", err)
+	}
+	f.Close()
+
+	if name != "guide_1700000000-2.pdf" {
+		t.Errorf("name = %q, want %q", name, "guide_1700000000-2.pdf")
+	}
+	if got := readTestFile(t, filepath.Join(dir, "guide_1700000000.pdf")); got != "taken" {
+		t.Errorf("existing file was overwritten: %q", got)
+	}
+}
+
+func TestEnhanceAttachmentLinksInHTML(t *testing.T) {
+	attachments := map[string]AttachmentMetadata{
+		"guide_1.pdf": {Kind: "PDF", Size: "1.2 MB"},
+	}
+
+	tests := []struct {
+		name string
+		html string
+		want string
+	}{
+		{
+			name: "known attachment",
+			html: `<p>Get the <a href="/static/attachments/guide_1.pdf">guide</a>.</p>`,
+			want: `<p>Get the <a href="/static/attachments/guide_1.pdf" class="prose-attachment" download>guide</a> <span class="prose-attachment-meta">(PDF, 1.2 MB)</span>.</p>`,
+		},
+		{
+			name: "unknown attachment is left alone",
+			html: `<a href="/static/attachments/missing.zip">zip</a>`,
+			want: `<a href="/static/attachments/missing.zip">zip</a>`,
+		},
+		{
+			name: "other links are left alone",
+			html: `<a href="/about/">About</a>`,
+			want: `<a href="/about/">About</a>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := enhanceAttachmentLinksInHTML(tt.html, attachments); got != tt.want {
+				t.Errorf("enhanceAttachmentLinksInHTML() =\n%v\nwant\n%v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCopyDynamicAttachmentsSkipsHidden(t *testing.T) {
+	docsDir := t.TempDir()
+	htmlDir := t.TempDir()
+
+	for _, f := range []string{"guide.pdf", ".history/old.pdf", ".DS_Store"} {
+		path := filepath.Join(docsDir, "assets", "attachments", f)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("cannot create dir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte("data"), 0644); err != nil {
+			t.Fatalf("cannot write file: %v", err)
+		}
+	}
+
+	if err := CopyDynamicAttachments(docsDir, htmlDir); err != nil {
+		t.Fatalf("CopyDynamicAttachments() error = %v", err)
+	}
+
+	target := filepath.Join(htmlDir, "static", "attachments")
+	if _, err := os.Stat(filepath.Join(target, "guide.pdf")); err != nil {
+		t.Errorf("guide.pdf was not copied: %v", err)
+	}
+	for _, hidden := range []string{".history", ".DS_Store"} {
+		if _, err := os.Stat(filepath.Join(target, hidden)); !os.IsNotExist(err) {
+			t.Errorf("%s should not be copied", hidden)
+		}
+	}
+}
@@ -0,0 +1,542 @@
+package ssg
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// BundleFormatVersion is the version of the layout of site bundles. Bundles
+// of a newer format are rejected.
+const BundleFormatVersion = 1
+
+const (
+	bundleManifestName = "manifest.json"
+	bundleDataDir      = "data"
+	bundleFilesDir     = "files"
+)
+
+// bundleSkipDirs are the directories of a site, relative to its base path,
+// left out of bundles: the database and the generated output, built again from
+// the restored data.
+var bundleSkipDirs = []string{"db", "documents/html", "documents/markdown"}
+
+// BundleManifest describes a site bundle: what site it holds and the schema
+// version of the instance it was exported from.
+type BundleManifest struct {
+	FormatVersion int            `json:"format_version"`
+	SchemaVersion string         `json:"schema_version"`
+	Site          BundleSite     `json:"site"`
+	ExportedAt    time.Time      `json:"exported_at"`
+	Tables        map[string]int `json:"tables"`
+	Files         int            `json:"files"`
+}
+
+// BundleSite is the site a bundle was exported from.
+type BundleSite struct {
+	ID   uuid.UUID `json:"id"`
+	Name string    `json:"name"`
+	Slug string    `json:"slug"`
+	Mode string    `json:"mode"`
+}
+
+// SiteTable holds the rows of a table that belong to a site, keyed by column.
+type SiteTable struct {
+	Name string
+	Rows []map[string]interface{}
+}
+
+// SiteBundle is a site ready to be written as a self contained tar.gz archive:
+// the manifest, the rows of every table of the site and its files.
+type SiteBundle struct {
+	Manifest BundleManifest
+	Tables   []SiteTable
+	dir      string
+	files    []string
+}
+
+// FileName returns the name the bundle is downloaded as.
+func (b *SiteBundle) FileName() string {
+	return fmt.Sprintf("%s-%s.clio.tar.gz", b.Manifest.Site.Slug, b.Manifest.ExportedAt.Format("20060102-150405"))
+}
+
+// Write writes the bundle to w. The manifest goes first so readers can reject
+// a bundle before reading the rest.
+func (b *SiteBundle) Write(w io.Writer) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	manifest, err := json.MarshalIndent(b.Manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("cannot encode manifest: %w", err)
+	}
+	if err := writeTarEntry(tw, bundleManifestName, manifest, b.Manifest.ExportedAt); err != nil {
+		return err
+	}
+
+	for _, t := range b.Tables {
+		data, err := json.Marshal(t.Rows)
+		if err != nil {
+			return fmt.Errorf("cannot encode %s rows: %w", t.Name, err)
+		}
+		if err := writeTarEntry(tw, path.Join(bundleDataDir, t.Name+".json"), data, b.Manifest.ExportedAt); err != nil {
+			return err
+		}
+	}
+
+	for _, rel := range b.files {
+		p := filepath.Join(b.dir, filepath.FromSlash(rel))
+		info, err := os.Stat(p)
+		if err != nil {
+			return err
+		}
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = path.Join(bundleFilesDir, rel)
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if err := copyInto(tw, p); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+func writeTarEntry(tw *tar.Writer, name string, data []byte, modTime time.Time) error {
+	header := &tar.Header{
+		Name:    name,
+		Mode:    0644,
+		Size:    int64(len(data)),
+		ModTime: modTime,
+	}
+	if err := tw.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err := tw.Write(data)
+	return err
+}
+
+// readBundle reads a bundle written by SiteBundle.Write, extracting its files
+// into filesDir.
+func readBundle(r io.Reader, filesDir string) (BundleManifest, []SiteTable, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return BundleManifest{}, nil, fmt.Errorf("not a site bundle: %w", err)
+	}
+	tr := tar.NewReader(gz)
+
+	var manifest BundleManifest
+	var tables []SiteTable
+	for first := true; ; first = false {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return BundleManifest{}, nil, fmt.Errorf("cannot read bundle: %w", err)
+		}
+
+		if first {
+			if header.Name != bundleManifestName {
+				return BundleManifest{}, nil, fmt.Errorf("not a site bundle: missing manifest")
+			}
+			if err := json.NewDecoder(tr).Decode(&manifest); err != nil {
+				return BundleManifest{}, nil, fmt.Errorf("cannot read manifest: %w", err)
+			}
+			if manifest.FormatVersion < 1 || manifest.FormatVersion > BundleFormatVersion {
+				return BundleManifest{}, nil, fmt.Errorf("unsupported bundle format version %d", manifest.FormatVersion)
+			}
+			continue
+		}
+
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		switch dir, name := path.Split(header.Name); {
+		case dir == bundleDataDir+"/" && strings.HasSuffix(name, ".json"):
+			rows, err := readBundleRows(tr)
+			if err != nil {
+				return BundleManifest{}, nil, fmt.Errorf("cannot read %s: %w", header.Name, err)
+			}
+			tables = append(tables, SiteTable{Name: strings.TrimSuffix(name, ".json"), Rows: rows})
+
+		case strings.HasPrefix(header.Name, bundleFilesDir+"/"):
+			rel := strings.TrimPrefix(header.Name, bundleFilesDir+"/")
+			target := filepath.Join(filesDir, filepath.FromSlash(rel))
+			if !isWithin(target, filesDir) {
+				return BundleManifest{}, nil, fmt.Errorf("invalid path in bundle: %s", header.Name)
+			}
+			if err := extractBundleFile(tr, target, os.FileMode(header.Mode).Perm()); err != nil {
+				return BundleManifest{}, nil, fmt.Errorf("cannot extract %s: %w", rel, err)
+			}
+		}
+	}
+
+	if manifest.FormatVersion == 0 {
+		return BundleManifest{}, nil, fmt.Errorf("not a site bundle: missing manifest")
+	}
+	return manifest, tables, nil
+}
+
+// readBundleRows decodes the rows of a table keeping integers as int64, as
+// the database returns them.
+func readBundleRows(r io.Reader) ([]map[string]interface{}, error) {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+
+	var rows []map[string]interface{}
+	if err := dec.Decode(&rows); err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		for col, val := range row {
+			n, ok := val.(json.Number)
+			if !ok {
+				continue
+			}
+			if i, err := n.Int64(); err == nil {
+				row[col] = i
+			} else if f, err := n.Float64(); err == nil {
+				row[col] = f
+			}
+		}
+	}
+	return rows, nil
+}
+
+func extractBundleFile(r io.Reader, target string, mode os.FileMode) error {
+	if mode == 0 {
+		mode = 0644
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, r); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// bundleFiles lists the files of the site directory dir that go into a
+// bundle.
+func bundleFiles(dir string) ([]string, error) {
+	files, err := listFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var bundled []string
+	for _, rel := range sortedKeys(files) {
+		skip := false
+		for _, d := range bundleSkipDirs {
+			if strings.HasPrefix(rel, d+"/") {
+				skip = true
+				break
+			}
+		}
+		if !skip {
+			bundled = append(bundled, rel)
+		}
+	}
+	return bundled, nil
+}
+
+// remapSiteIDs gives every row of tables a new ID, updating the references
+// between rows, and moves them from the site oldSiteID to siteID. References
+// to rows outside the bundle, such as users, are left as they are.
+func remapSiteIDs(tables []SiteTable, oldSiteID, siteID uuid.UUID) {
+	ids := map[string]string{oldSiteID.String(): siteID.String()}
+	for _, t := range tables {
+		for _, row := range t.Rows {
+			if id, ok := row["id"].(string); ok && id != oldSiteID.String() {
+				ids[id] = uuid.NewString()
+			}
+		}
+	}
+
+	for _, t := range tables {
+		for _, row := range t.Rows {
+			for col, val := range row {
+				if col != "id" && !strings.HasSuffix(col, "_id") && col != "rollback_of" {
+					continue
+				}
+				if s, ok := val.(string); ok {
+					if id, ok := ids[s]; ok {
+						row[col] = id
+					}
+				}
+			}
+		}
+	}
+}
+
+// adoptSiteImages makes the site siteID the owner of the image rows of
+// tables, which include the images it links but does not own, so a bundle or
+// a clone of the site holds them as its own.
+func adoptSiteImages(tables []SiteTable, siteID uuid.UUID) {
+	for _, t := range tables {
+		if t.Name != "image" {
+			continue
+		}
+		for _, row := range t.Rows {
+			row["site_id"] = siteID.String()
+		}
+	}
+}
+
+// bundleRowRefs are, for each table but site, the columns tying its rows to
+// the site: site_id, or a reference to a row of another table of the bundle.
+var bundleRowRefs = map[string]map[string]string{
+	"image":               {"site_id": "site"},
+	"layout":              {"site_id": "site"},
+	"section":             {"site_id": "site"},
+	"content":             {"site_id": "site"},
+	"meta":                {"site_id": "site", "content_id": "content"},
+	"tag":                 {"site_id": "site"},
+	"content_tag":         {"content_id": "content", "tag_id": "tag"},
+	"param":               {"site_id": "site"},
+	"image_variant":       {"image_id": "image"},
+	"image_revision":      {"image_id": "image"},
+	"content_images":      {"content_id": "content", "image_id": "image"},
+	"section_images":      {"section_id": "section", "image_id": "image"},
+	"attachment":          {"site_id": "site"},
+	"content_attachments": {"content_id": "content", "attachment_id": "attachment"},
+	"redirect":            {"site_id": "site"},
+	"publish_record":      {"site_id": "site"},
+}
+
+// checkBundleRows makes sure every row of tables belongs to the site siteID,
+// so a crafted or corrupt bundle cannot add rows to another site. The bundle
+// must hold exactly one site row, the one of siteID.
+func checkBundleRows(tables []SiteTable, siteID uuid.UUID) error {
+	ids := map[string]map[string]bool{}
+	siteRows := 0
+	for _, t := range tables {
+		ids[t.Name] = map[string]bool{}
+		for _, row := range t.Rows {
+			id, _ := row["id"].(string)
+			ids[t.Name][id] = true
+		}
+		if t.Name == "site" {
+			siteRows += len(t.Rows)
+		}
+	}
+	if siteRows != 1 || !ids["site"][siteID.String()] {
+		return fmt.Errorf("bundle must hold the record of site %s and no other", siteID)
+	}
+
+	for _, t := range tables {
+		if t.Name == "site" {
+			continue
+		}
+		refs, ok := bundleRowRefs[t.Name]
+		if !ok {
+			return fmt.Errorf("unknown table %q", t.Name)
+		}
+		for _, row := range t.Rows {
+			for col, table := range refs {
+				ref, _ := row[col].(string)
+				if table == "site" && ref != siteID.String() {
+					return fmt.Errorf("%s row %v belongs to another site", t.Name, row["id"])
+				}
+				if table != "site" && !ids[table][ref] {
+					return fmt.Errorf("%s row %v refers to a %s that is not in the bundle", t.Name, row["id"], table)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// bundleSecretParams are the params a bundle never carries: credentials and
+// where they are on the exporting machine. They are exported with an empty
+// value and have to be set again after a restore.
+var bundleSecretParams = []string{
+	SSGKey.PublishAuthToken,
+	SSGKey.PublishAuthSSHKey,
+}
+
+// scrubBundleSecrets empties the values of bundleSecretParams in tables.
+func scrubBundleSecrets(tables []SiteTable) {
+	secret := map[string]bool{}
+	for _, key := range bundleSecretParams {
+		secret[key] = true
+	}
+
+	for _, t := range tables {
+		if t.Name != "param" {
+			continue
+		}
+		for _, row := range t.Rows {
+			if key, ok := row["ref_key"].(string); ok && secret[key] {
+				row["value"] = ""
+			}
+		}
+	}
+}
+
+// ExportSite prepares the bundle of the site id: every row of the site, from
+// contents to params and redirects, and the files of its directory but the
+// generated output. Secrets are left out, see bundleSecretParams.
+func (sm *SiteManager) ExportSite(ctx context.Context, id uuid.UUID) (*SiteBundle, error) {
+	site, err := sm.siteRepo.GetSite(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	schemaVersion, err := sm.repo.SchemaVersion(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get schema version: %w", err)
+	}
+
+	tables, err := sm.repo.ExportSiteTables(ctx, site.ID)
+	if err != nil {
+		return nil, fmt.Errorf("cannot export site data: %w", err)
+	}
+	adoptSiteImages(tables, site.ID)
+	scrubBundleSecrets(tables)
+
+	sitesBasePath := sm.Cfg().StrValOrDef(SSGKey.SitesBasePath, "_workspace/sites")
+	dir := GetSiteBasePath(sitesBasePath, site.Slug())
+	files, err := bundleFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := BundleManifest{
+		FormatVersion: BundleFormatVersion,
+		SchemaVersion: schemaVersion,
+		Site: BundleSite{
+			ID:   site.ID,
+			Name: site.Name,
+			Slug: site.Slug(),
+			Mode: site.Mode,
+		},
+		ExportedAt: time.Now(),
+		Tables:     map[string]int{},
+		Files:      len(files),
+	}
+	for _, t := range tables {
+		manifest.Tables[t.Name] = len(t.Rows)
+	}
+
+	sm.Log().Info("Site bundle prepared", "slug", site.Slug(), "tables", len(tables), "files", len(files))
+	return &SiteBundle{Manifest: manifest, Tables: tables, dir: dir, files: files}, nil
+}
+
+// RestoreSite creates a site from the bundle read from r, under slug and name
+// or, when empty, those of the exported site. The IDs of the bundle are kept
+// unless the site already exists in this instance, in which case every row
+// gets a new one. Bundles from a newer schema are rejected, and so are those
+// with rows of other sites, see checkBundleRows.
+func (sm *SiteManager) RestoreSite(ctx context.Context, r io.Reader, slug, name string) (Site, error) {
+	sitesBasePath := sm.Cfg().StrValOrDef(SSGKey.SitesBasePath, "_workspace/sites")
+	if err := os.MkdirAll(sitesBasePath, 0755); err != nil {
+		return Site{}, fmt.Errorf("cannot create sites dir: %w", err)
+	}
+
+	staging, err := os.MkdirTemp(sitesBasePath, ".restore-*")
+	if err != nil {
+		return Site{}, fmt.Errorf("cannot create staging dir: %w", err)
+	}
+	defer os.RemoveAll(staging)
+
+	manifest, tables, err := readBundle(r, staging)
+	if err != nil {
+		return Site{}, err
+	}
+
+	schemaVersion, err := sm.repo.SchemaVersion(ctx)
+	if err != nil {
+		return Site{}, fmt.Errorf("cannot get schema version: %w", err)
+	}
+	if manifest.SchemaVersion > schemaVersion {
+		return Site{}, fmt.Errorf("bundle schema %s is newer than this instance schema %s", manifest.SchemaVersion, schemaVersion)
+	}
+
+	if slug == "" {
+		slug = manifest.Site.Slug
+	}
+	slug = NormalizeSlug(slug)
+	if slug == "" {
+		return Site{}, fmt.Errorf("invalid slug")
+	}
+	if name == "" {
+		name = manifest.Site.Name
+	}
+
+	if existing, err := sm.siteRepo.GetSiteBySlug(ctx, slug); err == nil && !existing.IsZero() {
+		return Site{}, fmt.Errorf("site with slug '%s' already exists", slug)
+	}
+	siteDir := GetSiteBasePath(sitesBasePath, slug)
+	if _, err := os.Stat(siteDir); err == nil {
+		return Site{}, fmt.Errorf("directory for site '%s' already exists: %s", slug, siteDir)
+	}
+
+	siteID := manifest.Site.ID
+	if existing, err := sm.siteRepo.GetSite(ctx, siteID); err == nil && !existing.IsZero() {
+		siteID = uuid.New()
+		remapSiteIDs(tables, manifest.Site.ID, siteID)
+		sm.Log().Info("Site already exists, restoring with new IDs", "id", manifest.Site.ID, "new_id", siteID)
+	}
+
+	if err := checkBundleRows(tables, siteID); err != nil {
+		return Site{}, fmt.Errorf("invalid bundle: %w", err)
+	}
+
+	for _, t := range tables {
+		if t.Name != "site" {
+			continue
+		}
+		for _, row := range t.Rows {
+			row["slug"] = slug
+			row["name"] = name
+			row["updated_at"] = time.Now()
+		}
+	}
+
+	if err := os.Rename(staging, siteDir); err != nil {
+		return Site{}, fmt.Errorf("cannot move site files into place: %w", err)
+	}
+	if err := sm.createSiteDirectories(slug); err != nil {
+		sm.deleteSiteDirectories(slug)
+		return Site{}, fmt.Errorf("failed to create directories: %w", err)
+	}
+
+	if err := sm.repo.ImportSiteTables(ctx, tables); err != nil {
+		sm.deleteSiteDirectories(slug)
+		return Site{}, fmt.Errorf("cannot restore site data: %w", err)
+	}
+
+	site, err := sm.siteRepo.GetSite(ctx, siteID)
+	if err != nil {
+		return Site{}, err
+	}
+	sm.writeSiteMarker(site)
+
+	sm.Log().Info("Site restored", "id", site.ID, "slug", slug, "from", manifest.Site.Slug)
+	return site, nil
+}
@@ -0,0 +1,265 @@
+package ssg
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestSiteBundleRoundTrip(t *testing.T) {
+	siteDir := t.TempDir()
+	writeSiteFiles(t, siteDir, map[string]string{
+		"documents/assets/images/cover.png": "png",
+		"documents/html/index.html":         "<html></html>",
+		"documents/markdown/post.md":        "# Post",
+		"db/clio.db":                        "db",
+	})
+
+	files, err := bundleFiles(siteDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 || files[0] != "documents/assets/images/cover.png" {
+		t.Fatalf("bundleFiles() = %v, want the image only", files)
+	}
+
+	siteID := uuid.New()
+	bundle := &SiteBundle{
+		Manifest: BundleManifest{
+			FormatVersion: BundleFormatVersion,
+			SchemaVersion: "20261019150000",
+			Site:          BundleSite{ID: siteID, Name: "Blog", Slug: "blog", Mode: "blog"},
+			ExportedAt:    time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC),
+			Files:         len(files),
+		},
+		Tables: []SiteTable{
+			{Name: "site", Rows: []map[string]interface{}{{"id": siteID.String(), "slug": "blog"}}},
+			{Name: "param", Rows: []map[string]interface{}{{"id": uuid.NewString(), "site_id": siteID.String(), "ref_key": "ssg.blog.maxitems", "position": int64(3)}}},
+		},
+		dir:   siteDir,
+		files: files,
+	}
+	if got := bundle.FileName(); got != "blog-20261019-120000.clio.tar.gz" {
+		t.Errorf("FileName() = %q", got)
+	}
+
+	var buf bytes.Buffer
+	if err := bundle.Write(&buf); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	filesDir := t.TempDir()
+	manifest, tables, err := readBundle(&buf, filesDir)
+	if err != nil {
+		t.Fatalf("readBundle() error = %v", err)
+	}
+	if manifest.Site.ID != siteID || manifest.SchemaVersion != "20261019150000" {
+		t.Errorf("manifest = %+v", manifest)
+	}
+	if len(tables) != 2 || tables[1].Name != "param" {
+		t.Fatalf("tables = %+v", tables)
+	}
+	if pos, ok := tables[1].Rows[0]["position"].(int64); !ok || pos != 3 {
+		t.Errorf("position = %#v, want int64 3", tables[1].Rows[0]["position"])
+	}
+	data, err := os.ReadFile(filepath.Join(filesDir, "documents", "assets", "images", "cover.png"))
+	if err != nil || string(data) != "png" {
+		t.Errorf("extracted image = %q, %v", data, err)
+	}
+}
+
+func TestReadBundleRejects(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries map[string]string
+		order   []string
+		wantErr string
+	}{
+		{
+			name:    "missing manifest",
+			order:   []string{"data/site.json"},
+			entries: map[string]string{"data/site.json": "[]"},
+			wantErr: "missing manifest",
+		},
+		{
+			name:    "newer format",
+			order:   []string{bundleManifestName},
+			entries: map[string]string{bundleManifestName: `{"format_version": 99}`},
+			wantErr: "unsupported bundle format",
+		},
+		{
+			name:  "path traversal",
+			order: []string{bundleManifestName, "files/../../evil.txt"},
+			entries: map[string]string{
+				bundleManifestName:     `{"format_version": 1}`,
+				"files/../../evil.txt": "evil",
+			},
+			wantErr: "invalid path",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			gz := gzip.NewWriter(&buf)
+			tw := tar.NewWriter(gz)
+			for _, name := range tt.order {
+				if err := writeTarEntry(tw, name, []byte(tt.entries[name]), time.Now()); err != nil {
+					t.Fatal(err)
+				}
+			}
+			tw.Close()
+			gz.Close()
+
+			_, _, err := readBundle(&buf, t.TempDir())
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("readBundle() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRemapSiteIDs(t *testing.T) {
+	oldSiteID, siteID := uuid.New(), uuid.New()
+	contentID, imageID, userID := uuid.NewString(), uuid.NewString(), uuid.NewString()
+	tables := []SiteTable{
+		{Name: "site", Rows: []map[string]interface{}{{"id": oldSiteID.String()}}},
+		{Name: "content", Rows: []map[string]interface{}{{"id": contentID, "site_id": oldSiteID.String(), "user_id": userID}}},
+		{Name: "image", Rows: []map[string]interface{}{{"id": imageID, "site_id": oldSiteID.String()}}},
+		{Name: "content_images", Rows: []map[string]interface{}{{"id": uuid.NewString(), "content_id": contentID, "image_id": imageID}}},
+	}
+
+	remapSiteIDs(tables, oldSiteID, siteID)
+
+	if tables[0].Rows[0]["id"] != siteID.String() {
+		t.Errorf("site id = %v, want %v", tables[0].Rows[0]["id"], siteID)
+	}
+	content, image, link := tables[1].Rows[0], tables[2].Rows[0], tables[3].Rows[0]
+	if content["id"] == contentID || image["id"] == imageID {
+		t.Errorf("row ids not remapped: %v, %v", content["id"], image["id"])
+	}
+	if content["site_id"] != siteID.String() || content["user_id"] != userID {
+		t.Errorf("content = %v, want the new site and the same user", content)
+	}
+	if link["content_id"] != content["id"] || link["image_id"] != image["id"] {
+		t.Errorf("content image link = %v, want the new content and image ids", link)
+	}
+}
+
+type fakeExportRepo struct {
+	Repo
+	tables []SiteTable
+}
+
+func (f *fakeExportRepo) SchemaVersion(ctx context.Context) (string, error) {
+	return "20261019150000", nil
+}
+
+func (f *fakeExportRepo) ExportSiteTables(ctx context.Context, siteID uuid.UUID) ([]SiteTable, error) {
+	return f.tables, nil
+}
+
+func TestExportSiteLeavesSecretsOut(t *testing.T) {
+	site := NewSite("Blog", "blog", "blog")
+	site.GenID()
+	sm, _, _, _ := newTestSiteManager(t, site)
+	sm.repo = &fakeExportRepo{tables: []SiteTable{
+		{Name: "param", Rows: []map[string]interface{}{
+			{"id": "p1", "ref_key": SSGKey.PublishAuthToken, "value": "ghp_secret"},
+			{"id": "p2", "ref_key": SSGKey.PublishAuthSSHKey, "value": "/home/me/.ssh/id_ed25519"},
+			{"id": "p3", "ref_key": SSGKey.PublishRepoURL, "value": "git@example.com:me/site.git"},
+		}},
+	}}
+
+	bundle, err := sm.ExportSite(context.Background(), site.ID)
+	if err != nil {
+		t.Fatalf("ExportSite() error = %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := bundle.Write(&buf); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	_, tables, err := readBundle(&buf, t.TempDir())
+	if err != nil {
+		t.Fatalf("readBundle() error = %v", err)
+	}
+
+	values := map[string]interface{}{}
+	for _, row := range tables[0].Rows {
+		values[row["ref_key"].(string)] = row["value"]
+	}
+	if values[SSGKey.PublishAuthToken] != "" || values[SSGKey.PublishAuthSSHKey] != "" {
+		t.Errorf("bundle carries secrets: %v", values)
+	}
+	if values[SSGKey.PublishRepoURL] != "git@example.com:me/site.git" {
+		t.Errorf("repo URL = %v, want it kept", values[SSGKey.PublishRepoURL])
+	}
+}
+
+func TestCheckBundleRows(t *testing.T) {
+	siteID := uuid.New()
+	site := siteID.String()
+	other := uuid.NewString()
+
+	tables := func(siteRows []map[string]interface{}, contentSite, linkedContent string) []SiteTable {
+		return []SiteTable{
+			{Name: "site", Rows: siteRows},
+			{Name: "image", Rows: []map[string]interface{}{{"id": "i1", "site_id": site}}},
+			{Name: "content", Rows: []map[string]interface{}{{"id": "c1", "site_id": contentSite}}},
+			{Name: "content_images", Rows: []map[string]interface{}{{"id": "l1", "content_id": linkedContent, "image_id": "i1"}}},
+		}
+	}
+	siteRow := []map[string]interface{}{{"id": site}}
+
+	tests := []struct {
+		name    string
+		tables  []SiteTable
+		wantErr bool
+	}{
+		{name: "rows of the site", tables: tables(siteRow, site, "c1")},
+		{name: "row of another site", tables: tables(siteRow, other, "c1"), wantErr: true},
+		{name: "link to a row outside the bundle", tables: tables(siteRow, site, uuid.NewString()), wantErr: true},
+		{name: "no site row", tables: tables(nil, site, "c1"), wantErr: true},
+		{name: "site row of another site", tables: tables([]map[string]interface{}{{"id": other}}, site, "c1"), wantErr: true},
+		{name: "two site rows", tables: tables([]map[string]interface{}{{"id": site}, {"id": other}}, site, "c1"), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkBundleRows(tt.tables, siteID); (err != nil) != tt.wantErr {
+				t.Errorf("checkBundleRows() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestExportSiteAdoptsLinkedImages(t *testing.T) {
+	site := NewSite("Blog", "blog", "blog")
+	site.GenID()
+	sm, _, _, _ := newTestSiteManager(t, site)
+	sm.repo = &fakeExportRepo{tables: []SiteTable{
+		{Name: "image", Rows: []map[string]interface{}{
+			{"id": "i1", "site_id": site.ID.String()},
+			{"id": "i2", "site_id": uuid.Nil.String()},
+		}},
+	}}
+
+	bundle, err := sm.ExportSite(context.Background(), site.ID)
+	if err != nil {
+		t.Fatalf("ExportSite() error = %v", err)
+	}
+	for _, row := range bundle.Tables[0].Rows {
+		if row["site_id"] != site.ID.String() {
+			t.Errorf("image %v site = %v, want the exported site", row["id"], row["site_id"])
+		}
+	}
+}
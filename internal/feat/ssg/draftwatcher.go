@@ -0,0 +1,126 @@
+package ssg
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/hermesgen/hm"
+)
+
+const defaultWatchInterval = 10 * time.Second
+
+type siteLister interface {
+	ListSites(ctx context.Context, activeOnly bool) ([]Site, error)
+}
+
+// DraftWatcher polls the watched drafts directory of every site and submits an
+// import job when there are new or modified files.
+type DraftWatcher struct {
+	hm.Core
+	svc    Service
+	sites  siteLister
+	jobs   *JobRunner
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewDraftWatcher creates a drafts watcher.
+func NewDraftWatcher(svc Service, sites siteLister, jobs *JobRunner, params hm.XParams) *DraftWatcher {
+	return &DraftWatcher{
+		Core:  hm.NewCore("ssg-draft-watcher", params),
+		svc:   svc,
+		sites: sites,
+		jobs:  jobs,
+	}
+}
+
+// Start begins polling in the background every ssg.watch.interval.
+func (w *DraftWatcher) Start(ctx context.Context) error {
+	interval := defaultWatchInterval
+	if v := w.Cfg().StrValOrDef(SSGKey.WatchInterval, ""); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			w.Log().Info("Invalid watch interval, using default", "value", v, "default", defaultWatchInterval)
+		} else {
+			interval = d
+		}
+	}
+
+	pollCtx, cancel := context.WithCancel(context.Background())
+	w.cancel = cancel
+	w.done = make(chan struct{})
+
+	go func() {
+		defer close(w.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-pollCtx.Done():
+				return
+			case <-ticker.C:
+				w.Poll(pollCtx)
+			}
+		}
+	}()
+
+	return nil
+}
+
+// Stop ends polling and waits for the current poll to return.
+func (w *DraftWatcher) Stop(ctx context.Context) error {
+	if w.cancel == nil {
+		return nil
+	}
+	w.cancel()
+
+	select {
+	case <-w.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Poll checks the watched directory of each active site once and submits an
+// import job for the sites with pending files. Sites already running a job are
+// left for the next poll.
+func (w *DraftWatcher) Poll(ctx context.Context) {
+	sites, err := w.sites.ListSites(ctx, true)
+	if err != nil {
+		w.Log().Error("Cannot list sites to watch", "error", err)
+		return
+	}
+
+	for _, site := range sites {
+		if ctx.Err() != nil {
+			return
+		}
+
+		siteCtx := context.WithValue(ctx, siteSlugKey, site.Slug())
+		siteCtx = context.WithValue(siteCtx, siteIDKey, site.ID)
+
+		status, err := w.svc.WatchStatus(siteCtx)
+		if err != nil {
+			w.Log().Error("Cannot check watched drafts", "slug", site.Slug(), "error", err)
+			continue
+		}
+		if !status.Enabled || len(status.Pending) == 0 {
+			continue
+		}
+
+		_, err = w.jobs.Submit(siteCtx, JobImportWatched, func(ctx context.Context) (string, error) {
+			report, err := w.svc.ImportWatched(ctx)
+			return report.Summary, err
+		})
+		if errors.Is(err, ErrSiteBusy) {
+			continue
+		}
+		if err != nil {
+			w.Log().Error("Cannot submit watched drafts import", "slug", site.Slug(), "error", err)
+			continue
+		}
+		w.Log().Info("Importing watched drafts", "slug", site.Slug(), "files", len(status.Pending))
+	}
+}
@@ -56,7 +56,11 @@ func (g *Generator) Generate(ctx context.Context, siteSlug string, contents []Co
 
 		// Content
 		frontMatter = append(frontMatter, yaml.MapItem{Key: "excerpt", Value: content.Meta.Description}) // Using description as a stand-in
-		frontMatter = append(frontMatter, yaml.MapItem{Key: "summary", Value: ""})                       // TODO: Add a summary field if needed
+		frontMatter = append(frontMatter, yaml.MapItem{Key: "summary", Value: content.Summary})
+		if content.Series != "" {
+			frontMatter = append(frontMatter, yaml.MapItem{Key: "series", Value: content.Series})
+			frontMatter = append(frontMatter, yaml.MapItem{Key: "series-order", Value: content.SeriesOrder})
+		}
 		frontMatter = append(frontMatter, yaml.MapItem{Key: "description", Value: content.Meta.Description})
 
 		// Media
@@ -0,0 +1,150 @@
+package ssg
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/hermesgen/hm"
+)
+
+// GitCredentialCommand is the subcommand that makes the clio binary act as a
+// git credential helper (see gitcredentials(7)).
+const GitCredentialCommand = "git-credential"
+
+const (
+	// gitTokenEnv carries the token from the publisher to the helper process.
+	// Environment variables are only readable by the same user and never show
+	// up in process listings or on disk.
+	gitTokenEnv      = "CLIO_GIT_TOKEN"
+	gitTokenUsername = "x-access-token"
+	redactedValue    = "********"
+)
+
+var urlCredentialsRe = regexp.MustCompile(`(://[^/@\s]*?:)[^@/\s]+@`)
+
+// gitAuthEnv returns the environment variables that provide credentials to
+// git for cfg. Tokens are answered by helper through the credential helper
+// protocol; ssh auth selects the configured private key.
+func gitAuthEnv(cfg PublisherConfig, helper string) ([]string, error) {
+	env := []string{"GIT_TERMINAL_PROMPT=0"}
+
+	switch cfg.Auth.Method {
+	case hm.AuthToken:
+		if helper == "" {
+			return nil, errors.New("no credential helper available for token auth")
+		}
+		// The empty helper resets any helper inherited from the user config
+		env = append(env,
+			"GIT_CONFIG_COUNT=2",
+			"GIT_CONFIG_KEY_0=credential.helper",
+			"GIT_CONFIG_VALUE_0=",
+			"GIT_CONFIG_KEY_1=credential.helper",
+			"GIT_CONFIG_VALUE_1="+helper,
+			gitTokenEnv+"="+cfg.Auth.Token,
+		)
+
+	case hm.AuthSSH:
+		sshCmd := "ssh -o BatchMode=yes -o StrictHostKeyChecking=accept-new"
+		if cfg.SSHKeyPath != "" {
+			sshCmd += " -o IdentitiesOnly=yes -i " + shellQuote(cfg.SSHKeyPath)
+		}
+		env = append(env, "GIT_SSH_COMMAND="+sshCmd)
+	}
+
+	return env, nil
+}
+
+// gitCredentialHelper returns the credential.helper value that runs the
+// current executable as a helper, or an empty string if it cannot be found.
+func gitCredentialHelper() string {
+	exe, err := os.Executable()
+	if err != nil {
+		return ""
+	}
+	return "!" + shellQuote(exe) + " " + GitCredentialCommand
+}
+
+// RunGitCredentialHelper serves a git credential request using the token
+// passed by the publisher in the environment. It returns the process exit code.
+func RunGitCredentialHelper(args []string, in io.Reader, out io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprintf(os.Stderr, "usage: clio %s get|store|erase\n", GitCredentialCommand)
+		return 2
+	}
+
+	if err := serveGitCredential(args[0], in, out, os.Getenv(gitTokenEnv)); err != nil {
+		fmt.Fprintf(os.Stderr, "clio %s: %v\n", GitCredentialCommand, err)
+		return 1
+	}
+	return 0
+}
+
+// serveGitCredential implements the helper side of the git credential
+// protocol. Only "get" produces output; "store" and "erase" are no-ops since
+// nothing is persisted.
+func serveGitCredential(op string, in io.Reader, out io.Writer, token string) error {
+	attrs := map[string]string{}
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			break
+		}
+		if k, v, ok := strings.Cut(line, "="); ok {
+			attrs[k] = v
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("cannot read request: %w", err)
+	}
+
+	if op != "get" || token == "" {
+		return nil
+	}
+
+	username := attrs["username"]
+	if username == "" {
+		username = gitTokenUsername
+	}
+
+	_, err := fmt.Fprintf(out, "username=%s\npassword=%s\n", username, token)
+	return err
+}
+
+// redact replaces the given secrets and any credentials embedded in URLs.
+func redact(s string, secrets ...string) string {
+	for _, secret := range secrets {
+		if secret != "" {
+			s = strings.ReplaceAll(s, secret, redactedValue)
+		}
+	}
+	return urlCredentialsRe.ReplaceAllString(s, "${1}"+redactedValue+"@")
+}
+
+// redactedError hides secrets from the message of an error while keeping it
+// available to errors.Is and errors.As.
+type redactedError struct {
+	msg string
+	err error
+}
+
+func (e *redactedError) Error() string { return e.msg }
+
+func (e *redactedError) Unwrap() error { return e.err }
+
+func redactError(err error, secrets ...string) error {
+	if err == nil {
+		return nil
+	}
+	return &redactedError{msg: redact(err.Error(), secrets...), err: err}
+}
+
+// shellQuote quotes s for the POSIX shell git uses to run helpers and ssh.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
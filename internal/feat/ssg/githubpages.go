@@ -0,0 +1,160 @@
+package ssg
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Files GitHub Pages reads from the root of the published site.
+const (
+	PagesCNAMEFile    = "CNAME"
+	PagesNoJekyllFile = ".nojekyll"
+)
+
+var domainRe = regexp.MustCompile(`^([a-z0-9]([a-z0-9-]*[a-z0-9])?\.)+[a-z]{2,}$`)
+
+// PagesOptions are the GitHub Pages settings of a site.
+type PagesOptions struct {
+	CustomDomain string // Served from a custom domain through a CNAME file if set
+	NoJekyll     bool   // Add .nojekyll so Pages serves the files as they are
+}
+
+// WritePagesFiles writes the CNAME and .nojekyll files for opts into the
+// generated site, removing the ones that are no longer wanted.
+func WritePagesFiles(htmlPath string, opts PagesOptions) error {
+	cnamePath := filepath.Join(htmlPath, PagesCNAMEFile)
+	if opts.CustomDomain == "" {
+		if err := os.Remove(cnamePath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("cannot remove CNAME: %w", err)
+		}
+	} else {
+		domain, err := normalizeDomain(opts.CustomDomain)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(cnamePath, []byte(domain+"\n"), 0644); err != nil {
+			return fmt.Errorf("cannot write CNAME: %w", err)
+		}
+	}
+
+	noJekyllPath := filepath.Join(htmlPath, PagesNoJekyllFile)
+	if !opts.NoJekyll {
+		if err := os.Remove(noJekyllPath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("cannot remove .nojekyll: %w", err)
+		}
+		return nil
+	}
+	if err := os.WriteFile(noJekyllPath, nil, 0644); err != nil {
+		return fmt.Errorf("cannot write .nojekyll: %w", err)
+	}
+	return nil
+}
+
+// normalizeDomain accepts a bare domain or a URL and returns the lower case
+// host name GitHub Pages expects in CNAME.
+func normalizeDomain(domain string) (string, error) {
+	d := strings.ToLower(strings.TrimSpace(domain))
+	d = strings.TrimPrefix(d, "https://")
+	d = strings.TrimPrefix(d, "http://")
+	d = strings.TrimSuffix(d, "/")
+
+	if !domainRe.MatchString(d) {
+		return "", fmt.Errorf("invalid custom domain %q", domain)
+	}
+	return d, nil
+}
+
+// parsePreserveList splits a comma or newline separated list of paths,
+// relative to the root of the published site.
+func parsePreserveList(s string) []string {
+	var preserve []string
+	for _, p := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == '\n' }) {
+		p = strings.Trim(strings.TrimSpace(p), "/")
+		if p == "" {
+			continue
+		}
+		preserve = append(preserve, path.Clean(p))
+	}
+	return preserve
+}
+
+// isPreserved reports whether the slash separated path rel is, or is inside,
+// one of the preserved paths. Entries may be glob patterns (see path.Match).
+func isPreserved(rel string, preserve []string) bool {
+	for _, p := range preserve {
+		if rel == p || strings.HasPrefix(rel, p+"/") {
+			return true
+		}
+		if ok, _ := path.Match(p, rel); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// holdsPreserved reports whether the directory dir may contain preserved paths.
+func holdsPreserved(dir string, preserve []string) bool {
+	for _, p := range preserve {
+		if strings.HasPrefix(p, dir+"/") || strings.ContainsAny(p, "*?[") {
+			return true
+		}
+	}
+	return false
+}
+
+// withoutPreserved drops the preserved paths from paths.
+func withoutPreserved(paths []string, preserve []string) []string {
+	if len(preserve) == 0 {
+		return paths
+	}
+
+	var kept []string
+	for _, p := range paths {
+		if !isPreserved(p, preserve) {
+			kept = append(kept, p)
+		}
+	}
+	return kept
+}
+
+// cleanTarget empties dir before a publish, keeping .git and the preserved
+// paths.
+func cleanTarget(dir string, preserve []string) error {
+	return cleanTargetDir(dir, "", preserve)
+}
+
+func cleanTargetDir(root, rel string, preserve []string) error {
+	entries, err := os.ReadDir(filepath.Join(root, filepath.FromSlash(rel)))
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if entry.Name() == ".git" {
+			continue
+		}
+
+		p := path.Join(rel, entry.Name())
+		if isPreserved(p, preserve) {
+			continue
+		}
+
+		full := filepath.Join(root, filepath.FromSlash(p))
+		if entry.IsDir() && holdsPreserved(p, preserve) {
+			if err := cleanTargetDir(root, p, preserve); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := os.RemoveAll(full); err != nil {
+			return fmt.Errorf("cannot remove %s: %w", p, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,110 @@
+package ssg
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestWritePagesFiles(t *testing.T) {
+	dir := t.TempDir()
+
+	if err := WritePagesFiles(dir, PagesOptions{CustomDomain: "https://Blog.Example.com/", NoJekyll: true}); err != nil {
+		t.Fatalf("WritePagesFiles() error = %v", err)
+	}
+
+	cname, err := os.ReadFile(filepath.Join(dir, PagesCNAMEFile))
+	if err != nil {
+		t.Fatalf("cannot read CNAME: %v", err)
+	}
+	if string(cname) != "blog.example.com\n" {
+		t.Errorf("CNAME = %q, want %q", cname, "blog.example.com\n")
+	}
+	if _, err := os.Stat(filepath.Join(dir, PagesNoJekyllFile)); err != nil {
+		t.Errorf(".nojekyll not written: %v", err)
+	}
+
+	if err := WritePagesFiles(dir, PagesOptions{}); err != nil {
+		t.Fatalf("WritePagesFiles() error = %v", err)
+	}
+	for _, name := range []string{PagesCNAMEFile, PagesNoJekyllFile} {
+		if _, err := os.Stat(filepath.Join(dir, name)); !os.IsNotExist(err) {
+			t.Errorf("%s not removed when disabled", name)
+		}
+	}
+
+	if err := WritePagesFiles(dir, PagesOptions{CustomDomain: "not a domain"}); err == nil {
+		t.Error("WritePagesFiles() expected error for an invalid domain")
+	}
+}
+
+func TestParsePreserveList(t *testing.T) {
+	got := parsePreserveList(" /google123.html, .well-known/ ,\ndocs//old,, ")
+	want := []string{"google123.html", ".well-known", "docs/old"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parsePreserveList() = %v, want %v", got, want)
+	}
+}
+
+func TestIsPreserved(t *testing.T) {
+	preserve := []string{"CNAME", ".well-known", "google*.html"}
+
+	tests := []struct {
+		rel  string
+		want bool
+	}{
+		{"CNAME", true},
+		{".well-known/security.txt", true},
+		{"google1234.html", true},
+		{"index.html", false},
+		{"blog/CNAME", false},
+		{".well-known-other", false},
+	}
+
+	for _, tt := range tests {
+		if got := isPreserved(tt.rel, preserve); got != tt.want {
+			t.Errorf("isPreserved(%q) = %v, want %v", tt.rel, got, tt.want)
+		}
+	}
+}
+
+func TestCleanTarget(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{
+		".git/HEAD",
+		"CNAME",
+		"index.html",
+		"old/page.html",
+		"docs/keep/file.txt",
+		"docs/drop.txt",
+	} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := cleanTarget(dir, []string{"CNAME", "docs/keep"}); err != nil {
+		t.Fatalf("cleanTarget() error = %v", err)
+	}
+
+	var left []string
+	filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			rel, _ := filepath.Rel(dir, path)
+			left = append(left, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	sort.Strings(left)
+
+	want := []string{".git/HEAD", "CNAME", "docs/keep/file.txt"}
+	if !reflect.DeepEqual(left, want) {
+		t.Errorf("files left = %v, want %v", left, want)
+	}
+}
@@ -2,19 +2,27 @@ package ssg
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"image"
+	_ "image/gif"
 	"io"
 	"mime/multipart"
 	"os"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/hermesgen/hm"
 )
 
-const cleanupOrphanedImages = false
+// imageURLPrefix is the URL prefix used to reference site images from content.
+const imageURLPrefix = "/static/images/"
+
+var imageRefRegex = regexp.MustCompile(regexp.QuoteMeta(imageURLPrefix) + `([^\s)"'<>?#|]+)`)
 
 // ImageType represents the type of image being processed
 type ImageType string
@@ -24,8 +32,13 @@ const (
 	ImageTypeHeader        ImageType = "header"
 	ImageTypeSectionHeader ImageType = "section_header"
 	ImageTypeBlogHeader    ImageType = "blog_header"
+	ImageTypeLibrary       ImageType = "library"
 )
 
+// libraryImagesDir is the directory, relative to the site images, where
+// images uploaded straight to the media library are stored.
+const libraryImagesDir = "library"
+
 // ImageProcessResult contains the result of image processing
 type ImageProcessResult struct {
 	FilePath     string            // Full file path where image was stored
@@ -33,22 +46,27 @@ type ImageProcessResult struct {
 	Filename     string            // Generated filename
 	Directory    string            // Directory where image was stored
 	Metadata     map[string]string // Image metadata (size, format, etc.)
+	Width        int               // Image width in pixels, 0 if unknown
+	Height       int               // Image height in pixels, 0 if unknown
+	Stripped     *MetadataReport   // Metadata removed for privacy, nil if not applied
 }
 
 // ImageManager handles all image-related operations
 type ImageManager struct {
 	hm.Core
+	pm            *ParamManager
 	baseImagePath string // Base path for all images (e.g., "./assets/images")
 }
 
 // NewImageManager creates a new ImageManager instance
 
 // NewImageManagerWithParams creates an ImageManager with XParams.
-func NewImageManager(params hm.XParams) *ImageManager {
+func NewImageManager(pm *ParamManager, params hm.XParams) *ImageManager {
 	core := hm.NewCore("image-manager", params)
 	imagesPath := core.Cfg().StrValOrDef(SSGKey.ImagesPath, "_workspace/documents/assets/images")
 	return &ImageManager{
 		Core:          core,
+		pm:            pm,
 		baseImagePath: imagesPath,
 	}
 }
@@ -60,8 +78,7 @@ func (im *ImageManager) ProcessUpload(ctx context.Context, file multipart.File,
 		return nil, fmt.Errorf("site slug not found in context")
 	}
 
-	sitesBasePath := im.Cfg().StrValOrDef(SSGKey.SitesBasePath, "_workspace/sites")
-	baseImagePath := GetSiteImagesPath(sitesBasePath, siteSlug)
+	baseImagePath := im.siteImagesPath(siteSlug)
 
 	directory, err := im.generateDirectoryPath(content, section, imageType)
 	if err != nil {
@@ -89,20 +106,93 @@ func (im *ImageManager) ProcessUpload(ctx context.Context, file multipart.File,
 
 	metadata := im.extractMetadata(header)
 
+	stripped, err := im.stripMetadata(ctx, fullPath)
+	if err != nil {
+		os.Remove(fullPath)
+		return nil, fmt.Errorf("failed to strip image metadata: %w", err)
+	}
+	if stripped != nil {
+		metadata["metadata_removed"] = strings.Join(stripped.Removed, ",")
+		if stripped.Reencoded {
+			metadata["orientation_applied"] = strconv.Itoa(stripped.Orientation)
+		}
+	}
+
+	width, height := imageDimensions(fullPath)
+
 	result := &ImageProcessResult{
 		FilePath:     fullPath,
 		RelativePath: filepath.Join(directory, filename),
 		Filename:     filename,
 		Directory:    directory,
 		Metadata:     metadata,
+		Width:        width,
+		Height:       height,
+		Stripped:     stripped,
 	}
 
 	im.Log().Debugf("Upload processed successfully: %s", result.RelativePath)
 	return result, nil
 }
 
+// ImportFile copies the local image src to relativePath under the site images
+// directory, as site migrations do with the images their contents reference.
+// A file already at relativePath is kept; copied reports whether src was
+// copied.
+func (im *ImageManager) ImportFile(ctx context.Context, src, relativePath string) (result *ImageProcessResult, copied bool, err error) {
+	siteSlug, ok := GetSiteSlugFromContext(ctx)
+	if !ok || siteSlug == "" {
+		return nil, false, fmt.Errorf("site slug not found in context")
+	}
+
+	fullPath := filepath.Join(im.siteImagesPath(siteSlug), filepath.FromSlash(relativePath))
+	result = &ImageProcessResult{
+		FilePath:     fullPath,
+		RelativePath: relativePath,
+		Filename:     filepath.Base(fullPath),
+		Directory:    filepath.Dir(relativePath),
+		Metadata:     map[string]string{"original_filename": filepath.Base(src)},
+	}
+
+	if _, err := os.Stat(fullPath); err == nil {
+		result.Width, result.Height = imageDimensions(fullPath)
+		return result, false, nil
+	}
+
+	if err := im.ensureDirectory(filepath.Dir(fullPath)); err != nil {
+		return nil, false, fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	file, err := os.Open(src)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to open image: %w", err)
+	}
+	defer file.Close()
+
+	if err := im.saveFile(file, fullPath); err != nil {
+		return nil, false, fmt.Errorf("failed to save file: %w", err)
+	}
+
+	stripped, err := im.stripMetadata(ctx, fullPath)
+	if err != nil {
+		os.Remove(fullPath)
+		return nil, false, fmt.Errorf("failed to strip image metadata: %w", err)
+	}
+	if stripped != nil {
+		result.Stripped = stripped
+		result.Metadata["metadata_removed"] = strings.Join(stripped.Removed, ",")
+	}
+
+	result.Width, result.Height = imageDimensions(fullPath)
+	return result, true, nil
+}
+
 // sanitizeForURL sanitizes a string for safe use in URLs and file paths
 func (im *ImageManager) sanitizeForURL(str string) string {
+	return sanitizeForURL(str)
+}
+
+func sanitizeForURL(str string) string {
 	// Replace problematic characters with hyphens
 	re := regexp.MustCompile(`[^a-zA-Z0-9\-_.]`)
 	sanitized := re.ReplaceAllString(str, "-")
@@ -149,6 +239,9 @@ func (im *ImageManager) generateDirectoryPath(content *Content, section *Section
 		}
 		return filepath.Join(section.Path, "blog"), nil
 
+	case ImageTypeLibrary:
+		return libraryImagesDir, nil
+
 	default:
 		return "", fmt.Errorf("unknown image type: %s", imageType)
 	}
@@ -184,6 +277,13 @@ func (im *ImageManager) generateFilename(content *Content, section *Section, ima
 		}
 		return fmt.Sprintf("blog_header_%d%s", timestamp, extension), nil
 
+	case ImageTypeLibrary:
+		name := im.sanitizeForURL(strings.TrimSuffix(filepath.Base(originalFilename), extension))
+		if name == "" {
+			name = "image"
+		}
+		return fmt.Sprintf("%s_%d%s", name, timestamp, extension), nil
+
 	default:
 		return "", fmt.Errorf("unknown image type: %s", imageType)
 	}
@@ -245,6 +345,44 @@ func (im *ImageManager) saveFile(src multipart.File, destPath string) error {
 	return nil
 }
 
+// stripMetadata removes privacy sensitive metadata (EXIF, GPS, XMP, etc.) from
+// the stored file unless the site disabled it. Formats other than JPEG and PNG
+// are left untouched and return a nil report. An image whose metadata cannot
+// be removed is an error, it must not be kept as uploaded.
+func (im *ImageManager) stripMetadata(ctx context.Context, path string) (*MetadataReport, error) {
+	if !im.stripMetadataEnabled(ctx) {
+		im.Log().Debugf("Image metadata stripping is disabled: %s", path)
+		return nil, nil
+	}
+
+	report, err := StripImageFileMetadata(path)
+	if errors.Is(err, errUnsupportedImageFormat) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if report.HasChanges() {
+		im.Log().Infof("Removed image metadata from %s: %s", filepath.Base(path), strings.Join(report.Removed, ", "))
+	}
+
+	return &report, nil
+}
+
+// stripMetadataEnabled reads the site setting, stripping is on by default.
+func (im *ImageManager) stripMetadataEnabled(ctx context.Context) bool {
+	if im.pm != nil {
+		return im.pm.SettingBool(ctx, SSGKey.ImagesStripMetadata)
+	}
+
+	enabled, err := strconv.ParseBool(im.Cfg().StrValOrDef(SSGKey.ImagesStripMetadata, "true"))
+	if err != nil {
+		return true
+	}
+	return enabled
+}
+
 // extractMetadata extracts basic metadata from the uploaded file
 func (im *ImageManager) extractMetadata(header *multipart.FileHeader) map[string]string {
 	metadata := make(map[string]string)
@@ -257,6 +395,22 @@ func (im *ImageManager) extractMetadata(header *multipart.FileHeader) map[string
 	return metadata
 }
 
+// imageDimensions returns the pixel size of the image stored at path.
+// Formats that cannot be decoded report a zero size.
+func imageDimensions(path string) (width, height int) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, 0
+	}
+	defer f.Close()
+
+	cfg, _, err := image.DecodeConfig(f)
+	if err != nil {
+		return 0, 0
+	}
+	return cfg.Width, cfg.Height
+}
+
 // GetContentImages returns all images for a specific content
 func (im *ImageManager) GetContentImages(ctx context.Context, content *Content, section *Section) ([]string, error) {
 	directory, err := im.generateDirectoryPath(content, section, ImageTypeContent)
@@ -285,17 +439,169 @@ func (im *ImageManager) GetContentImages(ctx context.Context, content *Content,
 	return images, nil
 }
 
-// CleanupOrphanedImages removes images that no longer have associated content
-// This is a placeholder for future implementation
-func (im *ImageManager) CleanupOrphanedImages(ctx context.Context) error {
-	if !cleanupOrphanedImages {
-		im.Log().Debug("Orphaned image cleanup is disabled")
-		return nil
+// ImageUsage is what the database knows about the images of a site.
+type ImageUsage struct {
+	Images   []Image            // Image records that belong to the site
+	Shared   []string           // Paths recorded without a site, never considered orphans
+	BodyRefs []string           // Paths referenced from content bodies
+	Linked   map[uuid.UUID]bool // Images with a content or section relationship
+}
+
+// OrphanReport lists the mismatches between the image files of a site and the
+// references kept in the database and content bodies.
+type OrphanReport struct {
+	OrphanFiles  []string `json:"orphan_files"`  // Files on disk nobody references
+	MissingFiles []Image  `json:"missing_files"` // Image records whose file is gone
+	BrokenRefs   []string `json:"broken_refs"`   // Body references to files that do not exist
+	UnusedImages []Image  `json:"unused_images"` // Image records nothing links or references, never purged
+	Purged       bool     `json:"purged"`
+}
+
+// HasOrphans reports whether there is anything to clean up.
+func (r OrphanReport) HasOrphans() bool {
+	return len(r.OrphanFiles) > 0 || len(r.MissingFiles) > 0
+}
+
+// RewriteImageRefs replaces the references to oldPath in body with newPath.
+// Both paths are relative to the site images directory. It reports whether
+// anything changed.
+func RewriteImageRefs(body, oldPath, newPath string) (string, bool) {
+	re := regexp.MustCompile(regexp.QuoteMeta(imageURLPrefix+oldPath) + `([\s)"'<>?#|]|$)`)
+	rewritten := re.ReplaceAllString(body, imageURLPrefix+newPath+"${1}")
+	return rewritten, rewritten != body
+}
+
+// ExtractImageRefs returns the image paths, relative to the site images
+// directory, referenced from a Markdown or HTML body.
+func ExtractImageRefs(body string) []string {
+	var refs []string
+	seen := make(map[string]bool)
+	for _, m := range imageRefRegex.FindAllStringSubmatch(body, -1) {
+		ref := filepath.Clean(m[1])
+		if seen[ref] {
+			continue
+		}
+		seen[ref] = true
+		refs = append(refs, ref)
+	}
+	return refs
+}
+
+// CleanupOrphanedImages compares the files stored under the site images path
+// with the usage known to the database. With purge set, orphan files are
+// removed; otherwise it only reports (dry run). Records pointing to missing
+// files are reported so the caller can drop them.
+func (im *ImageManager) CleanupOrphanedImages(ctx context.Context, usage ImageUsage, purge bool) (OrphanReport, error) {
+	report := OrphanReport{
+		OrphanFiles:  []string{},
+		MissingFiles: []Image{},
+		BrokenRefs:   []string{},
+		UnusedImages: []Image{},
 	}
 
-	// TODO: Implement orphaned image detection and cleanup
-	im.Log().Debug("Orphaned image cleanup not yet implemented")
-	return nil
+	siteSlug, err := RequireSiteSlug(ctx)
+	if err != nil {
+		return report, err
+	}
+	basePath := im.siteImagesPath(siteSlug)
+
+	files, err := im.listImageFiles(basePath)
+	if err != nil {
+		return report, fmt.Errorf("failed to list image files: %w", err)
+	}
+
+	known := make(map[string]bool)
+	for _, img := range usage.Images {
+		known[filepath.Clean(img.FilePath)] = true
+	}
+	for _, path := range usage.Shared {
+		known[filepath.Clean(path)] = true
+	}
+	for _, path := range usage.BodyRefs {
+		known[filepath.Clean(path)] = true
+	}
+
+	for _, file := range files {
+		if !known[file] {
+			report.OrphanFiles = append(report.OrphanFiles, file)
+		}
+	}
+
+	onDisk := make(map[string]bool, len(files))
+	for _, file := range files {
+		onDisk[file] = true
+	}
+	referenced := make(map[string]bool, len(usage.BodyRefs))
+	for _, ref := range usage.BodyRefs {
+		referenced[filepath.Clean(ref)] = true
+		if !onDisk[filepath.Clean(ref)] {
+			report.BrokenRefs = append(report.BrokenRefs, ref)
+		}
+	}
+	for _, img := range usage.Images {
+		switch {
+		case img.FilePath == "" || !onDisk[filepath.Clean(img.FilePath)]:
+			report.MissingFiles = append(report.MissingFiles, img)
+		case !usage.Linked[img.ID] && !referenced[filepath.Clean(img.FilePath)]:
+			report.UnusedImages = append(report.UnusedImages, img)
+		}
+	}
+
+	im.Log().Infof("Orphaned images for site %s: %d orphan files, %d missing files, %d broken references, %d unused images",
+		siteSlug, len(report.OrphanFiles), len(report.MissingFiles), len(report.BrokenRefs), len(report.UnusedImages))
+
+	if !purge {
+		return report, nil
+	}
+
+	for _, file := range report.OrphanFiles {
+		if err := os.Remove(filepath.Join(basePath, file)); err != nil && !os.IsNotExist(err) {
+			return report, fmt.Errorf("failed to remove orphan file %s: %w", file, err)
+		}
+		im.Log().Infof("Removed orphan image file: %s", file)
+	}
+	report.Purged = true
+
+	return report, nil
+}
+
+// listImageFiles returns the files under basePath relative to it, skipping
+// hidden files such as .gitkeep.
+func (im *ImageManager) listImageFiles(basePath string) ([]string, error) {
+	var files []string
+	if _, err := os.Stat(basePath); os.IsNotExist(err) {
+		return files, nil
+	}
+
+	err := filepath.WalkDir(basePath, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		hidden := strings.HasPrefix(d.Name(), ".")
+		if d.IsDir() {
+			if hidden && path != basePath {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if hidden {
+			return nil
+		}
+		rel, err := filepath.Rel(basePath, path)
+		if err != nil {
+			return err
+		}
+		files = append(files, rel)
+		return nil
+	})
+
+	return files, err
+}
+
+// siteImagesPath returns the images directory of a site.
+func (im *ImageManager) siteImagesPath(siteSlug string) string {
+	sitesBasePath := im.Cfg().StrValOrDef(SSGKey.SitesBasePath, "_workspace/sites")
+	return GetSiteImagesPath(sitesBasePath, siteSlug)
 }
 
 // DeleteImage deletes an image file by its relative path
@@ -304,7 +610,11 @@ func (im *ImageManager) DeleteImage(ctx context.Context, relativePath string) er
 		return nil // Nothing to delete
 	}
 
-	fullPath := filepath.Join(im.baseImagePath, relativePath)
+	basePath := im.baseImagePath
+	if siteSlug, ok := GetSiteSlugFromContext(ctx); ok && siteSlug != "" {
+		basePath = im.siteImagesPath(siteSlug)
+	}
+	fullPath := filepath.Join(basePath, relativePath)
 
 	if _, err := os.Stat(fullPath); os.IsNotExist(err) {
 		return nil // File doesn't exist, consider it deleted
@@ -316,3 +626,130 @@ func (im *ImageManager) DeleteImage(ctx context.Context, relativePath string) er
 
 	return nil
 }
+
+// imageHistoryDir is the hidden directory, relative to the site images, where
+// replaced image files are kept so replacements can be rolled back.
+const imageHistoryDir = ".history"
+
+// ImageReplaceResult contains the result of replacing the file of an image.
+type ImageReplaceResult struct {
+	RelativePath string          // Path of the new file, relative to the site images
+	Filename     string          // Name of the new file
+	BackupPath   string          // Where the previous file is kept, empty if there was none
+	Width        int             // Image width in pixels, 0 if unknown
+	Height       int             // Image height in pixels, 0 if unknown
+	Stripped     *MetadataReport // Metadata removed for privacy, nil if not applied
+}
+
+// ReplaceFile swaps the file of an existing image. The path is kept when the
+// extension does not change, otherwise only the extension is updated. The
+// previous file is moved to the history directory instead of being deleted.
+func (im *ImageManager) ReplaceFile(ctx context.Context, img Image, file multipart.File, header *multipart.FileHeader) (*ImageReplaceResult, error) {
+	siteSlug, err := RequireSiteSlug(ctx)
+	if err != nil {
+		return nil, err
+	}
+	basePath := im.siteImagesPath(siteSlug)
+
+	oldExt := filepath.Ext(img.FilePath)
+	newExt := filepath.Ext(header.Filename)
+	newRel := img.FilePath
+	if !strings.EqualFold(oldExt, newExt) {
+		newRel = strings.TrimSuffix(img.FilePath, oldExt) + strings.ToLower(newExt)
+		if _, err := os.Stat(filepath.Join(basePath, newRel)); err == nil {
+			return nil, fmt.Errorf("cannot replace image, %s already exists", newRel)
+		}
+	}
+
+	oldFull := filepath.Join(basePath, img.FilePath)
+	newFull := filepath.Join(basePath, newRel)
+
+	var backupRel string
+	if _, err := os.Stat(oldFull); err == nil {
+		backupRel = filepath.Join(imageHistoryDir, img.ID.String(), fmt.Sprintf("%d_%s", time.Now().UnixNano(), filepath.Base(img.FilePath)))
+		backupFull := filepath.Join(basePath, backupRel)
+		if err := im.ensureDirectory(filepath.Dir(backupFull)); err != nil {
+			return nil, fmt.Errorf("failed to create history directory: %w", err)
+		}
+		if err := os.Rename(oldFull, backupFull); err != nil {
+			return nil, fmt.Errorf("failed to keep previous file: %w", err)
+		}
+	}
+
+	restore := func() {
+		os.Remove(newFull)
+		if backupRel != "" {
+			os.Rename(filepath.Join(basePath, backupRel), oldFull)
+		}
+	}
+
+	if err := im.ensureDirectory(filepath.Dir(newFull)); err != nil {
+		restore()
+		return nil, fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	if err := im.saveFile(file, newFull); err != nil {
+		restore()
+		return nil, fmt.Errorf("failed to save file: %w", err)
+	}
+
+	stripped, err := im.stripMetadata(ctx, newFull)
+	if err != nil {
+		restore()
+		return nil, fmt.Errorf("failed to strip image metadata: %w", err)
+	}
+
+	width, height := imageDimensions(newFull)
+
+	im.Log().Debugf("Image file replaced: %s -> %s (previous kept at %s)", img.FilePath, newRel, backupRel)
+
+	return &ImageReplaceResult{
+		RelativePath: newRel,
+		Filename:     filepath.Base(newRel),
+		BackupPath:   backupRel,
+		Width:        width,
+		Height:       height,
+		Stripped:     stripped,
+	}, nil
+}
+
+// UndoReplace puts back the file of img that ReplaceFile replaced with result,
+// for when recording the replacement fails.
+func (im *ImageManager) UndoReplace(ctx context.Context, img Image, result *ImageReplaceResult) error {
+	if result.BackupPath == "" {
+		return im.DeleteImage(ctx, result.RelativePath)
+	}
+	return im.RestoreFile(ctx, result.RelativePath, ImageRevision{FilePath: img.FilePath, BackupPath: result.BackupPath})
+}
+
+// RestoreFile puts back a file kept by ReplaceFile, removing the current file
+// of the image at currentPath.
+func (im *ImageManager) RestoreFile(ctx context.Context, currentPath string, rev ImageRevision) error {
+	siteSlug, err := RequireSiteSlug(ctx)
+	if err != nil {
+		return err
+	}
+	basePath := im.siteImagesPath(siteSlug)
+
+	backupFull := filepath.Join(basePath, rev.BackupPath)
+	if _, err := os.Stat(backupFull); err != nil {
+		return fmt.Errorf("previous file not available: %w", err)
+	}
+
+	restoredFull := filepath.Join(basePath, rev.FilePath)
+	if currentPath != rev.FilePath {
+		if err := os.Remove(filepath.Join(basePath, currentPath)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove current file: %w", err)
+		}
+	}
+
+	if err := im.ensureDirectory(filepath.Dir(restoredFull)); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	if err := os.Rename(backupFull, restoredFull); err != nil {
+		return fmt.Errorf("failed to restore previous file: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,365 @@
+package ssg
+
+import (
+	"bytes"
+	"context"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/hermesgen/hm"
+)
+
+func TestExtractImageRefs(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []string
+	}{
+		{
+			name: "markdown images",
+			body: "Intro\n\n![A cat](/static/images/blog/cat_1.jpg)\n\n![Dog|||Long description](/static/images/dog.png)",
+			want: []string{"blog/cat_1.jpg", "dog.png"},
+		},
+		{
+			name: "html images and duplicates",
+			body: `<img src="/static/images/a/b.webp" alt="b"> and again ![b](/static/images/a/b.webp)`,
+			want: []string{"a/b.webp"},
+		},
+		{
+			name: "no images",
+			body: "Just text with a [link](/about/).",
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ExtractImageRefs(tt.body)
+			if !equalStrings(got, tt.want) {
+				t.Errorf("ExtractImageRefs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestImageManagerCleanupOrphanedImages(t *testing.T) {
+	tests := []struct {
+		name            string
+		purge           bool
+		wantOrphans     []string
+		wantMissing     []string
+		wantBrokenRefs  []string
+		wantUnused      []string
+		wantRemainFiles []string
+	}{
+		{
+			name:            "dry run keeps files",
+			purge:           false,
+			wantOrphans:     []string{"old/unused.jpg", "stray.png"},
+			wantMissing:     []string{"gone.jpg"},
+			wantBrokenRefs:  []string{"missing-in-body.jpg"},
+			wantUnused:      []string{"library/unused.jpg"},
+			wantRemainFiles: []string{"body/record.jpg", "body/ref.jpg", "library/unused.jpg", "old/unused.jpg", "post/header.jpg", "shared.jpg", "stray.png"},
+		},
+		{
+			name:            "purge removes orphan files",
+			purge:           true,
+			wantOrphans:     []string{"old/unused.jpg", "stray.png"},
+			wantMissing:     []string{"gone.jpg"},
+			wantBrokenRefs:  []string{"missing-in-body.jpg"},
+			wantUnused:      []string{"library/unused.jpg"},
+			wantRemainFiles: []string{"body/record.jpg", "body/ref.jpg", "library/unused.jpg", "post/header.jpg", "shared.jpg"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sitesBase := t.TempDir()
+			imagesPath := GetSiteImagesPath(sitesBase, "test")
+			for _, f := range []string{"post/header.jpg", "body/record.jpg", "body/ref.jpg", "library/unused.jpg", "shared.jpg", "old/unused.jpg", "stray.png", ".gitkeep"} {
+				path := filepath.Join(imagesPath, f)
+				if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+					t.Fatalf("cannot create dir: %v", err)
+				}
+				if err := os.WriteFile(path, []byte("img"), 0644); err != nil {
+					t.Fatalf("cannot write file: %v", err)
+				}
+			}
+
+			cfg := hm.NewConfig()
+			cfg.Set(SSGKey.SitesBasePath, sitesBase)
+			im := NewImageManager(nil, hm.XParams{Cfg: cfg, Log: hm.NewLogger("error")})
+
+			header := Image{ID: uuid.New(), FilePath: "post/header.jpg"}
+			usage := ImageUsage{
+				Images: []Image{
+					header,
+					{ID: uuid.New(), FilePath: "gone.jpg"},
+					{ID: uuid.New(), FilePath: "body/record.jpg"},
+					{ID: uuid.New(), FilePath: "library/unused.jpg"},
+				},
+				Shared:   []string{"shared.jpg"},
+				BodyRefs: []string{"body/ref.jpg", "body/record.jpg", "missing-in-body.jpg"},
+				Linked:   map[uuid.UUID]bool{header.ID: true},
+			}
+
+			ctx := context.WithValue(context.Background(), siteSlugKey, "test")
+			report, err := im.CleanupOrphanedImages(ctx, usage, tt.purge)
+			if err != nil {
+				t.Fatalf("CleanupOrphanedImages() error = %v", err)
+			}
+
+			sort.Strings(report.OrphanFiles)
+			if !equalStrings(report.OrphanFiles, tt.wantOrphans) {
+				t.Errorf("OrphanFiles = %v, want %v", report.OrphanFiles, tt.wantOrphans)
+			}
+
+			var missing []string
+			for _, img := range report.MissingFiles {
+				missing = append(missing, img.FilePath)
+			}
+			if !equalStrings(missing, tt.wantMissing) {
+				t.Errorf("MissingFiles = %v, want %v", missing, tt.wantMissing)
+			}
+
+			if !equalStrings(report.BrokenRefs, tt.wantBrokenRefs) {
+				t.Errorf("BrokenRefs = %v, want %v", report.BrokenRefs, tt.wantBrokenRefs)
+			}
+
+			var unused []string
+			for _, img := range report.UnusedImages {
+				unused = append(unused, img.FilePath)
+			}
+			if !equalStrings(unused, tt.wantUnused) {
+				t.Errorf("UnusedImages = %v, want %v", unused, tt.wantUnused)
+			}
+
+			if report.Purged != tt.purge {
+				t.Errorf("Purged = %v, want %v", report.Purged, tt.purge)
+			}
+
+			remaining, err := im.listImageFiles(imagesPath)
+			if err != nil {
+				t.Fatalf("cannot list remaining files: %v", err)
+			}
+			sort.Strings(remaining)
+			if !equalStrings(remaining, tt.wantRemainFiles) {
+				t.Errorf("remaining files = %v, want %v", remaining, tt.wantRemainFiles)
+			}
+		})
+	}
+}
+
+func TestImageManagerGenerateLibraryPath(t *testing.T) {
+	im := NewImageManager(nil, hm.XParams{Cfg: hm.NewConfig(), Log: hm.NewLogger("error")})
+
+	dir, err := im.generateDirectoryPath(nil, nil, ImageTypeLibrary)
+	if err != nil {
+		t.Fatalf("generateDirectoryPath() error = %v", err)
+	}
+	if dir != libraryImagesDir {
+		t.Errorf("directory = %q, want %q", dir, libraryImagesDir)
+	}
+
+	tests := []struct {
+		original   string
+		wantPrefix string
+		wantExt    string
+	}{
+		{original: "My Holiday Photo.JPG", wantPrefix: "my-holiday-photo_", wantExt: ".JPG"},
+		{original: "../../etc/passwd.png", wantPrefix: "passwd_", wantExt: ".png"},
+		{original: "???.webp", wantPrefix: "image_", wantExt: ".webp"},
+	}
+
+	for _, tt := range tests {
+		got, err := im.generateFilename(nil, nil, ImageTypeLibrary, tt.original)
+		if err != nil {
+			t.Fatalf("generateFilename(%q) error = %v", tt.original, err)
+		}
+		if !strings.HasPrefix(got, tt.wantPrefix) || !strings.HasSuffix(got, tt.wantExt) {
+			t.Errorf("generateFilename(%q) = %q, want %s<timestamp>%s", tt.original, got, tt.wantPrefix, tt.wantExt)
+		}
+	}
+}
+
+func TestRewriteImageRefs(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		want        string
+		wantChanged bool
+	}{
+		{
+			name:        "markdown and html",
+			body:        "![a](/static/images/blog/a.jpg) <img src=\"/static/images/blog/a.jpg\">",
+			want:        "![a](/static/images/blog/a.png) <img src=\"/static/images/blog/a.png\">",
+			wantChanged: true,
+		},
+		{
+			name:        "longer paths sharing the prefix are kept",
+			body:        "![a](/static/images/blog/a.jpg.bak) ![b](/static/images/blog/a.jpg)",
+			want:        "![a](/static/images/blog/a.jpg.bak) ![b](/static/images/blog/a.png)",
+			wantChanged: true,
+		},
+		{
+			name:        "reference at the end of the body",
+			body:        "see /static/images/blog/a.jpg",
+			want:        "see /static/images/blog/a.png",
+			wantChanged: true,
+		},
+		{
+			name: "no reference",
+			body: "![c](/static/images/blog/c.jpg)",
+			want: "![c](/static/images/blog/c.jpg)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, changed := RewriteImageRefs(tt.body, "blog/a.jpg", "blog/a.png")
+			if got != tt.want {
+				t.Errorf("RewriteImageRefs() = %q, want %q", got, tt.want)
+			}
+			if changed != tt.wantChanged {
+				t.Errorf("changed = %v, want %v", changed, tt.wantChanged)
+			}
+		})
+	}
+}
+
+func TestImageManagerReplaceAndRestoreFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		upload   string
+		wantPath string
+	}{
+		{name: "same extension keeps path", upload: "new.jpg", wantPath: "blog/cat.jpg"},
+		{name: "different extension changes path", upload: "new.png", wantPath: "blog/cat.png"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sitesBase := t.TempDir()
+			imagesPath := GetSiteImagesPath(sitesBase, "test")
+			if err := os.MkdirAll(filepath.Join(imagesPath, "blog"), 0755); err != nil {
+				t.Fatalf("cannot create dir: %v", err)
+			}
+			if err := os.WriteFile(filepath.Join(imagesPath, "blog/cat.jpg"), []byte("old"), 0644); err != nil {
+				t.Fatalf("cannot write file: %v", err)
+			}
+
+			cfg := hm.NewConfig()
+			cfg.Set(SSGKey.SitesBasePath, sitesBase)
+			cfg.Set(SSGKey.ImagesStripMetadata, "false")
+			im := NewImageManager(nil, hm.XParams{Cfg: cfg, Log: hm.NewLogger("error")})
+			ctx := context.WithValue(context.Background(), siteSlugKey, "test")
+
+			img := Image{ID: uuid.New(), FileName: "cat.jpg", FilePath: "blog/cat.jpg"}
+			file, header := testMultipartFile(t, tt.upload, []byte("new"))
+
+			result, err := im.ReplaceFile(ctx, img, file, header)
+			if err != nil {
+				t.Fatalf("ReplaceFile() error = %v", err)
+			}
+			if result.RelativePath != tt.wantPath {
+				t.Errorf("RelativePath = %q, want %q", result.RelativePath, tt.wantPath)
+			}
+			if got := readTestFile(t, filepath.Join(imagesPath, tt.wantPath)); got != "new" {
+				t.Errorf("replaced file content = %q, want %q", got, "new")
+			}
+			if got := readTestFile(t, filepath.Join(imagesPath, result.BackupPath)); got != "old" {
+				t.Errorf("backup file content = %q, want %q", got, "old")
+			}
+
+			files, err := im.listImageFiles(imagesPath)
+			if err != nil {
+				t.Fatalf("cannot list files: %v", err)
+			}
+			if !equalStrings(files, []string{tt.wantPath}) {
+				t.Errorf("listed files = %v, history must be hidden", files)
+			}
+
+			rev := NewImageRevision(img, result.BackupPath)
+			if err := im.RestoreFile(ctx, result.RelativePath, *rev); err != nil {
+				t.Fatalf("RestoreFile() error = %v", err)
+			}
+			if got := readTestFile(t, filepath.Join(imagesPath, "blog/cat.jpg")); got != "old" {
+				t.Errorf("restored file content = %q, want %q", got, "old")
+			}
+			if tt.wantPath != img.FilePath {
+				if _, err := os.Stat(filepath.Join(imagesPath, tt.wantPath)); !os.IsNotExist(err) {
+					t.Errorf("replacement file %s still exists after restore", tt.wantPath)
+				}
+			}
+		})
+	}
+}
+
+func testMultipartFile(t *testing.T, name string, data []byte) (multipart.File, *multipart.FileHeader) {
+	t.Helper()
+
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	part, err := mw.CreateFormFile("image", name)
+	if err != nil {
+		t.Fatalf("cannot create form file: %v", err)
+	}
+	part.Write(data)
+	mw.Close()
+
+	form, err := multipart.NewReader(&buf, mw.Boundary()).ReadForm(1 << 20)
+	if err != nil {
+		t.Fatalf("cannot read form: %v", err)
+	}
+	header := form.File["image"][0]
+	file, err := header.Open()
+	if err != nil {
+		t.Fatalf("cannot open form file: %v", err)
+	}
+	t.Cleanup(func() { file.Close() })
+	return file, header
+}
+
+func readTestFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("cannot read %s: %v", path, err)
+	}
+	return string(data)
+}
+
+func TestImageManagerReplaceFileRejectsMalformedImage(t *testing.T) {
+	sitesBase := t.TempDir()
+	imagesPath := GetSiteImagesPath(sitesBase, "test")
+	if err := os.MkdirAll(filepath.Join(imagesPath, "blog"), 0755); err != nil {
+		t.Fatalf("cannot create dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(imagesPath, "blog/cat.jpg"), []byte("old"), 0644); err != nil {
+		t.Fatalf("cannot write file: %v", err)
+	}
+
+	cfg := hm.NewConfig()
+	cfg.Set(SSGKey.SitesBasePath, sitesBase)
+	im := NewImageManager(nil, hm.XParams{Cfg: cfg, Log: hm.NewLogger("error")})
+	ctx := context.WithValue(context.Background(), siteSlugKey, "test")
+
+	// An EXIF segment whose length runs past the end of the file
+	data := append(append([]byte{}, jpegSOI...), 0xFF, 0xE1, 0x40, 0x00)
+	data = append(data, exifHeader...)
+
+	img := Image{ID: uuid.New(), FileName: "cat.jpg", FilePath: "blog/cat.jpg"}
+	file, header := testMultipartFile(t, "new.jpg", data)
+
+	if _, err := im.ReplaceFile(ctx, img, file, header); err == nil {
+		t.Fatal("ReplaceFile() error = nil, want the upload rejected")
+	}
+	if got := readTestFile(t, filepath.Join(imagesPath, "blog/cat.jpg")); got != "old" {
+		t.Errorf("stored file = %q, want the previous file restored", got)
+	}
+}
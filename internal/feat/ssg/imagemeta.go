@@ -0,0 +1,423 @@
+package ssg
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"os"
+)
+
+const (
+	imageFormatJPEG = "jpeg"
+	imageFormatPNG  = "png"
+
+	reencodeJPEGQuality = 92
+
+	exifTagOrientation = 0x0112
+	exifTagGPSInfo     = 0x8825
+)
+
+var (
+	jpegSOI      = []byte{0xFF, 0xD8}
+	pngSignature = []byte{0x89, 'P', 'N', 'G', '\r', '\n', 0x1A, '\n'}
+
+	exifHeader        = []byte("Exif\x00\x00")
+	xmpHeader         = []byte("http://ns.adobe.com/xap/1.0/")
+	xmpExtendedHeader = []byte("http://ns.adobe.com/xmp/extension/")
+	iccProfileHeader  = []byte("ICC_PROFILE\x00")
+	photoshopHeader   = []byte("Photoshop 3.0\x00")
+	adobeHeader       = []byte("Adobe")
+
+	errUnsupportedImageFormat = errors.New("unsupported image format")
+)
+
+// MetadataReport describes the metadata removed from an uploaded image.
+type MetadataReport struct {
+	Format      string   `json:"format"`
+	Removed     []string `json:"removed"`
+	HadLocation bool     `json:"had_location"`
+	Orientation int      `json:"orientation"`
+	Reencoded   bool     `json:"reencoded"`
+}
+
+// HasChanges reports whether anything was removed or rewritten.
+func (r MetadataReport) HasChanges() bool {
+	return len(r.Removed) > 0 || r.Reencoded
+}
+
+func (r *MetadataReport) addRemoved(kind string) {
+	for _, k := range r.Removed {
+		if k == kind {
+			return
+		}
+	}
+	r.Removed = append(r.Removed, kind)
+}
+
+// StripImageMetadata removes EXIF, XMP, IPTC, comments and textual chunks from
+// JPEG and PNG data. When the EXIF orientation is not the default one, the
+// rotation is applied to the pixels and the image is re-encoded, so the result
+// still displays correctly once the orientation tag is gone.
+// Data whose metadata cannot be parsed is decoded and re-encoded, which drops
+// all of it; it is an error only when the pixels cannot be decoded either.
+// Unsupported formats return errUnsupportedImageFormat.
+func StripImageMetadata(data []byte) ([]byte, MetadataReport, error) {
+	var (
+		stripped []byte
+		report   MetadataReport
+		err      error
+	)
+	switch {
+	case bytes.HasPrefix(data, jpegSOI):
+		stripped, report, err = stripJPEGMetadata(data)
+	case bytes.HasPrefix(data, pngSignature):
+		stripped, report, err = stripPNGMetadata(data)
+	default:
+		return data, MetadataReport{}, errUnsupportedImageFormat
+	}
+	if err != nil {
+		return reencodeImage(data, report.Format, err)
+	}
+	return stripped, report, nil
+}
+
+// reencodeImage decodes data and encodes its pixels again in format, leaving
+// every metadata block behind. parseErr is why the metadata could not be
+// stripped segment by segment.
+func reencodeImage(data []byte, format string, parseErr error) ([]byte, MetadataReport, error) {
+	report := MetadataReport{Format: format, Orientation: 1}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, report, fmt.Errorf("cannot parse image metadata (%v) nor decode image: %w", parseErr, err)
+	}
+
+	var encoded bytes.Buffer
+	switch format {
+	case imageFormatPNG:
+		err = png.Encode(&encoded, img)
+	default:
+		err = jpeg.Encode(&encoded, img, &jpeg.Options{Quality: reencodeJPEGQuality})
+	}
+	if err != nil {
+		return nil, report, fmt.Errorf("cannot encode %s: %w", format, err)
+	}
+
+	report.addRemoved("all")
+	report.Reencoded = true
+	return encoded.Bytes(), report, nil
+}
+
+// StripImageFileMetadata strips metadata from the image stored at path,
+// rewriting the file only when something was removed.
+func StripImageFileMetadata(path string) (MetadataReport, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return MetadataReport{}, fmt.Errorf("cannot read image: %w", err)
+	}
+
+	stripped, report, err := StripImageMetadata(data)
+	if err != nil {
+		return report, err
+	}
+
+	if !report.HasChanges() {
+		return report, nil
+	}
+
+	if err := os.WriteFile(path, stripped, 0644); err != nil {
+		return report, fmt.Errorf("cannot write stripped image: %w", err)
+	}
+
+	return report, nil
+}
+
+func stripJPEGMetadata(data []byte) ([]byte, MetadataReport, error) {
+	report := MetadataReport{Format: imageFormatJPEG, Orientation: 1}
+
+	out := bytes.NewBuffer(make([]byte, 0, len(data)))
+	out.Write(jpegSOI)
+
+	var iccSegments [][]byte
+	pos := len(jpegSOI)
+	for {
+		if pos >= len(data) {
+			return nil, report, errors.New("unexpected end of jpeg data")
+		}
+		if data[pos] != 0xFF {
+			return nil, report, fmt.Errorf("invalid jpeg marker at offset %d", pos)
+		}
+
+		// Skip fill bytes
+		for pos < len(data) && data[pos] == 0xFF {
+			pos++
+		}
+		if pos >= len(data) {
+			return nil, report, errors.New("unexpected end of jpeg data")
+		}
+		marker := data[pos]
+		pos++
+
+		// Standalone markers carry no length
+		if marker == 0x01 || (marker >= 0xD0 && marker <= 0xD7) {
+			out.Write([]byte{0xFF, marker})
+			continue
+		}
+		if marker == 0xD9 {
+			out.Write([]byte{0xFF, marker})
+			break
+		}
+
+		if pos+2 > len(data) {
+			return nil, report, errors.New("truncated jpeg segment length")
+		}
+		length := int(binary.BigEndian.Uint16(data[pos : pos+2]))
+		if length < 2 || pos+length > len(data) {
+			return nil, report, fmt.Errorf("invalid jpeg segment length at offset %d", pos)
+		}
+		segment := data[pos-2 : pos+length]
+		payload := data[pos+2 : pos+length]
+		pos += length
+
+		// Start of scan: the entropy coded data follows until the end.
+		if marker == 0xDA {
+			out.Write(segment)
+			out.Write(data[pos:])
+			break
+		}
+
+		kind, keep := classifyJPEGSegment(marker, payload)
+		if keep {
+			if kind == "icc" {
+				iccSegments = append(iccSegments, segment)
+			}
+			out.Write(segment)
+			continue
+		}
+
+		report.addRemoved(kind)
+		if kind == "exif" {
+			orientation, hasGPS := parseExif(payload[len(exifHeader):])
+			report.Orientation = orientation
+			report.HadLocation = report.HadLocation || hasGPS
+			if hasGPS {
+				report.addRemoved("gps")
+			}
+		}
+	}
+
+	if !needsRotation(report.Orientation) {
+		return out.Bytes(), report, nil
+	}
+
+	img, err := jpeg.Decode(bytes.NewReader(out.Bytes()))
+	if err != nil {
+		return nil, report, fmt.Errorf("cannot decode jpeg: %w", err)
+	}
+
+	var encoded bytes.Buffer
+	err = jpeg.Encode(&encoded, applyOrientation(img, report.Orientation), &jpeg.Options{Quality: reencodeJPEGQuality})
+	if err != nil {
+		return nil, report, fmt.Errorf("cannot encode jpeg: %w", err)
+	}
+	report.Reencoded = true
+
+	// The encoder does not write color profiles, put the original ones back.
+	rotated := encoded.Bytes()
+	result := bytes.NewBuffer(make([]byte, 0, len(rotated)))
+	result.Write(jpegSOI)
+	for _, seg := range iccSegments {
+		result.Write(seg)
+	}
+	result.Write(rotated[len(jpegSOI):])
+
+	return result.Bytes(), report, nil
+}
+
+// classifyJPEGSegment names a segment and tells whether it should be kept.
+// Only segments needed to render the image faithfully survive.
+func classifyJPEGSegment(marker byte, payload []byte) (kind string, keep bool) {
+	switch {
+	case marker == 0xE0:
+		return "jfif", true
+	case marker == 0xE1 && bytes.HasPrefix(payload, exifHeader):
+		return "exif", false
+	case marker == 0xE1 && (bytes.HasPrefix(payload, xmpHeader) || bytes.HasPrefix(payload, xmpExtendedHeader)):
+		return "xmp", false
+	case marker == 0xE2 && bytes.HasPrefix(payload, iccProfileHeader):
+		return "icc", true
+	case marker == 0xED && bytes.HasPrefix(payload, photoshopHeader):
+		return "iptc", false
+	case marker == 0xEE && bytes.HasPrefix(payload, adobeHeader):
+		return "adobe", true
+	case marker >= 0xE0 && marker <= 0xEF:
+		return fmt.Sprintf("app%d", marker-0xE0), false
+	case marker == 0xFE:
+		return "comment", false
+	default:
+		return "", true
+	}
+}
+
+func stripPNGMetadata(data []byte) ([]byte, MetadataReport, error) {
+	report := MetadataReport{Format: imageFormatPNG, Orientation: 1}
+
+	out := bytes.NewBuffer(make([]byte, 0, len(data)))
+	out.Write(pngSignature)
+
+	pos := len(pngSignature)
+	for pos < len(data) {
+		if pos+8 > len(data) {
+			return nil, report, errors.New("truncated png chunk header")
+		}
+		length := int(binary.BigEndian.Uint32(data[pos : pos+4]))
+		chunkType := string(data[pos+4 : pos+8])
+		end := pos + 12 + length
+		if end > len(data) {
+			return nil, report, fmt.Errorf("invalid png chunk length at offset %d", pos)
+		}
+		chunk := data[pos:end]
+		payload := data[pos+8 : pos+8+length]
+		pos = end
+
+		switch chunkType {
+		case "eXIf":
+			report.addRemoved("exif")
+			orientation, hasGPS := parseExif(payload)
+			report.Orientation = orientation
+			report.HadLocation = report.HadLocation || hasGPS
+			if hasGPS {
+				report.addRemoved("gps")
+			}
+		case "tEXt", "zTXt", "iTXt":
+			report.addRemoved("text")
+		case "tIME":
+			report.addRemoved("time")
+		default:
+			out.Write(chunk)
+		}
+
+		if chunkType == "IEND" {
+			break
+		}
+	}
+
+	if !needsRotation(report.Orientation) {
+		return out.Bytes(), report, nil
+	}
+
+	img, err := png.Decode(bytes.NewReader(out.Bytes()))
+	if err != nil {
+		return nil, report, fmt.Errorf("cannot decode png: %w", err)
+	}
+
+	var encoded bytes.Buffer
+	if err := png.Encode(&encoded, applyOrientation(img, report.Orientation)); err != nil {
+		return nil, report, fmt.Errorf("cannot encode png: %w", err)
+	}
+	report.Reencoded = true
+
+	return encoded.Bytes(), report, nil
+}
+
+// parseExif reads the orientation and the presence of a GPS IFD from a TIFF
+// structured EXIF payload. Malformed data yields the default orientation.
+func parseExif(tiff []byte) (orientation int, hasGPS bool) {
+	orientation = 1
+	if len(tiff) < 8 {
+		return orientation, false
+	}
+
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return orientation, false
+	}
+
+	if order.Uint16(tiff[2:4]) != 42 {
+		return orientation, false
+	}
+
+	ifd := int(order.Uint32(tiff[4:8]))
+	if ifd < 8 || ifd+2 > len(tiff) {
+		return orientation, false
+	}
+
+	count := int(order.Uint16(tiff[ifd : ifd+2]))
+	for i := 0; i < count; i++ {
+		entry := ifd + 2 + i*12
+		if entry+12 > len(tiff) {
+			break
+		}
+
+		switch order.Uint16(tiff[entry : entry+2]) {
+		case exifTagOrientation:
+			value := int(order.Uint16(tiff[entry+8 : entry+10]))
+			if value >= 1 && value <= 8 {
+				orientation = value
+			}
+		case exifTagGPSInfo:
+			hasGPS = true
+		}
+	}
+
+	return orientation, hasGPS
+}
+
+func needsRotation(orientation int) bool {
+	return orientation >= 2 && orientation <= 8
+}
+
+// applyOrientation returns a copy of img transformed as described by the EXIF
+// orientation value, so it displays upright without the tag.
+func applyOrientation(img image.Image, orientation int) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+
+	dstW, dstH := w, h
+	if orientation >= 5 {
+		dstW, dstH = h, w
+	}
+
+	src := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(src, src.Bounds(), img, b.Min, draw.Src)
+
+	dst := image.NewRGBA(image.Rect(0, 0, dstW, dstH))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var dx, dy int
+			switch orientation {
+			case 2:
+				dx, dy = w-1-x, y
+			case 3:
+				dx, dy = w-1-x, h-1-y
+			case 4:
+				dx, dy = x, h-1-y
+			case 5:
+				dx, dy = y, x
+			case 6:
+				dx, dy = h-1-y, x
+			case 7:
+				dx, dy = h-1-y, w-1-x
+			case 8:
+				dx, dy = y, w-1-x
+			default:
+				dx, dy = x, y
+			}
+			si := src.PixOffset(x, y)
+			di := dst.PixOffset(dx, dy)
+			copy(dst.Pix[di:di+4], src.Pix[si:si+4])
+		}
+	}
+
+	return dst
+}
@@ -0,0 +1,247 @@
+package ssg
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func TestStripImageMetadataJPEG(t *testing.T) {
+	tests := []struct {
+		name        string
+		orientation int
+		gps         bool
+		wantRemoved []string
+		wantWidth   int
+		wantHeight  int
+		wantReenc   bool
+	}{
+		{
+			name:        "removes exif and comment keeping orientation",
+			orientation: 1,
+			wantRemoved: []string{"exif", "comment"},
+			wantWidth:   4,
+			wantHeight:  2,
+		},
+		{
+			name:        "reports location data",
+			orientation: 1,
+			gps:         true,
+			wantRemoved: []string{"exif", "gps", "comment"},
+			wantWidth:   4,
+			wantHeight:  2,
+		},
+		{
+			name:        "applies rotation before dropping orientation",
+			orientation: 6,
+			wantRemoved: []string{"exif", "comment"},
+			wantWidth:   2,
+			wantHeight:  4,
+			wantReenc:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := testJPEGWithExif(t, tt.orientation, tt.gps)
+
+			got, report, err := StripImageMetadata(data)
+			if err != nil {
+				t.Fatalf("StripImageMetadata() error = %v", err)
+			}
+
+			if report.Format != imageFormatJPEG {
+				t.Errorf("Format = %q, want %q", report.Format, imageFormatJPEG)
+			}
+			if !equalStrings(report.Removed, tt.wantRemoved) {
+				t.Errorf("Removed = %v, want %v", report.Removed, tt.wantRemoved)
+			}
+			if report.HadLocation != tt.gps {
+				t.Errorf("HadLocation = %v, want %v", report.HadLocation, tt.gps)
+			}
+			if report.Reencoded != tt.wantReenc {
+				t.Errorf("Reencoded = %v, want %v", report.Reencoded, tt.wantReenc)
+			}
+			if bytes.Contains(got, exifHeader) || bytes.Contains(got, []byte("secret comment")) {
+				t.Error("stripped data still contains metadata")
+			}
+
+			cfg, err := jpeg.DecodeConfig(bytes.NewReader(got))
+			if err != nil {
+				t.Fatalf("stripped jpeg cannot be decoded: %v", err)
+			}
+			if cfg.Width != tt.wantWidth || cfg.Height != tt.wantHeight {
+				t.Errorf("size = %dx%d, want %dx%d", cfg.Width, cfg.Height, tt.wantWidth, tt.wantHeight)
+			}
+		})
+	}
+}
+
+func TestStripImageMetadataPNG(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, testImage(4, 2)); err != nil {
+		t.Fatalf("cannot encode png: %v", err)
+	}
+
+	// Insert a text chunk and a timestamp right after IHDR
+	raw := buf.Bytes()
+	ihdrEnd := len(pngSignature) + 8 + 13 + 4
+	var data []byte
+	data = append(data, raw[:ihdrEnd]...)
+	data = append(data, testPNGChunk("tEXt", []byte("Author\x00someone"))...)
+	data = append(data, testPNGChunk("tIME", []byte{0x07, 0xE9, 1, 2, 3, 4, 5})...)
+	data = append(data, raw[ihdrEnd:]...)
+
+	got, report, err := StripImageMetadata(data)
+	if err != nil {
+		t.Fatalf("StripImageMetadata() error = %v", err)
+	}
+
+	if !equalStrings(report.Removed, []string{"text", "time"}) {
+		t.Errorf("Removed = %v, want [text time]", report.Removed)
+	}
+	if !bytes.Equal(got, raw) {
+		t.Error("stripped png differs from the original image data")
+	}
+}
+
+func TestStripImageMetadataReencodesUnparsable(t *testing.T) {
+	data := testJPEGWithExif(t, 1, true)
+
+	// A stray byte after the EXIF segment, which decoders skip
+	exifEnd := len(jpegSOI) + 2 + int(binary.BigEndian.Uint16(data[len(jpegSOI)+2:]))
+	data = append(append(append([]byte{}, data[:exifEnd]...), 0x00), data[exifEnd:]...)
+
+	got, report, err := StripImageMetadata(data)
+	if err != nil {
+		t.Fatalf("StripImageMetadata() error = %v", err)
+	}
+
+	if !report.Reencoded || !equalStrings(report.Removed, []string{"all"}) {
+		t.Errorf("report = %+v, want the image re-encoded", report)
+	}
+	if bytes.Contains(got, exifHeader) || bytes.Contains(got, []byte("secret comment")) {
+		t.Error("re-encoded data still contains metadata")
+	}
+	if cfg, err := jpeg.DecodeConfig(bytes.NewReader(got)); err != nil || cfg.Width != 4 || cfg.Height != 2 {
+		t.Errorf("re-encoded jpeg = %+v, %v", cfg, err)
+	}
+
+	if _, _, err := StripImageMetadata(data[:exifEnd]); err == nil {
+		t.Error("StripImageMetadata() of an image without pixels must fail")
+	}
+}
+
+func TestStripImageMetadataUnsupported(t *testing.T) {
+	_, _, err := StripImageMetadata([]byte("GIF89a"))
+	if err != errUnsupportedImageFormat {
+		t.Errorf("error = %v, want %v", err, errUnsupportedImageFormat)
+	}
+}
+
+func TestApplyOrientation(t *testing.T) {
+	src := testImage(3, 2)
+	marked := color.RGBA{R: 255, A: 255}
+	src.Set(0, 0, marked)
+
+	tests := []struct {
+		orientation int
+		wantX       int
+		wantY       int
+	}{
+		{orientation: 2, wantX: 2, wantY: 0},
+		{orientation: 3, wantX: 2, wantY: 1},
+		{orientation: 4, wantX: 0, wantY: 1},
+		{orientation: 5, wantX: 0, wantY: 0},
+		{orientation: 6, wantX: 1, wantY: 0},
+		{orientation: 7, wantX: 1, wantY: 2},
+		{orientation: 8, wantX: 0, wantY: 2},
+	}
+
+	for _, tt := range tests {
+		got := applyOrientation(src, tt.orientation)
+		if c := color.RGBAModel.Convert(got.At(tt.wantX, tt.wantY)); c != marked {
+			t.Errorf("orientation %d: pixel (%d,%d) = %v, want %v", tt.orientation, tt.wantX, tt.wantY, c, marked)
+		}
+	}
+}
+
+func testImage(w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.RGBA{B: 200, A: 255})
+		}
+	}
+	return img
+}
+
+// testJPEGWithExif encodes a small image and inserts an EXIF segment and a
+// comment after the SOI marker.
+func testJPEGWithExif(t *testing.T, orientation int, gps bool) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, testImage(4, 2), nil); err != nil {
+		t.Fatalf("cannot encode jpeg: %v", err)
+	}
+
+	entries := 1
+	if gps {
+		entries++
+	}
+
+	tiff := []byte{'M', 'M', 0, 42, 0, 0, 0, 8}
+	tiff = binary.BigEndian.AppendUint16(tiff, uint16(entries))
+	tiff = binary.BigEndian.AppendUint16(tiff, exifTagOrientation)
+	tiff = binary.BigEndian.AppendUint16(tiff, 3) // SHORT
+	tiff = binary.BigEndian.AppendUint32(tiff, 1)
+	tiff = binary.BigEndian.AppendUint16(tiff, uint16(orientation))
+	tiff = append(tiff, 0, 0)
+	if gps {
+		tiff = binary.BigEndian.AppendUint16(tiff, exifTagGPSInfo)
+		tiff = binary.BigEndian.AppendUint16(tiff, 4) // LONG
+		tiff = binary.BigEndian.AppendUint32(tiff, 1)
+		tiff = binary.BigEndian.AppendUint32(tiff, 0)
+	}
+	tiff = append(tiff, 0, 0, 0, 0)
+
+	exif := append(append([]byte{}, exifHeader...), tiff...)
+
+	var data []byte
+	data = append(data, jpegSOI...)
+	data = append(data, testJPEGSegment(0xE1, exif)...)
+	data = append(data, testJPEGSegment(0xFE, []byte("secret comment"))...)
+	data = append(data, buf.Bytes()[len(jpegSOI):]...)
+	return data
+}
+
+func testJPEGSegment(marker byte, payload []byte) []byte {
+	seg := []byte{0xFF, marker}
+	seg = binary.BigEndian.AppendUint16(seg, uint16(len(payload)+2))
+	return append(seg, payload...)
+}
+
+func testPNGChunk(chunkType string, payload []byte) []byte {
+	chunk := binary.BigEndian.AppendUint32(nil, uint32(len(payload)))
+	chunk = append(chunk, chunkType...)
+	chunk = append(chunk, payload...)
+	return binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(chunk[4:]))
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
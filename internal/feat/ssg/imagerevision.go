@@ -0,0 +1,34 @@
+package ssg
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ImageRevision records the file an image used before it was replaced, so the
+// replacement can be rolled back.
+type ImageRevision struct {
+	ID         uuid.UUID `json:"id" db:"id"`
+	ImageID    uuid.UUID `json:"image_id" db:"image_id"`
+	FileName   string    `json:"file_name" db:"file_name"`
+	FilePath   string    `json:"file_path" db:"file_path"`
+	BackupPath string    `json:"backup_path" db:"backup_path"`
+	Width      int       `json:"width" db:"width"`
+	Height     int       `json:"height" db:"height"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+}
+
+// NewImageRevision creates a revision holding the current file of img, kept at backupPath.
+func NewImageRevision(img Image, backupPath string) *ImageRevision {
+	return &ImageRevision{
+		ID:         uuid.New(),
+		ImageID:    img.ID,
+		FileName:   img.FileName,
+		FilePath:   img.FilePath,
+		BackupPath: backupPath,
+		Width:      img.Width,
+		Height:     img.Height,
+		CreatedAt:  time.Now(),
+	}
+}
@@ -0,0 +1,532 @@
+package ssg
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gopkg.in/yaml.v2"
+
+	"github.com/hermesgen/hm"
+)
+
+// Import actions, as listed in the import report.
+const (
+	ImportCreated   = "created"
+	ImportUpdated   = "updated"
+	ImportUnchanged = "unchanged"
+	ImportConflict  = "conflict"
+	ImportFailed    = "failed"
+)
+
+// shortIDRe matches the short IDs generated by hm.GenShortID.
+var shortIDRe = regexp.MustCompile(`^[0-9a-f]{12}$`)
+
+// importDateLayouts are the date formats accepted in front matter.
+var importDateLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05 -0700",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// ImportItem is the outcome of importing one Markdown file.
+type ImportItem struct {
+	Path      string    `json:"path"`
+	Action    string    `json:"action"`
+	ContentID uuid.UUID `json:"content_id,omitempty"`
+	Heading   string    `json:"heading,omitempty"`
+	Message   string    `json:"message,omitempty"`
+}
+
+// ImportReport is the result of importing a directory of Markdown files.
+type ImportReport struct {
+	Items           []ImportItem `json:"items"`
+	Created         int          `json:"created"`
+	Updated         int          `json:"updated"`
+	Unchanged       int          `json:"unchanged"`
+	Conflicts       int          `json:"conflicts"`
+	Failed          int          `json:"failed"`
+	SectionsCreated []string     `json:"sections_created"`
+	ImagesCopied    int          `json:"images_copied,omitempty"`
+	Redirects       int          `json:"redirects,omitempty"`
+	Summary         string       `json:"summary"`
+}
+
+func (r *ImportReport) add(item ImportItem) {
+	r.Items = append(r.Items, item)
+	switch item.Action {
+	case ImportCreated:
+		r.Created++
+	case ImportUpdated:
+		r.Updated++
+	case ImportUnchanged:
+		r.Unchanged++
+	case ImportConflict:
+		r.Conflicts++
+	default:
+		r.Failed++
+	}
+}
+
+func (r *ImportReport) summarize() {
+	r.Summary = fmt.Sprintf("Created: %d, Updated: %d, Unchanged: %d, Failed: %d",
+		r.Created, r.Updated, r.Unchanged, r.Failed)
+	if r.Conflicts > 0 {
+		r.Summary += fmt.Sprintf(", Conflicts: %d", r.Conflicts)
+	}
+	if len(r.SectionsCreated) > 0 {
+		r.Summary += fmt.Sprintf(", Sections created: %d", len(r.SectionsCreated))
+	}
+	if r.ImagesCopied > 0 {
+		r.Summary += fmt.Sprintf(", Images copied: %d", r.ImagesCopied)
+	}
+	if r.Redirects > 0 {
+		r.Summary += fmt.Sprintf(", Redirects: %d", r.Redirects)
+	}
+}
+
+// importOptions change how files are imported.
+type importOptions struct {
+	// NewAsDraft imports new contents as drafts whatever their front matter says
+	NewAsDraft bool
+	// Conflict, if set, is asked before updating existing; a non empty reason
+	// leaves the content as it is and reports a conflict.
+	Conflict func(existing Content) string
+	// MatchSlug updates the content a file clashes with by slug or heading
+	// when it has no matching short ID. Otherwise the clash is reported as a
+	// conflict and nothing is written.
+	MatchSlug bool
+}
+
+// markdownFrontMatter holds the front matter keys written by
+// Generator.Generate, plus kind and series for series posts. Dates are kept as
+// text so hand written files can use any of importDateLayouts.
+type markdownFrontMatter struct {
+	Title           string   `yaml:"title"`
+	Slug            string   `yaml:"slug"`
+	Tags            []string `yaml:"tags"`
+	Layout          string   `yaml:"layout"`
+	Draft           *bool    `yaml:"draft"`
+	Featured        bool     `yaml:"featured"`
+	Kind            string   `yaml:"kind"`
+	Series          string   `yaml:"series"`
+	SeriesOrder     int      `yaml:"series-order"`
+	Excerpt         string   `yaml:"excerpt"`
+	Summary         string   `yaml:"summary"`
+	Description     string   `yaml:"description"`
+	PublishedAt     string   `yaml:"published-at"`
+	CreatedAt       string   `yaml:"created-at"`
+	Robots          string   `yaml:"robots"`
+	Keywords        string   `yaml:"keywords"`
+	CanonicalURL    string   `yaml:"canonical-url"`
+	Sitemap         string   `yaml:"sitemap"`
+	TableOfContents bool     `yaml:"table-of-contents"`
+	Comments        bool     `yaml:"comments"`
+	Share           bool     `yaml:"share"`
+}
+
+// markdownDoc is a Markdown file split into its front matter and body.
+type markdownDoc struct {
+	Front markdownFrontMatter
+	Body  string
+}
+
+// parseMarkdownDoc splits data into the YAML front matter between the leading
+// --- lines and the body that follows. Files without front matter are all body.
+func parseMarkdownDoc(data []byte) (markdownDoc, error) {
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+	front, body, ok, err := splitFrontMatter(text, "---")
+	if err != nil {
+		return markdownDoc{}, err
+	}
+	if !ok {
+		return markdownDoc{Body: text}, nil
+	}
+
+	var doc markdownDoc
+	if err := yaml.Unmarshal([]byte(front), &doc.Front); err != nil {
+		return markdownDoc{}, fmt.Errorf("invalid front matter: %w", err)
+	}
+	doc.Body = body
+	return doc, nil
+}
+
+// splitFrontMatter splits text into the front matter between the leading
+// delim lines and the body that follows. ok is false if text does not start
+// with front matter.
+func splitFrontMatter(text, delim string) (front, body string, ok bool, err error) {
+	open, closing := delim+"\n", "\n"+delim+"\n"
+	if !strings.HasPrefix(text, open) {
+		return "", text, false, nil
+	}
+
+	rest := text[len(open):]
+	if strings.HasPrefix(rest, open) {
+		return "", rest[len(open):], true, nil
+	}
+
+	end := strings.Index(rest, closing)
+	if end < 0 {
+		if !strings.HasSuffix(rest, "\n"+delim) {
+			return "", "", false, fmt.Errorf("front matter is not closed")
+		}
+		return rest[:len(rest)-len(delim)-1], "", true, nil
+	}
+	return rest[:end], rest[end+len(closing):], true, nil
+}
+
+// parseImportDate parses a front matter date. Empty and zero dates are nil.
+func parseImportDate(s string) (*time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "null" {
+		return nil, nil
+	}
+	for _, layout := range importDateLayouts {
+		t, err := time.Parse(layout, s)
+		if err != nil {
+			continue
+		}
+		if t.IsZero() {
+			return nil, nil
+		}
+		return &t, nil
+	}
+	return nil, fmt.Errorf("invalid date %q", s)
+}
+
+// markdownFiles lists the Markdown files under dir as sorted, slash separated
+// paths relative to dir. Hidden directories such as .git are skipped.
+func markdownFiles(dir string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if p != dir && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if ext := strings.ToLower(filepath.Ext(p)); ext != ".md" && ext != ".markdown" {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		files = append(files, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// importedContent is a Markdown file read into a Content, still detached from
+// the site.
+type importedContent struct {
+	Content   Content
+	Slug      string
+	CreatedAt *time.Time
+	// DraftSet reports whether the file has a draft key. Without one, new
+	// contents are drafts and existing ones keep their state.
+	DraftSet bool
+}
+
+// toContent reads doc, found at the relative path rel, into a Content. The
+// section path comes from the directory of the file, as laid out by
+// Generator.Generate; the short ID from the slug or, failing that, the file
+// name.
+func (doc markdownDoc) toContent(rel string) (importedContent, error) {
+	f := doc.Front
+
+	ic := importedContent{Slug: f.Slug}
+	if ic.Slug == "" {
+		ic.Slug = strings.TrimSuffix(path.Base(rel), path.Ext(rel))
+	}
+
+	c := &ic.Content
+	c.Heading = strings.TrimSpace(f.Title)
+	if c.Heading == "" {
+		c.Heading = headingFromSlug(ic.Slug)
+	}
+	c.Body = doc.Body
+	if shortID := sourceShortID(ic.Slug); shortIDRe.MatchString(shortID) {
+		c.ShortID = shortID
+	}
+
+	dir := path.Dir(rel)
+	if dir == "." {
+		dir = ""
+	}
+	c.SectionPath = normalizeSectionPath(dir)
+	c.SectionName = strings.TrimSpace(f.Layout)
+
+	c.Kind = f.Kind
+	c.Draft = f.Draft == nil || *f.Draft
+	ic.DraftSet = f.Draft != nil
+	c.Featured = f.Featured
+	c.Series = f.Series
+	c.SeriesOrder = f.SeriesOrder
+	c.Summary = f.Summary
+
+	publishedAt, err := parseImportDate(f.PublishedAt)
+	if err != nil {
+		return importedContent{}, fmt.Errorf("published-at: %w", err)
+	}
+	c.PublishedAt = publishedAt
+
+	ic.CreatedAt, err = parseImportDate(f.CreatedAt)
+	if err != nil {
+		return importedContent{}, fmt.Errorf("created-at: %w", err)
+	}
+
+	c.Meta = Meta{
+		Description:     f.Description,
+		Keywords:        f.Keywords,
+		Robots:          f.Robots,
+		CanonicalURL:    f.CanonicalURL,
+		Sitemap:         f.Sitemap,
+		TableOfContents: f.TableOfContents,
+		Comments:        f.Comments,
+		Share:           f.Share,
+	}
+	if c.Meta.Description == "" {
+		c.Meta.Description = f.Excerpt
+	}
+
+	seen := map[string]bool{}
+	for _, name := range f.Tags {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[strings.ToLower(name)] {
+			continue
+		}
+		seen[strings.ToLower(name)] = true
+		c.Tags = append(c.Tags, Tag{Name: name})
+	}
+
+	return ic, nil
+}
+
+// headingFromSlug turns a file name such as my-first-post-1a2b3c4d5e6f into a
+// heading, dropping the short ID.
+func headingFromSlug(slug string) string {
+	if i := strings.LastIndex(slug, "-"); i > 0 && shortIDRe.MatchString(slug[i+1:]) {
+		slug = slug[:i]
+	}
+	words := strings.FieldsFunc(slug, func(r rune) bool { return r == '-' || r == '_' })
+	for i, w := range words {
+		words[i] = hm.Cap(w)
+	}
+	return strings.Join(words, " ")
+}
+
+// markdownImport matches imported files against the contents and sections a
+// site already has.
+type markdownImport struct {
+	byShortID map[string]Content
+	bySlug    map[string]Content
+	sections  map[string]Section
+	claimed   map[uuid.UUID]bool
+}
+
+func newMarkdownImport(contents []Content, sections []Section) *markdownImport {
+	imp := &markdownImport{
+		byShortID: map[string]Content{},
+		bySlug:    map[string]Content{},
+		sections:  map[string]Section{},
+		claimed:   map[uuid.UUID]bool{},
+	}
+
+	for _, c := range contents {
+		if c.ShortID != "" {
+			imp.byShortID[c.ShortID] = c
+		}
+		for _, slug := range []string{c.Slug(), hm.Normalize(c.Heading)} {
+			if _, ok := imp.bySlug[slug]; !ok {
+				imp.bySlug[slug] = c
+			}
+		}
+	}
+
+	for _, s := range sections {
+		imp.sections[normalizeSectionPath(s.Path)] = s
+	}
+
+	return imp
+}
+
+// match returns the existing content with the short ID of ic. A content is
+// matched once, later files with the same ID are imported as new contents.
+func (imp *markdownImport) match(ic importedContent) (Content, bool) {
+	c, ok := imp.byShortID[ic.Content.ShortID]
+	if ic.Content.ShortID == "" || !ok || imp.claimed[c.ID] {
+		return Content{}, false
+	}
+	imp.claimed[c.ID] = true
+	return c, true
+}
+
+// collision returns the unmatched content ic clashes with: the one with its
+// slug or, only for files without a slug, the one with its heading.
+func (imp *markdownImport) collision(ic importedContent) (Content, bool) {
+	key := ic.Slug
+	if key == "" {
+		key = hm.Normalize(ic.Content.Heading)
+	}
+	if key == "" {
+		return Content{}, false
+	}
+	c, ok := imp.bySlug[key]
+	if !ok || imp.claimed[c.ID] {
+		return Content{}, false
+	}
+	return c, true
+}
+
+// claim marks c as matched, so no later file updates it.
+func (imp *markdownImport) claim(c Content) {
+	imp.claimed[c.ID] = true
+}
+
+// created records a content created by the import so later files can not
+// claim it or its short ID.
+func (imp *markdownImport) created(c Content) {
+	imp.byShortID[c.ShortID] = c
+	imp.claimed[c.ID] = true
+}
+
+// shortIDTaken reports whether a new content can not keep shortID.
+func (imp *markdownImport) shortIDTaken(shortID string) bool {
+	_, ok := imp.byShortID[shortID]
+	return ok
+}
+
+func (imp *markdownImport) section(p string) (Section, bool) {
+	s, ok := imp.sections[normalizeSectionPath(p)]
+	return s, ok
+}
+
+func (imp *markdownImport) addSection(s Section) {
+	imp.sections[normalizeSectionPath(s.Path)] = s
+}
+
+// defaultLayoutID is the layout new sections get: the one of the root section,
+// or of any section if there is no root.
+func (imp *markdownImport) defaultLayoutID() uuid.UUID {
+	if root, ok := imp.sections["/"]; ok {
+		return root.LayoutID
+	}
+	paths := make([]string, 0, len(imp.sections))
+	for p := range imp.sections {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	if len(paths) > 0 {
+		return imp.sections[paths[0]].LayoutID
+	}
+	return uuid.Nil
+}
+
+func normalizeSectionPath(p string) string {
+	p = strings.Trim(strings.TrimSpace(p), "/")
+	if p == "" {
+		return "/"
+	}
+	return "/" + p
+}
+
+// sectionNameFromPath names a section created for path, e.g. /deep-dives
+// becomes Deep Dives. The root section is named root, as in the seed.
+func sectionNameFromPath(p string) string {
+	p = normalizeSectionPath(p)
+	if p == "/" {
+		return "root"
+	}
+	return headingFromSlug(path.Base(p))
+}
+
+// merge applies the imported fields onto existing and reports whether
+// anything changed. Identity, ownership and images are kept.
+func merge(existing Content, ic importedContent) (Content, bool) {
+	in := ic.Content
+	c := existing
+	c.Heading = in.Heading
+	c.Body = in.Body
+	c.SectionID = in.SectionID
+	if ic.DraftSet {
+		c.Draft = in.Draft
+	}
+	c.Featured = in.Featured
+	c.PublishedAt = in.PublishedAt
+	if in.Kind != "" {
+		c.Kind = in.Kind
+	}
+	if in.Series != "" {
+		c.Series = in.Series
+		c.SeriesOrder = in.SeriesOrder
+	}
+	if in.Summary != "" {
+		c.Summary = in.Summary
+	}
+
+	c.Meta.ContentID = existing.ID
+	c.Meta.Description = in.Meta.Description
+	c.Meta.Keywords = in.Meta.Keywords
+	c.Meta.Robots = in.Meta.Robots
+	c.Meta.CanonicalURL = in.Meta.CanonicalURL
+	c.Meta.Sitemap = in.Meta.Sitemap
+	c.Meta.TableOfContents = in.Meta.TableOfContents
+	c.Meta.Comments = in.Meta.Comments
+	c.Meta.Share = in.Meta.Share
+	c.Tags = in.Tags
+
+	changed := c.Heading != existing.Heading ||
+		c.Body != existing.Body ||
+		c.SectionID != existing.SectionID ||
+		c.Draft != existing.Draft ||
+		c.Featured != existing.Featured ||
+		!sameTime(c.PublishedAt, existing.PublishedAt) ||
+		c.Kind != existing.Kind ||
+		c.Series != existing.Series ||
+		c.SeriesOrder != existing.SeriesOrder ||
+		c.Summary != existing.Summary ||
+		c.Meta != existing.Meta ||
+		!sameTagNames(c.Tags, existing.Tags)
+	return c, changed
+}
+
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+func sameTagNames(a, b []Tag) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	names := map[string]bool{}
+	for _, t := range a {
+		names[strings.ToLower(t.Name)] = true
+	}
+	for _, t := range b {
+		if !names[strings.ToLower(t.Name)] {
+			return false
+		}
+	}
+	return true
+}
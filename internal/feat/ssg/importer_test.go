@@ -0,0 +1,391 @@
+package ssg
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hermesgen/hm"
+)
+
+func TestImportGeneratedMarkdown(t *testing.T) {
+	sitesBase := t.TempDir()
+	cfg := hm.NewConfig()
+	cfg.Set(SSGKey.SitesBasePath, sitesBase)
+	gen := NewGenerator(hm.XParams{Cfg: cfg, Log: hm.NewLogger("error")})
+
+	published := time.Date(2025, 7, 7, 10, 24, 35, 0, time.UTC)
+	content := Content{
+		ID:          uuid.New(),
+		ShortID:     "1a2b3c4d5e6f",
+		Heading:     "Building Operators",
+		Summary:     "Operators from scratch",
+		Body:        "# Building Operators\n\nSome text.\n",
+		Featured:    true,
+		Series:      "Kubernetes",
+		SeriesOrder: 2,
+		PublishedAt: &published,
+		SectionPath: "/tech",
+		SectionName: "Tech",
+		Tags:        []Tag{{Name: "Go"}, {Name: "Kubernetes"}},
+		Meta: Meta{
+			Description:     "How to build operators",
+			Keywords:        "go, k8s",
+			Robots:          "index, follow",
+			TableOfContents: true,
+			Share:           true,
+		},
+	}
+
+	if err := gen.Generate(context.Background(), "test", []Content{content}); err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	dir := GetSiteMarkdownPath(sitesBase, "test")
+	files, err := markdownFiles(dir)
+	if err != nil {
+		t.Fatalf("markdownFiles() error = %v", err)
+	}
+	want := "tech/building-operators-1a2b3c4d5e6f.md"
+	if len(files) != 1 || files[0] != want {
+		t.Fatalf("markdownFiles() = %v, want [%s]", files, want)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(files[0])))
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc, err := parseMarkdownDoc(data)
+	if err != nil {
+		t.Fatalf("parseMarkdownDoc() error = %v", err)
+	}
+	ic, err := doc.toContent(files[0])
+	if err != nil {
+		t.Fatalf("toContent() error = %v", err)
+	}
+
+	got := ic.Content
+	if got.Heading != content.Heading || got.Body != content.Body || got.ShortID != content.ShortID {
+		t.Errorf("heading, body, short ID = %q, %q, %q", got.Heading, got.Body, got.ShortID)
+	}
+	if got.SectionPath != "/tech" || got.SectionName != "Tech" {
+		t.Errorf("section = %q %q, want /tech Tech", got.SectionPath, got.SectionName)
+	}
+	if got.Draft || !got.Featured {
+		t.Errorf("draft, featured = %t, %t", got.Draft, got.Featured)
+	}
+	if got.Summary != content.Summary || got.Series != content.Series || got.SeriesOrder != content.SeriesOrder {
+		t.Errorf("summary, series, order = %q, %q, %d", got.Summary, got.Series, got.SeriesOrder)
+	}
+	if !sameTime(got.PublishedAt, content.PublishedAt) {
+		t.Errorf("published at = %v, want %v", got.PublishedAt, content.PublishedAt)
+	}
+	if got.Meta != (Meta{
+		Description:     "How to build operators",
+		Keywords:        "go, k8s",
+		Robots:          "index, follow",
+		TableOfContents: true,
+		Share:           true,
+	}) {
+		t.Errorf("meta = %+v", got.Meta)
+	}
+	if !sameTagNames(got.Tags, content.Tags) {
+		t.Errorf("tags = %v, want %v", got.Tags, content.Tags)
+	}
+
+	// Importing the export of a content back over it changes nothing
+	existing := content
+	existing.SectionID = uuid.New()
+	existing.Meta.ContentID = content.ID
+	ic.Content.SectionID = existing.SectionID
+	if _, changed := merge(existing, ic); changed {
+		t.Errorf("merge() of an unchanged export reports changes")
+	}
+}
+
+func TestParseMarkdownDoc(t *testing.T) {
+	tests := []struct {
+		name      string
+		data      string
+		wantTitle string
+		wantBody  string
+		wantErr   bool
+	}{
+		{
+			name:      "front matter and body",
+			data:      "---\ntitle: Hello\n---\nBody text\n",
+			wantTitle: "Hello",
+			wantBody:  "Body text\n",
+		},
+		{
+			name:      "windows line endings",
+			data:      "---\r\ntitle: Hello\r\n---\r\nBody\r\n",
+			wantTitle: "Hello",
+			wantBody:  "Body\n",
+		},
+		{
+			name:     "no front matter",
+			data:     "# Just Markdown\n",
+			wantBody: "# Just Markdown\n",
+		},
+		{
+			name:     "empty front matter",
+			data:     "---\n---\nBody",
+			wantBody: "Body",
+		},
+		{
+			name:      "front matter only",
+			data:      "---\ntitle: Hello\n---",
+			wantTitle: "Hello",
+		},
+		{
+			name:    "unclosed front matter",
+			data:    "---\ntitle: Hello\nBody",
+			wantErr: true,
+		},
+		{
+			name:    "invalid yaml",
+			data:    "---\ntitle: [\n---\nBody",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := parseMarkdownDoc([]byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseMarkdownDoc() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if doc.Front.Title != tt.wantTitle || doc.Body != tt.wantBody {
+				t.Errorf("parseMarkdownDoc() = %q, %q, want %q, %q", doc.Front.Title, doc.Body, tt.wantTitle, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestMarkdownDocToContent(t *testing.T) {
+	doc := markdownDoc{
+		Front: markdownFrontMatter{
+			Tags:        []string{"go", " Go ", "", "web"},
+			Excerpt:     "An excerpt",
+			PublishedAt: "2024-03-01",
+			Kind:        "series",
+			Series:      "operators",
+			SeriesOrder: 2,
+		},
+		Body: "Body",
+	}
+
+	ic, err := doc.toContent("deep-dives/my-first-post.md")
+	if err != nil {
+		t.Fatalf("toContent() error = %v", err)
+	}
+
+	c := ic.Content
+	if c.Heading != "My First Post" {
+		t.Errorf("heading = %q, want heading from the file name", c.Heading)
+	}
+	if c.ShortID != "" || ic.Slug != "my-first-post" {
+		t.Errorf("short ID, slug = %q, %q", c.ShortID, ic.Slug)
+	}
+	if c.SectionPath != "/deep-dives" {
+		t.Errorf("section path = %q", c.SectionPath)
+	}
+	if !c.Draft {
+		t.Errorf("contents without a draft key are imported as drafts")
+	}
+	if c.Meta.Description != "An excerpt" {
+		t.Errorf("description = %q, want the excerpt", c.Meta.Description)
+	}
+	if len(c.Tags) != 2 || c.Tags[0].Name != "go" || c.Tags[1].Name != "web" {
+		t.Errorf("tags = %v, want [go web]", c.Tags)
+	}
+	if c.Kind != "series" || c.Series != "operators" || c.SeriesOrder != 2 {
+		t.Errorf("kind, series = %q, %q, %d", c.Kind, c.Series, c.SeriesOrder)
+	}
+	if c.PublishedAt == nil || !c.PublishedAt.Equal(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("published at = %v", c.PublishedAt)
+	}
+
+	root, err := markdownDoc{}.toContent("about.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if root.Content.SectionPath != "/" {
+		t.Errorf("root section path = %q, want /", root.Content.SectionPath)
+	}
+
+	if _, err := (markdownDoc{Front: markdownFrontMatter{PublishedAt: "yesterday"}}).toContent("a.md"); err == nil {
+		t.Errorf("toContent() with an invalid date succeeded")
+	}
+}
+
+func TestMarkdownImportMatch(t *testing.T) {
+	byID := Content{ID: uuid.New(), ShortID: "aaaaaaaaaaaa", Heading: "First Post"}
+	bySlug := Content{ID: uuid.New(), ShortID: "bbbbbbbbbbbb", Heading: "Second Post"}
+	byHeading := Content{ID: uuid.New(), ShortID: "cccccccccccc", Heading: "Third Post"}
+	root := Section{ID: uuid.New(), Path: "/", LayoutID: uuid.New()}
+	tech := Section{ID: uuid.New(), Path: "/tech"}
+
+	imp := newMarkdownImport([]Content{byID, bySlug, byHeading}, []Section{root, tech})
+
+	imported := func(slug string) importedContent {
+		ic := importedContent{Slug: slug}
+		if id := sourceShortID(slug); shortIDRe.MatchString(id) {
+			ic.Content.ShortID = id
+		}
+		return ic
+	}
+
+	// A renamed content keeps its short ID
+	if c, ok := imp.match(imported("renamed-post-aaaaaaaaaaaa")); !ok || c.ID != byID.ID {
+		t.Errorf("match() by short ID = %v, %t", c.ID, ok)
+	}
+	// A content is only matched once
+	if _, ok := imp.match(imported("first-post-aaaaaaaaaaaa")); ok {
+		t.Errorf("match() matched a content twice")
+	}
+	if !imp.shortIDTaken("aaaaaaaaaaaa") {
+		t.Errorf("shortIDTaken() = false for an existing short ID")
+	}
+	// Hand written files never match, they clash by slug or heading
+	if _, ok := imp.match(imported("second-post")); ok {
+		t.Errorf("match() matched a file without short ID")
+	}
+	if c, ok := imp.collision(imported("second-post")); !ok || c.ID != bySlug.ID {
+		t.Errorf("collision() by slug = %v, %t", c.ID, ok)
+	}
+	// A slug is authoritative, the heading is only used without one
+	withSlug := imported("old-url")
+	withSlug.Content.Heading = "Third Post"
+	if c, ok := imp.collision(withSlug); ok {
+		t.Errorf("collision() of a file with its own slug by heading = %v", c.ID)
+	}
+	noSlug := importedContent{Content: Content{Heading: "Third Post"}}
+	if c, ok := imp.collision(noSlug); !ok || c.ID != byHeading.ID {
+		t.Errorf("collision() by heading = %v, %t", c.ID, ok)
+	}
+	imp.claim(byHeading)
+	if _, ok := imp.collision(noSlug); ok {
+		t.Errorf("collision() with a claimed content")
+	}
+	if _, ok := imp.collision(imported("new-post")); ok {
+		t.Errorf("collision() with an unknown content")
+	}
+
+	if s, ok := imp.section("tech/"); !ok || s.ID != tech.ID {
+		t.Errorf("section() = %v, %t", s.ID, ok)
+	}
+	if _, ok := imp.section("/food"); ok {
+		t.Errorf("section() found a missing section")
+	}
+	if imp.defaultLayoutID() != root.LayoutID {
+		t.Errorf("defaultLayoutID() is not the layout of the root section")
+	}
+	if got := sectionNameFromPath("/deep-dives"); got != "Deep Dives" {
+		t.Errorf("sectionNameFromPath() = %q", got)
+	}
+}
+
+func TestMergeImportedContent(t *testing.T) {
+	userID := uuid.New()
+	metaID := uuid.New()
+	existing := Content{
+		ID:       uuid.New(),
+		ShortID:  "aaaaaaaaaaaa",
+		UserID:   userID,
+		Kind:     "article",
+		Heading:  "Old",
+		Body:     "Old body",
+		Tags:     []Tag{{Name: "go"}},
+		Meta:     Meta{ID: metaID, Description: "Old description"},
+		Featured: true,
+	}
+
+	ic := importedContent{Content: Content{
+		Heading: "New",
+		Body:    "New body",
+		Tags:    []Tag{{Name: "Go"}, {Name: "web"}},
+		Meta:    Meta{Description: "New description"},
+	}}
+
+	got, changed := merge(existing, ic)
+	if !changed {
+		t.Fatalf("merge() reports no changes")
+	}
+	if got.ID != existing.ID || got.ShortID != existing.ShortID || got.UserID != userID {
+		t.Errorf("merge() changed the identity of the content")
+	}
+	if got.Kind != "article" {
+		t.Errorf("kind = %q, an empty imported kind must keep the current one", got.Kind)
+	}
+	if got.Heading != "New" || got.Body != "New body" || got.Featured {
+		t.Errorf("merge() = %q, %q, featured %t", got.Heading, got.Body, got.Featured)
+	}
+	if got.Meta.ID != metaID || got.Meta.ContentID != existing.ID || got.Meta.Description != "New description" {
+		t.Errorf("meta = %+v", got.Meta)
+	}
+	if len(got.Tags) != 2 {
+		t.Errorf("tags = %v", got.Tags)
+	}
+}
+
+func TestMergeKeepsDraftWithoutDraftKey(t *testing.T) {
+	id := uuid.New()
+	existing := Content{ID: id, Heading: "Post", Body: "Body", Meta: Meta{ContentID: id}}
+
+	data := []byte("---\ntitle: Post\n---\nBody")
+	doc, err := parseMarkdownDoc(data)
+	if err != nil {
+		t.Fatalf("parseMarkdownDoc() error = %v", err)
+	}
+	ic, err := doc.toContent("post.md")
+	if err != nil {
+		t.Fatalf("toContent() error = %v", err)
+	}
+	if !ic.Content.Draft {
+		t.Errorf("a new content without draft key is not a draft")
+	}
+
+	got, changed := merge(existing, ic)
+	if got.Draft || changed {
+		t.Errorf("merge() = draft %t, changed %t, want the published content unchanged", got.Draft, changed)
+	}
+
+	doc, err = parseMarkdownDoc([]byte("---\ntitle: Post\ndraft: true\n---\nBody"))
+	if err != nil {
+		t.Fatalf("parseMarkdownDoc() error = %v", err)
+	}
+	ic, err = doc.toContent("post.md")
+	if err != nil {
+		t.Fatalf("toContent() error = %v", err)
+	}
+	if got, _ := merge(existing, ic); !got.Draft {
+		t.Errorf("merge() ignores an explicit draft key")
+	}
+}
+
+func TestImportContentClash(t *testing.T) {
+	root := Section{ID: uuid.New(), Path: "/"}
+	post := Content{ID: uuid.New(), ShortID: "aaaaaaaaaaaa", Heading: "First Post", Body: "Written in the admin"}
+	params := hm.XParams{Cfg: hm.NewConfig(), Log: hm.NewLogger("error")}
+	// The embedded nil Repo fails the test with a panic on any write
+	svc := &BaseService{Service: hm.NewService("ssg-svc", params), repo: &fakeImageRepo{}}
+
+	imp := newMarkdownImport([]Content{post}, []Section{root})
+	ic := importedContent{Slug: "first-post", Content: Content{Heading: "First Post", Body: "Dropped in the watched dir", SectionPath: "/"}}
+
+	item, _, err := svc.importContent(context.Background(), imp, uuid.New(), "first-post.md", ic, importOptions{NewAsDraft: true}, &ImportReport{})
+	if err != nil {
+		t.Fatalf("importContent() error = %v", err)
+	}
+	if item.Action != ImportConflict || item.ContentID != post.ID {
+		t.Errorf("importContent() = %s for %s, want a conflict with %s", item.Action, item.ContentID, post.ID)
+	}
+}
@@ -0,0 +1,359 @@
+package ssg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hermesgen/hm"
+)
+
+// Job kinds run by the JobRunner.
+const (
+	JobGenerateMarkdown = "generate-markdown"
+	JobGenerateHTML     = "generate-html"
+	JobPlan             = "plan"
+	JobPublish          = "publish"
+	JobImportMarkdown   = "import-markdown"
+	JobImportWatched    = "import-watched"
+	JobMigrateSite      = "migrate-site"
+	JobImportWordPress  = "import-wordpress"
+)
+
+// Job statuses.
+const (
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	JobFailed    = "failed"
+)
+
+// Job event types sent to subscribers.
+const (
+	JobEventLog  = "log"
+	JobEventDone = "done"
+)
+
+// maxJobs is how many jobs the runner remembers; the oldest finished ones are
+// dropped first.
+const maxJobs = 100
+
+// ErrSiteBusy is returned when a job is submitted for a site that is already
+// running one.
+var ErrSiteBusy = errors.New("another job is already running for this site")
+
+// Job is a background generate, plan or publish operation on a site.
+type Job struct {
+	ID         uuid.UUID  `json:"id"`
+	SiteSlug   string     `json:"site_slug"`
+	Kind       string     `json:"kind"`
+	Status     string     `json:"status"`
+	Result     string     `json:"result,omitempty"`
+	Error      string     `json:"error,omitempty"`
+	Logs       []JobLog   `json:"logs"`
+	CreatedAt  time.Time  `json:"created_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// JobLog is a progress message of a job.
+type JobLog struct {
+	Time    time.Time `json:"time"`
+	Message string    `json:"message"`
+}
+
+// JobEvent is sent to the subscribers of a job for each progress message and
+// once when the job finishes.
+type JobEvent struct {
+	Type    string    `json:"type"`
+	JobID   uuid.UUID `json:"job_id"`
+	Time    time.Time `json:"time"`
+	Message string    `json:"message,omitempty"`
+	Status  string    `json:"status"`
+	Result  string    `json:"result,omitempty"`
+	Error   string    `json:"error,omitempty"`
+}
+
+// JobFunc does the work of a job. The context carries the site of the job and
+// a progress reporter, see reportProgress.
+type JobFunc func(ctx context.Context) (result string, err error)
+
+type jobEntry struct {
+	job  Job
+	subs map[chan JobEvent]struct{}
+}
+
+// JobRunner runs jobs in the background, one at a time per site, and keeps
+// their status and logs in memory.
+type JobRunner struct {
+	hm.Core
+	mu     sync.Mutex
+	jobs   map[uuid.UUID]*jobEntry
+	order  []uuid.UUID
+	active map[string]uuid.UUID
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewJobRunner creates a job runner.
+func NewJobRunner(params hm.XParams) *JobRunner {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &JobRunner{
+		Core:   hm.NewCore("ssg-job-runner", params),
+		jobs:   make(map[uuid.UUID]*jobEntry),
+		active: make(map[string]uuid.UUID),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// Stop cancels the running jobs and waits for them to return.
+func (r *JobRunner) Stop(ctx context.Context) error {
+	r.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		r.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("jobs still running at shutdown: %w", ctx.Err())
+	}
+}
+
+// Submit starts fn as a job of kind for the site in ctx. The job outlives the
+// request that submitted it.
+func (r *JobRunner) Submit(ctx context.Context, kind string, fn JobFunc) (Job, error) {
+	siteSlug, err := RequireSiteSlug(ctx)
+	if err != nil {
+		return Job{}, err
+	}
+
+	jobCtx := context.WithValue(r.ctx, siteSlugKey, siteSlug)
+	if siteID, ok := GetSiteIDFromContext(ctx); ok {
+		jobCtx = context.WithValue(jobCtx, siteIDKey, siteID)
+	}
+	if user := hm.GetUserCtxData(ctx); user != nil {
+		jobCtx = context.WithValue(jobCtx, hm.UserKey, user)
+	}
+
+	r.mu.Lock()
+	if id, ok := r.active[siteSlug]; ok {
+		r.mu.Unlock()
+		return Job{}, fmt.Errorf("%w (job %s)", ErrSiteBusy, id)
+	}
+
+	entry := &jobEntry{
+		job: Job{
+			ID:        uuid.New(),
+			SiteSlug:  siteSlug,
+			Kind:      kind,
+			Status:    JobRunning,
+			Logs:      []JobLog{},
+			CreatedAt: time.Now(),
+		},
+		subs: make(map[chan JobEvent]struct{}),
+	}
+	r.jobs[entry.job.ID] = entry
+	r.order = append(r.order, entry.job.ID)
+	r.active[siteSlug] = entry.job.ID
+	r.prune()
+	job := entry.job
+	r.mu.Unlock()
+
+	r.Log().Info("Job started", "id", job.ID, "kind", kind, "site", siteSlug)
+
+	r.wg.Add(1)
+	go r.run(jobCtx, job.ID, fn)
+
+	return job, nil
+}
+
+// LockSite keeps jobs from starting for the site until unlock is called, for
+// operations that must not run along a build, such as moving the site
+// directory. It fails with ErrSiteBusy if a job is running.
+func (r *JobRunner) LockSite(siteSlug string) (unlock func(), err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if id, ok := r.active[siteSlug]; ok {
+		return nil, fmt.Errorf("%w (job %s)", ErrSiteBusy, id)
+	}
+	r.active[siteSlug] = uuid.Nil
+
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if id, ok := r.active[siteSlug]; ok && id == uuid.Nil {
+			delete(r.active, siteSlug)
+		}
+	}, nil
+}
+
+// Get returns a copy of a job.
+func (r *JobRunner) Get(id uuid.UUID) (Job, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry, ok := r.jobs[id]
+	if !ok {
+		return Job{}, false
+	}
+	return copyJob(entry.job), true
+}
+
+// List returns the jobs of a site, newest first.
+func (r *JobRunner) List(siteSlug string) []Job {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	jobs := []Job{}
+	for i := len(r.order) - 1; i >= 0; i-- {
+		entry := r.jobs[r.order[i]]
+		if entry.job.SiteSlug == siteSlug {
+			jobs = append(jobs, copyJob(entry.job))
+		}
+	}
+	return jobs
+}
+
+// Subscribe returns the current state of a job and a channel with its
+// following events. The channel is closed after the done event; it is nil if
+// the job has already finished. unsubscribe must be called when done.
+func (r *JobRunner) Subscribe(id uuid.UUID) (job Job, events <-chan JobEvent, unsubscribe func(), err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry, ok := r.jobs[id]
+	if !ok {
+		return Job{}, nil, func() {}, fmt.Errorf("job %s not found", id)
+	}
+
+	job = copyJob(entry.job)
+	if job.Status != JobRunning {
+		return job, nil, func() {}, nil
+	}
+
+	ch := make(chan JobEvent, 256)
+	entry.subs[ch] = struct{}{}
+	unsubscribe = func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		delete(entry.subs, ch)
+	}
+	return job, ch, unsubscribe, nil
+}
+
+func (r *JobRunner) run(ctx context.Context, id uuid.UUID, fn JobFunc) {
+	defer r.wg.Done()
+
+	ctx = withJobProgress(ctx, func(msg string) { r.progress(id, msg) })
+
+	var result string
+	err := func() (err error) {
+		defer func() {
+			if p := recover(); p != nil {
+				err = fmt.Errorf("job panicked: %v", p)
+			}
+		}()
+		result, err = fn(ctx)
+		return err
+	}()
+
+	r.finish(id, result, err)
+}
+
+func (r *JobRunner) progress(id uuid.UUID, msg string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry, ok := r.jobs[id]
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	entry.job.Logs = append(entry.job.Logs, JobLog{Time: now, Message: msg})
+	r.notify(entry, JobEvent{Type: JobEventLog, Time: now, Message: msg})
+}
+
+func (r *JobRunner) finish(id uuid.UUID, result string, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry, ok := r.jobs[id]
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	entry.job.FinishedAt = &now
+	entry.job.Result = result
+	entry.job.Status = JobSucceeded
+	if err != nil {
+		entry.job.Status = JobFailed
+		entry.job.Error = err.Error()
+	}
+	delete(r.active, entry.job.SiteSlug)
+
+	r.notify(entry, JobEvent{Type: JobEventDone, Time: now})
+	for ch := range entry.subs {
+		close(ch)
+	}
+	entry.subs = make(map[chan JobEvent]struct{})
+
+	r.Log().Info("Job finished", "id", id, "status", entry.job.Status)
+}
+
+// notify sends ev to the subscribers of entry. Slow subscribers miss events
+// rather than blocking the job. Callers must hold r.mu.
+func (r *JobRunner) notify(entry *jobEntry, ev JobEvent) {
+	ev.JobID = entry.job.ID
+	ev.Status = entry.job.Status
+	ev.Result = entry.job.Result
+	ev.Error = entry.job.Error
+
+	for ch := range entry.subs {
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+}
+
+// prune drops the oldest finished jobs beyond maxJobs. Callers must hold r.mu.
+func (r *JobRunner) prune() {
+	for i := 0; len(r.order) > maxJobs && i < len(r.order); {
+		id := r.order[i]
+		if r.jobs[id].job.Status == JobRunning {
+			i++
+			continue
+		}
+		delete(r.jobs, id)
+		r.order = append(r.order[:i], r.order[i+1:]...)
+	}
+}
+
+func copyJob(job Job) Job {
+	job.Logs = append([]JobLog{}, job.Logs...)
+	return job
+}
+
+type jobProgressKey struct{}
+
+func withJobProgress(ctx context.Context, fn func(string)) context.Context {
+	return context.WithValue(ctx, jobProgressKey{}, fn)
+}
+
+// reportProgress sends a progress message to the job running with ctx, if any.
+func reportProgress(ctx context.Context, format string, args ...interface{}) {
+	if fn, ok := ctx.Value(jobProgressKey{}).(func(string)); ok {
+		fn(fmt.Sprintf(format, args...))
+	}
+}
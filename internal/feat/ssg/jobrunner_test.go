@@ -0,0 +1,266 @@
+package ssg
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hermesgen/hm"
+)
+
+func newTestJobRunner() *JobRunner {
+	return NewJobRunner(hm.XParams{Cfg: hm.NewConfig(), Log: hm.NewLogger("error")})
+}
+
+func siteCtx(slug string) context.Context {
+	return context.WithValue(context.Background(), siteSlugKey, slug)
+}
+
+func waitJob(t *testing.T, r *JobRunner, job Job) Job {
+	t.Helper()
+	_, events, unsubscribe, err := r.Subscribe(job.ID)
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+	defer unsubscribe()
+
+	timeout := time.After(5 * time.Second)
+	for events != nil {
+		select {
+		case _, open := <-events:
+			if !open {
+				events = nil
+			}
+		case <-timeout:
+			t.Fatal("job did not finish")
+		}
+	}
+
+	got, _ := r.Get(job.ID)
+	return got
+}
+
+func TestJobRunnerRunsJobWithProgress(t *testing.T) {
+	r := newTestJobRunner()
+
+	job, err := r.Submit(siteCtx("blog"), JobPlan, func(ctx context.Context) (string, error) {
+		slug, _ := GetSiteSlugFromContext(ctx)
+		reportProgress(ctx, "Planning %s", slug)
+		return "2 files changed", nil
+	})
+	if err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+	if job.Status != JobRunning || job.SiteSlug != "blog" || job.Kind != JobPlan {
+		t.Errorf("Submit() = %+v", job)
+	}
+
+	got := waitJob(t, r, job)
+	if got.Status != JobSucceeded {
+		t.Errorf("Status = %q, want %q", got.Status, JobSucceeded)
+	}
+	if got.Result != "2 files changed" {
+		t.Errorf("Result = %q", got.Result)
+	}
+	if len(got.Logs) != 1 || got.Logs[0].Message != "Planning blog" {
+		t.Errorf("Logs = %+v", got.Logs)
+	}
+	if got.FinishedAt == nil {
+		t.Error("FinishedAt not set")
+	}
+}
+
+func TestJobRunnerRecordsFailure(t *testing.T) {
+	r := newTestJobRunner()
+
+	tests := []struct {
+		name string
+		fn   JobFunc
+	}{
+		{"error", func(ctx context.Context) (string, error) { return "", errors.New("push rejected") }},
+		{"panic", func(ctx context.Context) (string, error) { panic("boom") }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			job, err := r.Submit(siteCtx("blog"), JobPublish, tt.fn)
+			if err != nil {
+				t.Fatalf("Submit() error = %v", err)
+			}
+			got := waitJob(t, r, job)
+			if got.Status != JobFailed || got.Error == "" {
+				t.Errorf("job = %+v, want a failure", got)
+			}
+		})
+	}
+}
+
+func TestJobRunnerRejectsConcurrentJobsOnSameSite(t *testing.T) {
+	r := newTestJobRunner()
+	release := make(chan struct{})
+	blocking := func(ctx context.Context) (string, error) {
+		<-release
+		return "", nil
+	}
+
+	first, err := r.Submit(siteCtx("blog"), JobGenerateHTML, blocking)
+	if err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+
+	if _, err := r.Submit(siteCtx("blog"), JobPublish, blocking); !errors.Is(err, ErrSiteBusy) {
+		t.Errorf("Submit() on busy site error = %v, want ErrSiteBusy", err)
+	}
+
+	other, err := r.Submit(siteCtx("docs"), JobPublish, blocking)
+	if err != nil {
+		t.Errorf("Submit() on another site error = %v", err)
+	}
+
+	close(release)
+	waitJob(t, r, first)
+	waitJob(t, r, other)
+
+	if _, err := r.Submit(siteCtx("blog"), JobPublish, func(ctx context.Context) (string, error) { return "", nil }); err != nil {
+		t.Errorf("Submit() after the job finished error = %v", err)
+	}
+
+	if jobs := r.List("blog"); len(jobs) != 2 || jobs[1].ID != first.ID {
+		t.Errorf("List() = %+v, want newest first", jobs)
+	}
+}
+
+func TestJobRunnerLockSite(t *testing.T) {
+	r := newTestJobRunner()
+
+	unlock, err := r.LockSite("blog")
+	if err != nil {
+		t.Fatalf("LockSite() error = %v", err)
+	}
+	noop := func(ctx context.Context) (string, error) { return "", nil }
+	if _, err := r.Submit(siteCtx("blog"), JobPublish, noop); !errors.Is(err, ErrSiteBusy) {
+		t.Errorf("Submit() on a locked site error = %v, want ErrSiteBusy", err)
+	}
+	if _, err := r.LockSite("blog"); !errors.Is(err, ErrSiteBusy) {
+		t.Errorf("LockSite() on a locked site error = %v, want ErrSiteBusy", err)
+	}
+
+	unlock()
+	job, err := r.Submit(siteCtx("blog"), JobPublish, noop)
+	if err != nil {
+		t.Fatalf("Submit() after unlock error = %v", err)
+	}
+	waitJob(t, r, job)
+}
+
+func TestJobRunnerSubscribeStreamsEvents(t *testing.T) {
+	r := newTestJobRunner()
+	release := make(chan struct{})
+
+	job, err := r.Submit(siteCtx("blog"), JobPublish, func(ctx context.Context) (string, error) {
+		<-release
+		reportProgress(ctx, "Publishing")
+		return "https://example.com/commit/abc", nil
+	})
+	if err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+
+	_, events, unsubscribe, err := r.Subscribe(job.ID)
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+	defer unsubscribe()
+	close(release)
+
+	var got []JobEvent
+	for ev := range events {
+		got = append(got, ev)
+	}
+
+	if len(got) != 2 {
+		t.Fatalf("events = %+v, want log and done", got)
+	}
+	if got[0].Type != JobEventLog || got[0].Message != "Publishing" {
+		t.Errorf("first event = %+v", got[0])
+	}
+	if got[1].Type != JobEventDone || got[1].Status != JobSucceeded || got[1].Result != "https://example.com/commit/abc" {
+		t.Errorf("last event = %+v", got[1])
+	}
+
+	_, events, _, err = r.Subscribe(job.ID)
+	if err != nil || events != nil {
+		t.Errorf("Subscribe() to finished job = %v, %v; want nil channel", events, err)
+	}
+}
+
+func TestJobRunnerStopCancelsJobs(t *testing.T) {
+	r := newTestJobRunner()
+
+	job, err := r.Submit(siteCtx("blog"), JobGenerateHTML, func(ctx context.Context) (string, error) {
+		<-ctx.Done()
+		return "", ctx.Err()
+	})
+	if err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := r.Stop(ctx); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+
+	got, _ := r.Get(job.ID)
+	if got.Status != JobFailed {
+		t.Errorf("Status = %q, want %q", got.Status, JobFailed)
+	}
+}
+
+func TestJobRunnerRequiresSite(t *testing.T) {
+	r := newTestJobRunner()
+	if _, err := r.Submit(context.Background(), JobPlan, func(ctx context.Context) (string, error) { return "", nil }); err == nil {
+		t.Error("Submit() without site expected error")
+	}
+}
+
+func TestSiteLockedHandler(t *testing.T) {
+	params := hm.XParams{Cfg: hm.NewConfig(), Log: hm.NewLogger("error")}
+	jobs := NewJobRunner(params)
+	h := NewAPIHandler("ssg-api", nil, nil, jobs, params)
+
+	var submitErr error
+	handler := h.siteLocked(func(w http.ResponseWriter, r *http.Request) {
+		_, submitErr = jobs.Submit(r.Context(), JobGenerateHTML, func(ctx context.Context) (string, error) {
+			return "", nil
+		})
+	})
+	request := func() *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/api/v1/ssg/generate-html", nil)
+		w := httptest.NewRecorder()
+		handler(w, r.WithContext(siteCtx("blog")))
+		return w
+	}
+
+	unlock, err := jobs.LockSite("blog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w := request(); w.Code != http.StatusConflict {
+		t.Errorf("status while the site is locked = %d, want %d", w.Code, http.StatusConflict)
+	}
+	unlock()
+
+	if w := request(); w.Code != http.StatusOK {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if !errors.Is(submitErr, ErrSiteBusy) {
+		t.Errorf("Submit() while the request runs error = %v, want ErrSiteBusy", submitErr)
+	}
+	if _, err := jobs.LockSite("blog"); err != nil {
+		t.Errorf("LockSite() after the request error = %v, want the lock released", err)
+	}
+}
@@ -15,17 +15,38 @@ type SSGKeys struct {
 	BlocksMaxItems string
 	IndexMaxItems  string
 
+	ImagesStripMetadata string
+
 	SearchGoogleEnabled string
 	SearchGoogleID      string
 
-	PublishRepoURL         string
-	PublishBranch          string
-	PublishPagesSubdir     string
-	PublishAuthMethod      string
-	PublishAuthToken       string
-	PublishCommitUserName  string
-	PublishCommitUserEmail string
-	PublishCommitMessage   string
+	PublishTarget           string
+	PublishTargetPath       string
+	PublishArchiveFormat    string
+	PublishRepoURL          string
+	PublishBranch           string
+	PublishPagesSubdir      string
+	PublishAuthMethod       string
+	PublishAuthToken        string
+	PublishAuthSSHKey       string
+	PublishCommitUserName   string
+	PublishCommitUserEmail  string
+	PublishCommitMessage    string
+	PublishHistorySnapshots string
+	PublishCustomDomain     string
+	PublishNoJekyll         string
+	PublishPreserve         string
+
+	ContentVersioning string
+	ContentRepoURL    string
+	ContentBranch     string
+
+	WatchInterval     string
+	WatchEnabled      string
+	WatchDir          string
+	WatchProcessedDir string
+
+	QualityPrefix string // Followed by the check name, e.g. ssg.quality.broken-links
 }
 
 var SSGKey = SSGKeys{
@@ -43,15 +64,36 @@ var SSGKey = SSGKeys{
 	BlocksMaxItems: "ssg.blocks.maxitems",
 	IndexMaxItems:  "ssg.index.maxitems",
 
+	ImagesStripMetadata: "ssg.images.strip.metadata",
+
 	SearchGoogleEnabled: "ssg.search.google.enabled",
 	SearchGoogleID:      "ssg.search.google.id",
 
-	PublishRepoURL:         "ssg.publish.repo.url",
-	PublishBranch:          "ssg.publish.branch",
-	PublishPagesSubdir:     "ssg.publish.pages.subdir",
-	PublishAuthMethod:      "ssg.publish.auth.method",
-	PublishAuthToken:       "ssg.publish.auth.token",
-	PublishCommitUserName:  "ssg.publish.commit.user.name",
-	PublishCommitUserEmail: "ssg.publish.commit.user.email",
-	PublishCommitMessage:   "ssg.publish.commit.message",
+	PublishTarget:           "ssg.publish.target",
+	PublishTargetPath:       "ssg.publish.target.path",
+	PublishArchiveFormat:    "ssg.publish.archive.format",
+	PublishRepoURL:          "ssg.publish.repo.url",
+	PublishBranch:           "ssg.publish.branch",
+	PublishPagesSubdir:      "ssg.publish.pages.subdir",
+	PublishAuthMethod:       "ssg.publish.auth.method",
+	PublishAuthToken:        "ssg.publish.auth.token",
+	PublishAuthSSHKey:       "ssg.publish.auth.ssh.key",
+	PublishCommitUserName:   "ssg.publish.commit.user.name",
+	PublishCommitUserEmail:  "ssg.publish.commit.user.email",
+	PublishCommitMessage:    "ssg.publish.commit.message",
+	PublishHistorySnapshots: "ssg.publish.history.snapshots",
+	PublishCustomDomain:     "ssg.publish.custom.domain",
+	PublishNoJekyll:         "ssg.publish.nojekyll",
+	PublishPreserve:         "ssg.publish.preserve",
+
+	ContentVersioning: "ssg.content.versioning",
+	ContentRepoURL:    "ssg.content.repo.url",
+	ContentBranch:     "ssg.content.branch",
+
+	WatchInterval:     "ssg.watch.interval",
+	WatchEnabled:      "ssg.watch.enabled",
+	WatchDir:          "ssg.watch.dir",
+	WatchProcessedDir: "ssg.watch.processed.dir",
+
+	QualityPrefix: "ssg.quality",
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -41,22 +42,10 @@ func NewSiteContextMw(sessionMgr *auth.SessionManager, siteRepoProvider SiteRepo
 	}
 }
 
+// isExemptPath reports whether path is a site management page, which works
+// with no site selected.
 func (mw *SiteContextMw) isExemptPath(path string) bool {
-	exemptPaths := []string{
-		"/ssg/sites",
-		"/ssg/sites/new",
-		"/ssg/sites/create",
-		"/ssg/sites/switch",
-		"/ssg/sites/delete",
-	}
-
-	for _, exempt := range exemptPaths {
-		if path == exempt {
-			return true
-		}
-	}
-
-	return false
+	return path == "/ssg/sites" || strings.HasPrefix(path, "/ssg/sites/")
 }
 
 func (mw *SiteContextMw) WebHandler(next http.Handler) http.Handler {
@@ -83,27 +72,19 @@ func (mw *SiteContextMw) WebHandler(next http.Handler) http.Handler {
 		}
 
 		site, err := mw.siteRepoProvider.GetSiteBySlug(ctx, siteSlug)
+		if err == nil && site.Active == 0 {
+			err = fmt.Errorf("site '%s' is archived", siteSlug)
+		}
 		if err != nil {
-			mw.Log().Info("Site not found in database, clearing session", "slug", siteSlug)
-			http.SetCookie(w, &http.Cookie{
-				Name:     lastSiteCookie,
-				Value:    "",
-				Path:     "/",
-				MaxAge:   -1,
-				SameSite: http.SameSiteLaxMode,
-			})
+			// Also the case of a cookie left with the old slug of a renamed site.
+			mw.Log().Info("Site not available, clearing session", "slug", siteSlug, "error", err)
+			ClearLastSiteCookie(w)
 			http.Redirect(w, r, "/ssg/sites", http.StatusFound)
 			return
 		}
 
-		http.SetCookie(w, &http.Cookie{
-			Name:     lastSiteCookie,
-			Value:    siteSlug,
-			Path:     "/",
-			MaxAge:   lastSiteMaxAge,
-			Expires:  time.Now().Add(time.Duration(lastSiteMaxAge) * time.Second),
-			SameSite: http.SameSiteLaxMode,
-		})
+		siteSlug = site.Slug()
+		SetLastSiteCookie(w, siteSlug)
 
 		// Add site slug and ID to context
 		ctx = context.WithValue(ctx, siteSlugKey, siteSlug)
@@ -112,11 +93,34 @@ func (mw *SiteContextMw) WebHandler(next http.Handler) http.Handler {
 	})
 }
 
+// isExemptAPIPath reports whether path is a site management endpoint, which
+// works with no site selected.
+func (mw *SiteContextMw) isExemptAPIPath(path string) bool {
+	return path == "/api/v1/ssg/sites" || strings.HasPrefix(path, "/api/v1/ssg/sites/")
+}
+
+// APIHandler injects the site of the X-Site-Slug header in the context. API
+// clients that cannot send it use the site query value or the site they
+// switched to, see APIHandler.SwitchSite.
 func (mw *SiteContextMw) APIHandler(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if mw.isExemptAPIPath(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
 		ctx := r.Context()
 
 		siteSlug := r.Header.Get("X-Site-Slug")
+		if siteSlug == "" {
+			// EventSource cannot send headers
+			siteSlug = r.URL.Query().Get("site")
+		}
+		if siteSlug == "" {
+			if cookie, err := r.Cookie(lastSiteCookie); err == nil {
+				siteSlug = cookie.Value
+			}
+		}
 
 		if siteSlug == "" {
 			http.Error(w, "X-Site-Slug header is required", http.StatusBadRequest)
@@ -129,6 +133,10 @@ func (mw *SiteContextMw) APIHandler(next http.Handler) http.Handler {
 			http.Error(w, "Site not found", http.StatusNotFound)
 			return
 		}
+		if site.Active == 0 {
+			http.Error(w, "Site is archived", http.StatusConflict)
+			return
+		}
 
 		ctx = context.WithValue(ctx, siteSlugKey, siteSlug)
 		ctx = context.WithValue(ctx, siteIDKey, site.ID)
@@ -140,6 +148,29 @@ func (mw *SiteContextMw) Handler(next http.Handler) http.Handler {
 	return mw.WebHandler(next)
 }
 
+// SetLastSiteCookie remembers slug as the site to open on the next visit.
+func SetLastSiteCookie(w http.ResponseWriter, slug string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     lastSiteCookie,
+		Value:    slug,
+		Path:     "/",
+		MaxAge:   lastSiteMaxAge,
+		Expires:  time.Now().Add(time.Duration(lastSiteMaxAge) * time.Second),
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// ClearLastSiteCookie forgets the site to open on the next visit.
+func ClearLastSiteCookie(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     lastSiteCookie,
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
 // GetSiteSlugFromContext retrieves site slug from request context.
 func GetSiteSlugFromContext(ctx context.Context) (string, bool) {
 	slug, ok := ctx.Value(siteSlugKey).(string)
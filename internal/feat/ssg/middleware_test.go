@@ -0,0 +1,67 @@
+package ssg
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/hermesgen/hm"
+)
+
+func TestSiteContextMwAPIHandler(t *testing.T) {
+	blog := NewSite("Blog", "blog", "blog")
+	blog.GenID()
+	old := NewSite("Old", "old", "structured")
+	old.GenID()
+	old.Active = 0
+
+	siteRepo := &fakeSiteRepo{sites: map[uuid.UUID]Site{blog.ID: blog, old.ID: old}}
+	params := hm.XParams{Cfg: hm.NewConfig(), Log: hm.NewLogger("error")}
+	mw := NewSiteContextMw(nil, siteRepo, params)
+
+	var gotSlug string
+	handler := mw.APIHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotSlug, _ = GetSiteSlugFromContext(r.Context())
+	}))
+
+	tests := []struct {
+		name     string
+		path     string
+		header   string
+		cookie   string
+		wantCode int
+		wantSlug string
+	}{
+		{name: "header", path: "/api/v1/ssg/contents", header: "blog", wantCode: http.StatusOK, wantSlug: "blog"},
+		{name: "switched site", path: "/api/v1/ssg/contents", cookie: "blog", wantCode: http.StatusOK, wantSlug: "blog"},
+		{name: "no site", path: "/api/v1/ssg/contents", wantCode: http.StatusBadRequest},
+		{name: "unknown site", path: "/api/v1/ssg/contents", header: "nope", wantCode: http.StatusNotFound},
+		{name: "archived site", path: "/api/v1/ssg/contents", header: "old", wantCode: http.StatusConflict},
+		{name: "site management", path: "/api/v1/ssg/sites", wantCode: http.StatusOK},
+		{name: "site endpoint", path: "/api/v1/ssg/sites/" + old.ID.String() + "/settings", wantCode: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotSlug = ""
+			r := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.header != "" {
+				r.Header.Set("X-Site-Slug", tt.header)
+			}
+			if tt.cookie != "" {
+				r.AddCookie(&http.Cookie{Name: lastSiteCookie, Value: tt.cookie})
+			}
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, r)
+
+			if w.Code != tt.wantCode {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantCode)
+			}
+			if gotSlug != tt.wantSlug {
+				t.Errorf("site slug = %q, want %q", gotSlug, tt.wantSlug)
+			}
+		})
+	}
+}
@@ -0,0 +1,566 @@
+package ssg
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v2"
+
+	"github.com/hermesgen/hm"
+)
+
+// Sites a migration can read.
+const (
+	MigrateHugo   = "hugo"
+	MigrateJekyll = "jekyll"
+)
+
+// migratedImagesDir is the directory, relative to the site images, where
+// site wide images of a migrated site are copied.
+const migratedImagesDir = "imported"
+
+var (
+	// jekyllPostRe matches Jekyll post file names: 2024-03-01-my-post.md.
+	jekyllPostRe = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2})-(.+)$`)
+	// jekyllSiteURLRe matches the Liquid site URL prefixes of Jekyll links.
+	jekyllSiteURLRe = regexp.MustCompile(`\{\{-?\s*site\.(baseurl|url)\s*-?\}\}`)
+	// markdownImageRe matches the target of Markdown images.
+	markdownImageRe = regexp.MustCompile(`(!\[([^\]]*)\]\(\s*<?)([^)\s>]+)`)
+	// htmlImageRe matches the src of HTML img tags.
+	htmlImageRe = regexp.MustCompile(`(<img\b[^>]*?\bsrc\s*=\s*["'])([^"']+)`)
+)
+
+// migrationImageExts are the file types copied to the site images.
+var migrationImageExts = []string{".png", ".jpg", ".jpeg", ".gif", ".webp", ".svg", ".avif"}
+
+// migrationFile is a content file of a Hugo or Jekyll site and what its place
+// in the tree says about it.
+type migrationFile struct {
+	Path        string // Relative to the site root, slash separated
+	SectionPath string
+	Kind        string
+	Slug        string
+	Date        *time.Time // From the name of Jekyll posts
+	Draft       bool       // Jekyll _drafts
+}
+
+// migrationImage is a local image referenced by a migrated content, to copy
+// to Target under the site images.
+type migrationImage struct {
+	Source   string // Path on disk
+	Target   string // Relative to the site images, slash separated
+	Title    string
+	AltText  string
+	IsHeader bool
+}
+
+// migratedContent is a Hugo or Jekyll file read into a Content with the images
+// its body references already rewritten to their place in the site.
+type migratedContent struct {
+	importedContent
+	Images []migrationImage
+	// Redirects are the old URLs of the content, kept working after the move
+	Redirects []string
+	Notes     []string
+}
+
+// detectMigrationSource tells a Hugo site from a Jekyll one by its layout.
+func detectMigrationSource(root string) (string, error) {
+	isDir := func(name string) bool {
+		info, err := os.Stat(filepath.Join(root, name))
+		return err == nil && info.IsDir()
+	}
+
+	switch {
+	case isDir("content"):
+		return MigrateHugo, nil
+	case isDir("_posts"), isDir("_pages"), isDir("_drafts"):
+		return MigrateJekyll, nil
+	default:
+		return "", fmt.Errorf("%s is neither a Hugo site (content/) nor a Jekyll site (_posts/, _pages/)", root)
+	}
+}
+
+// migrationFiles lists the content files of the site at root. Hugo section
+// index files (_index.md) are not contents; their titles are returned as the
+// names of their sections.
+func migrationFiles(root, source string) (files []migrationFile, sectionNames map[string]string, err error) {
+	sectionNames = map[string]string{}
+
+	switch source {
+	case MigrateHugo:
+		rels, err := markdownFiles(filepath.Join(root, "content"))
+		if err != nil {
+			return nil, nil, fmt.Errorf("cannot list content files: %w", err)
+		}
+
+		for _, rel := range rels {
+			dir, name := path.Split(rel)
+			dir = strings.TrimSuffix(dir, "/")
+			base := strings.TrimSuffix(name, path.Ext(name))
+
+			if base == "_index" {
+				title, err := hugoSectionTitle(filepath.Join(root, "content", filepath.FromSlash(rel)))
+				if err == nil && title != "" {
+					sectionNames[normalizeSectionPath(dir)] = title
+				}
+				continue
+			}
+
+			slug := base
+			if base == "index" {
+				// Leaf bundle: the directory is the content
+				if dir == "" {
+					continue
+				}
+				dir, slug = path.Dir(dir), path.Base(dir)
+				if dir == "." {
+					dir = ""
+				}
+			}
+
+			kind := "blog"
+			if dir == "" {
+				kind = "page"
+			}
+
+			files = append(files, migrationFile{
+				Path:        path.Join("content", rel),
+				SectionPath: normalizeSectionPath(dir),
+				Kind:        kind,
+				Slug:        slug,
+			})
+		}
+
+	case MigrateJekyll:
+		for _, dir := range []string{"_posts", "_drafts", "_pages"} {
+			if _, err := os.Stat(filepath.Join(root, dir)); os.IsNotExist(err) {
+				continue
+			}
+
+			rels, err := markdownFiles(filepath.Join(root, dir))
+			if err != nil {
+				return nil, nil, fmt.Errorf("cannot list %s files: %w", dir, err)
+			}
+
+			for _, rel := range rels {
+				base := strings.TrimSuffix(path.Base(rel), path.Ext(rel))
+				f := migrationFile{
+					Path:        path.Join(dir, rel),
+					SectionPath: "/",
+					Kind:        "blog",
+					Slug:        base,
+					Draft:       dir == "_drafts",
+				}
+				if dir == "_pages" {
+					f.Kind = "page"
+				}
+				if m := jekyllPostRe.FindStringSubmatch(base); m != nil && dir != "_pages" {
+					if t, err := time.Parse("2006-01-02", m[1]); err == nil {
+						f.Date = &t
+					}
+					f.Slug = m[2]
+				}
+				files = append(files, f)
+			}
+		}
+
+	default:
+		return nil, nil, fmt.Errorf("unknown migration source %q", source)
+	}
+
+	return files, sectionNames, nil
+}
+
+func hugoSectionTitle(p string) (string, error) {
+	data, err := os.ReadFile(p)
+	if err != nil {
+		return "", err
+	}
+	fm, _, err := parseForeignDoc(data)
+	if err != nil {
+		return "", err
+	}
+	return fm.str("title"), nil
+}
+
+// readMigrationFile reads the content file f of the site at root.
+func readMigrationFile(root, source string, f migrationFile, sectionNames map[string]string) (migratedContent, error) {
+	data, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(f.Path)))
+	if err != nil {
+		return migratedContent{}, fmt.Errorf("cannot read file: %w", err)
+	}
+
+	fm, body, err := parseForeignDoc(data)
+	if err != nil {
+		return migratedContent{}, err
+	}
+	if source == MigrateJekyll {
+		body = jekyllSiteURLRe.ReplaceAllString(body, "")
+	}
+
+	mc, err := f.toContent(fm, body)
+	if err != nil {
+		return migratedContent{}, err
+	}
+	mc.Content.SectionName = sectionNames[f.SectionPath]
+
+	mc.migrationImages(headerImageRef(fm), migrationImageSource(root, source, f))
+	return mc, nil
+}
+
+// headerImageRef returns the header image of the front matter: Hugo themes
+// and Jekyll use image, featured_image or cover, as text or with a path.
+func headerImageRef(fm frontMatterValues) string {
+	for _, key := range []string{"image", "featured_image", "featureImage", "cover"} {
+		switch val := fm[key].(type) {
+		case string:
+			if val != "" {
+				return jekyllSiteURLRe.ReplaceAllString(val, "")
+			}
+		case map[interface{}]interface{}:
+			for _, k := range []string{"path", "image"} {
+				if s, ok := val[k].(string); ok && s != "" {
+					return s
+				}
+			}
+		case map[string]interface{}:
+			for _, k := range []string{"path", "image"} {
+				if s, ok := val[k].(string); ok && s != "" {
+					return s
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// frontMatterValues is front matter as written by Hugo or Jekyll, read without
+// a fixed schema since both accept several spellings and types for a key.
+type frontMatterValues map[string]interface{}
+
+// parseForeignDoc splits a Hugo or Jekyll file into its front matter, YAML
+// between --- lines, TOML between +++ lines or a leading JSON object, and its
+// body.
+func parseForeignDoc(data []byte) (frontMatterValues, string, error) {
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+	fm := frontMatterValues{}
+
+	switch {
+	case strings.HasPrefix(text, "---\n"):
+		front, body, _, err := splitFrontMatter(text, "---")
+		if err != nil {
+			return nil, "", err
+		}
+		if err := yaml.Unmarshal([]byte(front), &fm); err != nil {
+			return nil, "", fmt.Errorf("invalid YAML front matter: %w", err)
+		}
+		if fm == nil {
+			fm = frontMatterValues{}
+		}
+		return fm, body, nil
+
+	case strings.HasPrefix(text, "+++\n"):
+		front, body, _, err := splitFrontMatter(text, "+++")
+		if err != nil {
+			return nil, "", err
+		}
+		if _, err := toml.Decode(front, &fm); err != nil {
+			return nil, "", fmt.Errorf("invalid TOML front matter: %w", err)
+		}
+		return fm, body, nil
+
+	case strings.HasPrefix(text, "{"):
+		dec := json.NewDecoder(strings.NewReader(text))
+		if err := dec.Decode(&fm); err != nil {
+			return nil, "", fmt.Errorf("invalid JSON front matter: %w", err)
+		}
+		return fm, strings.TrimPrefix(text[dec.InputOffset():], "\n"), nil
+
+	default:
+		return fm, text, nil
+	}
+}
+
+// str returns the first of keys with a value, as text.
+func (v frontMatterValues) str(keys ...string) string {
+	for _, key := range keys {
+		switch val := v[key].(type) {
+		case nil:
+			continue
+		case string:
+			if s := strings.TrimSpace(val); s != "" {
+				return s
+			}
+		case time.Time:
+			return val.Format(time.RFC3339Nano)
+		case []interface{}:
+			if len(val) > 0 {
+				return strings.TrimSpace(fmt.Sprint(val[0]))
+			}
+		default:
+			return fmt.Sprint(val)
+		}
+	}
+	return ""
+}
+
+// boolean returns the value of key and whether it is set.
+func (v frontMatterValues) boolean(key string) (value, ok bool) {
+	switch val := v[key].(type) {
+	case bool:
+		return val, true
+	case string:
+		b, err := strconv.ParseBool(strings.TrimSpace(val))
+		return b, err == nil
+	default:
+		return false, false
+	}
+}
+
+func (v frontMatterValues) integer(key string) int {
+	n, _ := strconv.Atoi(v.str(key))
+	return n
+}
+
+// list returns the values of keys, lists or space separated text as Jekyll
+// writes tags and categories.
+func (v frontMatterValues) list(keys ...string) []string {
+	var items []string
+	for _, key := range keys {
+		switch val := v[key].(type) {
+		case string:
+			items = append(items, strings.Fields(val)...)
+		case []interface{}:
+			for _, item := range val {
+				if s := strings.TrimSpace(fmt.Sprint(item)); s != "" {
+					items = append(items, s)
+				}
+			}
+		}
+	}
+	return items
+}
+
+// toContent maps the front matter conventions of Hugo and Jekyll to a
+// content: title, date, draft, tags, categories, series, weight, slug,
+// description and summary. Aliases become redirects to the content.
+func (f migrationFile) toContent(fm frontMatterValues, body string) (migratedContent, error) {
+	mc := migratedContent{}
+	ic := &mc.importedContent
+	c := &ic.Content
+
+	slug := fm.str("slug")
+	if slug == "" {
+		slug = f.Slug
+	}
+	ic.Slug = hm.Normalize(slug)
+
+	c.Heading = fm.str("title")
+	if c.Heading == "" {
+		c.Heading = headingFromSlug(slug)
+	}
+	c.Body = body
+	c.SectionPath = f.SectionPath
+	c.Kind = f.Kind
+
+	published, err := parseImportDate(fm.str("date", "publishDate", "publishdate"))
+	if err != nil {
+		return migratedContent{}, fmt.Errorf("date: %w", err)
+	}
+	if published == nil {
+		published = f.Date
+	}
+	c.PublishedAt = published
+	ic.CreatedAt = published
+
+	draft, _ := fm.boolean("draft")
+	if published, ok := fm.boolean("published"); ok && !published {
+		draft = true
+	}
+	c.Draft = draft || f.Draft
+	c.Featured, _ = fm.boolean("featured")
+
+	if series := fm.list("series"); len(series) > 0 {
+		c.Series = series[0]
+		c.Kind = "series"
+	}
+	c.SeriesOrder = fm.integer("weight")
+
+	c.Summary = fm.str("summary")
+	c.Meta = Meta{
+		Description:  fm.str("description", "excerpt", "summary"),
+		Keywords:     strings.Join(fm.list("keywords"), ", "),
+		CanonicalURL: fm.str("canonicalURL", "canonical_url"),
+	}
+
+	seen := map[string]bool{}
+	for _, name := range fm.list("tags", "categories", "category") {
+		if seen[strings.ToLower(name)] {
+			continue
+		}
+		seen[strings.ToLower(name)] = true
+		c.Tags = append(c.Tags, Tag{Name: name})
+	}
+
+	mc.Redirects = fm.list("aliases", "redirect_from")
+
+	return mc, nil
+}
+
+// migrationImages finds the local images referenced by mc, copied to where the
+// site keeps them, and rewrites the body to point there. src resolves a
+// reference to a file on disk, empty for remote or missing images, and to the
+// path of images shared by the whole site, empty for those of the content.
+func (mc *migratedContent) migrationImages(header string, src func(ref string) (file, shared string)) {
+	c := &mc.Content
+	dir := path.Join(strings.TrimPrefix(c.SectionPath, "/"), sanitizeForURL(mc.Slug))
+	targets := map[string]string{}
+
+	target := func(ref string) (string, bool) {
+		if t, ok := targets[ref]; ok {
+			return t, true
+		}
+		file, shared := src(ref)
+		if file == "" {
+			if isLocalRef(ref) {
+				mc.Notes = append(mc.Notes, "Image not found: "+ref)
+			}
+			return "", false
+		}
+
+		var t string
+		if shared != "" {
+			t = sharedImageTarget(shared)
+		} else {
+			t = path.Join(dir, sanitizeForURL(path.Base(refPath(ref))))
+		}
+		targets[ref] = t
+		mc.Images = append(mc.Images, migrationImage{Source: file, Target: t})
+		return t, true
+	}
+
+	rewrite := func(re *regexp.Regexp, prefixGroup, refGroup, altGroup int) {
+		c.Body = re.ReplaceAllStringFunc(c.Body, func(m string) string {
+			sub := re.FindStringSubmatch(m)
+			t, ok := target(sub[refGroup])
+			if !ok {
+				return m
+			}
+			if altGroup > 0 {
+				mc.setAltText(t, sub[altGroup])
+			}
+			return sub[prefixGroup] + imageURLPrefix + t
+		})
+	}
+	rewrite(markdownImageRe, 1, 3, 2)
+	rewrite(htmlImageRe, 1, 2, 0)
+
+	if header != "" {
+		if t, ok := target(header); ok {
+			for i := range mc.Images {
+				if mc.Images[i].Target == t {
+					mc.Images[i].IsHeader = true
+				}
+			}
+		}
+	}
+}
+
+// sharedImageTarget returns where an image shared by the whole migrated site,
+// found at the site path shared, is copied under the site images.
+func sharedImageTarget(shared string) string {
+	clean := strings.TrimPrefix(path.Clean("/"+shared), "/")
+	parts := strings.Split(clean, "/")
+	for i, p := range parts {
+		parts[i] = sanitizeForURL(p)
+	}
+	return path.Join(append([]string{migratedImagesDir}, parts...)...)
+}
+
+func (mc *migratedContent) setAltText(target, alt string) {
+	for i := range mc.Images {
+		if mc.Images[i].Target == target && mc.Images[i].AltText == "" {
+			mc.Images[i].AltText = strings.TrimSpace(alt)
+		}
+	}
+}
+
+// migrationImageSource returns how the references of a file resolve to images
+// on disk: relative ones from the file directory and absolute ones from the
+// Hugo static and assets directories or the Jekyll site root.
+func migrationImageSource(root, source string, f migrationFile) func(ref string) (file, shared string) {
+	var bases []string
+	switch source {
+	case MigrateHugo:
+		bases = []string{filepath.Join(root, "static"), filepath.Join(root, "assets")}
+	default:
+		bases = []string{root}
+	}
+	fileDir := filepath.Join(root, filepath.FromSlash(path.Dir(f.Path)))
+
+	return func(ref string) (string, string) {
+		if !isLocalRef(ref) {
+			return "", ""
+		}
+		p := refPath(ref)
+		if !isImageFile(p) {
+			return "", ""
+		}
+
+		if strings.HasPrefix(p, "/") {
+			for _, base := range bases {
+				if file := filepath.Join(base, filepath.FromSlash(p)); isFile(file) {
+					return file, p
+				}
+			}
+			return "", ""
+		}
+
+		if file := filepath.Join(fileDir, filepath.FromSlash(p)); isFile(file) {
+			return file, ""
+		}
+		return "", ""
+	}
+}
+
+// isLocalRef reports whether ref points to a file of the site rather than a
+// remote URL.
+func isLocalRef(ref string) bool {
+	if ref == "" || strings.HasPrefix(ref, "//") || strings.HasPrefix(ref, "#") || strings.HasPrefix(ref, imageURLPrefix) {
+		return false
+	}
+	u, err := url.Parse(ref)
+	return err == nil && u.Scheme == ""
+}
+
+// refPath returns the unescaped path of a reference, without query or
+// fragment.
+func refPath(ref string) string {
+	if i := strings.IndexAny(ref, "?#"); i >= 0 {
+		ref = ref[:i]
+	}
+	if p, err := url.PathUnescape(ref); err == nil {
+		return p
+	}
+	return ref
+}
+
+func isFile(p string) bool {
+	info, err := os.Stat(p)
+	return err == nil && info.Mode().IsRegular()
+}
+
+func isImageFile(p string) bool {
+	return slices.Contains(migrationImageExts, strings.ToLower(path.Ext(p)))
+}
@@ -0,0 +1,290 @@
+package ssg
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeSiteFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for rel, data := range files {
+		p := filepath.Join(root, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestDetectMigrationSource(t *testing.T) {
+	hugo := t.TempDir()
+	writeSiteFiles(t, hugo, map[string]string{"content/about.md": ""})
+	jekyll := t.TempDir()
+	writeSiteFiles(t, jekyll, map[string]string{"_posts/2024-01-01-a.md": ""})
+
+	if got, err := detectMigrationSource(hugo); err != nil || got != MigrateHugo {
+		t.Errorf("detectMigrationSource(hugo) = %q, %v", got, err)
+	}
+	if got, err := detectMigrationSource(jekyll); err != nil || got != MigrateJekyll {
+		t.Errorf("detectMigrationSource(jekyll) = %q, %v", got, err)
+	}
+	if _, err := detectMigrationSource(t.TempDir()); err == nil {
+		t.Errorf("detectMigrationSource() of an empty dir succeeded")
+	}
+}
+
+func TestMigrateHugoSite(t *testing.T) {
+	root := t.TempDir()
+	writeSiteFiles(t, root, map[string]string{
+		"content/_index.md":       "---\ntitle: Home\n---\n",
+		"content/about.md":        "---\ntitle: About\n---\nAbout me\n",
+		"content/posts/_index.md": "+++\ntitle = \"Blog Posts\"\n+++\n",
+		"content/posts/bundle/index.md": `+++
+title = "Building Operators"
+date = 2024-03-01T10:00:00Z
+draft = true
+slug = "operators"
+tags = ["go", "k8s"]
+categories = ["Go", "cloud"]
+series = ["Operators"]
+weight = 2
+aliases = ["/old/operators/"]
+description = "How to build operators"
+image = "cover.jpg"
+
+[params]
+author = "Someone"
++++
+![Diagram](diagram.png "Flow")
+![Logo](/images/logo.png)
+<img src="https://example.com/remote.png">
+![Missing](missing.png)
+`,
+		"content/posts/bundle/diagram.png": "png",
+		"content/posts/bundle/cover.jpg":   "jpg",
+		"static/images/logo.png":           "png",
+	})
+
+	files, sectionNames, err := migrationFiles(root, MigrateHugo)
+	if err != nil {
+		t.Fatalf("migrationFiles() error = %v", err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("migrationFiles() = %+v, want about and the bundle", files)
+	}
+	if sectionNames["/posts"] != "Blog Posts" || sectionNames["/"] != "Home" {
+		t.Errorf("section names = %v", sectionNames)
+	}
+
+	about, bundle := files[0], files[1]
+	if about.SectionPath != "/" || about.Kind != "page" {
+		t.Errorf("about = %+v", about)
+	}
+	if bundle.Path != "content/posts/bundle/index.md" || bundle.SectionPath != "/posts" || bundle.Slug != "bundle" || bundle.Kind != "blog" {
+		t.Errorf("bundle = %+v", bundle)
+	}
+
+	mc, err := readMigrationFile(root, MigrateHugo, bundle, sectionNames)
+	if err != nil {
+		t.Fatalf("readMigrationFile() error = %v", err)
+	}
+
+	c := mc.Content
+	if c.Heading != "Building Operators" || mc.Slug != "operators" || !c.Draft {
+		t.Errorf("heading, slug, draft = %q, %q, %t", c.Heading, mc.Slug, c.Draft)
+	}
+	if c.SectionName != "Blog Posts" {
+		t.Errorf("section name = %q", c.SectionName)
+	}
+	if c.Kind != "series" || c.Series != "Operators" || c.SeriesOrder != 2 {
+		t.Errorf("kind, series, order = %q, %q, %d", c.Kind, c.Series, c.SeriesOrder)
+	}
+	if c.PublishedAt == nil || !c.PublishedAt.Equal(time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("published at = %v", c.PublishedAt)
+	}
+	if c.Meta.Description != "How to build operators" {
+		t.Errorf("description = %q", c.Meta.Description)
+	}
+	if len(c.Tags) != 3 || c.Tags[0].Name != "go" || c.Tags[1].Name != "k8s" || c.Tags[2].Name != "cloud" {
+		t.Errorf("tags = %v, want tags and categories without duplicates", c.Tags)
+	}
+
+	wantBody := []string{
+		"![Diagram](/static/images/posts/operators/diagram.png \"Flow\")",
+		"![Logo](/static/images/imported/images/logo.png)",
+		"<img src=\"https://example.com/remote.png\">",
+		"![Missing](missing.png)",
+	}
+	for _, want := range wantBody {
+		if !strings.Contains(c.Body, want) {
+			t.Errorf("body = %q, want it to contain %q", c.Body, want)
+		}
+	}
+
+	if len(mc.Images) != 3 {
+		t.Fatalf("images = %+v, want diagram, logo and the header", mc.Images)
+	}
+	if mc.Images[0].AltText != "Diagram" || mc.Images[0].Source != filepath.Join(root, "content/posts/bundle/diagram.png") {
+		t.Errorf("diagram = %+v", mc.Images[0])
+	}
+	if header := mc.Images[2]; header.Target != "posts/operators/cover.jpg" || !header.IsHeader {
+		t.Errorf("header = %+v", header)
+	}
+
+	if len(mc.Redirects) != 1 || mc.Redirects[0] != "/old/operators/" {
+		t.Errorf("redirects = %v, want the alias", mc.Redirects)
+	}
+	if notes := strings.Join(mc.Notes, "; "); !strings.Contains(notes, "missing.png") {
+		t.Errorf("notes = %q, want the missing image", notes)
+	}
+}
+
+func TestMigrateJekyllSite(t *testing.T) {
+	root := t.TempDir()
+	writeSiteFiles(t, root, map[string]string{
+		"_posts/2024-03-01-hello-world.md": `---
+title: Hello World
+tags: go web
+categories: [News]
+published: false
+excerpt: First post
+---
+![Pic]({{ site.baseurl }}/assets/pic.png)
+`,
+		"_drafts/work-in-progress.md": "---\ntitle: WIP\n---\nSoon\n",
+		"_pages/about.md":             "---\ntitle: About\n---\nAbout\n",
+		"assets/pic.png":              "png",
+	})
+
+	files, _, err := migrationFiles(root, MigrateJekyll)
+	if err != nil {
+		t.Fatalf("migrationFiles() error = %v", err)
+	}
+	if len(files) != 3 {
+		t.Fatalf("migrationFiles() = %+v", files)
+	}
+
+	post, draft, page := files[0], files[1], files[2]
+	if post.Slug != "hello-world" || post.Kind != "blog" || post.Date == nil {
+		t.Errorf("post = %+v", post)
+	}
+	if !draft.Draft || page.Kind != "page" {
+		t.Errorf("draft = %+v, page = %+v", draft, page)
+	}
+
+	mc, err := readMigrationFile(root, MigrateJekyll, post, nil)
+	if err != nil {
+		t.Fatalf("readMigrationFile() error = %v", err)
+	}
+	c := mc.Content
+	if !c.Draft {
+		t.Errorf("published: false must import a draft")
+	}
+	if c.PublishedAt == nil || !c.PublishedAt.Equal(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("published at = %v, want the date of the file name", c.PublishedAt)
+	}
+	if len(c.Tags) != 3 {
+		t.Errorf("tags = %v, want go, web and News", c.Tags)
+	}
+	if c.Meta.Description != "First post" {
+		t.Errorf("description = %q", c.Meta.Description)
+	}
+	if !strings.Contains(c.Body, "![Pic](/static/images/imported/assets/pic.png)") {
+		t.Errorf("body = %q", c.Body)
+	}
+
+	mc, err = readMigrationFile(root, MigrateJekyll, draft, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !mc.Content.Draft {
+		t.Errorf("files in _drafts must import drafts")
+	}
+}
+
+func TestParseTOMLFrontMatter(t *testing.T) {
+	front := `# comment
+title = "Say \"hi\""
+literal = 'C:\path'
+draft = false
+weight = 3
+ratio = 1.5
+date = 2024-03-01T10:00:00+01:00
+tags = [
+  "go",
+  'web', # trailing comment
+]
+body = """
+two
+lines"""
+
+[params]
+hidden = true
+`
+	fm, body, err := parseForeignDoc([]byte("+++\n" + front + "+++\nBody"))
+	if err != nil {
+		t.Fatalf("parseForeignDoc() error = %v", err)
+	}
+	if body != "Body" {
+		t.Errorf("body = %q", body)
+	}
+
+	if fm.str("title") != `Say "hi"` || fm.str("literal") != `C:\path` {
+		t.Errorf("strings = %q, %q", fm.str("title"), fm.str("literal"))
+	}
+	if draft, ok := fm.boolean("draft"); !ok || draft {
+		t.Errorf("draft = %t, %t", draft, ok)
+	}
+	if fm.integer("weight") != 3 || fm["ratio"] != 1.5 {
+		t.Errorf("numbers = %v, %v", fm["weight"], fm["ratio"])
+	}
+	if fm.str("date") != "2024-03-01T10:00:00+01:00" {
+		t.Errorf("date = %q", fm.str("date"))
+	}
+	if tags := fm.list("tags"); len(tags) != 2 || tags[0] != "go" || tags[1] != "web" {
+		t.Errorf("tags = %v", tags)
+	}
+	if fm.str("body") != "two\nlines" {
+		t.Errorf("multi-line string = %q", fm.str("body"))
+	}
+	if _, ok := fm["hidden"]; ok {
+		t.Errorf("keys of tables must not be top level keys")
+	}
+
+	for _, invalid := range []string{"title", `title = "open`, "tags = [\"a\"", "title = "} {
+		if _, _, err := parseForeignDoc([]byte("+++\n" + invalid + "\n+++\n")); err == nil {
+			t.Errorf("parseForeignDoc(%q) succeeded", invalid)
+		}
+	}
+}
+
+func TestParseForeignDoc(t *testing.T) {
+	fm, body, err := parseForeignDoc([]byte("{\n  \"title\": \"JSON\",\n  \"tags\": [\"a\"]\n}\nBody\n"))
+	if err != nil {
+		t.Fatalf("parseForeignDoc() error = %v", err)
+	}
+	if fm.str("title") != "JSON" || len(fm.list("tags")) != 1 || body != "Body\n" {
+		t.Errorf("parseForeignDoc() = %v, %q", fm, body)
+	}
+
+	fm, body, err = parseForeignDoc([]byte("---\ndate: 2024-03-01\n---\nBody"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if date, err := parseImportDate(fm.str("date")); err != nil || date == nil {
+		t.Errorf("YAML date = %q, %v", fm.str("date"), err)
+	}
+	if body != "Body" {
+		t.Errorf("body = %q", body)
+	}
+
+	fm, body, err = parseForeignDoc([]byte("No front matter"))
+	if err != nil || len(fm) != 0 || body != "No front matter" {
+		t.Errorf("parseForeignDoc() without front matter = %v, %q, %v", fm, body, err)
+	}
+}
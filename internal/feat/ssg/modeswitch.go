@@ -0,0 +1,273 @@
+package ssg
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// Site modes.
+const (
+	ModeStructured = "structured"
+	ModeBlog       = "blog"
+)
+
+// ModeSwitchOptions describes a change of the site mode. Contents that would
+// no longer be listed in any index under Mode can be given the kind Kind
+// and the section SectionID; empty values keep theirs. Redirects keeps the
+// previous URLs of the contents that move working.
+type ModeSwitchOptions struct {
+	Mode      string    `json:"mode"`
+	Kind      string    `json:"kind"`
+	SectionID uuid.UUID `json:"section_id"`
+	Redirects bool      `json:"redirects"`
+}
+
+// ModeSwitchItem is a published content affected by a mode switch.
+type ModeSwitchItem struct {
+	ContentID uuid.UUID `json:"content_id"`
+	Heading   string    `json:"heading"`
+	Kind      string    `json:"kind"`
+	FromPath  string    `json:"from_path"`
+	ToPath    string    `json:"to_path"`
+}
+
+// ModeSwitchPlan shows what switching the site mode would do before doing it:
+// the contents whose URL changes, those that would no longer be listed in any
+// index and those reassigned to stay listed.
+type ModeSwitchPlan struct {
+	From       string           `json:"from"`
+	To         string           `json:"to"`
+	Moved      []ModeSwitchItem `json:"moved"`
+	Unlisted   []ModeSwitchItem `json:"unlisted"`
+	Reassigned []ModeSwitchItem `json:"reassigned"`
+	Summary    string           `json:"summary"`
+}
+
+// modeSwitchKinds are the kinds a content can be reassigned to.
+var modeSwitchKinds = []string{"article", "blog", "series", "page"}
+
+// ValidateSiteMode returns an error unless mode is a known site mode.
+func ValidateSiteMode(mode string) error {
+	if mode != ModeStructured && mode != ModeBlog {
+		return fmt.Errorf("invalid site mode: must be '%s' or '%s'", ModeStructured, ModeBlog)
+	}
+	return nil
+}
+
+// planModeSwitch computes the plan of switching the published contents of a
+// site in mode from to opts.Mode. It returns the contents as they would be
+// after the switch, with reassignments applied, along with the plan.
+func planModeSwitch(contents []Content, sections []Section, from string, opts ModeSwitchOptions) ([]Content, ModeSwitchPlan) {
+	plan := ModeSwitchPlan{From: from, To: opts.Mode}
+
+	var published []Content
+	for _, c := range contents {
+		if !c.Draft {
+			published = append(published, c)
+		}
+	}
+
+	listedBefore := listedContentIDs(published, sections, from)
+	listedAfter := listedContentIDs(published, sections, opts.Mode)
+
+	var targetPath string
+	for _, s := range sections {
+		if s.ID == opts.SectionID {
+			targetPath = s.Path
+		}
+	}
+
+	switched := make([]Content, len(published))
+	for i, c := range published {
+		if listedBefore[c.ID] && !listedAfter[c.ID] {
+			if opts.Kind != "" {
+				c.Kind = opts.Kind
+			}
+			if opts.SectionID != uuid.Nil && targetPath != "" {
+				c.SectionID = opts.SectionID
+				c.SectionPath = targetPath
+			}
+		}
+		switched[i] = c
+	}
+	listedSwitched := listedContentIDs(switched, sections, opts.Mode)
+
+	for i, c := range published {
+		s := switched[i]
+		item := ModeSwitchItem{
+			ContentID: c.ID,
+			Heading:   c.Heading,
+			Kind:      s.Kind,
+			FromPath:  GetContentPath(c, from),
+			ToPath:    GetContentPath(s, opts.Mode),
+		}
+		if item.FromPath != item.ToPath {
+			plan.Moved = append(plan.Moved, item)
+		}
+		if !listedBefore[c.ID] {
+			continue
+		}
+		if s.Kind != c.Kind || s.SectionID != c.SectionID {
+			plan.Reassigned = append(plan.Reassigned, item)
+		}
+		if !listedSwitched[c.ID] {
+			plan.Unlisted = append(plan.Unlisted, item)
+		}
+	}
+
+	plan.Summary = fmt.Sprintf("%d contents change URL, %d reassigned, %d no longer listed in an index",
+		len(plan.Moved), len(plan.Reassigned), len(plan.Unlisted))
+	return switched, plan
+}
+
+// listedContentIDs returns the IDs of the contents listed in any index of a
+// site in mode.
+func listedContentIDs(contents []Content, sections []Section, mode string) map[uuid.UUID]bool {
+	listed := make(map[uuid.UUID]bool)
+	for _, index := range BuildIndexes(contents, sections, mode) {
+		for _, c := range index.Content {
+			listed[c.ID] = true
+		}
+	}
+	return listed
+}
+
+// PlanModeSwitch returns what switching the site in context to opts.Mode
+// would change, without changing anything.
+func (svc *BaseService) PlanModeSwitch(ctx context.Context, opts ModeSwitchOptions) (ModeSwitchPlan, error) {
+	_, plan, err := svc.planModeSwitch(ctx, opts)
+	return plan, err
+}
+
+// SwitchMode switches the site in context to opts.Mode. Contents that would
+// no longer be listed get the kind and section of opts, and the previous URLs
+// of the contents that move redirect to them if opts.Redirects is set. All of
+// it is done in one transaction, nothing changes if any step fails.
+func (svc *BaseService) SwitchMode(ctx context.Context, opts ModeSwitchOptions) (plan ModeSwitchPlan, err error) {
+	switched, plan, err := svc.planModeSwitch(ctx, opts)
+	if err != nil {
+		return ModeSwitchPlan{}, err
+	}
+	if plan.From == plan.To {
+		return ModeSwitchPlan{}, fmt.Errorf("site is already in %s mode", plan.To)
+	}
+	siteID, err := RequireSiteID(ctx)
+	if err != nil {
+		return ModeSwitchPlan{}, err
+	}
+
+	ctx, tx, err := svc.repo.BeginTx(ctx)
+	if err != nil {
+		return ModeSwitchPlan{}, fmt.Errorf("cannot begin transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				err = fmt.Errorf("cannot rollback transaction: %v (original error: %w)", rbErr, err)
+			}
+		}
+		// The site mode param may have been cached while the transaction
+		// was open
+		svc.pm.InvalidateSite(siteID)
+	}()
+
+	byID := make(map[uuid.UUID]Content, len(switched))
+	for _, c := range switched {
+		byID[c.ID] = c
+	}
+	for _, item := range plan.Reassigned {
+		content := byID[item.ContentID]
+		content.GenUpdateValues()
+		if err := svc.repo.UpdateContent(ctx, &content); err != nil {
+			return ModeSwitchPlan{}, fmt.Errorf("cannot reassign %s: %w", item.Heading, err)
+		}
+	}
+
+	if opts.Redirects {
+		for _, item := range plan.Moved {
+			if _, err := svc.saveRedirect(ctx, item.FromPath, item.ContentID, "", RedirectModeSwitch); err != nil {
+				return ModeSwitchPlan{}, fmt.Errorf("cannot redirect %s: %w", item.FromPath, err)
+			}
+		}
+	}
+
+	if err := svc.pm.SetSiteMode(ctx, plan.To); err != nil {
+		return ModeSwitchPlan{}, fmt.Errorf("cannot set site mode: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return ModeSwitchPlan{}, fmt.Errorf("cannot commit mode switch: %w", err)
+	}
+
+	svc.Log().Info("Site mode switched", "from", plan.From, "to", plan.To, "summary", plan.Summary)
+	return plan, nil
+}
+
+func (svc *BaseService) planModeSwitch(ctx context.Context, opts ModeSwitchOptions) ([]Content, ModeSwitchPlan, error) {
+	if err := ValidateSiteMode(opts.Mode); err != nil {
+		return nil, ModeSwitchPlan{}, err
+	}
+	opts.Kind = strings.ToLower(opts.Kind)
+	if opts.Kind != "" && !validModeSwitchKind(opts.Kind) {
+		return nil, ModeSwitchPlan{}, fmt.Errorf("invalid kind: %s", opts.Kind)
+	}
+
+	contents, err := svc.repo.GetAllContentWithMeta(ctx)
+	if err != nil {
+		return nil, ModeSwitchPlan{}, fmt.Errorf("cannot get contents: %w", err)
+	}
+	sections, err := svc.repo.GetSections(ctx)
+	if err != nil {
+		return nil, ModeSwitchPlan{}, fmt.Errorf("cannot get sections: %w", err)
+	}
+	if opts.SectionID != uuid.Nil && !hasSection(sections, opts.SectionID) {
+		return nil, ModeSwitchPlan{}, fmt.Errorf("section not found: %s", opts.SectionID)
+	}
+
+	switched, plan := planModeSwitch(contents, sections, svc.pm.GetSiteMode(ctx), opts)
+	return switched, plan, nil
+}
+
+func validModeSwitchKind(kind string) bool {
+	for _, k := range modeSwitchKinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+func hasSection(sections []Section, id uuid.UUID) bool {
+	for _, s := range sections {
+		if s.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// SetSiteMode records mode as the mode of the site id, as shown in the sites
+// list. The mode used to generate the site is a param of the site, see
+// SwitchMode.
+func (sm *SiteManager) SetSiteMode(ctx context.Context, id uuid.UUID, mode string, userID uuid.UUID) (Site, error) {
+	if err := ValidateSiteMode(mode); err != nil {
+		return Site{}, err
+	}
+
+	site, err := sm.siteRepo.GetSite(ctx, id)
+	if err != nil {
+		return Site{}, err
+	}
+
+	site.Mode = mode
+	site.GenUpdateValues(userID)
+	if err := sm.siteRepo.UpdateSite(ctx, &site); err != nil {
+		return Site{}, err
+	}
+	sm.writeSiteMarker(site)
+
+	return site, nil
+}
@@ -0,0 +1,158 @@
+package ssg
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/hermesgen/hm"
+)
+
+func TestPlanModeSwitch(t *testing.T) {
+	rootID, techID := uuid.New(), uuid.New()
+	sections := []Section{
+		{ID: rootID, Name: "root", Path: "/"},
+		{ID: techID, Name: "tech", Path: "/tech/"},
+	}
+	contents := []Content{
+		{ID: uuid.New(), ShortID: "a1", SectionID: rootID, SectionPath: "/", Kind: "blog", Heading: "Root Post"},
+		{ID: uuid.New(), ShortID: "b2", SectionID: techID, SectionPath: "/tech/", Kind: "article", Heading: "Tech Article"},
+		{ID: uuid.New(), ShortID: "c3", SectionID: techID, SectionPath: "/tech/", Kind: "page", Heading: "Tech Page"},
+		{ID: uuid.New(), ShortID: "d4", SectionID: techID, SectionPath: "/tech/", Kind: "article", Heading: "Draft", Draft: true},
+	}
+
+	headings := func(items []ModeSwitchItem) []string {
+		var h []string
+		for _, i := range items {
+			h = append(h, i.Heading)
+		}
+		return h
+	}
+
+	t.Run("to blog without reassignment", func(t *testing.T) {
+		_, plan := planModeSwitch(contents, sections, ModeStructured, ModeSwitchOptions{Mode: ModeBlog})
+
+		if got := headings(plan.Moved); len(got) != 2 || got[0] != "Tech Article" || got[1] != "Tech Page" {
+			t.Errorf("moved = %v, want [Tech Article Tech Page]", got)
+		}
+		if plan.Moved[0].FromPath != "/tech/tech-article-b2" || plan.Moved[0].ToPath != "/tech-article-b2" {
+			t.Errorf("move = %s -> %s", plan.Moved[0].FromPath, plan.Moved[0].ToPath)
+		}
+		if got := headings(plan.Unlisted); len(got) != 1 || got[0] != "Tech Article" {
+			t.Errorf("unlisted = %v, want [Tech Article]", got)
+		}
+		if len(plan.Reassigned) != 0 {
+			t.Errorf("reassigned = %v, want none", headings(plan.Reassigned))
+		}
+	})
+
+	t.Run("to blog with reassignment", func(t *testing.T) {
+		opts := ModeSwitchOptions{Mode: ModeBlog, Kind: "blog", SectionID: rootID}
+		switched, plan := planModeSwitch(contents, sections, ModeStructured, opts)
+
+		if len(plan.Unlisted) != 0 {
+			t.Errorf("unlisted = %v, want none", headings(plan.Unlisted))
+		}
+		if got := headings(plan.Reassigned); len(got) != 1 || got[0] != "Tech Article" {
+			t.Fatalf("reassigned = %v, want [Tech Article]", got)
+		}
+		if c := switched[1]; c.Kind != "blog" || c.SectionID != rootID || c.SectionPath != "/" {
+			t.Errorf("reassigned content = %s %s %s", c.Kind, c.SectionID, c.SectionPath)
+		}
+		if c := switched[2]; c.Kind != "page" || c.SectionID != techID {
+			t.Errorf("unindexed page was reassigned: %s %s", c.Kind, c.SectionID)
+		}
+		if len(switched) != 3 {
+			t.Errorf("switched %d contents, want drafts left out", len(switched))
+		}
+	})
+
+	t.Run("to structured", func(t *testing.T) {
+		_, plan := planModeSwitch(contents, sections, ModeBlog, ModeSwitchOptions{Mode: ModeStructured})
+
+		if got := headings(plan.Moved); len(got) != 2 {
+			t.Errorf("moved = %v, want the tech contents", got)
+		}
+		if len(plan.Unlisted) != 0 {
+			t.Errorf("unlisted = %v, want none", headings(plan.Unlisted))
+		}
+	})
+}
+
+type fakeTx struct {
+	committed, rolledBack bool
+}
+
+func (f *fakeTx) Commit() error {
+	f.committed = true
+	return nil
+}
+
+func (f *fakeTx) Rollback() error {
+	f.rolledBack = true
+	return nil
+}
+
+type fakeModeSwitchRepo struct {
+	fakeParamRepo
+	contents []Content
+	sections []Section
+	tx       fakeTx
+	updated  []Content
+}
+
+func (f *fakeModeSwitchRepo) BeginTx(ctx context.Context) (context.Context, hm.Tx, error) {
+	return ctx, &f.tx, nil
+}
+
+func (f *fakeModeSwitchRepo) GetAllContentWithMeta(ctx context.Context) ([]Content, error) {
+	return f.contents, nil
+}
+
+func (f *fakeModeSwitchRepo) GetSections(ctx context.Context) ([]Section, error) {
+	return f.sections, nil
+}
+
+func (f *fakeModeSwitchRepo) UpdateContent(ctx context.Context, content *Content) error {
+	f.updated = append(f.updated, *content)
+	return nil
+}
+
+func (f *fakeModeSwitchRepo) SaveRedirect(ctx context.Context, redirect *Redirect) error {
+	return errors.New("disk full")
+}
+
+func TestSwitchModeRollsBack(t *testing.T) {
+	siteID, rootID, techID := uuid.New(), uuid.New(), uuid.New()
+	repo := &fakeModeSwitchRepo{
+		fakeParamRepo: fakeParamRepo{params: map[string]Param{
+			siteModeRefKey: {ID: uuid.New(), SiteID: siteID, RefKey: siteModeRefKey, Value: ModeStructured},
+		}},
+		sections: []Section{
+			{ID: rootID, Name: "root", Path: "/"},
+			{ID: techID, Name: "tech", Path: "/tech/"},
+		},
+		contents: []Content{
+			{ID: uuid.New(), ShortID: "b2", SectionID: techID, SectionPath: "/tech/", Kind: "article", Heading: "Tech Article"},
+		},
+	}
+	params := hm.XParams{Cfg: hm.NewConfig(), Log: hm.NewLogger("error")}
+	svc := &BaseService{Service: hm.NewService("ssg-svc", params), repo: repo, pm: NewParamManager(repo, params)}
+	ctx := context.WithValue(context.Background(), siteIDKey, siteID)
+
+	opts := ModeSwitchOptions{Mode: ModeBlog, Kind: "blog", SectionID: rootID, Redirects: true}
+	if _, err := svc.SwitchMode(ctx, opts); err == nil {
+		t.Fatal("SwitchMode() error = nil, want the redirect error")
+	}
+
+	if len(repo.updated) != 1 {
+		t.Fatalf("updated = %d contents, want the reassignment done before the failure", len(repo.updated))
+	}
+	if !repo.tx.rolledBack || repo.tx.committed {
+		t.Errorf("transaction rolled back %t, committed %t", repo.tx.rolledBack, repo.tx.committed)
+	}
+	if mode := repo.params[siteModeRefKey].Value; mode != ModeStructured {
+		t.Errorf("site mode = %s, want it unchanged", mode)
+	}
+}
@@ -3,21 +3,27 @@ package ssg
 import (
 	"context"
 	"fmt"
+	"strconv"
 
+	"github.com/google/uuid"
 	"github.com/hermesgen/hm"
 )
 
+// ParamManager reads and writes the params of the site in context, caching
+// them per site. Writes through it drop the cached params of the site.
 type ParamManager struct {
 	hm.Core
-	repo Repo
+	repo  Repo
+	cache *paramCache
 }
 
 // NewParamManagerWithParams creates a ParamManager with XParams.
 func NewParamManager(repo Repo, params hm.XParams) *ParamManager {
 	core := hm.NewCore("param-manager", params)
 	return &ParamManager{
-		Core: core,
-		repo: repo,
+		Core:  core,
+		repo:  repo,
+		cache: newParamCache(),
 	}
 }
 
@@ -30,16 +36,123 @@ func (pm *ParamManager) findParamByName(ctx context.Context, name string) (Param
 	if pm.repo == nil {
 		return Param{}, fmt.Errorf("no repository available")
 	}
-	// TODO: This is a simple wrapper for now but a simple caching strategy can be added here.
-	return pm.repo.GetParamByName(ctx, name)
+	return pm.lookup(ctx, name, false, pm.repo.GetParamByName)
 }
 
 func (pm *ParamManager) FindParamByRef(ctx context.Context, refKey string) (Param, error) {
 	if pm.repo == nil {
 		return Param{}, fmt.Errorf("no repository available")
 	}
-	// TODO: This is a simple wrapper for now but a simple caching strategy can be added here.
-	return pm.repo.GetParamByRefKey(ctx, refKey)
+	return pm.lookup(ctx, refKey, true, pm.repo.GetParamByRefKey)
+}
+
+// lookup reads a param of the site in context from the cache, loading it on
+// a miss.
+func (pm *ParamManager) lookup(ctx context.Context, key string, byRef bool, load func(context.Context, string) (Param, error)) (Param, error) {
+	siteID, ok := GetSiteIDFromContext(ctx)
+	if !ok {
+		return load(ctx, key)
+	}
+
+	if param, known := pm.cache.get(siteID, key, byRef); known {
+		if param.IsZero() {
+			return Param{}, errParamNotFound
+		}
+		return param, nil
+	}
+
+	gen := pm.cache.generation()
+	param, err := load(ctx, key)
+	if err != nil || param.IsZero() {
+		return param, err
+	}
+	pm.cache.put(siteID, gen, param)
+	return param, nil
+}
+
+// ListParams returns every param of the site in context, from the cache if
+// it holds them all.
+func (pm *ParamManager) ListParams(ctx context.Context) ([]Param, error) {
+	if pm.repo == nil {
+		return nil, fmt.Errorf("no repository available")
+	}
+
+	siteID, ok := GetSiteIDFromContext(ctx)
+	if !ok {
+		return pm.repo.ListParams(ctx)
+	}
+	if params, ok := pm.cache.all(siteID); ok {
+		return params, nil
+	}
+	return pm.load(ctx, siteID)
+}
+
+// Preload loads every param of the site in context into the cache with a
+// single query. A generation run calls it first, so the settings it reads
+// come from one fresh read of the database.
+func (pm *ParamManager) Preload(ctx context.Context) error {
+	if pm.repo == nil {
+		return fmt.Errorf("no repository available")
+	}
+
+	siteID, err := RequireSiteID(ctx)
+	if err != nil {
+		return err
+	}
+	_, err = pm.load(ctx, siteID)
+	return err
+}
+
+func (pm *ParamManager) load(ctx context.Context, siteID uuid.UUID) ([]Param, error) {
+	gen := pm.cache.generation()
+	params, err := pm.repo.ListParams(ctx)
+	if err != nil {
+		return nil, err
+	}
+	pm.cache.fill(siteID, gen, params)
+	return params, nil
+}
+
+// CreateParam creates a param of the site in context.
+func (pm *ParamManager) CreateParam(ctx context.Context, param *Param) error {
+	if pm.repo == nil {
+		return fmt.Errorf("no repository available")
+	}
+	defer pm.invalidate(ctx, param.SiteID)
+	return pm.repo.CreateParam(ctx, param)
+}
+
+// UpdateParam updates a param of the site in context.
+func (pm *ParamManager) UpdateParam(ctx context.Context, param *Param) error {
+	if pm.repo == nil {
+		return fmt.Errorf("no repository available")
+	}
+	defer pm.invalidate(ctx, param.SiteID)
+	return pm.repo.UpdateParam(ctx, param)
+}
+
+// DeleteParam deletes a param of the site in context.
+func (pm *ParamManager) DeleteParam(ctx context.Context, id uuid.UUID) error {
+	if pm.repo == nil {
+		return fmt.Errorf("no repository available")
+	}
+	defer pm.invalidate(ctx, uuid.Nil)
+	return pm.repo.DeleteParam(ctx, id)
+}
+
+// InvalidateSite drops the cached params of a site whose params were changed
+// without going through the ParamManager, such as a purged site.
+func (pm *ParamManager) InvalidateSite(siteID uuid.UUID) {
+	pm.cache.invalidate(siteID)
+}
+
+func (pm *ParamManager) invalidate(ctx context.Context, siteID uuid.UUID) {
+	if id, ok := GetSiteIDFromContext(ctx); ok {
+		pm.cache.invalidate(id)
+	}
+	if siteID != uuid.Nil {
+		pm.cache.invalidate(siteID)
+	}
 }
 
 func (pm *ParamManager) Get(ctx context.Context, refKey string, defVal string) string {
@@ -48,7 +161,7 @@ func (pm *ParamManager) Get(ctx context.Context, refKey string, defVal string) s
 		return pm.Cfg().StrValOrDef(refKey, defVal)
 	}
 
-	param, err := pm.repo.GetParamByRefKey(ctx, refKey)
+	param, err := pm.FindParamByRef(ctx, refKey)
 	if err == nil && !param.IsZero() {
 		return param.Value
 	}
@@ -74,23 +187,95 @@ func (pm *ParamManager) SetSiteMode(ctx context.Context, mode string) error {
 		return fmt.Errorf("no repository available")
 	}
 
-	if mode != "structured" && mode != "blog" {
-		return fmt.Errorf("invalid site mode: must be 'structured' or 'blog'")
+	if err := ValidateSiteMode(mode); err != nil {
+		return err
+	}
+
+	siteID, err := RequireSiteID(ctx)
+	if err != nil {
+		return err
 	}
 
-	param, err := pm.repo.GetParamByRefKey(ctx, "site.mode")
+	param, err := pm.FindParamByRef(ctx, siteModeRefKey)
 	if err != nil || param.IsZero() {
 		// Create new param
 		param = NewParam("Site Mode", mode)
+		param.SiteID = siteID
 		param.Description = "Site operation mode: 'structured' (multi-section) or 'blog' (single chronological feed)"
-		param.RefKey = "site.mode"
+		param.RefKey = siteModeRefKey
 		param.System = 1
 		param.GenCreateValues()
-		return pm.repo.CreateParam(ctx, &param)
+		return pm.CreateParam(ctx, &param)
 	}
 
 	// Update existing
 	param.Value = mode
 	param.GenUpdateValues()
-	return pm.repo.UpdateParam(ctx, &param)
+	return pm.UpdateParam(ctx, &param)
+}
+
+// Setting returns the value of a setting for the site in context, read from
+// its param, then from the configuration and then from the schema default.
+// Keys outside the schema are read with Get.
+func (pm *ParamManager) Setting(ctx context.Context, key string) string {
+	setting, ok := FindSetting(key)
+	if !ok {
+		return pm.Get(ctx, key, "")
+	}
+
+	siteVal := pm.siteValue(ctx, key)
+	value, source := setting.resolve(siteVal, pm.Cfg().StrValOrDef(key, ""))
+	if siteVal != "" && source != SourceSite {
+		pm.Log().Errorf("Invalid site setting %s=%q, ignoring it", key, siteVal)
+	}
+	return value
+}
+
+// SettingBool returns the value of a bool setting for the site in context.
+func (pm *ParamManager) SettingBool(ctx context.Context, key string) bool {
+	value, _ := strconv.ParseBool(pm.Setting(ctx, key))
+	return value
+}
+
+// SettingInt returns the value of an int setting for the site in context.
+func (pm *ParamManager) SettingInt(ctx context.Context, key string) int {
+	value, _ := strconv.Atoi(pm.Setting(ctx, key))
+	return value
+}
+
+// SettingValues returns every setting of the schema with its value for the
+// site in context. Secret values are left out.
+func (pm *ParamManager) SettingValues(ctx context.Context) ([]SettingValue, error) {
+	siteVals := map[string]string{}
+	if pm.repo != nil {
+		params, err := pm.ListParams(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range params {
+			siteVals[p.RefKey] = p.Value
+		}
+	}
+
+	values := make([]SettingValue, 0, len(SiteSettings))
+	for _, s := range SiteSettings {
+		value, source := s.resolve(siteVals[s.Key], pm.Cfg().StrValOrDef(s.Key, ""))
+		sv := SettingValue{Setting: s, Value: value, Source: source, IsSet: value != ""}
+		if s.Type == SettingSecret {
+			sv.Value = ""
+		}
+		values = append(values, sv)
+	}
+	return values, nil
+}
+
+func (pm *ParamManager) siteValue(ctx context.Context, key string) string {
+	if pm.repo == nil {
+		return ""
+	}
+	param, err := pm.FindParamByRef(ctx, key)
+	if err != nil || param.IsZero() {
+		return ""
+	}
+	return param.Value
 }
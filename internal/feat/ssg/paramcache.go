@@ -0,0 +1,128 @@
+package ssg
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+var errParamNotFound = errors.New("param not found")
+
+// paramCache keeps the params of each site in memory, by name and by ref key,
+// so the params read over and over by requests and generation runs do not hit
+// the database every time. It is safe for concurrent use.
+type paramCache struct {
+	mu    sync.RWMutex
+	sites map[uuid.UUID]*siteParams
+	// gen changes on every invalidation, so a load that started before it is
+	// not cached after it.
+	gen uint64
+}
+
+// siteParams are the cached params of a site. A complete entry holds every
+// param of the site, so a miss means the site has no such param.
+type siteParams struct {
+	byName   map[string]Param
+	byRef    map[string]Param
+	complete bool
+}
+
+func newParamCache() *paramCache {
+	return &paramCache{sites: map[uuid.UUID]*siteParams{}}
+}
+
+func newSiteParams() *siteParams {
+	return &siteParams{byName: map[string]Param{}, byRef: map[string]Param{}}
+}
+
+// get returns the cached param of a site by name or by ref key. known reports
+// whether the cache has the answer: the param, or a zero Param when the site
+// is known not to have it.
+func (c *paramCache) get(siteID uuid.UUID, key string, byRef bool) (p Param, known bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	sp, ok := c.sites[siteID]
+	if !ok {
+		return Param{}, false
+	}
+	if byRef {
+		p, ok = sp.byRef[key]
+	} else {
+		p, ok = sp.byName[key]
+	}
+	return p, ok || sp.complete
+}
+
+// all returns every param of a site if the cache holds them all.
+func (c *paramCache) all(siteID uuid.UUID) ([]Param, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	sp, ok := c.sites[siteID]
+	if !ok || !sp.complete {
+		return nil, false
+	}
+	params := make([]Param, 0, len(sp.byName))
+	for _, p := range sp.byName {
+		params = append(params, p)
+	}
+	return params, true
+}
+
+// generation returns the current generation, to be passed to put or fill
+// along with what is loaded after calling it.
+func (c *paramCache) generation() uint64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.gen
+}
+
+// put caches a param loaded at generation gen.
+func (c *paramCache) put(siteID uuid.UUID, gen uint64, p Param) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if gen != c.gen {
+		return
+	}
+	sp, ok := c.sites[siteID]
+	if !ok {
+		sp = newSiteParams()
+		c.sites[siteID] = sp
+	}
+	sp.add(p)
+}
+
+// fill caches every param of a site, loaded at generation gen.
+func (c *paramCache) fill(siteID uuid.UUID, gen uint64, params []Param) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if gen != c.gen {
+		return
+	}
+	sp := newSiteParams()
+	for _, p := range params {
+		sp.add(p)
+	}
+	sp.complete = true
+	c.sites[siteID] = sp
+}
+
+// invalidate drops the cached params of a site.
+func (c *paramCache) invalidate(siteID uuid.UUID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.sites, siteID)
+	c.gen++
+}
+
+func (sp *siteParams) add(p Param) {
+	sp.byName[p.Name] = p
+	if p.RefKey != "" {
+		sp.byRef[p.RefKey] = p
+	}
+}
@@ -0,0 +1,155 @@
+package ssg
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/hermesgen/hm"
+)
+
+// countingParamRepo serves the params of two sites and counts the queries.
+type countingParamRepo struct {
+	Repo
+	mu      sync.Mutex
+	params  map[uuid.UUID][]Param
+	queries int
+}
+
+func (f *countingParamRepo) siteParams(ctx context.Context) []Param {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.queries++
+	siteID, _ := GetSiteIDFromContext(ctx)
+	return append([]Param(nil), f.params[siteID]...)
+}
+
+func (f *countingParamRepo) GetParamByRefKey(ctx context.Context, refKey string) (Param, error) {
+	for _, p := range f.siteParams(ctx) {
+		if p.RefKey == refKey {
+			return p, nil
+		}
+	}
+	return Param{}, errParamNotFound
+}
+
+func (f *countingParamRepo) GetParamByName(ctx context.Context, name string) (Param, error) {
+	for _, p := range f.siteParams(ctx) {
+		if p.Name == name {
+			return p, nil
+		}
+	}
+	return Param{}, errParamNotFound
+}
+
+func (f *countingParamRepo) ListParams(ctx context.Context) ([]Param, error) {
+	return f.siteParams(ctx), nil
+}
+
+func (f *countingParamRepo) UpdateParam(ctx context.Context, p *Param) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i, old := range f.params[p.SiteID] {
+		if old.ID == p.ID {
+			f.params[p.SiteID][i] = *p
+		}
+	}
+	return nil
+}
+
+func (f *countingParamRepo) count() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.queries
+}
+
+func TestParamManagerCache(t *testing.T) {
+	blogID, docsID := uuid.New(), uuid.New()
+	newRepo := func() *countingParamRepo {
+		return &countingParamRepo{params: map[uuid.UUID][]Param{
+			blogID: {{ID: uuid.New(), SiteID: blogID, Name: "Index page size", RefKey: SSGKey.IndexMaxItems, Value: "12"}},
+			docsID: {{ID: uuid.New(), SiteID: docsID, Name: "Index page size", RefKey: SSGKey.IndexMaxItems, Value: "30"}},
+		}}
+	}
+	params := hm.XParams{Cfg: hm.NewConfig(), Log: hm.NewLogger("error")}
+	blog := context.WithValue(context.Background(), siteIDKey, blogID)
+	docs := context.WithValue(context.Background(), siteIDKey, docsID)
+
+	t.Run("per site", func(t *testing.T) {
+		repo := newRepo()
+		pm := NewParamManager(repo, params)
+
+		for i := 0; i < 3; i++ {
+			if got := pm.Setting(blog, SSGKey.IndexMaxItems); got != "12" {
+				t.Errorf("blog setting = %s, want 12", got)
+			}
+			if got := pm.Setting(docs, SSGKey.IndexMaxItems); got != "30" {
+				t.Errorf("docs setting = %s, want 30", got)
+			}
+		}
+		if n := repo.count(); n != 2 {
+			t.Errorf("queries = %d, want 2", n)
+		}
+		if _, err := pm.FindParam(blog, "Index page size"); err != nil || repo.count() != 2 {
+			t.Errorf("find by name was not cached: err = %v, queries = %d", err, repo.count())
+		}
+	})
+
+	t.Run("preload", func(t *testing.T) {
+		repo := newRepo()
+		pm := NewParamManager(repo, params)
+
+		if err := pm.Preload(blog); err != nil {
+			t.Fatalf("Preload() error = %v", err)
+		}
+		pm.Setting(blog, SSGKey.IndexMaxItems)
+		pm.Setting(blog, SSGKey.HeaderStyle)
+		pm.Setting(blog, SSGKey.PublishTarget)
+		if n := repo.count(); n != 1 {
+			t.Errorf("queries = %d, want only the preload", n)
+		}
+	})
+
+	t.Run("invalidated on update", func(t *testing.T) {
+		repo := newRepo()
+		pm := NewParamManager(repo, params)
+		if err := pm.Preload(blog); err != nil {
+			t.Fatalf("Preload() error = %v", err)
+		}
+
+		param, _ := pm.FindParamByRef(blog, SSGKey.IndexMaxItems)
+		param.Value = "15"
+		if err := pm.UpdateParam(blog, &param); err != nil {
+			t.Fatalf("UpdateParam() error = %v", err)
+		}
+
+		if got := pm.Setting(blog, SSGKey.IndexMaxItems); got != "15" {
+			t.Errorf("setting after update = %s, want 15", got)
+		}
+	})
+
+	t.Run("concurrent use", func(t *testing.T) {
+		repo := newRepo()
+		pm := NewParamManager(repo, params)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				switch i % 4 {
+				case 0:
+					pm.Preload(blog)
+				case 1:
+					pm.InvalidateSite(blogID)
+				default:
+					if p, err := pm.FindParamByRef(blog, SSGKey.IndexMaxItems); err != nil || p.Value != "12" {
+						t.Errorf("param = %q, %v, want 12", p.Value, err)
+					}
+				}
+			}(i)
+		}
+		wg.Wait()
+	})
+}
@@ -150,3 +150,37 @@ func GetSiteAssetsPath(sitesBasePath, siteSlug string) string {
 func GetSiteImagesPath(sitesBasePath, siteSlug string) string {
 	return filepath.Join(GetSiteAssetsPath(sitesBasePath, siteSlug), "images")
 }
+
+// GetSiteAttachmentsPath returns the attachments path for a specific site.
+func GetSiteAttachmentsPath(sitesBasePath, siteSlug string) string {
+	return filepath.Join(GetSiteAssetsPath(sitesBasePath, siteSlug), "attachments")
+}
+
+// GetSitePublishSnapshotsPath returns where the outputs of past publishes of a
+// site are kept for rollback.
+func GetSitePublishSnapshotsPath(sitesBasePath, siteSlug string) string {
+	return filepath.Join(sitesBasePath, siteSlug, "snapshots")
+}
+
+// GetSiteBuildReportPath returns where the problems of the last HTML build of
+// a site are recorded. It is kept out of the HTML dir so it is not published.
+func GetSiteBuildReportPath(sitesBasePath, siteSlug string) string {
+	return filepath.Join(GetSiteDocsPath(sitesBasePath, siteSlug), "build-report.json")
+}
+
+// GetSiteDraftsPath returns the default watched drafts directory of a site.
+func GetSiteDraftsPath(sitesBasePath, siteSlug string) string {
+	return filepath.Join(GetSiteDocsPath(sitesBasePath, siteSlug), "drafts")
+}
+
+// GetSiteWatchStatePath returns where the import state of the watched drafts
+// directory of a site is kept.
+func GetSiteWatchStatePath(sitesBasePath, siteSlug string) string {
+	return filepath.Join(GetSiteDocsPath(sitesBasePath, siteSlug), "watch-state.json")
+}
+
+// GetSiteMarkerPath returns the file recording which site a directory belongs
+// to, used to attach the directory again if its site record is gone.
+func GetSiteMarkerPath(sitesBasePath, siteSlug string) string {
+	return filepath.Join(sitesBasePath, siteSlug, "site.json")
+}
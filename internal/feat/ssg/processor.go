@@ -102,3 +102,30 @@ func enhanceImagesInHTML(html string, imageContext *ImageContext) string {
 
 	return result
 }
+
+// AttachmentMetadata holds what is shown next to a link to a downloadable attachment.
+type AttachmentMetadata struct {
+	Kind string // Short file type label, e.g. PDF
+	Size string // Human readable size, e.g. 1.2 MB
+}
+
+var attachmentLinkRegex = regexp.MustCompile(`<a href="` + regexp.QuoteMeta(attachmentURLPrefix) + `([^"]+)"([^>]*)>(.*?)</a>`)
+
+// enhanceAttachmentLinksInHTML marks links to known attachments as downloads and
+// appends their file type and size. Keys are paths relative to /static/attachments/.
+func enhanceAttachmentLinksInHTML(html string, attachments map[string]AttachmentMetadata) string {
+	if len(attachments) == 0 {
+		return html
+	}
+
+	return attachmentLinkRegex.ReplaceAllStringFunc(html, func(match string) string {
+		parts := attachmentLinkRegex.FindStringSubmatch(match)
+		meta, ok := attachments[parts[1]]
+		if !ok {
+			return match
+		}
+
+		return fmt.Sprintf(`<a href="%s%s"%s class="prose-attachment" download>%s</a> <span class="prose-attachment-meta">(%s, %s)</span>`,
+			attachmentURLPrefix, parts[1], parts[2], parts[3], meta.Kind, meta.Size)
+	})
+}
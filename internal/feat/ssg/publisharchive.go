@@ -0,0 +1,263 @@
+package ssg
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/hermesgen/hm"
+)
+
+// Archive formats supported by the archive publish target.
+const (
+	ArchiveFormatTarGz = "tar.gz"
+	ArchiveFormatZip   = "zip"
+)
+
+// archivePublisher packs the generated site into a tar.gz or zip file.
+type archivePublisher struct {
+	hm.Core
+}
+
+// NewArchivePublisher creates a Publisher that writes the site as an archive into cfg.TargetPath.
+func NewArchivePublisher(params hm.XParams) *archivePublisher {
+	return &archivePublisher{
+		Core: hm.NewCore("ssg-archive-pub", params),
+	}
+}
+
+func (p *archivePublisher) Validate(cfg PublisherConfig) error {
+	if cfg.TargetPath == "" {
+		return fmt.Errorf("target path cannot be empty")
+	}
+
+	switch archiveFormat(cfg) {
+	case ArchiveFormatTarGz, ArchiveFormatZip:
+		return nil
+	default:
+		return fmt.Errorf("unsupported archive format %q (use %s or %s)", cfg.ArchiveFormat, ArchiveFormatTarGz, ArchiveFormatZip)
+	}
+}
+
+// Publish writes a new archive named after the site and the current time and
+// returns its path. Previous archives are kept.
+func (p *archivePublisher) Publish(ctx context.Context, cfg PublisherConfig, sourceDir string) (string, PlanReport, error) {
+	files, err := listFiles(sourceDir)
+	if err != nil {
+		return "", PlanReport{}, err
+	}
+
+	if err := os.MkdirAll(cfg.TargetPath, 0755); err != nil {
+		return "", PlanReport{}, fmt.Errorf("cannot create target dir: %w", err)
+	}
+
+	name := "site"
+	if slug, ok := GetSiteSlugFromContext(ctx); ok && slug != "" {
+		name = slug
+	}
+	format := archiveFormat(cfg)
+	archivePath := filepath.Join(cfg.TargetPath, fmt.Sprintf("%s-%s.%s", name, time.Now().Format("20060102-150405"), format))
+
+	tmp, err := os.CreateTemp(cfg.TargetPath, ".clio-archive-*")
+	if err != nil {
+		return "", PlanReport{}, fmt.Errorf("cannot create archive: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if format == ArchiveFormatZip {
+		err = writeZip(tmp, sourceDir, sortedKeys(files))
+	} else {
+		err = writeTarGz(tmp, sourceDir, sortedKeys(files))
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", PlanReport{}, fmt.Errorf("cannot write archive: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), archivePath); err != nil {
+		return "", PlanReport{}, fmt.Errorf("cannot move archive into place: %w", err)
+	}
+
+	p.Log().Info("Archive publish completed", "path", archivePath, "files", len(files))
+	return archivePath, archiveReport(files), nil
+}
+
+// Plan lists every file of the site as added, since each publish produces a
+// complete, new archive.
+func (p *archivePublisher) Plan(ctx context.Context, cfg PublisherConfig, sourceDir string) (PlanReport, error) {
+	files, err := listFiles(sourceDir)
+	if err != nil {
+		return PlanReport{}, err
+	}
+
+	report := archiveReport(files)
+	if err := planChanges(&report, sourceDir, ""); err != nil {
+		return PlanReport{}, err
+	}
+	return report, nil
+}
+
+// archiveReport lists files as added, an archive always holds the whole site.
+func archiveReport(files map[string]bool) PlanReport {
+	report := PlanReport{Added: sortedKeys(files)}
+	report.Summary = fmt.Sprintf("Added: %d, Modified: 0, Removed: 0", len(report.Added))
+	return report
+}
+
+func archiveFormat(cfg PublisherConfig) string {
+	if cfg.ArchiveFormat == "" {
+		return ArchiveFormatTarGz
+	}
+	return cfg.ArchiveFormat
+}
+
+func writeTarGz(w io.Writer, sourceDir string, files []string) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	for _, rel := range files {
+		path := filepath.Join(sourceDir, filepath.FromSlash(rel))
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = rel
+
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if err := copyInto(tw, path); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+func writeZip(w io.Writer, sourceDir string, files []string) error {
+	zw := zip.NewWriter(w)
+
+	for _, rel := range files {
+		path := filepath.Join(sourceDir, filepath.FromSlash(rel))
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+
+		header, err := zip.FileInfoHeader(info)
+		if err != nil {
+			return err
+		}
+		header.Name = rel
+		header.Method = zip.Deflate
+
+		fw, err := zw.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+		if err := copyInto(fw, path); err != nil {
+			return err
+		}
+	}
+
+	return zw.Close()
+}
+
+func copyInto(w io.Writer, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = io.Copy(w, f)
+	return err
+}
+
+// writeSnapshot stores the whole of sourceDir as a tar.gz file at path.
+func writeSnapshot(path, sourceDir string) error {
+	files, err := listFiles(sourceDir)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("cannot create snapshot dir: %w", err)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("cannot create snapshot: %w", err)
+	}
+
+	if err := writeTarGz(f, sourceDir, sortedKeys(files)); err != nil {
+		f.Close()
+		os.Remove(path)
+		return fmt.Errorf("cannot write snapshot: %w", err)
+	}
+	return f.Close()
+}
+
+// extractSnapshot unpacks a snapshot written by writeSnapshot into dst.
+func extractSnapshot(path, dst string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("cannot open snapshot: %w", err)
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return fmt.Errorf("cannot read snapshot: %w", err)
+	}
+	tr := tar.NewReader(gz)
+
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("cannot read snapshot: %w", err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		target := filepath.Join(dst, filepath.FromSlash(header.Name))
+		if !isWithin(target, dst) {
+			return fmt.Errorf("invalid path in snapshot: %s", header.Name)
+		}
+
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(header.Mode).Perm())
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(out, tr); err != nil {
+			out.Close()
+			return err
+		}
+		if err := out.Close(); err != nil {
+			return err
+		}
+	}
+}
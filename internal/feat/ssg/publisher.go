@@ -13,11 +13,16 @@ import (
 
 // PublisherConfig holds all configuration needed for a publishing operation.
 type PublisherConfig struct {
-	RepoURL      string // Full URL to the GitHub repository
-	Branch       string // Target branch for publishing (e.g., "gh-pages")
-	PagesSubdir  string // Subdirectory within the repo (e.g., "" for root, "docs")
-	Auth         hm.GitAuth
-	CommitAuthor hm.GitCommit
+	Target        string   // Publish target type (github, git, local, archive), github if empty
+	TargetPath    string   // Directory used by the local and archive targets
+	ArchiveFormat string   // Archive format for the archive target (tar.gz or zip)
+	RepoURL       string   // Full URL to the git repository (https, ssh or file://)
+	Branch        string   // Target branch for publishing (e.g., "gh-pages")
+	PagesSubdir   string   // Subdirectory within the repo (e.g., "" for root, "docs")
+	SSHKeyPath    string   // Private key for ssh auth, ssh defaults if empty
+	Preserve      []string // Paths in the target, relative to the site root, that a publish never deletes
+	Auth          hm.GitAuth
+	CommitAuthor  hm.GitCommit
 }
 
 // Publisher defines the interface for orchestrating the publishing process.
@@ -26,31 +31,38 @@ type Publisher interface {
 	Validate(cfg PublisherConfig) error
 
 	// Publish takes the source directory (containing generated HTML) and publishes it
-	// to the configured GitHub repo.
-	Publish(ctx context.Context, cfg PublisherConfig, sourceDir string) (commitURL string, err error)
+	// to the configured target. It returns a reference to the published result
+	// (a commit URL or hash, a directory or an archive path) and the changes it
+	// made to the target.
+	Publish(ctx context.Context, cfg PublisherConfig, sourceDir string) (commitURL string, report PlanReport, err error)
 
 	// Plan performs a dry-run, showing what changes would be made without
 	// actually pushing to the remote.
 	Plan(ctx context.Context, cfg PublisherConfig, sourceDir string) (PlanReport, error)
 }
 
+// PlanReport lists the changes a publish would make, relative to the root of
+// the published site.
 type PlanReport struct {
-	Added    []string
-	Modified []string
-	Removed  []string
-	Summary  string
+	Added    []string     `json:"added"`
+	Modified []string     `json:"modified"`
+	Removed  []string     `json:"removed"`
+	Summary  string       `json:"summary"`
+	Changes  []PlanChange `json:"changes"`
 }
 
 type publisher struct {
 	hm.Core
-	gitClient hm.GitClient
+	gitClient        hm.GitClient
+	credentialHelper string
 }
 
 // NewPublisherWithParams creates a Publisher with XParams.
 func NewPublisher(gitClient hm.GitClient, params hm.XParams) *publisher {
 	return &publisher{
-		Core:      hm.NewCore("ssg-pub", params),
-		gitClient: gitClient,
+		Core:             hm.NewCore("ssg-pub", params),
+		gitClient:        gitClient,
+		credentialHelper: gitCredentialHelper(),
 	}
 }
 
@@ -65,93 +77,90 @@ func (p *publisher) Validate(cfg PublisherConfig) error {
 		return fmt.Errorf("publish branch cannot be empty")
 	}
 
+	isHTTP := strings.HasPrefix(cfg.RepoURL, "https://") || strings.HasPrefix(cfg.RepoURL, "http://")
+	if cfg.Auth.Method == hm.AuthToken && !isHTTP {
+		return fmt.Errorf("token auth needs an http(s) repo URL, use ssh auth for %s", redact(cfg.RepoURL))
+	}
+
+	if cfg.Auth.Method == hm.AuthSSH {
+		if isHTTP {
+			return fmt.Errorf("ssh auth needs an ssh repo URL, got %s", redact(cfg.RepoURL))
+		}
+		if cfg.SSHKeyPath != "" {
+			if _, err := os.Stat(cfg.SSHKeyPath); err != nil {
+				return fmt.Errorf("cannot access ssh key: %w", err)
+			}
+		}
+	}
+
 	return nil
 }
 
-func (p *publisher) Publish(ctx context.Context, cfg PublisherConfig, sourceDir string) (commitURL string, err error) {
+func (p *publisher) Publish(ctx context.Context, cfg PublisherConfig, sourceDir string) (commitURL string, report PlanReport, err error) {
 	p.Log().Info("Starting publish process")
+	defer func() { err = redactError(err, cfg.Auth.Token) }()
 
 	// Temp dir for the publisher's work
 	parentTempDir, err := os.MkdirTemp("", "clio-publish-parent-*")
 	if err != nil {
-		return "", fmt.Errorf("cannot create parent temp dir: %w", err)
+		return "", PlanReport{}, fmt.Errorf("cannot create parent temp dir: %w", err)
 	}
 	defer os.RemoveAll(parentTempDir)
 
 	// The actual repository will be cloned into a subdirectory
 	tempDir := filepath.Join(parentTempDir, "repo")
 
-	// NOTE: This is a temporary hack and we need to get rid of it, but for now
-	// it does the trick.
-	// Create a temporary script to provide the GitHub token for the publisher's git operations
-	askpassScriptPath := filepath.Join(parentTempDir, "git-askpass.sh")
-	err = os.WriteFile(askpassScriptPath, []byte(fmt.Sprintf("#!/bin/sh\necho %s", cfg.Auth.Token)), 0700)
+	env, auth, err := p.gitEnv(cfg)
 	if err != nil {
-		return "", fmt.Errorf("cannot create askpass script: %w", err)
+		return "", PlanReport{}, err
 	}
 
-	// Set GIT_ASKPASS environment variable for all git commands in tempDir
-	env := os.Environ()
-	env = append(env, "GIT_ASKPASS="+askpassScriptPath)
-
-	if err := p.gitClient.Clone(ctx, cfg.RepoURL, tempDir, cfg.Auth, env); err != nil {
-		return "", fmt.Errorf("cannot clone repo: %w", err)
+	if err := p.gitClient.Clone(ctx, cfg.RepoURL, tempDir, auth, env); err != nil {
+		return "", PlanReport{}, fmt.Errorf("cannot clone repo: %w", err)
 	}
 	p.Log().Info("Repo cloned")
 
 	// Checkout target branch
-	if err := p.gitClient.Checkout(ctx, tempDir, cfg.Branch, false, env); err != nil {
-		return "", fmt.Errorf("cannot checkout branch: %w", err)
+	if err := p.checkout(ctx, tempDir, cfg.Branch, env); err != nil {
+		return "", PlanReport{}, fmt.Errorf("cannot checkout branch: %w", err)
 	}
 	p.Log().Info("Checked out branch", "branch", cfg.Branch)
 
 	// Clean and copy source dir content
 	targetDir := filepath.Join(tempDir, cfg.PagesSubdir)
-	p.Log().Info("Cleaning target directory", "path", targetDir)
-
-	if cfg.PagesSubdir == "" {
-		// Remove all contents except .git
-		dirs, err := os.ReadDir(tempDir)
-		if err != nil {
-			return "", fmt.Errorf("cannot read temp dir: %w", err)
-		}
+	p.Log().Info("Cleaning target directory", "path", targetDir, "preserve", cfg.Preserve)
 
-		for _, d := range dirs {
-			if d.Name() == ".git" {
-				continue
-			}
-			if err := os.RemoveAll(filepath.Join(tempDir, d.Name())); err != nil {
-				return "", fmt.Errorf("cannot remove %s from temp dir: %w", d.Name(), err)
-			}
-		}
+	if err := os.MkdirAll(targetDir, 0755); err != nil {
+		return "", PlanReport{}, fmt.Errorf("cannot create target dir: %w", err)
+	}
 
-	} else {
-		// When publishing to a subdirectory we remove the subdirectory
-		if err := os.RemoveAll(targetDir); err != nil {
-			return "", fmt.Errorf("cannot clean target dir: %w", err)
-		}
+	report, err = diffDirs(sourceDir, targetDir, cfg.Preserve)
+	if err != nil {
+		return "", PlanReport{}, fmt.Errorf("cannot compare site with published branch: %w", err)
+	}
 
-		if err := os.MkdirAll(targetDir, 0755); err != nil {
-			return "", fmt.Errorf("cannot create target dir: %w", err)
-		}
+	// Remove everything but .git and the preserved paths, so files dropped
+	// from the site are deleted from the branch too
+	if err := cleanTarget(targetDir, cfg.Preserve); err != nil {
+		return "", PlanReport{}, fmt.Errorf("cannot clean target dir: %w", err)
 	}
 
 	p.Log().Info("Copying generated site to target directory")
 	if err := copyDir(sourceDir, targetDir); err != nil {
-		return "", fmt.Errorf("cannot copy site content: %w", err)
+		return "", PlanReport{}, fmt.Errorf("cannot copy site content: %w", err)
 	}
 
 	// Stage
 	p.Log().Info("Staging changes")
 	if err := p.gitClient.Add(ctx, tempDir, ".", env); err != nil {
-		return "", fmt.Errorf("cannot stage changes: %w", err)
+		return "", PlanReport{}, fmt.Errorf("cannot stage changes: %w", err)
 	}
 
 	// Commit
 	p.Log().Info("Committing changes")
 	commitHash, err := p.gitClient.Commit(ctx, tempDir, cfg.CommitAuthor, env)
 	if err != nil {
-		return "", fmt.Errorf("cannot commit changes: %w", err)
+		return "", PlanReport{}, fmt.Errorf("cannot commit changes: %w", err)
 	}
 	p.Log().Info("Changes committed", "hash", commitHash)
 
@@ -171,22 +180,54 @@ func (p *publisher) Publish(ctx context.Context, cfg PublisherConfig, sourceDir
 
 	// Push
 	p.Log().Info("Pushing changes to remote")
-	if err := p.gitClient.Push(ctx, tempDir, cfg.Auth, "origin", cfg.Branch, env); err != nil {
-		return "", fmt.Errorf("cannot push changes: %w", err)
+	if err := p.gitClient.Push(ctx, tempDir, auth, "origin", cfg.Branch, env); err != nil {
+		return "", PlanReport{}, fmt.Errorf("cannot push changes: %w", err)
 	}
 
 	// // NOTE: We need to find a neater way to do this
-	commitURL = fmt.Sprintf("%s/commit/%s", cfg.RepoURL, commitHash)
+	commitURL = fmt.Sprintf("%s/commit/%s", redact(cfg.RepoURL), commitHash)
+	if cfg.Target == PublishTargetGit {
+		// Arbitrary remotes have no web view to link to
+		commitURL = commitHash
+	}
 	p.Log().Info("Publish process completed successfully", "commit_url", commitURL)
 
-	return commitURL, nil
+	return commitURL, report, nil
+}
+
+// gitEnv returns the environment for the git commands of a publish along with
+// the auth handed to the git client. Credentials travel only through the
+// environment; the client gets an empty auth so it never puts the token in a
+// command line or in the remote URL.
+func (p *publisher) gitEnv(cfg PublisherConfig) ([]string, hm.GitAuth, error) {
+	authEnv, err := gitAuthEnv(cfg, p.credentialHelper)
+	if err != nil {
+		return nil, hm.GitAuth{}, err
+	}
+
+	env := append(os.Environ(), authEnv...)
+	return env, hm.GitAuth{}, nil
+}
+
+// checkout switches to branch, creating it when the remote does not have it
+// yet (e.g. a freshly created bare repository).
+func (p *publisher) checkout(ctx context.Context, repoDir, branch string, env []string) error {
+	err := p.gitClient.Checkout(ctx, repoDir, branch, false, env)
+	if err == nil {
+		return nil
+	}
+
+	p.Log().Info("Branch not found, creating it", "branch", branch)
+	if createErr := p.gitClient.Checkout(ctx, repoDir, branch, true, env); createErr != nil {
+		return fmt.Errorf("%w (create: %v)", err, createErr)
+	}
+	return nil
 }
 
 // Plan implementation
-func (p *publisher) Plan(ctx context.Context, cfg PublisherConfig, sourceDir string) (PlanReport, error) {
+func (p *publisher) Plan(ctx context.Context, cfg PublisherConfig, sourceDir string) (report PlanReport, err error) {
 	p.Log().Info("Starting plan dry-run process")
-
-	var report PlanReport
+	defer func() { err = redactError(err, cfg.Auth.Token) }()
 
 	parentTempDir, err := os.MkdirTemp("", "clio-plan-parent-*")
 	if err != nil {
@@ -196,72 +237,28 @@ func (p *publisher) Plan(ctx context.Context, cfg PublisherConfig, sourceDir str
 
 	tempDir := filepath.Join(parentTempDir, "repo") // Git will create this
 
-	askpassScriptPath := filepath.Join(parentTempDir, "git-askpass.sh")
-	err = os.WriteFile(askpassScriptPath, []byte(fmt.Sprintf("#!/bin/sh\necho %s", cfg.Auth.Token)), 0700)
+	env, auth, err := p.gitEnv(cfg)
 	if err != nil {
-		return PlanReport{}, fmt.Errorf("cannot create askpass script for plan: %w", err)
+		return PlanReport{}, err
 	}
 
-	env := os.Environ()
-	env = append(env, "GIT_ASKPASS="+askpassScriptPath)
-
-	if err := p.gitClient.Clone(ctx, cfg.RepoURL, tempDir, cfg.Auth, env); err != nil {
+	if err := p.gitClient.Clone(ctx, cfg.RepoURL, tempDir, auth, env); err != nil {
 		return PlanReport{}, fmt.Errorf("cannot clone repo for plan: %w", err)
 	}
 	p.Log().Info("Repo cloned for plan")
 
-	if err := p.gitClient.Checkout(ctx, tempDir, cfg.Branch, false, env); err != nil {
+	if err := p.checkout(ctx, tempDir, cfg.Branch, env); err != nil {
 		return PlanReport{}, fmt.Errorf("cannot checkout branch for plan: %w", err)
 	}
 	p.Log().Info("Checked out branch for plan", "branch", cfg.Branch)
 
+	// Compare against the published tree, the clone is discarded afterwards
 	targetDir := filepath.Join(tempDir, cfg.PagesSubdir)
-	p.Log().Info("Cleaning target directory for plan", "path", targetDir)
-	if err := os.RemoveAll(targetDir); err != nil {
-		return PlanReport{}, fmt.Errorf("cannot clean target dir for plan: %w", err)
-	}
-
-	if err := os.MkdirAll(targetDir, 0755); err != nil {
-		return PlanReport{}, fmt.Errorf("cannot create target dir for plan: %w", err)
-	}
-
-	p.Log().Info("Copying generated site to target directory for plan")
-	if err := copyDir(sourceDir, targetDir); err != nil {
-		return PlanReport{}, fmt.Errorf("cannot copy site content for plan: %w", err)
-	}
-
-	p.Log().Info("Staging changes for plan")
-	if err := p.gitClient.Add(ctx, tempDir, ".", env); err != nil {
-		return PlanReport{}, fmt.Errorf("cannot stage changes for plan: %w", err)
-	}
-
-	p.Log().Info("Getting git status for plan")
-	statusOutput, err := p.gitClient.Status(ctx, tempDir, env)
+	report, err = diffDirs(sourceDir, targetDir, cfg.Preserve)
 	if err != nil {
-		return PlanReport{}, fmt.Errorf("cannot get git status for plan: %w", err)
-	}
-
-	lines := strings.Split(statusOutput, "\n")
-	for _, line := range lines {
-		if len(line) < 3 {
-			continue
-		}
-		status := line[0:2]
-		filename := strings.TrimSpace(line[3:])
-
-		switch status {
-		case "A ":
-			report.Added = append(report.Added, filename)
-		case "M ":
-			report.Modified = append(report.Modified, filename)
-		case "D ":
-			report.Removed = append(report.Removed, filename)
-		case "??":
-			report.Added = append(report.Added, filename)
-		}
+		return PlanReport{}, fmt.Errorf("cannot compare site with published branch: %w", err)
 	}
 
-	report.Summary = fmt.Sprintf("Added: %d, Modified: %d, Removed: %d", len(report.Added), len(report.Modified), len(report.Removed))
 	p.Log().Info("Plan dry-run process completed successfully", "summary", report.Summary)
 
 	return report, nil
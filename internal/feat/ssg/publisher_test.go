@@ -119,7 +119,7 @@ func TestPublisherPublishFakeClient(t *testing.T) {
 			params := hm.XParams{Log: hm.NewLogger("debug")}
 			publisher := ssg.NewPublisher(tt.gitClient, params)
 
-			_, err = publisher.Publish(context.Background(), tt.config, sourceDir)
+			_, _, err = publisher.Publish(context.Background(), tt.config, sourceDir)
 
 			if tt.expectedError != nil {
 				if err == nil || !strings.Contains(err.Error(), tt.expectedError.Error()) {
@@ -154,3 +154,46 @@ func TestPublisherPublishFakeClient(t *testing.T) {
 		})
 	}
 }
+
+func TestPublisherKeepsTokenOutOfClientAndErrors(t *testing.T) {
+	const token = "ghp_secret$token"
+
+	sourceDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(sourceDir, "index.html"), []byte("<html></html>"), 0644); err != nil {
+		t.Fatalf("cannot write source file: %v", err)
+	}
+
+	gitClient := &fake.GithubClient{
+		CloneFn: func(ctx context.Context, repoURL, localPath string, auth hm.GitAuth, env []string) error {
+			return errors.New("fatal: could not read from https://oauth2:" + token + "@github.com/test/repo.git")
+		},
+	}
+
+	cfg := ssg.PublisherConfig{
+		RepoURL: "https://github.com/test/repo.git",
+		Branch:  "gh-pages",
+		Auth:    hm.GitAuth{Method: hm.AuthToken, Token: token},
+	}
+
+	publisher := ssg.NewPublisher(gitClient, hm.XParams{Log: hm.NewLogger("error")})
+	_, _, err := publisher.Publish(context.Background(), cfg, sourceDir)
+	if err == nil {
+		t.Fatal("expected clone error")
+	}
+	if strings.Contains(err.Error(), token) {
+		t.Errorf("error leaks the token: %v", err)
+	}
+
+	if len(gitClient.CloneCalls) != 1 {
+		t.Fatalf("expected 1 Clone call, got %d", len(gitClient.CloneCalls))
+	}
+	call := gitClient.CloneCalls[0]
+	if call.Auth.Token != "" || strings.Contains(call.RepoURL, token) {
+		t.Errorf("token passed to the git client: auth %+v, url %s", call.Auth, call.RepoURL)
+	}
+	for _, e := range call.Env {
+		if strings.HasPrefix(e, "GIT_ASKPASS=") {
+			t.Errorf("unexpected askpass in env: %s", e)
+		}
+	}
+}
@@ -259,7 +259,7 @@ func TestPublisherIntegration(t *testing.T) {
 				},
 			}
 
-			_, err = publisher.Publish(context.Background(), pubCfg, sourceDir)
+			_, _, err = publisher.Publish(context.Background(), pubCfg, sourceDir)
 
 			if tt.expectedError != nil {
 				require.Error(t, err)
@@ -0,0 +1,142 @@
+package ssg
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/google/uuid"
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+const (
+	PlanStatusAdded    = "added"
+	PlanStatusModified = "modified"
+	PlanStatusRemoved  = "removed"
+)
+
+// maxDiffSize is the largest file, in bytes, for which a plan includes a diff.
+const maxDiffSize = 512 * 1024
+
+var diffableExts = map[string]bool{
+	".html": true,
+	".htm":  true,
+	".css":  true,
+	".js":   true,
+	".json": true,
+	".xml":  true,
+	".txt":  true,
+	".md":   true,
+	".svg":  true,
+}
+
+// PlanChange is a file that a publish would add, modify or remove. HTML pages
+// generated from a content carry its ID and heading.
+type PlanChange struct {
+	Path           string    `json:"path"`
+	Status         string    `json:"status"`
+	ContentID      uuid.UUID `json:"content_id,omitempty"`
+	ContentHeading string    `json:"content_heading,omitempty"`
+	Diff           string    `json:"diff,omitempty"`
+}
+
+// IsPage reports whether the change is an HTML page.
+func (c PlanChange) IsPage() bool {
+	ext := path.Ext(c.Path)
+	return ext == ".html" || ext == ".htm"
+}
+
+// planChanges fills report.Changes from its file lists. Modified text files
+// get a unified diff between targetDir (published) and sourceDir (generated).
+func planChanges(report *PlanReport, sourceDir, targetDir string) error {
+	report.Changes = nil
+
+	for _, rel := range report.Added {
+		report.Changes = append(report.Changes, PlanChange{Path: rel, Status: PlanStatusAdded})
+	}
+
+	for _, rel := range report.Modified {
+		diff, err := fileDiff(rel, filepath.Join(targetDir, rel), filepath.Join(sourceDir, rel))
+		if err != nil {
+			return err
+		}
+		report.Changes = append(report.Changes, PlanChange{Path: rel, Status: PlanStatusModified, Diff: diff})
+	}
+
+	for _, rel := range report.Removed {
+		report.Changes = append(report.Changes, PlanChange{Path: rel, Status: PlanStatusRemoved})
+	}
+
+	return nil
+}
+
+// fileDiff returns a unified diff from oldPath to newPath, or an empty string
+// for binary or oversized files.
+func fileDiff(rel, oldPath, newPath string) (string, error) {
+	if !diffableExts[path.Ext(rel)] {
+		return "", nil
+	}
+
+	oldData, err := readDiffable(oldPath)
+	if err != nil || oldData == nil {
+		return "", err
+	}
+	newData, err := readDiffable(newPath)
+	if err != nil || newData == nil {
+		return "", err
+	}
+
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(string(oldData)),
+		B:        difflib.SplitLines(string(newData)),
+		FromFile: "a/" + rel,
+		ToFile:   "b/" + rel,
+		Context:  3,
+	})
+	if err != nil {
+		return "", fmt.Errorf("cannot diff %s: %w", rel, err)
+	}
+	return diff, nil
+}
+
+// readDiffable reads a file for diffing, returning nil if it is too large or
+// does not look like text.
+func readDiffable(p string) ([]byte, error) {
+	info, err := os.Stat(p)
+	if err != nil {
+		return nil, fmt.Errorf("cannot stat %s: %w", p, err)
+	}
+	if info.Size() > maxDiffSize {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(p)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read %s: %w", p, err)
+	}
+	if bytes.IndexByte(data, 0) >= 0 {
+		return nil, nil
+	}
+	return data, nil
+}
+
+// mapPlanToContents links the page changes of report to the contents that
+// generate them.
+func mapPlanToContents(report *PlanReport, contents []Content, siteMode string) {
+	pages := make(map[string]Content, len(contents))
+	for _, content := range contents {
+		rel := filepath.ToSlash(GetContentFilePath("", content, siteMode))
+		pages[rel] = content
+	}
+
+	for i, change := range report.Changes {
+		content, ok := pages[change.Path]
+		if !ok {
+			continue
+		}
+		report.Changes[i].ContentID = content.ID
+		report.Changes[i].ContentHeading = content.Heading
+	}
+}
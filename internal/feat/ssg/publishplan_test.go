@@ -0,0 +1,81 @@
+package ssg
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestPlanChangesDiffsOnlyText(t *testing.T) {
+	sourceDir := t.TempDir()
+	targetDir := t.TempDir()
+
+	files := map[string][2]string{
+		"index.html":     {"<h1>Old</h1>\n", "<h1>New</h1>\n"},
+		"static/img.png": {"\x89PNG\x00old", "\x89PNG\x00new"},
+	}
+	for rel, data := range files {
+		for i, dir := range []string{targetDir, sourceDir} {
+			path := filepath.Join(dir, rel)
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, []byte(data[i]), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	report := PlanReport{Modified: []string{"index.html", "static/img.png"}}
+	if err := planChanges(&report, sourceDir, targetDir); err != nil {
+		t.Fatalf("planChanges() error = %v", err)
+	}
+
+	if len(report.Changes) != 2 {
+		t.Fatalf("Changes = %+v, want 2 entries", report.Changes)
+	}
+
+	html := report.Changes[0]
+	if !strings.Contains(html.Diff, "--- a/index.html") || !strings.Contains(html.Diff, "-<h1>Old</h1>") || !strings.Contains(html.Diff, "+<h1>New</h1>") {
+		t.Errorf("index.html diff = %q", html.Diff)
+	}
+	if img := report.Changes[1]; img.Diff != "" {
+		t.Errorf("binary file should have no diff, got %q", img.Diff)
+	}
+}
+
+func TestMapPlanToContents(t *testing.T) {
+	postID := uuid.New()
+	pageID := uuid.New()
+	contents := []Content{
+		{ID: postID, Heading: "First Post", SectionPath: "blog", ShortID: "a1b2c3"},
+		{ID: pageID, Heading: "About", SectionPath: "", ShortID: "d4e5f6"},
+	}
+
+	report := PlanReport{Changes: []PlanChange{
+		{Path: "blog/first-post-a1b2c3/index.html", Status: PlanStatusModified},
+		{Path: "about-d4e5f6/index.html", Status: PlanStatusAdded},
+		{Path: "index.html", Status: PlanStatusModified},
+		{Path: "css/main.css", Status: PlanStatusModified},
+	}}
+
+	mapPlanToContents(&report, contents, "structured")
+
+	if c := report.Changes[0]; c.ContentID != postID || c.ContentHeading != "First Post" {
+		t.Errorf("post change = %+v", c)
+	}
+	if c := report.Changes[1]; c.ContentID != pageID {
+		t.Errorf("page change = %+v", c)
+	}
+	for _, c := range report.Changes[2:] {
+		if c.ContentID != uuid.Nil {
+			t.Errorf("%s should not map to a content", c.Path)
+		}
+	}
+	if !report.Changes[2].IsPage() || report.Changes[3].IsPage() {
+		t.Error("IsPage() should only match HTML files")
+	}
+}
@@ -0,0 +1,71 @@
+package ssg
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	PublishStatusSuccess = "success"
+	PublishStatusFailure = "failure"
+)
+
+// PublishRecord is an entry of the publish history of a site. Successful
+// publishes keep a snapshot of their output so they can be published again.
+type PublishRecord struct {
+	ID           uuid.UUID `json:"id" db:"id"`
+	SiteID       uuid.UUID `json:"site_id" db:"site_id"`
+	Target       string    `json:"target" db:"target"`
+	Status       string    `json:"status" db:"status"`
+	Reference    string    `json:"reference" db:"reference"`
+	CommitHash   string    `json:"commit_hash" db:"commit_hash"`
+	Summary      string    `json:"summary" db:"summary"`
+	Error        string    `json:"error" db:"error"`
+	DurationMS   int64     `json:"duration_ms" db:"duration_ms"`
+	SnapshotPath string    `json:"snapshot_path" db:"snapshot_path"`
+	RollbackOf   uuid.UUID `json:"rollback_of" db:"rollback_of"`
+	PublishedBy  string    `json:"published_by" db:"published_by"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+}
+
+// NewPublishRecord creates a history entry for a publish to target started now.
+func NewPublishRecord(siteID uuid.UUID, target, publishedBy string) *PublishRecord {
+	return &PublishRecord{
+		ID:          uuid.New(),
+		SiteID:      siteID,
+		Target:      target,
+		PublishedBy: publishedBy,
+		CreatedAt:   time.Now(),
+	}
+}
+
+// CanRollback reports whether the output of this publish can be published again.
+func (r PublishRecord) CanRollback() bool {
+	return r.Status == PublishStatusSuccess && r.SnapshotPath != ""
+}
+
+// IsRollback reports whether this publish re-published a previous one.
+func (r PublishRecord) IsRollback() bool {
+	return r.RollbackOf != uuid.Nil
+}
+
+// Duration returns how long the publish took.
+func (r PublishRecord) Duration() time.Duration {
+	return time.Duration(r.DurationMS) * time.Millisecond
+}
+
+// commitHashFrom extracts the commit hash from the reference returned by a
+// publish to target, empty for targets that do not commit.
+func commitHashFrom(target, ref string) string {
+	switch target {
+	case PublishTargetGitHub:
+		if i := strings.LastIndex(ref, "/commit/"); i >= 0 {
+			return ref[i+len("/commit/"):]
+		}
+	case PublishTargetGit:
+		return ref
+	}
+	return ""
+}
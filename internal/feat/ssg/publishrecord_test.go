@@ -0,0 +1,201 @@
+package ssg
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/hermesgen/clio/internal/feat/auth"
+	"github.com/hermesgen/hm"
+)
+
+type fakePublishRepo struct {
+	fakeParamRepo
+	users   map[uuid.UUID]auth.User
+	records []PublishRecord
+}
+
+func (f *fakePublishRepo) GetUser(ctx context.Context, id uuid.UUID) (auth.User, error) {
+	u, ok := f.users[id]
+	if !ok {
+		return auth.User{}, errors.New("user not found")
+	}
+	return u, nil
+}
+
+func (f *fakePublishRepo) CreatePublishRecord(ctx context.Context, record *PublishRecord) error {
+	f.records = append(f.records, *record)
+	return nil
+}
+
+func (f *fakePublishRepo) ListPublishRecords(ctx context.Context, siteID uuid.UUID) ([]PublishRecord, error) {
+	return f.records, nil
+}
+
+// planCountingPublisher publishes nothing and counts the plans asked for.
+type planCountingPublisher struct {
+	plans int
+}
+
+func (p *planCountingPublisher) Validate(cfg PublisherConfig) error {
+	return nil
+}
+
+func (p *planCountingPublisher) Publish(ctx context.Context, cfg PublisherConfig, sourceDir string) (string, PlanReport, error) {
+	return "abc123", PlanReport{Summary: "Added: 1, Modified: 0, Removed: 0"}, nil
+}
+
+func (p *planCountingPublisher) Plan(ctx context.Context, cfg PublisherConfig, sourceDir string) (PlanReport, error) {
+	p.plans++
+	return PlanReport{}, nil
+}
+
+func TestCommitHashFrom(t *testing.T) {
+	tests := []struct {
+		target string
+		ref    string
+		want   string
+	}{
+		{PublishTargetGitHub, "https://github.com/a/b.git/commit/abc123", "abc123"},
+		{PublishTargetGit, "def456", "def456"},
+		{PublishTargetLocal, "/var/www/site", ""},
+		{PublishTargetArchive, "/tmp/site-1.tar.gz", ""},
+	}
+
+	for _, tt := range tests {
+		if got := commitHashFrom(tt.target, tt.ref); got != tt.want {
+			t.Errorf("commitHashFrom(%q, %q) = %q, want %q", tt.target, tt.ref, got, tt.want)
+		}
+	}
+}
+
+func TestPublishRecordCanRollback(t *testing.T) {
+	record := NewPublishRecord(uuid.New(), PublishTargetGitHub, "Clio")
+	if record.CanRollback() || record.IsRollback() {
+		t.Error("new record should neither allow nor be a rollback")
+	}
+
+	record.Status = PublishStatusSuccess
+	record.SnapshotPath = record.ID.String() + ".tar.gz"
+	if !record.CanRollback() {
+		t.Error("successful record with snapshot should allow rollback")
+	}
+
+	record.Status = PublishStatusFailure
+	if record.CanRollback() {
+		t.Error("failed record should not allow rollback")
+	}
+}
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	sourceDir := t.TempDir()
+	files := map[string]string{
+		"index.html":           "home",
+		"blog/post/index.html": "post",
+		"static/css/main.css":  "body {}",
+	}
+	for rel, data := range files {
+		path := filepath.Join(sourceDir, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	snapshot := filepath.Join(t.TempDir(), "snapshots", "one.tar.gz")
+	if err := writeSnapshot(snapshot, sourceDir); err != nil {
+		t.Fatalf("writeSnapshot() error = %v", err)
+	}
+
+	dst := t.TempDir()
+	if err := extractSnapshot(snapshot, dst); err != nil {
+		t.Fatalf("extractSnapshot() error = %v", err)
+	}
+
+	for rel, want := range files {
+		got, err := os.ReadFile(filepath.Join(dst, rel))
+		if err != nil {
+			t.Errorf("missing %s: %v", rel, err)
+			continue
+		}
+		if string(got) != want {
+			t.Errorf("%s = %q, want %q", rel, got, want)
+		}
+	}
+}
+
+func TestExtractSnapshotRejectsEscapingPaths(t *testing.T) {
+	snapshot := filepath.Join(t.TempDir(), "evil.tar.gz")
+	f, err := os.Create(snapshot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	data := []byte("x")
+	if err := tw.WriteHeader(&tar.Header{Name: "../evil.html", Mode: 0644, Size: int64(len(data)), Typeflag: tar.TypeReg}); err != nil {
+		t.Fatal(err)
+	}
+	tw.Write(data)
+	tw.Close()
+	gz.Close()
+	f.Close()
+
+	if err := extractSnapshot(snapshot, t.TempDir()); err == nil {
+		t.Error("extractSnapshot() expected error for path outside destination")
+	}
+}
+
+func TestPublishAndRecord(t *testing.T) {
+	siteID := uuid.New()
+	userID := uuid.New()
+	repo := &fakePublishRepo{
+		fakeParamRepo: fakeParamRepo{params: map[string]Param{}},
+		users:         map[uuid.UUID]auth.User{userID: {ID: userID, Username: "jdoe", Name: "Jane Doe"}},
+	}
+
+	cfg := hm.NewConfig()
+	cfg.Set(SSGKey.SitesBasePath, t.TempDir())
+	params := hm.XParams{Cfg: cfg, Log: hm.NewLogger("error")}
+	pub := &planCountingPublisher{}
+	svc := &BaseService{Service: hm.NewService("ssg-svc", params), repo: repo, pub: pub, pm: NewParamManager(repo, params)}
+
+	ctx := context.WithValue(context.Background(), siteIDKey, siteID)
+	ctx = context.WithValue(ctx, siteSlugKey, "test")
+	ctx = context.WithValue(ctx, hm.UserKey, &hm.UserCtxData{ID: userID})
+
+	sourceDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(sourceDir, "index.html"), []byte("home"), 0644); err != nil {
+		t.Fatalf("cannot write file: %v", err)
+	}
+
+	record := NewPublishRecord(siteID, PublishTargetGit, svc.actingUser(ctx))
+	if err := svc.publishAndRecord(ctx, PublisherConfig{Target: PublishTargetGit}, sourceDir, record); err != nil {
+		t.Fatalf("publishAndRecord() error = %v", err)
+	}
+
+	if pub.plans != 0 {
+		t.Errorf("Plan() called %d times, want the summary taken from the publish", pub.plans)
+	}
+	if len(repo.records) != 1 {
+		t.Fatalf("records = %d, want 1", len(repo.records))
+	}
+	got := repo.records[0]
+	if got.PublishedBy != "Jane Doe" {
+		t.Errorf("PublishedBy = %q, want %q", got.PublishedBy, "Jane Doe")
+	}
+	if got.Summary != "Added: 1, Modified: 0, Removed: 0" {
+		t.Errorf("Summary = %q, want the publish summary", got.Summary)
+	}
+
+	if by := svc.actingUser(context.Background()); by != "" {
+		t.Errorf("actingUser() without user = %q, want empty", by)
+	}
+}
@@ -0,0 +1,410 @@
+package ssg
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hermesgen/hm"
+)
+
+// Publish target types, selected per site through the ssg.publish.target param.
+const (
+	PublishTargetGitHub  = "github"
+	PublishTargetGit     = "git"
+	PublishTargetLocal   = "local"
+	PublishTargetArchive = "archive"
+)
+
+// TargetPublisher dispatches publishing to the Publisher registered for the
+// target type in the configuration.
+type TargetPublisher struct {
+	hm.Core
+	targets map[string]Publisher
+}
+
+// NewTargetPublisher creates a TargetPublisher with the given target implementations.
+func NewTargetPublisher(targets map[string]Publisher, params hm.XParams) *TargetPublisher {
+	return &TargetPublisher{
+		Core:    hm.NewCore("ssg-target-pub", params),
+		targets: targets,
+	}
+}
+
+// Targets returns the registered target types, sorted.
+func (tp *TargetPublisher) Targets() []string {
+	targets := make([]string, 0, len(tp.targets))
+	for t := range tp.targets {
+		targets = append(targets, t)
+	}
+	sort.Strings(targets)
+	return targets
+}
+
+func (tp *TargetPublisher) Validate(cfg PublisherConfig) error {
+	pub, err := tp.target(cfg)
+	if err != nil {
+		return err
+	}
+	return pub.Validate(cfg)
+}
+
+func (tp *TargetPublisher) Publish(ctx context.Context, cfg PublisherConfig, sourceDir string) (string, PlanReport, error) {
+	pub, err := tp.target(cfg)
+	if err != nil {
+		return "", PlanReport{}, err
+	}
+	tp.Log().Info("Publishing", "target", targetName(cfg))
+	return pub.Publish(ctx, cfg, sourceDir)
+}
+
+func (tp *TargetPublisher) Plan(ctx context.Context, cfg PublisherConfig, sourceDir string) (PlanReport, error) {
+	pub, err := tp.target(cfg)
+	if err != nil {
+		return PlanReport{}, err
+	}
+	return pub.Plan(ctx, cfg, sourceDir)
+}
+
+func (tp *TargetPublisher) target(cfg PublisherConfig) (Publisher, error) {
+	name := targetName(cfg)
+	pub, ok := tp.targets[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown publish target %q (available: %s)", name, strings.Join(tp.Targets(), ", "))
+	}
+	return pub, nil
+}
+
+func targetName(cfg PublisherConfig) string {
+	if cfg.Target == "" {
+		return PublishTargetGitHub
+	}
+	return cfg.Target
+}
+
+// localManifestFile is written to the target directory by a local publish.
+// It lists the files the publish wrote, the only ones a later publish removes.
+const localManifestFile = ".clio-publish.json"
+
+type localManifest struct {
+	Files []string `json:"files"`
+}
+
+// localPublisher syncs the generated site into a directory on this machine.
+type localPublisher struct {
+	hm.Core
+}
+
+// NewLocalPublisher creates a Publisher that mirrors the site into cfg.TargetPath.
+func NewLocalPublisher(params hm.XParams) *localPublisher {
+	return &localPublisher{
+		Core: hm.NewCore("ssg-local-pub", params),
+	}
+}
+
+func (p *localPublisher) Validate(cfg PublisherConfig) error {
+	if cfg.TargetPath == "" {
+		return fmt.Errorf("target path cannot be empty")
+	}
+	return nil
+}
+
+// Publish copies new and changed files into the target directory and deletes
+// the files of the previous publish that are no longer part of the site. The
+// target must be empty or hold a previous publish, so files Clio did not
+// write are never deleted. A .git directory and the preserved paths in the
+// target are left untouched.
+func (p *localPublisher) Publish(ctx context.Context, cfg PublisherConfig, sourceDir string) (string, PlanReport, error) {
+	targetDir, report, err := p.diff(cfg, sourceDir)
+	if err != nil {
+		return "", PlanReport{}, err
+	}
+
+	for _, rel := range append(report.Added, report.Modified...) {
+		dst := filepath.Join(targetDir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+			return "", PlanReport{}, fmt.Errorf("cannot create directory: %w", err)
+		}
+		if err := copyFileFromFS(filepath.Join(sourceDir, filepath.FromSlash(rel)), dst); err != nil {
+			return "", PlanReport{}, err
+		}
+	}
+
+	for _, rel := range report.Removed {
+		if err := os.Remove(filepath.Join(targetDir, filepath.FromSlash(rel))); err != nil && !os.IsNotExist(err) {
+			return "", PlanReport{}, fmt.Errorf("cannot remove %s: %w", rel, err)
+		}
+		removeEmptyParents(targetDir, rel)
+	}
+
+	if err := writeLocalManifest(targetDir, sourceDir); err != nil {
+		return "", PlanReport{}, err
+	}
+
+	p.Log().Info("Local publish completed", "path", targetDir, "summary", report.Summary)
+	return targetDir, report, nil
+}
+
+func (p *localPublisher) Plan(ctx context.Context, cfg PublisherConfig, sourceDir string) (PlanReport, error) {
+	_, report, err := p.diff(cfg, sourceDir)
+	return report, err
+}
+
+// diff compares the generated site with the target directory. Only files
+// listed in the manifest of the previous publish are reported as removed. A
+// target with files and no manifest is refused.
+func (p *localPublisher) diff(cfg PublisherConfig, sourceDir string) (string, PlanReport, error) {
+	targetDir, err := p.targetDir(cfg, sourceDir)
+	if err != nil {
+		return "", PlanReport{}, err
+	}
+
+	published, err := readLocalManifest(targetDir)
+	if err != nil {
+		return "", PlanReport{}, err
+	}
+	if published == nil {
+		existing, err := listFiles(targetDir)
+		if err != nil {
+			return "", PlanReport{}, err
+		}
+		if len(existing) > 0 {
+			return "", PlanReport{}, fmt.Errorf("target path %s is not empty and holds no previous publish", targetDir)
+		}
+		published = map[string]bool{}
+	}
+
+	report, err := diffOwnedDirs(sourceDir, targetDir, cfg.Preserve, published)
+	if err != nil {
+		return "", PlanReport{}, fmt.Errorf("cannot compare directories: %w", err)
+	}
+	return targetDir, report, nil
+}
+
+// readLocalManifest returns the files of the previous publish into targetDir,
+// or nil if there is none.
+func readLocalManifest(targetDir string) (map[string]bool, error) {
+	data, err := os.ReadFile(filepath.Join(targetDir, localManifestFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot read publish manifest: %w", err)
+	}
+
+	var manifest localManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("invalid publish manifest: %w", err)
+	}
+	files := make(map[string]bool, len(manifest.Files))
+	for _, f := range manifest.Files {
+		files[f] = true
+	}
+	return files, nil
+}
+
+// writeLocalManifest records the files of sourceDir as published in targetDir.
+func writeLocalManifest(targetDir, sourceDir string) error {
+	files, err := listFiles(sourceDir)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(localManifest{Files: sortedKeys(files)}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("cannot encode publish manifest: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(targetDir, localManifestFile), data, 0644); err != nil {
+		return fmt.Errorf("cannot write publish manifest: %w", err)
+	}
+	return nil
+}
+
+// removeEmptyParents deletes the directories of rel below root that the
+// removal of rel left empty.
+func removeEmptyParents(root, rel string) {
+	for dir := filepath.Dir(filepath.FromSlash(rel)); dir != "."; dir = filepath.Dir(dir) {
+		if err := os.Remove(filepath.Join(root, dir)); err != nil {
+			return
+		}
+	}
+}
+
+// targetDir resolves the target path and refuses to sync a directory into itself.
+func (p *localPublisher) targetDir(cfg PublisherConfig, sourceDir string) (string, error) {
+	targetDir, err := filepath.Abs(cfg.TargetPath)
+	if err != nil {
+		return "", fmt.Errorf("invalid target path: %w", err)
+	}
+	srcDir, err := filepath.Abs(sourceDir)
+	if err != nil {
+		return "", fmt.Errorf("invalid source path: %w", err)
+	}
+
+	if isWithin(targetDir, srcDir) || isWithin(srcDir, targetDir) {
+		return "", fmt.Errorf("target path %s overlaps the generated site %s", targetDir, srcDir)
+	}
+	return targetDir, nil
+}
+
+// isWithin reports whether path is dir or is inside it.
+func isWithin(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// diffDirs compares the files of sourceDir with those of targetDir. Paths in
+// the report are relative and slash separated. A missing targetDir counts as
+// empty. Preserved target files are never reported as removed.
+func diffDirs(sourceDir, targetDir string, preserve []string) (PlanReport, error) {
+	return diffOwnedDirs(sourceDir, targetDir, preserve, nil)
+}
+
+// diffOwnedDirs is diffDirs reporting as removed only the target files in
+// owned. A nil owned owns every target file.
+func diffOwnedDirs(sourceDir, targetDir string, preserve []string, owned map[string]bool) (PlanReport, error) {
+	var report PlanReport
+
+	source, err := listFiles(sourceDir)
+	if err != nil {
+		return PlanReport{}, err
+	}
+	target, err := listFiles(targetDir)
+	if err != nil {
+		return PlanReport{}, err
+	}
+
+	for _, rel := range sortedKeys(source) {
+		if _, ok := target[rel]; !ok {
+			report.Added = append(report.Added, rel)
+			continue
+		}
+		same, err := sameContent(filepath.Join(sourceDir, rel), filepath.Join(targetDir, rel))
+		if err != nil {
+			return PlanReport{}, err
+		}
+		if !same {
+			report.Modified = append(report.Modified, rel)
+		}
+	}
+
+	for _, rel := range sortedKeys(target) {
+		if _, ok := source[rel]; !ok && (owned == nil || owned[rel]) {
+			report.Removed = append(report.Removed, rel)
+		}
+	}
+	report.Removed = withoutPreserved(report.Removed, preserve)
+
+	report.Summary = fmt.Sprintf("Added: %d, Modified: %d, Removed: %d", len(report.Added), len(report.Modified), len(report.Removed))
+
+	if err := planChanges(&report, sourceDir, targetDir); err != nil {
+		return PlanReport{}, err
+	}
+	return report, nil
+}
+
+// listFiles returns the regular files under dir keyed by their slash separated
+// relative path, skipping any .git directory.
+func listFiles(dir string) (map[string]bool, error) {
+	files := make(map[string]bool)
+
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return files, nil
+	}
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(rel)] = true
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("cannot list files in %s: %w", dir, err)
+	}
+
+	return files, nil
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sameContent(a, b string) (bool, error) {
+	infoA, err := os.Stat(a)
+	if err != nil {
+		return false, err
+	}
+	infoB, err := os.Stat(b)
+	if err != nil {
+		return false, err
+	}
+	if infoA.Size() != infoB.Size() {
+		return false, nil
+	}
+
+	dataA, err := os.ReadFile(a)
+	if err != nil {
+		return false, err
+	}
+	dataB, err := os.ReadFile(b)
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(dataA, dataB), nil
+}
+
+// removeEmptyDirs deletes the empty directories below root, deepest first.
+func removeEmptyDirs(root string) error {
+	var dirs []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			if path != root {
+				dirs = append(dirs, path)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for i := len(dirs) - 1; i >= 0; i-- {
+		entries, err := os.ReadDir(dirs[i])
+		if err != nil {
+			return err
+		}
+		if len(entries) == 0 {
+			if err := os.Remove(dirs[i]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,410 @@
+package ssg_test
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/hermesgen/clio/internal/fake"
+	"github.com/hermesgen/clio/internal/feat/ssg"
+	"github.com/hermesgen/hm"
+	"github.com/hermesgen/hm/github"
+)
+
+func writeTree(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("cannot create dir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("cannot write file: %v", err)
+		}
+	}
+}
+
+func TestTargetPublisherDispatch(t *testing.T) {
+	githubPub := fake.NewSSGPublisher()
+	local := fake.NewSSGPublisher()
+	params := hm.XParams{Log: hm.NewLogger("error")}
+	tp := ssg.NewTargetPublisher(map[string]ssg.Publisher{
+		ssg.PublishTargetGitHub: githubPub,
+		ssg.PublishTargetLocal:  local,
+	}, params)
+
+	tests := []struct {
+		name      string
+		target    string
+		wantCalls *fake.SSGPublisher
+		wantErr   bool
+	}{
+		{name: "empty target defaults to github", target: "", wantCalls: githubPub},
+		{name: "selected target", target: ssg.PublishTargetLocal, wantCalls: local},
+		{name: "unknown target", target: "ftp", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			githubPub.PublishCalls = nil
+			local.PublishCalls = nil
+
+			_, _, err := tp.Publish(context.Background(), ssg.PublisherConfig{Target: tt.target}, "src")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Publish() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(tt.wantCalls.PublishCalls) != 1 {
+				t.Errorf("expected the %q target to be called once, got %d", tt.target, len(tt.wantCalls.PublishCalls))
+			}
+		})
+	}
+}
+
+func TestLocalPublisherPublish(t *testing.T) {
+	sourceDir := t.TempDir()
+	targetDir := t.TempDir()
+
+	writeTree(t, sourceDir, map[string]string{
+		"index.html":          "old index",
+		"css/style.css":       "same",
+		"old/page/index.html": "gone",
+	})
+
+	pub := ssg.NewLocalPublisher(hm.XParams{Log: hm.NewLogger("error")})
+	cfg := ssg.PublisherConfig{Target: ssg.PublishTargetLocal, TargetPath: targetDir}
+
+	if err := pub.Validate(cfg); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	if _, _, err := pub.Publish(context.Background(), cfg, sourceDir); err != nil {
+		t.Fatalf("first Publish() error = %v", err)
+	}
+
+	if err := os.RemoveAll(filepath.Join(sourceDir, "old")); err != nil {
+		t.Fatal(err)
+	}
+	writeTree(t, sourceDir, map[string]string{
+		"index.html":           "new index",
+		"blog/post/index.html": "post",
+	})
+	writeTree(t, targetDir, map[string]string{
+		"notes.txt": "not published",
+		".git/HEAD": "ref: refs/heads/main",
+	})
+
+	report, err := pub.Plan(context.Background(), cfg, sourceDir)
+	if err != nil {
+		t.Fatalf("Plan() error = %v", err)
+	}
+	if want := []string{"blog/post/index.html"}; !reflect.DeepEqual(report.Added, want) {
+		t.Errorf("Added = %v, want %v", report.Added, want)
+	}
+	if want := []string{"index.html"}; !reflect.DeepEqual(report.Modified, want) {
+		t.Errorf("Modified = %v, want %v", report.Modified, want)
+	}
+	if want := []string{"old/page/index.html"}; !reflect.DeepEqual(report.Removed, want) {
+		t.Errorf("Removed = %v, want %v", report.Removed, want)
+	}
+	if len(report.Changes) != 3 {
+		t.Fatalf("Changes = %+v, want 3 entries", report.Changes)
+	}
+	if diff := report.Changes[1].Diff; !strings.Contains(diff, "-old index") || !strings.Contains(diff, "+new index") {
+		t.Errorf("index.html diff = %q, want old and new lines", diff)
+	}
+
+	_, published, err := pub.Publish(context.Background(), cfg, sourceDir)
+	if err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+	if published.Summary != report.Summary {
+		t.Errorf("Publish() summary = %q, want the planned %q", published.Summary, report.Summary)
+	}
+
+	if data, _ := os.ReadFile(filepath.Join(targetDir, "index.html")); string(data) != "new index" {
+		t.Errorf("index.html = %q, want updated content", data)
+	}
+	if _, err := os.Stat(filepath.Join(targetDir, "blog", "post", "index.html")); err != nil {
+		t.Errorf("added file missing: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(targetDir, "old")); !os.IsNotExist(err) {
+		t.Errorf("removed page directory should be gone")
+	}
+	if _, err := os.Stat(filepath.Join(targetDir, ".git", "HEAD")); err != nil {
+		t.Errorf(".git should be preserved: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(targetDir, "notes.txt")); err != nil {
+		t.Errorf("file not published by Clio should be kept: %v", err)
+	}
+
+	report, err = pub.Plan(context.Background(), cfg, sourceDir)
+	if err != nil {
+		t.Fatalf("Plan() error = %v", err)
+	}
+	if len(report.Added)+len(report.Modified)+len(report.Removed) != 0 {
+		t.Errorf("expected no changes after publish, got %s", report.Summary)
+	}
+}
+
+func TestLocalPublisherRejectsOverlap(t *testing.T) {
+	sourceDir := t.TempDir()
+	pub := ssg.NewLocalPublisher(hm.XParams{Log: hm.NewLogger("error")})
+
+	for _, target := range []string{sourceDir, filepath.Join(sourceDir, "out"), filepath.Dir(sourceDir)} {
+		cfg := ssg.PublisherConfig{Target: ssg.PublishTargetLocal, TargetPath: target}
+		if _, _, err := pub.Publish(context.Background(), cfg, sourceDir); err == nil {
+			t.Errorf("Publish() to %s should fail", target)
+		}
+	}
+}
+
+func TestLocalPublisherRefusesForeignDirectory(t *testing.T) {
+	sourceDir := t.TempDir()
+	targetDir := t.TempDir()
+	writeTree(t, sourceDir, map[string]string{"index.html": "index"})
+	writeTree(t, targetDir, map[string]string{"Documents/taxes.pdf": "keep"})
+
+	pub := ssg.NewLocalPublisher(hm.XParams{Log: hm.NewLogger("error")})
+	cfg := ssg.PublisherConfig{Target: ssg.PublishTargetLocal, TargetPath: targetDir}
+
+	if _, err := pub.Plan(context.Background(), cfg, sourceDir); err == nil {
+		t.Error("Plan() into a directory without a previous publish should fail")
+	}
+	if _, _, err := pub.Publish(context.Background(), cfg, sourceDir); err == nil {
+		t.Error("Publish() into a directory without a previous publish should fail")
+	}
+	if _, err := os.Stat(filepath.Join(targetDir, "Documents", "taxes.pdf")); err != nil {
+		t.Errorf("existing file was touched: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(targetDir, "index.html")); !os.IsNotExist(err) {
+		t.Errorf("site was published into the directory: %v", err)
+	}
+}
+
+func TestArchivePublisherPublish(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		read   func(t *testing.T, path string) []string
+	}{
+		{name: "tar.gz", format: ssg.ArchiveFormatTarGz, read: tarGzNames},
+		{name: "zip", format: ssg.ArchiveFormatZip, read: zipNames},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sourceDir := t.TempDir()
+			targetDir := t.TempDir()
+			writeTree(t, sourceDir, map[string]string{
+				"index.html":    "index",
+				"css/style.css": "css",
+			})
+
+			pub := ssg.NewArchivePublisher(hm.XParams{Log: hm.NewLogger("error")})
+			cfg := ssg.PublisherConfig{Target: ssg.PublishTargetArchive, TargetPath: targetDir, ArchiveFormat: tt.format}
+			if err := pub.Validate(cfg); err != nil {
+				t.Fatalf("Validate() error = %v", err)
+			}
+
+			path, _, err := pub.Publish(context.Background(), cfg, sourceDir)
+			if err != nil {
+				t.Fatalf("Publish() error = %v", err)
+			}
+			if !strings.HasSuffix(path, "."+tt.format) {
+				t.Errorf("archive path %s does not end in .%s", path, tt.format)
+			}
+
+			got := tt.read(t, path)
+			want := []string{"css/style.css", "index.html"}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("archive entries = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestArchivePublisherValidate(t *testing.T) {
+	pub := ssg.NewArchivePublisher(hm.XParams{Log: hm.NewLogger("error")})
+
+	if err := pub.Validate(ssg.PublisherConfig{ArchiveFormat: ssg.ArchiveFormatZip}); err == nil {
+		t.Error("Validate() should fail without target path")
+	}
+	if err := pub.Validate(ssg.PublisherConfig{TargetPath: "out", ArchiveFormat: "rar"}); err == nil {
+		t.Error("Validate() should fail with an unknown format")
+	}
+}
+
+func TestGitPublisherFileRemote(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	remote := filepath.Join(t.TempDir(), "site.git")
+	if out, err := exec.Command("git", "init", "--bare", remote).CombinedOutput(); err != nil {
+		t.Fatalf("cannot create bare repo: %v: %s", err, out)
+	}
+
+	sourceDir := t.TempDir()
+	writeTree(t, sourceDir, map[string]string{"index.html": "hello"})
+
+	params := hm.XParams{Cfg: hm.NewConfig(), Log: hm.NewLogger("error")}
+	pub := ssg.NewPublisher(github.NewClient(params), params)
+	cfg := ssg.PublisherConfig{
+		Target:  ssg.PublishTargetGit,
+		RepoURL: "file://" + remote,
+		Branch:  "pages",
+		CommitAuthor: hm.GitCommit{
+			UserName:  "Test",
+			UserEmail: "test@example.com",
+			Message:   "Publish",
+		},
+	}
+
+	hash, _, err := pub.Publish(context.Background(), cfg, sourceDir)
+	if err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+	if hash == "" || strings.Contains(hash, "/commit/") {
+		t.Errorf("expected a plain commit hash, got %q", hash)
+	}
+
+	out, err := exec.Command("git", "--git-dir", remote, "show", "pages:index.html").CombinedOutput()
+	if err != nil {
+		t.Fatalf("cannot read published file: %v: %s", err, out)
+	}
+	if string(out) != "hello" {
+		t.Errorf("published index.html = %q, want %q", out, "hello")
+	}
+
+	writeTree(t, sourceDir, map[string]string{"index.html": "bye", "about/index.html": "about"})
+	report, err := pub.Plan(context.Background(), cfg, sourceDir)
+	if err != nil {
+		t.Fatalf("Plan() error = %v", err)
+	}
+	if want := []string{"about/index.html"}; !reflect.DeepEqual(report.Added, want) {
+		t.Errorf("Added = %v, want %v", report.Added, want)
+	}
+	if want := []string{"index.html"}; !reflect.DeepEqual(report.Modified, want) {
+		t.Errorf("Modified = %v, want %v", report.Modified, want)
+	}
+	if len(report.Changes) != 2 || !strings.Contains(report.Changes[1].Diff, "+bye") {
+		t.Errorf("Changes = %+v, want a diff for index.html", report.Changes)
+	}
+}
+
+func tarGzNames(t *testing.T, path string) []string {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("cannot open archive: %v", err)
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatalf("cannot read gzip: %v", err)
+	}
+	tr := tar.NewReader(gz)
+
+	var names []string
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("cannot read tar: %v", err)
+		}
+		names = append(names, h.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func zipNames(t *testing.T, path string) []string {
+	t.Helper()
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		t.Fatalf("cannot open zip: %v", err)
+	}
+	defer zr.Close()
+
+	var names []string
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestGitPublisherKeepsPreservedPaths(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	remote := filepath.Join(t.TempDir(), "site.git")
+	if out, err := exec.Command("git", "init", "--bare", remote).CombinedOutput(); err != nil {
+		t.Fatalf("cannot create bare repo: %v: %s", err, out)
+	}
+
+	params := hm.XParams{Cfg: hm.NewConfig(), Log: hm.NewLogger("error")}
+	pub := ssg.NewPublisher(github.NewClient(params), params)
+	cfg := ssg.PublisherConfig{
+		Target:  ssg.PublishTargetGit,
+		RepoURL: "file://" + remote,
+		Branch:  "pages",
+		CommitAuthor: hm.GitCommit{
+			UserName:  "Test",
+			UserEmail: "test@example.com",
+			Message:   "Publish",
+		},
+	}
+
+	first := t.TempDir()
+	writeTree(t, first, map[string]string{
+		"index.html":           "home",
+		"old.html":             "old",
+		"google1234.html":      "verification",
+		".well-known/security": "contact",
+	})
+	if _, _, err := pub.Publish(context.Background(), cfg, first); err != nil {
+		t.Fatalf("first Publish() error = %v", err)
+	}
+
+	second := t.TempDir()
+	writeTree(t, second, map[string]string{"index.html": "home", "CNAME": "blog.example.com\n"})
+	cfg.Preserve = []string{"google*.html", ".well-known"}
+
+	report, err := pub.Plan(context.Background(), cfg, second)
+	if err != nil {
+		t.Fatalf("Plan() error = %v", err)
+	}
+	if want := []string{"old.html"}; !reflect.DeepEqual(report.Removed, want) {
+		t.Errorf("Removed = %v, want %v", report.Removed, want)
+	}
+
+	if _, _, err := pub.Publish(context.Background(), cfg, second); err != nil {
+		t.Fatalf("second Publish() error = %v", err)
+	}
+
+	out, err := exec.Command("git", "--git-dir", remote, "ls-tree", "-r", "--name-only", "pages").CombinedOutput()
+	if err != nil {
+		t.Fatalf("cannot list published files: %v: %s", err, out)
+	}
+	got := strings.Fields(string(out))
+	want := []string{".well-known/security", "CNAME", "google1234.html", "index.html"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("published files = %v, want %v", got, want)
+	}
+}
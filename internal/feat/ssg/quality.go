@@ -0,0 +1,407 @@
+package ssg
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Quality checks run before publishing.
+const (
+	QualityBrokenLinks        = "broken-links"
+	QualityMissingAlt         = "missing-alt"
+	QualityMissingDescription = "missing-description"
+	QualityDraftLinks         = "draft-links"
+	QualityDuplicateSlugs     = "duplicate-slugs"
+	QualityBuildErrors        = "build-errors"
+)
+
+// Severities of a quality check, set per site through the ssg.quality.* params.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+	SeverityOff     = "off"
+)
+
+// QualityChecks lists the checks in the order they are reported, with their
+// default severity.
+var QualityChecks = []struct {
+	Name     string
+	Severity string
+}{
+	{QualityBuildErrors, SeverityError},
+	{QualityBrokenLinks, SeverityError},
+	{QualityDraftLinks, SeverityError},
+	{QualityDuplicateSlugs, SeverityError},
+	{QualityMissingAlt, SeverityWarning},
+	{QualityMissingDescription, SeverityWarning},
+}
+
+var (
+	htmlTagRe  = regexp.MustCompile(`(?is)<(a|img|link|script)\b([^>]*)>`)
+	htmlAttrRe = regexp.MustCompile(`(?is)\b(href|src|alt)\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s>]+))`)
+)
+
+// QualityIssue is a problem found by a quality check.
+type QualityIssue struct {
+	Check          string    `json:"check"`
+	Severity       string    `json:"severity"`
+	Message        string    `json:"message"`
+	Page           string    `json:"page,omitempty"`
+	ContentID      uuid.UUID `json:"content_id,omitempty"`
+	ContentHeading string    `json:"content_heading,omitempty"`
+}
+
+// QualityReport is the result of running the quality checks on a site.
+type QualityReport struct {
+	Issues   []QualityIssue `json:"issues"`
+	Errors   int            `json:"errors"`
+	Warnings int            `json:"warnings"`
+	Summary  string         `json:"summary"`
+}
+
+// Blocking reports whether the issues must stop a publish.
+func (r QualityReport) Blocking() bool {
+	return r.Errors > 0
+}
+
+// QualityGateError is returned when a publish is blocked by the quality gate.
+type QualityGateError struct {
+	Report QualityReport
+}
+
+func (e *QualityGateError) Error() string {
+	return fmt.Sprintf("publish blocked by quality checks: %s", e.Report.Summary)
+}
+
+// BuildReport records the problems of the last HTML build of a site.
+type BuildReport struct {
+	GeneratedAt time.Time    `json:"generated_at"`
+	Errors      []BuildError `json:"errors"`
+}
+
+// BuildError is a page that could not be converted, rendered or written.
+type BuildError struct {
+	Page           string    `json:"page,omitempty"`
+	ContentID      uuid.UUID `json:"content_id,omitempty"`
+	ContentHeading string    `json:"content_heading,omitempty"`
+	Message        string    `json:"message"`
+}
+
+func (b *BuildReport) add(content *Content, page, format string, args ...interface{}) {
+	e := BuildError{Page: page, Message: fmt.Sprintf(format, args...)}
+	if content != nil {
+		e.ContentID = content.ID
+		e.ContentHeading = content.Heading
+	}
+	b.Errors = append(b.Errors, e)
+}
+
+func writeBuildReport(p string, report BuildReport) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("cannot encode build report: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return fmt.Errorf("cannot create build report dir: %w", err)
+	}
+	if err := os.WriteFile(p, data, 0644); err != nil {
+		return fmt.Errorf("cannot write build report: %w", err)
+	}
+	return nil
+}
+
+// readBuildReport returns the last build report, or nil if the site has not
+// been generated yet.
+func readBuildReport(p string) (*BuildReport, error) {
+	data, err := os.ReadFile(p)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot read build report: %w", err)
+	}
+
+	var report BuildReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("cannot decode build report: %w", err)
+	}
+	return &report, nil
+}
+
+// qualityInput is what the quality checks look at.
+type qualityInput struct {
+	contents   []Content
+	siteMode   string
+	htmlPath   string
+	build      *BuildReport
+	severities map[string]string
+}
+
+type qualityChecker struct {
+	qualityInput
+	report QualityReport
+	seen   map[QualityIssue]bool
+}
+
+// checkQuality runs the enabled checks on the generated site and the contents
+// it was generated from.
+func checkQuality(in qualityInput) (QualityReport, error) {
+	c := &qualityChecker{qualityInput: in, seen: map[QualityIssue]bool{}}
+	c.report.Issues = []QualityIssue{}
+
+	c.checkBuild()
+	c.checkContents()
+	if err := c.checkPages(); err != nil {
+		return QualityReport{}, err
+	}
+
+	sort.SliceStable(c.report.Issues, func(i, j int) bool {
+		return checkOrder(c.report.Issues[i].Check) < checkOrder(c.report.Issues[j].Check)
+	})
+	c.report.Summary = fmt.Sprintf("Errors: %d, Warnings: %d", c.report.Errors, c.report.Warnings)
+	return c.report, nil
+}
+
+func (c *qualityChecker) severity(check string) string {
+	if s, ok := c.severities[check]; ok {
+		return s
+	}
+	for _, qc := range QualityChecks {
+		if qc.Name == check {
+			return qc.Severity
+		}
+	}
+	return SeverityOff
+}
+
+func (c *qualityChecker) enabled(check string) bool {
+	return c.severity(check) != SeverityOff
+}
+
+func (c *qualityChecker) add(issue QualityIssue) {
+	issue.Severity = c.severity(issue.Check)
+	if issue.Severity == SeverityOff || c.seen[issue] {
+		return
+	}
+	c.seen[issue] = true
+
+	c.report.Issues = append(c.report.Issues, issue)
+	if issue.Severity == SeverityError {
+		c.report.Errors++
+	} else {
+		c.report.Warnings++
+	}
+}
+
+func (c *qualityChecker) checkBuild() {
+	if !c.enabled(QualityBuildErrors) {
+		return
+	}
+
+	if c.build == nil {
+		c.add(QualityIssue{Check: QualityBuildErrors, Message: "The site has not been generated yet"})
+		return
+	}
+
+	for _, e := range c.build.Errors {
+		c.add(QualityIssue{
+			Check:          QualityBuildErrors,
+			Message:        e.Message,
+			Page:           e.Page,
+			ContentID:      e.ContentID,
+			ContentHeading: e.ContentHeading,
+		})
+	}
+}
+
+func (c *qualityChecker) checkContents() {
+	pages := map[string][]Content{}
+
+	for _, content := range c.contents {
+		if content.Draft {
+			continue
+		}
+
+		if strings.TrimSpace(content.Meta.Description) == "" {
+			c.add(QualityIssue{
+				Check:          QualityMissingDescription,
+				Message:        "Content has no meta description",
+				ContentID:      content.ID,
+				ContentHeading: content.Heading,
+			})
+		}
+
+		page := filepath.ToSlash(GetContentFilePath("", content, c.siteMode))
+		pages[page] = append(pages[page], content)
+	}
+
+	paths := make([]string, 0, len(pages))
+	for page := range pages {
+		paths = append(paths, page)
+	}
+	sort.Strings(paths)
+
+	for _, page := range paths {
+		same := pages[page]
+		if len(same) < 2 {
+			continue
+		}
+
+		var headings []string
+		for _, content := range same {
+			headings = append(headings, content.Heading)
+		}
+		for _, content := range same {
+			c.add(QualityIssue{
+				Check:          QualityDuplicateSlugs,
+				Message:        fmt.Sprintf("Contents share the slug %s: %s", content.Slug(), strings.Join(headings, ", ")),
+				Page:           page,
+				ContentID:      content.ID,
+				ContentHeading: content.Heading,
+			})
+		}
+	}
+}
+
+// checkPages scans the generated HTML for broken links, links to drafts and
+// images without alt text.
+func (c *qualityChecker) checkPages() error {
+	if !c.enabled(QualityBrokenLinks) && !c.enabled(QualityDraftLinks) && !c.enabled(QualityMissingAlt) {
+		return nil
+	}
+	if _, err := os.Stat(c.htmlPath); os.IsNotExist(err) {
+		return nil
+	}
+
+	files, err := listFiles(c.htmlPath)
+	if err != nil {
+		return err
+	}
+
+	pageContents := map[string]Content{}
+	drafts := map[string]Content{}
+	for _, content := range c.contents {
+		if content.Draft {
+			drafts[strings.Trim(filepath.ToSlash(GetContentPath(content, c.siteMode)), "/")] = content
+			continue
+		}
+		pageContents[filepath.ToSlash(GetContentFilePath("", content, c.siteMode))] = content
+	}
+
+	for _, page := range sortedKeys(files) {
+		if ext := path.Ext(page); ext != ".html" && ext != ".htm" {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(c.htmlPath, filepath.FromSlash(page)))
+		if err != nil {
+			return fmt.Errorf("cannot read %s: %w", page, err)
+		}
+
+		owner := pageContents[page]
+		issue := func(check, msg string) {
+			c.add(QualityIssue{Check: check, Message: msg, Page: page, ContentID: owner.ID, ContentHeading: owner.Heading})
+		}
+
+		for _, tag := range htmlTagRe.FindAllStringSubmatch(string(data), -1) {
+			name := strings.ToLower(tag[1])
+			attrs := htmlAttrs(tag[2])
+
+			if name == "img" {
+				if alt, ok := attrs["alt"]; !ok || strings.TrimSpace(alt) == "" {
+					issue(QualityMissingAlt, fmt.Sprintf("Image %s has no alt text", attrs["src"]))
+				}
+			}
+
+			ref, ok := attrs["href"]
+			if name == "img" || name == "script" {
+				ref, ok = attrs["src"]
+			}
+			if !ok {
+				continue
+			}
+
+			target, internal := resolveInternalLink(page, ref)
+			if !internal {
+				continue
+			}
+
+			if draft, ok := drafts[strings.Trim(target, "/")]; ok {
+				issue(QualityDraftLinks, fmt.Sprintf("Links to the draft %q (%s)", draft.Heading, ref))
+				continue
+			}
+
+			if !linkTargetExists(files, target) {
+				issue(QualityBrokenLinks, fmt.Sprintf("Broken link to %s", ref))
+			}
+		}
+	}
+
+	return nil
+}
+
+func htmlAttrs(s string) map[string]string {
+	attrs := map[string]string{}
+	for _, m := range htmlAttrRe.FindAllStringSubmatch(s, -1) {
+		attrs[strings.ToLower(m[1])] = m[2] + m[3] + m[4]
+	}
+	return attrs
+}
+
+// resolveInternalLink resolves ref, found in page, to a slash separated path
+// relative to the site root. External links, fragments and special schemes
+// are not internal.
+func resolveInternalLink(page, ref string) (string, bool) {
+	ref = strings.TrimSpace(ref)
+	if ref == "" || strings.HasPrefix(ref, "#") || strings.HasPrefix(ref, "//") {
+		return "", false
+	}
+
+	u, err := url.Parse(ref)
+	if err != nil || u.Scheme != "" || u.Host != "" {
+		return "", false
+	}
+
+	p := u.Path
+	if p == "" {
+		return "", false
+	}
+	if !strings.HasPrefix(p, "/") {
+		p = path.Join("/", path.Dir(page), p)
+	}
+
+	// Keep a trailing slash, it asks for a directory index
+	cleaned := path.Clean(p)
+	if strings.HasSuffix(p, "/") && cleaned != "/" {
+		cleaned += "/"
+	}
+	return strings.TrimPrefix(cleaned, "/"), true
+}
+
+// linkTargetExists reports whether target is a generated file or a directory
+// with an index page.
+func linkTargetExists(files map[string]bool, target string) bool {
+	if strings.HasSuffix(target, "/") || target == "" {
+		return files[target+"index.html"]
+	}
+	return files[target] || files[target+"/index.html"] || files[target+".html"]
+}
+
+func checkOrder(check string) int {
+	for i, qc := range QualityChecks {
+		if qc.Name == check {
+			return i
+		}
+	}
+	return len(QualityChecks)
+}
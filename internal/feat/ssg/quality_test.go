@@ -0,0 +1,166 @@
+package ssg
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func writeQualitySite(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func issuesByCheck(report QualityReport) map[string][]QualityIssue {
+	got := map[string][]QualityIssue{}
+	for _, issue := range report.Issues {
+		got[issue.Check] = append(got[issue.Check], issue)
+	}
+	return got
+}
+
+func TestCheckQuality(t *testing.T) {
+	post := Content{ID: uuid.New(), Heading: "First Post", SectionPath: "notes", ShortID: "a1b2c3"}
+	post.Meta.Description = "A post"
+	about := Content{ID: uuid.New(), Heading: "About", ShortID: "d4e5f6"}
+	draft := Content{ID: uuid.New(), Heading: "Secret", ShortID: "0f0f0f", Draft: true}
+
+	htmlPath := writeQualitySite(t, map[string]string{
+		"index.html": `<a href="/notes/first-post-a1b2c3/">ok</a>
+			<a href='/missing/'>broken</a>
+			<a href="/secret-0f0f0f/">draft</a>
+			<a href="https://example.com/nowhere">external</a>
+			<a href="#top">fragment</a>
+			<a href="mailto:me@example.com">mail</a>
+			<link rel="stylesheet" href="/static/css/main.css?v=1">
+			<img src="/static/img/header.png" alt="Header">`,
+		"notes/first-post-a1b2c3/index.html": `<img src="img/photo.png">
+			<img src="img/missing.png" alt="">
+			<a href="../../about-d4e5f6/">about</a>`,
+		"notes/first-post-a1b2c3/img/photo.png": "png",
+		"about-d4e5f6/index.html":               `<a href="/notes/first-post-a1b2c3">no slash</a>`,
+		"static/css/main.css":                   "body{}",
+		"static/img/header.png":                 "png",
+	})
+
+	build := &BuildReport{Errors: []BuildError{{ContentHeading: "Broken", Message: "Cannot render template: boom"}}}
+
+	report, err := checkQuality(qualityInput{
+		contents: []Content{post, about, draft},
+		siteMode: "structured",
+		htmlPath: htmlPath,
+		build:    build,
+	})
+	if err != nil {
+		t.Fatalf("checkQuality() error = %v", err)
+	}
+
+	got := issuesByCheck(report)
+
+	if n := len(got[QualityBuildErrors]); n != 1 {
+		t.Errorf("build errors = %v, want 1", got[QualityBuildErrors])
+	}
+	if issues := got[QualityBrokenLinks]; len(issues) != 2 || issues[0].Message != "Broken link to /missing/" || issues[1].Message != "Broken link to img/missing.png" {
+		t.Errorf("broken links = %+v", issues)
+	}
+	if issues := got[QualityBrokenLinks]; len(issues) == 2 && issues[1].ContentID != post.ID {
+		t.Errorf("broken link not attributed to its content: %+v", issues[1])
+	}
+	if issues := got[QualityDraftLinks]; len(issues) != 1 || issues[0].Page != "index.html" {
+		t.Errorf("draft links = %+v", issues)
+	}
+	if issues := got[QualityMissingAlt]; len(issues) != 2 {
+		t.Errorf("missing alt = %+v, want 2", issues)
+	}
+	if issues := got[QualityMissingDescription]; len(issues) != 1 || issues[0].ContentID != about.ID {
+		t.Errorf("missing description = %+v", issues)
+	}
+
+	if report.Errors != 4 || report.Warnings != 3 || !report.Blocking() {
+		t.Errorf("report counts = %d errors, %d warnings", report.Errors, report.Warnings)
+	}
+	if report.Issues[0].Check != QualityBuildErrors {
+		t.Errorf("issues not in check order: %+v", report.Issues[0])
+	}
+}
+
+func TestCheckQualitySeverities(t *testing.T) {
+	htmlPath := writeQualitySite(t, map[string]string{"index.html": `<a href="/missing/">x</a>`})
+
+	report, err := checkQuality(qualityInput{
+		siteMode: "blog",
+		htmlPath: htmlPath,
+		severities: map[string]string{
+			QualityBrokenLinks: SeverityWarning,
+			QualityBuildErrors: SeverityOff,
+		},
+	})
+	if err != nil {
+		t.Fatalf("checkQuality() error = %v", err)
+	}
+
+	if report.Blocking() || report.Warnings != 1 || report.Issues[0].Check != QualityBrokenLinks {
+		t.Errorf("report = %+v, want a single broken link warning", report)
+	}
+}
+
+func TestCheckQualityNotGenerated(t *testing.T) {
+	report, err := checkQuality(qualityInput{siteMode: "blog", htmlPath: filepath.Join(t.TempDir(), "html")})
+	if err != nil {
+		t.Fatalf("checkQuality() error = %v", err)
+	}
+	if !report.Blocking() {
+		t.Errorf("report = %+v, want an error for a site never generated", report)
+	}
+}
+
+func TestCheckQualityDuplicateSlugs(t *testing.T) {
+	a := Content{ID: uuid.New(), Heading: "Same", SectionPath: "a", ShortID: "abc123"}
+	b := Content{ID: uuid.New(), Heading: "Same", SectionPath: "b", ShortID: "abc123"}
+
+	for mode, want := range map[string]int{"blog": 2, "structured": 0} {
+		report, err := checkQuality(qualityInput{
+			contents:   []Content{a, b},
+			siteMode:   mode,
+			build:      &BuildReport{},
+			severities: map[string]string{QualityMissingDescription: SeverityOff},
+		})
+		if err != nil {
+			t.Fatalf("checkQuality() error = %v", err)
+		}
+		if n := len(issuesByCheck(report)[QualityDuplicateSlugs]); n != want {
+			t.Errorf("%s mode: duplicate slug issues = %d, want %d", mode, n, want)
+		}
+	}
+}
+
+func TestBuildReportRoundTrip(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "docs", "build-report.json")
+
+	if report, err := readBuildReport(p); err != nil || report != nil {
+		t.Fatalf("readBuildReport() of missing file = %v, %v", report, err)
+	}
+
+	var build BuildReport
+	build.add(&Content{Heading: "Post"}, "post/index.html", "Cannot render template: %s", "boom")
+	if err := writeBuildReport(p, build); err != nil {
+		t.Fatalf("writeBuildReport() error = %v", err)
+	}
+
+	got, err := readBuildReport(p)
+	if err != nil || got == nil || len(got.Errors) != 1 || got.Errors[0].Message != "Cannot render template: boom" {
+		t.Errorf("readBuildReport() = %+v, %v", got, err)
+	}
+}
@@ -0,0 +1,126 @@
+package ssg
+
+import (
+	"fmt"
+	"html/template"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Sources of redirects.
+const (
+	RedirectManual     = "manual"
+	RedirectWordPress  = "wordpress"
+	RedirectHugo       = "hugo"
+	RedirectJekyll     = "jekyll"
+	RedirectModeSwitch = "mode-switch"
+)
+
+// redirectMarker identifies the pages written for redirects so generation can
+// replace them but never a real page.
+const redirectMarker = `<meta name="generator" content="clio-redirect">`
+
+var redirectPageTmpl = template.Must(template.New("redirect").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+` + redirectMarker + `
+<title>Redirecting…</title>
+<link rel="canonical" href="{{ . }}">
+<meta name="robots" content="noindex">
+<meta http-equiv="refresh" content="0; url={{ . }}">
+</head>
+<body>
+<p>This page has moved to <a href="{{ . }}">{{ . }}</a>.</p>
+</body>
+</html>
+`))
+
+// Redirect keeps an old URL of a site, such as a WordPress permalink, working
+// by sending it to a content or to another path of the site.
+type Redirect struct {
+	ID        uuid.UUID `json:"id" db:"id"`
+	SiteID    uuid.UUID `json:"site_id" db:"site_id"`
+	FromPath  string    `json:"from_path" db:"from_path"`
+	ContentID uuid.UUID `json:"content_id" db:"content_id"`
+	ToPath    string    `json:"to_path" db:"to_path"`
+	Source    string    `json:"source" db:"source"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// NewRedirect creates a redirect from the old URL or path from. Only the path
+// of from is kept, see NormalizeRedirectPath.
+func NewRedirect(siteID uuid.UUID, from, source string) (*Redirect, error) {
+	fromPath, err := NormalizeRedirectPath(from)
+	if err != nil {
+		return nil, err
+	}
+	return &Redirect{
+		ID:        uuid.New(),
+		SiteID:    siteID,
+		FromPath:  fromPath,
+		Source:    source,
+		CreatedAt: time.Now(),
+	}, nil
+}
+
+// NormalizeRedirectPath returns the path of an old URL as a static site can
+// serve it: /2021/05/my-post/ or /about.html. Query strings can not be served
+// and are rejected, as is the site root.
+func NormalizeRedirectPath(from string) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(from))
+	if err != nil {
+		return "", fmt.Errorf("invalid redirect path %q: %w", from, err)
+	}
+	if u.RawQuery != "" {
+		return "", fmt.Errorf("redirect path %q has a query string", from)
+	}
+
+	p := path.Clean("/" + u.Path)
+	if p == "/" {
+		return "", fmt.Errorf("the site root can not be redirected")
+	}
+	if path.Ext(p) == "" {
+		p += "/"
+	}
+	return p, nil
+}
+
+// redirectFilePath returns where the page of a redirect from fromPath is
+// written under htmlPath.
+func redirectFilePath(htmlPath, fromPath string) string {
+	if strings.HasSuffix(fromPath, "/") {
+		return filepath.Join(htmlPath, filepath.FromSlash(fromPath), "index.html")
+	}
+	return filepath.Join(htmlPath, filepath.FromSlash(fromPath))
+}
+
+// writeRedirectPage writes a page sending visitors of fromPath to target. A
+// page generated for a content or index is never replaced; written reports
+// whether the redirect page was written.
+func writeRedirectPage(htmlPath, fromPath, target string) (written bool, err error) {
+	p := redirectFilePath(htmlPath, fromPath)
+
+	if data, err := os.ReadFile(p); err == nil && !strings.Contains(string(data), redirectMarker) {
+		return false, nil
+	}
+
+	var buf strings.Builder
+	if err := redirectPageTmpl.Execute(&buf, target); err != nil {
+		return false, fmt.Errorf("cannot render redirect page: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return false, fmt.Errorf("cannot create redirect dir: %w", err)
+	}
+	if err := os.WriteFile(p, []byte(buf.String()), 0644); err != nil {
+		return false, fmt.Errorf("cannot write redirect page: %w", err)
+	}
+	return true, nil
+}
@@ -0,0 +1,111 @@
+package ssg
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/hermesgen/hm"
+)
+
+func TestNormalizeRedirectPath(t *testing.T) {
+	tests := []struct {
+		from    string
+		want    string
+		wantErr bool
+	}{
+		{from: "https://example.com/2021/05/my-post/", want: "/2021/05/my-post/"},
+		{from: "/2021/05/my-post", want: "/2021/05/my-post/"},
+		{from: "old/about.html", want: "/old/about.html"},
+		{from: "/a/../b/#top", want: "/b/"},
+		{from: "https://example.com/?p=12", wantErr: true},
+		{from: "https://example.com/", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := NormalizeRedirectPath(tt.from)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("NormalizeRedirectPath(%q) error = %v, wantErr %t", tt.from, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("NormalizeRedirectPath(%q) = %q, want %q", tt.from, got, tt.want)
+		}
+	}
+}
+
+func TestWriteRedirectPage(t *testing.T) {
+	htmlPath := t.TempDir()
+
+	written, err := writeRedirectPage(htmlPath, "/2021/05/my-post/", "/blog/my-post-abc123/")
+	if err != nil || !written {
+		t.Fatalf("writeRedirectPage() = %t, %v", written, err)
+	}
+	page := filepath.Join(htmlPath, "2021", "05", "my-post", "index.html")
+	data, err := os.ReadFile(page)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `url=/blog/my-post-abc123/`) {
+		t.Errorf("redirect page = %s", data)
+	}
+
+	written, err = writeRedirectPage(htmlPath, "/2021/05/my-post/", "/blog/moved/")
+	if err != nil || !written {
+		t.Errorf("writeRedirectPage() over a redirect page = %t, %v", written, err)
+	}
+
+	real := filepath.Join(htmlPath, "about.html")
+	if err := os.WriteFile(real, []byte("<html>About</html>"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	written, err = writeRedirectPage(htmlPath, "/about.html", "/pages/about/")
+	if err != nil || written {
+		t.Errorf("writeRedirectPage() over a generated page = %t, %v", written, err)
+	}
+	if data, _ := os.ReadFile(real); string(data) != "<html>About</html>" {
+		t.Errorf("generated page replaced: %s", data)
+	}
+}
+
+type fakeRedirectRepo struct {
+	Repo
+	redirects []Redirect
+}
+
+func (f *fakeRedirectRepo) DeleteRedirect(ctx context.Context, siteID, id uuid.UUID) error {
+	for i, r := range f.redirects {
+		if r.ID == id && r.SiteID == siteID {
+			f.redirects = append(f.redirects[:i], f.redirects[i+1:]...)
+			return nil
+		}
+	}
+	return errors.New("redirect not found")
+}
+
+func TestDeleteRedirectOfOtherSite(t *testing.T) {
+	siteID, otherID := uuid.New(), uuid.New()
+	own := Redirect{ID: uuid.New(), SiteID: siteID, FromPath: "/old/"}
+	other := Redirect{ID: uuid.New(), SiteID: otherID, FromPath: "/old/"}
+	repo := &fakeRedirectRepo{redirects: []Redirect{own, other}}
+	params := hm.XParams{Cfg: hm.NewConfig(), Log: hm.NewLogger("error")}
+	svc := &BaseService{Service: hm.NewService("ssg-svc", params), repo: repo}
+	ctx := context.WithValue(context.Background(), siteIDKey, siteID)
+
+	if err := svc.DeleteRedirect(ctx, other.ID); err == nil {
+		t.Errorf("DeleteRedirect() of another site succeeded")
+	}
+	if err := svc.DeleteRedirect(ctx, own.ID); err != nil {
+		t.Fatalf("DeleteRedirect() error = %v", err)
+	}
+	if len(repo.redirects) != 1 || repo.redirects[0].ID != other.ID {
+		t.Errorf("redirects = %+v, want only the one of the other site", repo.redirects)
+	}
+	if err := svc.DeleteRedirect(context.Background(), other.ID); err == nil {
+		t.Errorf("DeleteRedirect() without a site succeeded")
+	}
+}
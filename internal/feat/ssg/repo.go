@@ -56,6 +56,7 @@ type Repo interface {
 	UpdateImage(ctx context.Context, image *Image) error
 	DeleteImage(ctx context.Context, id uuid.UUID) error
 	ListImages(ctx context.Context) ([]Image, error)
+	ListSiteImages(ctx context.Context, offset, limit int, search string) ([]Image, int, error)
 
 	// ImageVariant related
 	CreateImageVariant(ctx context.Context, variant *ImageVariant) error
@@ -68,17 +69,54 @@ type Repo interface {
 	CreateContentImage(ctx context.Context, contentImage *ContentImage) error
 	DeleteContentImage(ctx context.Context, id uuid.UUID) error
 	GetContentImagesByContentID(ctx context.Context, contentID uuid.UUID) ([]ContentImage, error)
+	GetAllContentImages(ctx context.Context) ([]ContentImage, error)
 
 	// SectionImage relationship methods
 	CreateSectionImage(ctx context.Context, sectionImage *SectionImage) error
 	DeleteSectionImage(ctx context.Context, id uuid.UUID) error
 	GetSectionImagesBySectionID(ctx context.Context, sectionID uuid.UUID) ([]SectionImage, error)
+	GetAllSectionImages(ctx context.Context) ([]SectionImage, error)
+
+	// ImageRevision related
+	CreateImageRevision(ctx context.Context, revision *ImageRevision) error
+	GetImageRevisionsByImageID(ctx context.Context, imageID uuid.UUID) ([]ImageRevision, error)
+	DeleteImageRevision(ctx context.Context, id uuid.UUID) error
+
+	// Attachment related
+	CreateAttachment(ctx context.Context, attachment *Attachment) error
+	GetAttachment(ctx context.Context, id uuid.UUID) (Attachment, error)
+	UpdateAttachment(ctx context.Context, attachment *Attachment) error
+	DeleteAttachment(ctx context.Context, id uuid.UUID) error
+	ListAttachments(ctx context.Context) ([]Attachment, error)
+
+	// ContentAttachment relationship methods
+	CreateContentAttachment(ctx context.Context, contentAttachment *ContentAttachment) error
+	DeleteContentAttachment(ctx context.Context, contentID, attachmentID uuid.UUID) error
+	GetContentAttachmentsByContentID(ctx context.Context, contentID uuid.UUID) ([]ContentAttachment, error)
+
+	// Redirect related
+	SaveRedirect(ctx context.Context, redirect *Redirect) error
+	ListRedirects(ctx context.Context, siteID uuid.UUID) ([]Redirect, error)
+	DeleteRedirect(ctx context.Context, siteID, id uuid.UUID) error
+
+	// Site data related
+	SchemaVersion(ctx context.Context) (string, error)
+	ExportSiteTables(ctx context.Context, siteID uuid.UUID) ([]SiteTable, error)
+	ImportSiteTables(ctx context.Context, tables []SiteTable) error
+	PurgeSiteData(ctx context.Context, siteID uuid.UUID) error
+
+	// PublishRecord related
+	CreatePublishRecord(ctx context.Context, record *PublishRecord) error
+	GetPublishRecord(ctx context.Context, id uuid.UUID) (PublishRecord, error)
+	ListPublishRecords(ctx context.Context, siteID uuid.UUID) ([]PublishRecord, error)
+	ClearPublishRecordSnapshot(ctx context.Context, id uuid.UUID) error
 
 	AddTagToContent(ctx context.Context, contentID, tagID uuid.UUID) error
 	RemoveTagFromContent(ctx context.Context, contentID, tagID uuid.UUID) error
 	GetTagsForContent(ctx context.Context, contentID uuid.UUID) ([]Tag, error)
 	GetContentForTag(ctx context.Context, tagID uuid.UUID) ([]Content, error)
 
+	GetUser(ctx context.Context, id uuid.UUID) (auth.User, error)
 	GetUserByUsername(ctx context.Context, username string) (auth.User, error)
 
 	// Site related
@@ -15,6 +15,7 @@ import (
 	"path/filepath"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/hermesgen/hm"
@@ -55,6 +56,10 @@ type Service interface {
 	ListParams(ctx context.Context) ([]Param, error)
 	UpdateParam(ctx context.Context, param *Param) error
 	DeleteParam(ctx context.Context, id uuid.UUID) error
+	InvalidateParams(siteID uuid.UUID)
+	ListSettings(ctx context.Context) ([]SettingValue, error)
+	UpdateSettings(ctx context.Context, values map[string]string) ([]Param, error)
+	ResetSetting(ctx context.Context, key string) error
 
 	// Image related
 	CreateImage(ctx context.Context, image *Image) error
@@ -80,6 +85,30 @@ type Service interface {
 	UploadSectionImage(ctx context.Context, sectionID uuid.UUID, file multipart.File, header *multipart.FileHeader, imageType ImageType, altText, caption string) (*ImageProcessResult, error)
 	DeleteSectionImage(ctx context.Context, sectionID uuid.UUID, imageType ImageType) error
 
+	// Image maintenance
+	CleanupOrphanedImages(ctx context.Context, purge bool) (OrphanReport, error)
+
+	// Media Library
+	UploadLibraryImage(ctx context.Context, file multipart.File, header *multipart.FileHeader, altText, title string) (Image, error)
+	ListLibraryImages(ctx context.Context, offset, limit int, search string) ([]LibraryImage, int, error)
+	GetLibraryImage(ctx context.Context, id uuid.UUID) (LibraryImage, error)
+	UpdateImageMetadata(ctx context.Context, id uuid.UUID, title, altText string) (Image, error)
+	AddLibraryImageToContent(ctx context.Context, contentID, imageID uuid.UUID) (string, error)
+
+	// Image file replacement
+	ReplaceImageFile(ctx context.Context, id uuid.UUID, file multipart.File, header *multipart.FileHeader) (ImageReplacement, error)
+	ListImageRevisions(ctx context.Context, id uuid.UUID) ([]ImageRevision, error)
+	RollbackImageFile(ctx context.Context, id uuid.UUID) (ImageReplacement, error)
+
+	// Attachments
+	UploadAttachment(ctx context.Context, file multipart.File, header *multipart.FileHeader, title string) (Attachment, error)
+	ListAttachments(ctx context.Context) ([]Attachment, error)
+	GetAttachment(ctx context.Context, id uuid.UUID) (Attachment, error)
+	DeleteAttachment(ctx context.Context, id uuid.UUID) error
+	GetContentAttachments(ctx context.Context, contentID uuid.UUID) ([]Attachment, error)
+	AddAttachmentToContent(ctx context.Context, contentID, attachmentID uuid.UUID) (string, error)
+	RemoveAttachmentFromContent(ctx context.Context, contentID, attachmentID uuid.UUID) error
+
 	// ContentTag related
 	AddTagToContent(ctx context.Context, contentID uuid.UUID, tagName string) error
 	RemoveTagFromContent(ctx context.Context, contentID, tagID uuid.UUID) error
@@ -88,8 +117,22 @@ type Service interface {
 
 	GenerateMarkdown(ctx context.Context) error
 	GenerateHTMLFromContent(ctx context.Context) error
-	Publish(ctx context.Context, commitMessage string) (string, error)
+	ImportMarkdown(ctx context.Context, dir string) (ImportReport, error)
+	MigrateSite(ctx context.Context, dir, source string) (ImportReport, error)
+	ImportWordPress(ctx context.Context, file, uploadsDir, categoriesAs string) (ImportReport, error)
+	WatchStatus(ctx context.Context) (WatchStatus, error)
+	ImportWatched(ctx context.Context) (ImportReport, error)
+	ResolveWatchConflict(ctx context.Context, path, keep string) (WatchedFile, error)
+	Publish(ctx context.Context, commitMessage string, force bool) (string, error)
 	Plan(ctx context.Context) (PlanReport, error)
+	CheckQuality(ctx context.Context) (QualityReport, error)
+	ListPublishRecords(ctx context.Context) ([]PublishRecord, error)
+	RollbackPublish(ctx context.Context, id uuid.UUID) (PublishRecord, error)
+	ListRedirects(ctx context.Context) ([]Redirect, error)
+	CreateRedirect(ctx context.Context, from string, contentID uuid.UUID, toPath string) (Redirect, error)
+	DeleteRedirect(ctx context.Context, id uuid.UUID) error
+	PlanModeSwitch(ctx context.Context, opts ModeSwitchOptions) (ModeSwitchPlan, error)
+	SwitchMode(ctx context.Context, opts ModeSwitchOptions) (ModeSwitchPlan, error)
 }
 
 // BaseService is the concrete implementation of the Service interface.
@@ -98,20 +141,24 @@ type BaseService struct {
 	assetsFS embed.FS
 	repo     Repo
 	gen      *Generator
+	src      *SourceRepo
 	pub      Publisher
 	pm       *ParamManager
 	im       *ImageManager
+	am       *AttachmentManager
 }
 
-func NewService(assetsFS embed.FS, repo Repo, gen *Generator, publisher Publisher, pm *ParamManager, im *ImageManager, params hm.XParams) *BaseService {
+func NewService(assetsFS embed.FS, repo Repo, gen *Generator, src *SourceRepo, publisher Publisher, pm *ParamManager, im *ImageManager, am *AttachmentManager, params hm.XParams) *BaseService {
 	return &BaseService{
 		Service:  hm.NewService("ssg-svc", params),
 		assetsFS: assetsFS,
 		repo:     repo,
 		gen:      gen,
+		src:      src,
 		pub:      publisher,
 		pm:       pm,
 		im:       im,
+		am:       am,
 	}
 }
 
@@ -122,75 +169,387 @@ func (svc *BaseService) getRepo(ctx context.Context) (Repo, error) {
 	return nil, fmt.Errorf("no repository available in service")
 }
 
-// Publish delegates the publishing task to the underlying pub.
-func (svc *BaseService) Publish(ctx context.Context, commitMessage string) (string, error) {
+// Publish delegates the publishing task to the underlying pub and records
+// the attempt in the publish history of the site.
+func (svc *BaseService) Publish(ctx context.Context, commitMessage string, force bool) (string, error) {
 	svc.Log().Info("Service starting publish process")
 
-	// For now, we build the config from the application's configuration.
-	cfg := PublisherConfig{
-		RepoURL: svc.pm.Get(ctx, SSGKey.PublishRepoURL, ""),
-		Branch:  svc.pm.Get(ctx, SSGKey.PublishBranch, ""),
-		Auth: hm.GitAuth{
-			// NOTE: This is oversimplified. We need to work out a bit more here.
-			Method: hm.AuthToken,
-			Token:  svc.pm.Get(ctx, SSGKey.PublishAuthToken, ""),
-		},
-		CommitAuthor: hm.GitCommit{
-			UserName:  svc.pm.Get(ctx, SSGKey.PublishCommitUserName, ""),
-			UserEmail: svc.pm.Get(ctx, SSGKey.PublishCommitUserEmail, ""),
-			Message:   svc.pm.Get(ctx, SSGKey.PublishCommitMessage, ""),
-		},
+	siteID, err := RequireSiteID(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	if err := svc.pm.Preload(ctx); err != nil {
+		svc.Log().Errorf("Cannot preload params: %v", err)
 	}
 
+	cfg := svc.publisherConfig(ctx)
+
 	// Override commit message if provided in the request body
 	if commitMessage != "" {
 		cfg.CommitAuthor.Message = commitMessage
 	}
 
-	// Get the output directory for HTML files, which is the source for publishing
-	sourceDir := svc.Cfg().StrValOrDef(SSGKey.HTMLPath, "_workspace/documents/html")
+	if err := svc.pub.Validate(cfg); err != nil {
+		return "", fmt.Errorf("invalid publish settings: %w", err)
+	}
+
+	sourceDir, err := svc.publishSourceDir(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	reportProgress(ctx, "Running quality checks")
+	quality, err := svc.CheckQuality(ctx)
+	if err != nil {
+		return "", err
+	}
+	reportProgress(ctx, "Quality checks: %s", quality.Summary)
+	if quality.Blocking() {
+		if !force {
+			return "", &QualityGateError{Report: quality}
+		}
+		svc.Log().Info("Publishing despite quality errors", "summary", quality.Summary)
+	}
+
+	record := NewPublishRecord(siteID, targetName(cfg), svc.actingUser(ctx))
+	if err := svc.publishAndRecord(ctx, cfg, sourceDir, record); err != nil {
+		return "", err
+	}
+
+	svc.Log().Info("Service publish process finished successfully", "commit_url", record.Reference)
+	return record.Reference, nil
+}
+
+// ListPublishRecords returns the publish history of the site in context, newest first.
+func (svc *BaseService) ListPublishRecords(ctx context.Context) ([]PublishRecord, error) {
+	siteID, err := RequireSiteID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := svc.repo.ListPublishRecords(ctx, siteID)
+	if err != nil {
+		return nil, fmt.Errorf("cannot list publish records: %w", err)
+	}
+	return records, nil
+}
+
+// RollbackPublish publishes again the exact output of a previous successful
+// publish, taken from its snapshot, and records it as a new publish.
+func (svc *BaseService) RollbackPublish(ctx context.Context, id uuid.UUID) (PublishRecord, error) {
+	siteID, err := RequireSiteID(ctx)
+	if err != nil {
+		return PublishRecord{}, err
+	}
+
+	previous, err := svc.repo.GetPublishRecord(ctx, id)
+	if err != nil {
+		return PublishRecord{}, err
+	}
+	if previous.SiteID != siteID {
+		return PublishRecord{}, fmt.Errorf("publish record not found")
+	}
+	if !previous.CanRollback() {
+		return PublishRecord{}, fmt.Errorf("publish %s has no snapshot to roll back to", previous.ID)
+	}
+
+	cfg := svc.publisherConfig(ctx)
+	cfg.CommitAuthor.Message = fmt.Sprintf("Roll back to publish of %s", previous.CreatedAt.Format("2006-01-02 15:04:05"))
+
+	if err := svc.pub.Validate(cfg); err != nil {
+		return PublishRecord{}, fmt.Errorf("invalid publish settings: %w", err)
+	}
+
+	sourceDir, err := os.MkdirTemp("", "clio-rollback-*")
+	if err != nil {
+		return PublishRecord{}, fmt.Errorf("cannot create rollback dir: %w", err)
+	}
+	defer os.RemoveAll(sourceDir)
+
+	snapshotPath, err := svc.snapshotPath(ctx, previous.SnapshotPath)
+	if err != nil {
+		return PublishRecord{}, err
+	}
+	if err := extractSnapshot(snapshotPath, sourceDir); err != nil {
+		return PublishRecord{}, err
+	}
+
+	record := NewPublishRecord(siteID, targetName(cfg), svc.actingUser(ctx))
+	record.RollbackOf = previous.ID
+	if err := svc.publishAndRecord(ctx, cfg, sourceDir, record); err != nil {
+		return *record, err
+	}
+
+	svc.Log().Info("Publish rolled back", "to", previous.ID, "reference", record.Reference)
+	return *record, nil
+}
+
+// actingUser returns the name of the user behind the request in ctx, or its
+// ID when the user cannot be found. It is empty when there is no user.
+func (svc *BaseService) actingUser(ctx context.Context) string {
+	user := hm.GetUserCtxData(ctx)
+	if user == nil || user.ID == uuid.Nil {
+		return ""
+	}
+
+	u, err := svc.repo.GetUser(ctx, user.ID)
+	if err != nil {
+		return user.ID.String()
+	}
+	if u.Name != "" {
+		return u.Name
+	}
+	return u.Username
+}
+
+// publishAndRecord publishes sourceDir and saves the outcome in record. On
+// success the published output is kept as a snapshot for later rollbacks.
+func (svc *BaseService) publishAndRecord(ctx context.Context, cfg PublisherConfig, sourceDir string, record *PublishRecord) error {
+	reportProgress(ctx, "Publishing to the %s target", record.Target)
+	start := time.Now()
+	ref, report, publishErr := svc.pub.Publish(ctx, cfg, sourceDir)
+	record.DurationMS = time.Since(start).Milliseconds()
+
+	if publishErr != nil {
+		record.Status = PublishStatusFailure
+		record.Error = publishErr.Error()
+	} else {
+		record.Status = PublishStatusSuccess
+		record.Reference = ref
+		record.CommitHash = commitHashFrom(record.Target, ref)
+		record.Summary = report.Summary
+		reportProgress(ctx, "%s", report.Summary)
+		reportProgress(ctx, "Published %s", ref)
+
+		name := record.ID.String() + ".tar.gz"
+		snapshotPath, err := svc.snapshotPath(ctx, name)
+		if err == nil {
+			err = writeSnapshot(snapshotPath, sourceDir)
+		}
+		if err != nil {
+			svc.Log().Error("Cannot keep publish snapshot", "error", err)
+		} else {
+			record.SnapshotPath = name
+		}
+	}
+
+	if err := svc.repo.CreatePublishRecord(ctx, record); err != nil {
+		svc.Log().Error("Cannot save publish record", "error", err)
+	}
+
+	if publishErr != nil {
+		return fmt.Errorf("cannot publish site: %w", publishErr)
+	}
+
+	svc.pruneSnapshots(ctx, record.SiteID)
+	return nil
+}
+
+// pruneSnapshots drops the snapshots of the oldest publishes beyond the
+// configured number to keep.
+func (svc *BaseService) pruneSnapshots(ctx context.Context, siteID uuid.UUID) {
+	keep := svc.pm.SettingInt(ctx, SSGKey.PublishHistorySnapshots)
+
+	records, err := svc.repo.ListPublishRecords(ctx, siteID)
+	if err != nil {
+		svc.Log().Error("Cannot list publish records to prune", "error", err)
+		return
+	}
+
+	kept := 0
+	for _, record := range records {
+		if record.SnapshotPath == "" {
+			continue
+		}
+		kept++
+		if kept <= keep {
+			continue
+		}
+
+		path, err := svc.snapshotPath(ctx, record.SnapshotPath)
+		if err == nil {
+			err = os.Remove(path)
+		}
+		if err != nil && !os.IsNotExist(err) {
+			svc.Log().Error("Cannot remove publish snapshot", "id", record.ID, "error", err)
+			continue
+		}
+		if err := svc.repo.ClearPublishRecordSnapshot(ctx, record.ID); err != nil {
+			svc.Log().Error("Cannot clear publish snapshot", "id", record.ID, "error", err)
+		}
+	}
+}
 
-	commitURL, err := svc.pub.Publish(ctx, cfg, sourceDir)
+// snapshotPath resolves the file of a publish snapshot of the site in context.
+func (svc *BaseService) snapshotPath(ctx context.Context, name string) (string, error) {
+	siteSlug, err := RequireSiteSlug(ctx)
 	if err != nil {
-		return "", fmt.Errorf("cannot publish site: %w", err)
+		return "", err
 	}
 
-	svc.Log().Info("Service publish process finished successfully", "commit_url", commitURL)
-	return commitURL, nil
+	sitesBasePath := svc.Cfg().StrValOrDef(SSGKey.SitesBasePath, "_workspace/sites")
+	return filepath.Join(GetSitePublishSnapshotsPath(sitesBasePath, siteSlug), filepath.Base(name)), nil
 }
 
 // Plan delegates the plan task to the underlying pub.
 func (svc *BaseService) Plan(ctx context.Context) (PlanReport, error) {
 	svc.Log().Info("Service starting plan process")
 
-	// For now, we build the config from the application's configuration.
-	cfg := PublisherConfig{
-		RepoURL: svc.pm.Get(ctx, SSGKey.PublishRepoURL, ""),
-		Branch:  svc.pm.Get(ctx, SSGKey.PublishBranch, ""),
-		Auth: hm.GitAuth{
-			// NOTE: This is oversimplified. We need to work out a bit more here.
-			Method: hm.AuthToken,
-			Token:  svc.pm.Get(ctx, SSGKey.PublishAuthToken, ""),
-		},
-		CommitAuthor: hm.GitCommit{
-			UserName:  svc.pm.Get(ctx, SSGKey.PublishCommitUserName, ""),
-			UserEmail: svc.pm.Get(ctx, SSGKey.PublishCommitUserEmail, ""),
-			Message:   svc.pm.Get(ctx, SSGKey.PublishCommitMessage, ""),
-		},
+	cfg := svc.publisherConfig(ctx)
+
+	if err := svc.pub.Validate(cfg); err != nil {
+		return PlanReport{}, fmt.Errorf("invalid publish settings: %w", err)
 	}
 
-	// Get the output directory for HTML files, which is the source for planning
-	sourceDir := svc.Cfg().StrValOrDef(SSGKey.HTMLPath, "_workspace/documents/html")
+	sourceDir, err := svc.publishSourceDir(ctx)
+	if err != nil {
+		return PlanReport{}, err
+	}
 
+	reportProgress(ctx, "Comparing the site with the %s target", targetName(cfg))
 	report, err := svc.pub.Plan(ctx, cfg, sourceDir)
 	if err != nil {
 		return PlanReport{}, fmt.Errorf("cannot plan site: %w", err)
 	}
+	reportProgress(ctx, "%s", report.Summary)
+
+	repo, err := svc.getRepo(ctx)
+	if err != nil {
+		return PlanReport{}, fmt.Errorf("repo not available: %w", err)
+	}
+
+	contents, err := repo.GetAllContentWithMeta(ctx)
+	if err != nil {
+		return PlanReport{}, fmt.Errorf("cannot get contents for plan: %w", err)
+	}
+
+	mapPlanToContents(&report, contents, svc.pm.GetSiteMode(ctx))
 
 	svc.Log().Info("Service plan process finished successfully", "summary", report.Summary)
 	return report, nil
 }
 
+// CheckQuality runs the quality checks on the last build of the site in
+// context.
+func (svc *BaseService) CheckQuality(ctx context.Context) (QualityReport, error) {
+	siteSlug, err := RequireSiteSlug(ctx)
+	if err != nil {
+		return QualityReport{}, err
+	}
+
+	repo, err := svc.getRepo(ctx)
+	if err != nil {
+		return QualityReport{}, fmt.Errorf("repo not available: %w", err)
+	}
+
+	contents, err := repo.GetAllContentWithMeta(ctx)
+	if err != nil {
+		return QualityReport{}, fmt.Errorf("cannot get contents for quality checks: %w", err)
+	}
+
+	sitesBasePath := svc.Cfg().StrValOrDef(SSGKey.SitesBasePath, "_workspace/sites")
+	build, err := readBuildReport(GetSiteBuildReportPath(sitesBasePath, siteSlug))
+	if err != nil {
+		return QualityReport{}, err
+	}
+
+	report, err := checkQuality(qualityInput{
+		contents:   contents,
+		siteMode:   svc.pm.GetSiteMode(ctx),
+		htmlPath:   GetSiteHTMLPath(sitesBasePath, siteSlug),
+		build:      build,
+		severities: svc.qualitySeverities(ctx),
+	})
+	if err != nil {
+		return QualityReport{}, fmt.Errorf("cannot check quality: %w", err)
+	}
+
+	svc.Log().Info("Quality checks finished", "summary", report.Summary)
+	return report, nil
+}
+
+// qualitySeverities reads the severity of each quality check from the settings
+// of the site in context.
+func (svc *BaseService) qualitySeverities(ctx context.Context) map[string]string {
+	severities := map[string]string{}
+	for _, qc := range QualityChecks {
+		severities[qc.Name] = svc.pm.Setting(ctx, SSGKey.QualityPrefix+"."+qc.Name)
+	}
+	return severities
+}
+
+// publisherConfig builds the publish configuration from the params of the site in context.
+func (svc *BaseService) publisherConfig(ctx context.Context) PublisherConfig {
+	token := svc.pm.Setting(ctx, SSGKey.PublishAuthToken)
+
+	authMethod := hm.AuthMethod(svc.pm.Setting(ctx, SSGKey.PublishAuthMethod))
+	if authMethod == hm.AuthToken && token == "" {
+		// Nothing to authenticate with, let git use its own configuration
+		authMethod = ""
+	}
+
+	return PublisherConfig{
+		Target:        svc.pm.Setting(ctx, SSGKey.PublishTarget),
+		TargetPath:    svc.pm.Setting(ctx, SSGKey.PublishTargetPath),
+		ArchiveFormat: svc.pm.Setting(ctx, SSGKey.PublishArchiveFormat),
+		RepoURL:       svc.pm.Setting(ctx, SSGKey.PublishRepoURL),
+		Branch:        svc.pm.Setting(ctx, SSGKey.PublishBranch),
+		PagesSubdir:   svc.pm.Setting(ctx, SSGKey.PublishPagesSubdir),
+		SSHKeyPath:    svc.pm.Setting(ctx, SSGKey.PublishAuthSSHKey),
+		Preserve:      parsePreserveList(svc.pm.Setting(ctx, SSGKey.PublishPreserve)),
+		Auth: hm.GitAuth{
+			Method: authMethod,
+			Token:  token,
+		},
+		CommitAuthor: hm.GitCommit{
+			UserName:  svc.pm.Setting(ctx, SSGKey.PublishCommitUserName),
+			UserEmail: svc.pm.Setting(ctx, SSGKey.PublishCommitUserEmail),
+			Message:   svc.pm.Setting(ctx, SSGKey.PublishCommitMessage),
+		},
+	}
+}
+
+// sourceVersioningEnabled reports whether the Markdown export of the site in
+// context is kept in git.
+func (svc *BaseService) sourceVersioningEnabled(ctx context.Context) bool {
+	if svc.src == nil {
+		return false
+	}
+
+	return svc.pm.SettingBool(ctx, SSGKey.ContentVersioning)
+}
+
+// sourceRepoConfig builds the configuration of the Markdown repository. The
+// remote is optional and authenticates with the publish credentials.
+func (svc *BaseService) sourceRepoConfig(ctx context.Context) PublisherConfig {
+	cfg := svc.publisherConfig(ctx)
+	cfg.Target = ""
+	cfg.RepoURL = svc.pm.Setting(ctx, SSGKey.ContentRepoURL)
+	cfg.Branch = svc.pm.Setting(ctx, SSGKey.ContentBranch)
+	cfg.PagesSubdir = ""
+	return cfg
+}
+
+// pagesOptions reads the GitHub Pages settings of the site in context.
+func (svc *BaseService) pagesOptions(ctx context.Context) PagesOptions {
+	return PagesOptions{
+		CustomDomain: svc.pm.Setting(ctx, SSGKey.PublishCustomDomain),
+		NoJekyll:     svc.pm.SettingBool(ctx, SSGKey.PublishNoJekyll),
+	}
+}
+
+// publishSourceDir returns the generated HTML directory of the site in context.
+func (svc *BaseService) publishSourceDir(ctx context.Context) (string, error) {
+	siteSlug, err := RequireSiteSlug(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	sitesBasePath := svc.Cfg().StrValOrDef(SSGKey.SitesBasePath, "_workspace/sites")
+	return GetSiteHTMLPath(sitesBasePath, siteSlug), nil
+}
+
 // GenerateMarkdown generates markdown files from the content in the database.
 func (svc *BaseService) GenerateMarkdown(ctx context.Context) error {
 	svc.Log().Info("Service starting markdown generation")
@@ -205,1030 +564,2624 @@ func (svc *BaseService) GenerateMarkdown(ctx context.Context) error {
 		return fmt.Errorf("cannot get all content with meta: %w", err)
 	}
 
+	reportProgress(ctx, "Generating Markdown for %d contents", len(contents))
 	if err := svc.gen.Generate(ctx, siteSlug, contents); err != nil {
 		return fmt.Errorf("cannot generate markdown: %w", err)
 	}
 
+	reportProgress(ctx, "Markdown generated")
+
+	if svc.sourceVersioningEnabled(ctx) {
+		reportProgress(ctx, "Committing Markdown changes")
+		sitesBasePath := svc.Cfg().StrValOrDef(SSGKey.SitesBasePath, "_workspace/sites")
+		commit, err := svc.src.Commit(ctx, svc.sourceRepoConfig(ctx), GetSiteMarkdownPath(sitesBasePath, siteSlug), contents)
+		if err != nil {
+			return fmt.Errorf("cannot version markdown: %w", err)
+		}
+		if commit.Hash == "" {
+			reportProgress(ctx, "No Markdown changes to commit")
+		} else {
+			reportProgress(ctx, "Committed %d Markdown changes", len(commit.Changes))
+		}
+	}
 	svc.Log().Info("Service markdown generation finished")
 	return nil
 }
 
-// GenerateHTMLFromContent generates HTML files from the content in the database.
-func (svc *BaseService) GenerateHTMLFromContent(ctx context.Context) error {
-	svc.Log().Info("Service starting HTML generation")
+// ImportMarkdown reads the Markdown files under dir, laid out as GenerateMarkdown
+// writes them, into the site in ctx. Files are matched to existing contents by
+// short ID and update them; files that clash with another content by slug or
+// heading are reported as conflicts and the rest are created, along with any
+// missing section. An empty dir imports the Markdown export of the site.
+func (svc *BaseService) ImportMarkdown(ctx context.Context, dir string) (ImportReport, error) {
+	svc.Log().Info("Service starting markdown import")
 
-	repo, err := svc.getRepo(ctx)
+	if dir == "" {
+		siteSlug, err := RequireSiteSlug(ctx)
+		if err != nil {
+			return ImportReport{}, err
+		}
+		sitesBasePath := svc.Cfg().StrValOrDef(SSGKey.SitesBasePath, "_workspace/sites")
+		dir = GetSiteMarkdownPath(sitesBasePath, siteSlug)
+	}
+
+	files, err := markdownFiles(dir)
 	if err != nil {
-		return fmt.Errorf("repo not available: %w", err)
+		return ImportReport{}, fmt.Errorf("cannot list markdown files: %w", err)
 	}
 
-	contents, err := repo.GetAllContentWithMeta(ctx)
+	imp, siteID, err := svc.newMarkdownImport(ctx)
 	if err != nil {
-		return fmt.Errorf("cannot get all content with meta: %w", err)
+		return ImportReport{}, err
 	}
 
-	// Set placeholder for content without image
-	for range contents {
-		// TODO: Handle placeholder image via relationships
-		// if contents[i].Image == "" {
-		//	contents[i].Image = "/static/img/placeholder.png"
-		// }
+	report := ImportReport{Items: []ImportItem{}, SectionsCreated: []string{}}
+
+	reportProgress(ctx, "Importing %d Markdown files", len(files))
+	for _, rel := range files {
+		item, _, err := svc.importMarkdownFile(ctx, imp, siteID, dir, rel, importOptions{}, &report)
+		if err != nil {
+			item.Action = ImportFailed
+			item.Message = err.Error()
+			reportProgress(ctx, "Cannot import %s: %v", rel, err)
+		}
+		report.add(item)
 	}
 
-	sections, err := repo.GetSections(ctx)
+	report.summarize()
+	reportProgress(ctx, "%s", report.Summary)
+	svc.Log().Info("Service markdown import finished", "summary", report.Summary)
+	return report, nil
+}
+
+// importMarkdownFile imports the file at rel under dir and returns the
+// content as stored.
+func (svc *BaseService) importMarkdownFile(ctx context.Context, imp *markdownImport, siteID uuid.UUID, dir, rel string, opts importOptions, report *ImportReport) (ImportItem, Content, error) {
+	item := ImportItem{Path: rel}
+
+	data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(rel)))
 	if err != nil {
-		return fmt.Errorf("cannot get sections: %w", err)
+		return item, Content{}, fmt.Errorf("cannot read file: %w", err)
 	}
 
-	// Get site mode to determine UI behavior
-	siteMode := svc.pm.GetSiteMode(ctx)
-	svc.Log().Infof("Site mode: %s", siteMode)
+	doc, err := parseMarkdownDoc(data)
+	if err != nil {
+		return item, Content{}, err
+	}
 
-	// In blog mode, hide section menu (only root exists, no need to show sections)
-	var menuSections []Section
-	if siteMode == "structured" {
-		for _, s := range sections {
-			if s.Name != "root" {
-				menuSections = append(menuSections, s)
-			}
-		}
+	ic, err := doc.toContent(rel)
+	if err != nil {
+		return item, Content{}, err
 	}
 
-	layoutPath := svc.Cfg().StrValOrDef(SSGKey.LayoutPath, "assets/ssg/layout/layout.html")
-	tmpl, err := template.ParseFS(svc.assetsFS,
-		layoutPath,
-		"assets/ssg/partial/list.tmpl",
-		"assets/ssg/partial/blocks.tmpl",
-		"assets/ssg/partial/article-blocks.tmpl",
-		"assets/ssg/partial/blog-blocks.tmpl",
-		"assets/ssg/partial/series-blocks.tmpl",
-		"assets/ssg/partial/pagination.tmpl",
-		"assets/ssg/partial/google-search.tmpl",
-	)
+	return svc.importContent(ctx, imp, siteID, rel, ic, opts, report)
+}
+
+// importContent creates the content ic stands for or updates the existing one
+// it matches. rel is the path of its file, as listed in the report.
+func (svc *BaseService) importContent(ctx context.Context, imp *markdownImport, siteID uuid.UUID, rel string, ic importedContent, opts importOptions, report *ImportReport) (ImportItem, Content, error) {
+	item := ImportItem{Path: rel, Heading: ic.Content.Heading}
+
+	section, err := svc.importSection(ctx, imp, siteID, ic.Content, report)
 	if err != nil {
-		return fmt.Errorf("cannot parse template from embedded fs: %w", err)
+		return item, Content{}, err
 	}
+	ic.Content.SectionID = section.ID
 
-	siteSlug, ok := GetSiteSlugFromContext(ctx)
+	existing, ok := imp.match(ic)
 	if !ok {
-		return fmt.Errorf("site slug not found in context")
+		if existing, ok = imp.collision(ic); ok {
+			if !opts.MatchSlug {
+				item.ContentID = existing.ID
+				item.Action = ImportConflict
+				item.Message = fmt.Sprintf("It has no short ID and clashes with the slug or heading of %q", existing.Heading)
+				return item, existing, nil
+			}
+			imp.claim(existing)
+		}
 	}
 
-	sitesBasePath := svc.Cfg().StrValOrDef(SSGKey.SitesBasePath, "_workspace/sites")
-	htmlPath := GetSiteHTMLPath(sitesBasePath, siteSlug)
+	if ok {
+		item.ContentID = existing.ID
 
-	if err := CopyStaticAssets(svc.assetsFS, htmlPath); err != nil {
-		return fmt.Errorf("cannot copy static assets: %w", err)
-	}
+		content, changed := merge(existing, ic)
+		if !changed {
+			item.Action = ImportUnchanged
+			return item, existing, nil
+		}
 
-	// Copy dynamic images from assets/images to html/static/images
-	docsDir := GetSiteDocsPath(sitesBasePath, siteSlug)
-	svc.Log().Info("Copying dynamic images", "from", filepath.Join(docsDir, "assets", "images"), "to", filepath.Join(htmlPath, "static", "images"))
-	if err := CopyDynamicImages(docsDir, htmlPath); err != nil {
-		svc.Log().Error("Failed to copy dynamic images", "error", err)
-		return fmt.Errorf("cannot copy dynamic images: %w", err)
+		if opts.Conflict != nil {
+			if reason := opts.Conflict(existing); reason != "" {
+				item.Action = ImportConflict
+				item.Message = reason
+				return item, existing, nil
+			}
+		}
+
+		content.SiteID = siteID
+		content.GenUpdateValues()
+		content.Meta.GenUpdateValues()
+		if err := svc.UpdateContent(ctx, &content); err != nil {
+			return item, Content{}, fmt.Errorf("cannot update content: %w", err)
+		}
+		if err := svc.syncContentTags(ctx, content.ID, content.Tags); err != nil {
+			return item, Content{}, err
+		}
+
+		item.Action = ImportUpdated
+		return item, content, nil
 	}
-	svc.Log().Info("Dynamic images copied successfully")
 
-	headerStyle := svc.Cfg().StrValOrDef(SSGKey.HeaderStyle, "boxed", true)
-	imageExtensions := []string{".png", ".jpg", ".jpeg", ".webp"}
+	content := ic.Content
+	if content.ShortID != "" && imp.shortIDTaken(content.ShortID) {
+		content.ShortID = ""
+	}
+	content.SiteID = siteID
+	if content.Kind == "" {
+		content.Kind = "article"
+	}
+	if opts.NewAsDraft {
+		content.Draft = true
+	}
+	content.GenCreateValues()
+	if ic.CreatedAt != nil {
+		content.CreatedAt = *ic.CreatedAt
+	}
 
-	// Prepare SearchData
-	searchData := SearchData{
-		Provider: "google", // O el proveedor que corresponda
-		Enabled:  svc.Cfg().BoolVal(SSGKey.SearchGoogleEnabled, false),
-		ID:       svc.Cfg().StrValOrDef(SSGKey.SearchGoogleID, ""),
+	if err := svc.CreateContent(ctx, &content); err != nil {
+		return item, Content{}, fmt.Errorf("cannot create content: %w", err)
 	}
-	svc.Log().Infof("SearchData: enabled=%v, id=%s", searchData.Enabled, searchData.ID)
+	imp.created(content)
+	item.ContentID = content.ID
 
-	for _, content := range contents {
-		svc.Log().Debug("Processing content for HTML generation", "slug", content.Slug(), "section_path", content.SectionPath)
-		if content.Draft {
-			svc.Log().Debug("Skipping draft content", "slug", content.Slug())
-			continue
+	for _, tag := range content.Tags {
+		if err := svc.AddTagToContent(ctx, content.ID, tag.Name); err != nil {
+			return item, Content{}, fmt.Errorf("cannot add tag %s: %w", tag.Name, err)
 		}
+	}
 
-		headerImagePath := ""
+	item.Action = ImportCreated
+	return item, content, nil
+}
 
-		if content.HeaderImageURL != "" {
-			headerImagePath = content.HeaderImageURL
-		} else {
-			// Use path helper to get correct content directory based on mode
-			contentDir := filepath.Dir(GetContentFilePath(htmlPath, content, siteMode))
-			contentImgDir := filepath.Join(contentDir, "img")
+// MigrateSite imports the contents of the Hugo or Jekyll site at dir into the
+// site in ctx. source is MigrateHugo or MigrateJekyll, detected from the tree
+// when empty. The local images the contents reference are copied to the site
+// images and linked to their contents. Running it again updates what changed.
+func (svc *BaseService) MigrateSite(ctx context.Context, dir, source string) (ImportReport, error) {
+	svc.Log().Info("Service starting site migration", "dir", dir, "source", source)
 
-			foundSpecificHeader := false
-			for _, ext := range imageExtensions {
-				checkPath := filepath.Join("assets", "content", content.SectionPath, content.Slug(), "img", "header"+ext)
-				if f, err := svc.assetsFS.Open(checkPath); err == nil {
-					f.Close()
-					if err := os.MkdirAll(contentImgDir, 0755); err != nil {
-						return fmt.Errorf("cannot create img directory: %w", err)
-					}
-					dst := filepath.Join(contentImgDir, "header"+ext)
-					if err := copyFile(svc.assetsFS, checkPath, dst); err != nil {
-						return fmt.Errorf("cannot copy specific header: %w", err)
-					}
-					headerImagePath = "img/header" + ext
-					foundSpecificHeader = true
-					break
-				}
-			}
+	if dir == "" {
+		return ImportReport{}, fmt.Errorf("the directory of the site to migrate is required")
+	}
 
-			if !foundSpecificHeader {
-				headerImagePath = "/static/img/header.png"
-			}
+	if source == "" {
+		var err error
+		source, err = detectMigrationSource(dir)
+		if err != nil {
+			return ImportReport{}, err
 		}
+	}
 
-		assetPath := "/"
+	files, sectionNames, err := migrationFiles(dir, source)
+	if err != nil {
+		return ImportReport{}, err
+	}
 
-		contentImages, err := svc.GetContentImages(ctx, content.ID)
+	imp, siteID, err := svc.newMarkdownImport(ctx)
+	if err != nil {
+		return ImportReport{}, err
+	}
+
+	imagesByPath, err := svc.siteImagesByPath(ctx, siteID)
+	if err != nil {
+		return ImportReport{}, err
+	}
+
+	report := ImportReport{Items: []ImportItem{}, SectionsCreated: []string{}}
+
+	reportProgress(ctx, "Migrating %d %s files", len(files), source)
+	for _, f := range files {
+		item, err := svc.migrateFile(ctx, imp, siteID, dir, source, f, sectionNames, imagesByPath, &report)
 		if err != nil {
-			svc.Log().Debug("Failed to load content images", "contentID", content.ID, "error", err)
-			contentImages = []ImageWithMeta{}
+			item.Action = ImportFailed
+			item.Message = err.Error()
+			reportProgress(ctx, "Cannot migrate %s: %v", f.Path, err)
 		}
+		report.add(item)
+	}
 
-		imageContext := &ImageContext{
-			Images: make(map[string]ImageMetadata),
-		}
+	report.summarize()
+	reportProgress(ctx, "%s", report.Summary)
+	return report, nil
+}
 
-		for _, img := range contentImages {
-			svc.Log().Debug("Adding image to context", "filePath", img.FilePath, "altText", img.AltText)
-			imageContext.Images[img.FilePath] = ImageMetadata{
-				AltText: img.AltText,
-				Title:   img.Title,
-			}
+// siteImagesByPath returns the images of the site by their path under the site
+// images.
+func (svc *BaseService) siteImagesByPath(ctx context.Context, siteID uuid.UUID) (map[string]Image, error) {
+	images, err := svc.ListImages(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("cannot list images: %w", err)
+	}
+	imagesByPath := map[string]Image{}
+	for _, img := range images {
+		if img.SiteID == siteID {
+			imagesByPath[img.FilePath] = img
 		}
+	}
+	return imagesByPath, nil
+}
 
-		processor := NewMarkdownProcessor()
+// ImportWordPress imports the posts and pages of the WordPress export file
+// into the site in ctx. Images are copied from uploadsDir, a local copy of
+// wp-content/uploads, when given. categoriesAs is WordPressCategoriesAsTags,
+// the default, or WordPressCategoriesAsSections. Old permalinks are kept as
+// redirects to the imported contents.
+func (svc *BaseService) ImportWordPress(ctx context.Context, file, uploadsDir, categoriesAs string) (ImportReport, error) {
+	svc.Log().Info("Service starting WordPress import", "file", file, "uploads", uploadsDir, "categories_as", categoriesAs)
 
-		htmlBody, err := processor.ToHTMLWithImageContext([]byte(content.Body), imageContext)
-		if err != nil {
-			svc.Log().Error("Error converting markdown to HTML", "slug", content.Slug(), "error", err)
-			continue
-		}
+	if file == "" {
+		return ImportReport{}, fmt.Errorf("the WordPress export file is required")
+	}
+	switch categoriesAs {
+	case "":
+		categoriesAs = WordPressCategoriesAsTags
+	case WordPressCategoriesAsTags, WordPressCategoriesAsSections:
+	default:
+		return ImportReport{}, fmt.Errorf("categories can be imported as %s or %s, not %q",
+			WordPressCategoriesAsTags, WordPressCategoriesAsSections, categoriesAs)
+	}
 
-		if headerStyle == "boxed" || headerStyle == "overlay" {
-			htmlBody = svc.removeFirstH1(htmlBody)
-		}
+	f, err := os.Open(file)
+	if err != nil {
+		return ImportReport{}, fmt.Errorf("cannot open WordPress export: %w", err)
+	}
+	defer f.Close()
 
-		pageContent := PageContent{
-			Heading:            content.Heading,
-			HeaderImage:        headerImagePath,
-			HeaderImageAlt:     content.HeaderImageAlt,
-			HeaderImageCaption: content.HeaderImageCaption,
-			Body:               template.HTML(htmlBody),
-			Kind:               content.Kind,
-		}
+	export, err := parseWXR(f)
+	if err != nil {
+		return ImportReport{}, err
+	}
+
+	imp, siteID, err := svc.newMarkdownImport(ctx)
+	if err != nil {
+		return ImportReport{}, err
+	}
 
-		blocks := BuildBlocks(content, contents, int(svc.Cfg().IntVal(SSGKey.BlocksMaxItems, 5)))
+	imagesByPath, err := svc.siteImagesByPath(ctx, siteID)
+	if err != nil {
+		return ImportReport{}, err
+	}
 
-		data := PageData{
-			HeaderStyle: headerStyle,
-			AssetPath:   assetPath,
-			Menu:        menuSections,
-			Content:     pageContent,
-			Blocks:      blocks,
-			Search:      searchData,
+	media := newWordPressMedia(export, uploadsDir)
+	posts := export.posts()
+	report := ImportReport{Items: []ImportItem{}, SectionsCreated: []string{}}
+
+	reportProgress(ctx, "Importing %d WordPress posts and pages", len(posts))
+	for _, post := range posts {
+		item, err := svc.importWordPressItem(ctx, imp, siteID, post, media, categoriesAs, imagesByPath, &report)
+		if err != nil {
+			item.Action = ImportFailed
+			item.Message = err.Error()
+			reportProgress(ctx, "Cannot import %s: %v", item.Path, err)
 		}
+		report.add(item)
+	}
 
-		var buf bytes.Buffer
-		if err := tmpl.Execute(&buf, data); err != nil {
-			svc.Log().Error("Error executing template for content", "slug", content.Slug(), "error", err)
-			continue
+	unattached := media.unattached(posts)
+	if len(unattached) > 0 {
+		reportProgress(ctx, "Copying %d images not attached to any post", len(unattached))
+	}
+	for _, mi := range unattached {
+		if _, err := svc.importImage(ctx, siteID, mi, imagesByPath, &report); err != nil {
+			reportProgress(ctx, "%v", err)
 		}
+	}
 
-		// Use path helper to get correct output path based on mode
-		outputPath := GetContentFilePath(htmlPath, content, siteMode)
+	report.summarize()
+	reportProgress(ctx, "%s", report.Summary)
+	return report, nil
+}
 
-		if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
-			svc.Log().Error("Error creating directory for HTML file", "path", outputPath, "error", err)
-			continue
-		}
+// importWordPressItem imports a post or page of a WordPress export with its
+// images and keeps its permalink as a redirect.
+func (svc *BaseService) importWordPressItem(ctx context.Context, imp *markdownImport, siteID uuid.UUID, post wxrItem, media *wordPressMedia, categoriesAs string, images map[string]Image, report *ImportReport) (ImportItem, error) {
+	rel := post.Link
+	if rel == "" {
+		rel = post.PostType + " " + post.PostID
+	}
 
-		if err := os.WriteFile(outputPath, buf.Bytes(), 0644); err != nil {
-			svc.Log().Error("Error writing index HTML file", "path", outputPath, "error", err)
-			continue
-		}
+	mc, err := readWordPressItem(post, media, categoriesAs)
+	if err != nil {
+		return ImportItem{Path: rel}, err
 	}
 
-	// Generate index pages
-	indexes := BuildIndexes(contents, sections, siteMode)
-	svc.Log().Infof("Built %d indexes (mode: %s)", len(indexes), siteMode)
-	for _, idx := range indexes {
-		svc.Log().Infof("  Index: path=%s, type=%s, content_count=%d", idx.Path, idx.Type, len(idx.Content))
+	item, content, err := svc.importContent(ctx, imp, siteID, rel, mc.importedContent, importOptions{MatchSlug: true}, report)
+	if err != nil {
+		return item, err
 	}
 
-	// Create a lookup map for manual index pages
-	manualIndexPages := make(map[string]bool)
-	for _, c := range contents {
-		if strings.ToLower(c.Kind) == "page" && c.Slug() == "index" {
-			manualIndexPages[c.SectionPath] = true
-		}
+	if err := svc.attachMigratedImages(ctx, siteID, content.ID, mc.Images, images, report); err != nil {
+		return item, err
 	}
 
-	postsPerPage := int(svc.Cfg().IntVal(SSGKey.IndexMaxItems, 9))
+	notes := append(mc.Notes, svc.saveImportRedirects(ctx, siteID, content.ID, mc.Redirects, RedirectWordPress, report)...)
+	item.Message = strings.Join(notes, "; ")
+	return item, nil
+}
 
-	for _, index := range indexes {
-		svc.Log().Infof("Processing index: path=%s, content_count=%d", index.Path, len(index.Content))
+// migrateFile imports the content file f of the site at root and links the
+// images it references.
+func (svc *BaseService) migrateFile(ctx context.Context, imp *markdownImport, siteID uuid.UUID, root, source string, f migrationFile, sectionNames map[string]string, images map[string]Image, report *ImportReport) (ImportItem, error) {
+	mc, err := readMigrationFile(root, source, f, sectionNames)
+	if err != nil {
+		return ImportItem{Path: f.Path}, err
+	}
 
-		// Check if a manual index page exists for this path
-		if manualIndexPages[index.Path] {
-			svc.Log().Info(fmt.Sprintf("Skipping index generation for '%s': manual index page found.", index.Path))
+	item, content, err := svc.importContent(ctx, imp, siteID, f.Path, mc.importedContent, importOptions{MatchSlug: true}, report)
+	if err != nil {
+		return item, err
+	}
+
+	if err := svc.attachMigratedImages(ctx, siteID, content.ID, mc.Images, images, report); err != nil {
+		return item, err
+	}
+
+	notes := append(mc.Notes, svc.saveImportRedirects(ctx, siteID, content.ID, mc.Redirects, source, report)...)
+	item.Message = strings.Join(notes, "; ")
+	return item, nil
+}
+
+// saveImportRedirects keeps the old URLs of an imported content working by
+// redirecting them to it. It returns a note for each URL that cannot be kept.
+func (svc *BaseService) saveImportRedirects(ctx context.Context, siteID, contentID uuid.UUID, paths []string, source string, report *ImportReport) []string {
+	var notes []string
+	for _, from := range paths {
+		redirect, err := NewRedirect(siteID, from, source)
+		if err != nil {
+			notes = append(notes, fmt.Sprintf("Redirect from %s not kept: %v", from, err))
+			continue
+		}
+		redirect.ContentID = contentID
+		if err := svc.repo.SaveRedirect(ctx, redirect); err != nil {
+			notes = append(notes, fmt.Sprintf("Redirect from %s not kept: %v", from, err))
 			continue
 		}
+		report.Redirects++
+	}
+	return notes
+}
 
-		// Get section header image for this index
-		var sectionHeaderImage string
-		for _, section := range sections {
-			if section.Path == index.Path {
-				headerPath, err := svc.GetSectionHeaderImage(ctx, section.ID)
-				if err == nil && headerPath != "" {
-					sectionHeaderImage = "/static/images/" + strings.TrimPrefix(headerPath, "/")
-				}
-				break
-			}
+// attachMigratedImages copies the images of a migrated content to the site
+// images and links them to the content, creating the image records missing
+// from images.
+func (svc *BaseService) attachMigratedImages(ctx context.Context, siteID, contentID uuid.UUID, migrated []migrationImage, images map[string]Image, report *ImportReport) error {
+	if len(migrated) == 0 {
+		return nil
+	}
+
+	contentImages, err := svc.repo.GetContentImagesByContentID(ctx, contentID)
+	if err != nil {
+		return fmt.Errorf("cannot get content images: %w", err)
+	}
+	linked := map[uuid.UUID]bool{}
+	for _, ci := range contentImages {
+		linked[ci.ImageID] = true
+	}
+
+	for _, mi := range migrated {
+		img, err := svc.importImage(ctx, siteID, mi, images, report)
+		if err != nil {
+			return err
 		}
 
-		// Paginate the content
-		totalContent := len(index.Content)
-		// In blog mode, always generate root index even if empty (it's the homepage)
-		// In structured mode, skip empty indexes
-		if totalContent == 0 && !(siteMode == "blog" && index.Path == "/") {
-			svc.Log().Info("Skipping empty index", "path", index.Path)
+		if linked[img.ID] {
 			continue
 		}
-		totalPages := (totalContent + postsPerPage - 1) / postsPerPage
-		if totalPages == 0 {
-			totalPages = 1 // At least one page for empty blog mode index
+		if err := svc.repo.CreateContentImage(ctx, NewContentImage(contentID, img.ID, mi.IsHeader)); err != nil {
+			return fmt.Errorf("cannot link image %s: %w", mi.Target, err)
 		}
+		linked[img.ID] = true
+	}
 
-		for page := 1; page <= totalPages; page++ {
-			start := (page - 1) * postsPerPage
-			end := start + postsPerPage
-			if end > totalContent {
-				end = totalContent
-			}
-			pageContent := index.Content[start:end]
+	return nil
+}
 
-			// Determine output path for the index page using path helper
-			outputPath := GetPaginationFilePath(htmlPath, index.Path, page)
-			svc.Log().Infof("Generating index page: path=%s, page=%d, output=%s", index.Path, page, outputPath)
+// importImage copies an imported image to the site images and returns its
+// record, created when missing from images.
+func (svc *BaseService) importImage(ctx context.Context, siteID uuid.UUID, mi migrationImage, images map[string]Image, report *ImportReport) (Image, error) {
+	result, copied, err := svc.im.ImportFile(ctx, mi.Source, mi.Target)
+	if err != nil {
+		return Image{}, fmt.Errorf("cannot copy image %s: %w", mi.Target, err)
+	}
+	if copied {
+		report.ImagesCopied++
+	}
 
-			assetPath := "/"
+	if img, ok := images[mi.Target]; ok {
+		return img, nil
+	}
 
-			// Prepare pagination data using path helpers
-			pagination := &PaginationData{
-				CurrentPage: page,
-				TotalPages:  totalPages,
-			}
-			if page > 1 {
-				pagination.PrevPageURL = GetPaginationPath(index.Path, page-1, siteMode)
-			}
-			if page < totalPages {
-				pagination.NextPageURL = GetPaginationPath(index.Path, page+1, siteMode)
-			}
+	img := Image{
+		SiteID:   siteID,
+		FileName: result.Filename,
+		FilePath: mi.Target,
+		Width:    result.Width,
+		Height:   result.Height,
+		Title:    mi.Title,
+		AltText:  mi.AltText,
+	}
+	img.GenCreateValues()
+	if err := svc.repo.CreateImage(ctx, &img); err != nil {
+		return Image{}, fmt.Errorf("cannot create image record for %s: %w", mi.Target, err)
+	}
+	images[mi.Target] = img
+	return img, nil
+}
 
-			data := PageData{
-				HeaderStyle:        headerStyle,
-				AssetPath:          assetPath,
-				Menu:               menuSections,
-				IsIndex:            true,
-				ListPageContent:    pageContent,
-				Pagination:         pagination,
-				Search:             searchData,
-				SectionHeaderImage: sectionHeaderImage,
-			}
+// watchConfig reads the watched drafts settings of the site in ctx. Relative
+// dirs are resolved inside the site directory and the processed dir inside the
+// watched one.
+func (svc *BaseService) watchConfig(ctx context.Context) (watchConfig, error) {
+	siteSlug, err := RequireSiteSlug(ctx)
+	if err != nil {
+		return watchConfig{}, err
+	}
+	sitesBasePath := svc.Cfg().StrValOrDef(SSGKey.SitesBasePath, "_workspace/sites")
 
-			var buf bytes.Buffer
-			if err := tmpl.Execute(&buf, data); err != nil {
-				svc.Log().Error("Error executing template for index", "path", index.Path, "error", err)
-				continue
-			}
+	enabled := svc.pm.SettingBool(ctx, SSGKey.WatchEnabled)
 
-			if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
-				svc.Log().Error("Error creating directory for index file", "path", outputPath, "error", err)
-				continue
-			}
+	dir := svc.pm.Setting(ctx, SSGKey.WatchDir)
+	switch {
+	case dir == "":
+		dir = GetSiteDraftsPath(sitesBasePath, siteSlug)
+	case !filepath.IsAbs(dir):
+		dir = filepath.Join(GetSiteBasePath(sitesBasePath, siteSlug), dir)
+	}
 
-			if err := os.WriteFile(outputPath, buf.Bytes(), 0644); err != nil {
-				svc.Log().Error("Error writing index HTML file", "path", outputPath, "error", err)
-				continue
-			}
-		}
+	processed := svc.pm.Setting(ctx, SSGKey.WatchProcessedDir)
+	if processed != "" && !filepath.IsAbs(processed) {
+		processed = filepath.Join(dir, processed)
 	}
 
-	svc.Log().Info("Service HTML generation finished")
-	return nil
+	return watchConfig{
+		Enabled:      enabled,
+		Dir:          filepath.Clean(dir),
+		ProcessedDir: processed,
+		StatePath:    GetSiteWatchStatePath(sitesBasePath, siteSlug),
+	}, nil
 }
 
-// Content related
-
-func (svc *BaseService) CreateContent(ctx context.Context, content *Content) error {
-	repo, err := svc.getRepo(ctx)
+// WatchStatus returns the watched drafts settings of the site in ctx with the
+// files waiting to be imported and the unresolved conflicts.
+func (svc *BaseService) WatchStatus(ctx context.Context) (WatchStatus, error) {
+	cfg, err := svc.watchConfig(ctx)
 	if err != nil {
-		return err
+		return WatchStatus{}, err
 	}
-	return repo.CreateContent(ctx, content)
-}
 
-func (svc *BaseService) GetContent(ctx context.Context, id uuid.UUID) (Content, error) {
-	repo, err := svc.getRepo(ctx)
+	state, err := readWatchState(cfg.StatePath)
 	if err != nil {
-		return Content{}, err
+		return WatchStatus{}, err
 	}
-	return repo.GetContent(ctx, id)
-}
 
-func (svc *BaseService) UpdateContent(ctx context.Context, content *Content) error {
-	repo, err := svc.getRepo(ctx)
+	pending, _, err := scanWatchDir(cfg.Dir, cfg.ProcessedDir, state)
 	if err != nil {
-		return err
+		return WatchStatus{}, err
 	}
-	return repo.UpdateContent(ctx, content)
+
+	return WatchStatus{
+		Enabled:      cfg.Enabled,
+		Dir:          cfg.Dir,
+		ProcessedDir: cfg.ProcessedDir,
+		LastRun:      state.LastRun,
+		Pending:      pending,
+		Conflicts:    state.sortedFiles(WatchConflict),
+		Files:        state.sortedFiles(),
+	}, nil
 }
 
-func (svc *BaseService) DeleteContent(ctx context.Context, id uuid.UUID) error {
-	repo, err := svc.getRepo(ctx)
+// ImportWatched imports the new and modified files of the watched drafts
+// directory of the site in ctx. New files become drafts. A file is not
+// imported over a content edited in the admin since the file was last
+// imported; the conflict is recorded instead, see ResolveWatchConflict.
+func (svc *BaseService) ImportWatched(ctx context.Context) (ImportReport, error) {
+	cfg, err := svc.watchConfig(ctx)
 	if err != nil {
-		return err
+		return ImportReport{}, err
+	}
+	if !cfg.Enabled {
+		return ImportReport{}, fmt.Errorf("watched drafts are disabled for this site")
+	}
+
+	if err := os.MkdirAll(cfg.Dir, 0755); err != nil {
+		return ImportReport{}, fmt.Errorf("cannot create watched dir: %w", err)
+	}
+
+	state, err := readWatchState(cfg.StatePath)
+	if err != nil {
+		return ImportReport{}, err
+	}
+
+	pending, modTimes, err := scanWatchDir(cfg.Dir, cfg.ProcessedDir, state)
+	if err != nil {
+		return ImportReport{}, err
+	}
+
+	report := ImportReport{Items: []ImportItem{}, SectionsCreated: []string{}}
+	if len(pending) > 0 {
+		imp, siteID, err := svc.newMarkdownImport(ctx)
+		if err != nil {
+			return ImportReport{}, err
+		}
+
+		reportProgress(ctx, "Importing %d watched files", len(pending))
+		for _, rel := range pending {
+			item := svc.importWatchedFile(ctx, imp, siteID, cfg, &state, rel, modTimes[rel], false, &report)
+			report.add(item)
+		}
+	}
+
+	state.LastRun = time.Now()
+	if err := writeWatchState(cfg.StatePath, state); err != nil {
+		return report, err
+	}
+
+	report.summarize()
+	reportProgress(ctx, "%s", report.Summary)
+	return report, nil
+}
+
+// ResolveWatchConflict settles the conflict of the watched file at path. Keep
+// WatchKeepFile imports the file over the admin edits; WatchKeepAdmin leaves
+// the content as it is and ignores the file until it changes again.
+func (svc *BaseService) ResolveWatchConflict(ctx context.Context, path, keep string) (WatchedFile, error) {
+	cfg, err := svc.watchConfig(ctx)
+	if err != nil {
+		return WatchedFile{}, err
+	}
+
+	state, err := readWatchState(cfg.StatePath)
+	if err != nil {
+		return WatchedFile{}, err
+	}
+
+	prev, ok := state.Files[path]
+	if !ok || prev.Status != WatchConflict {
+		return WatchedFile{}, fmt.Errorf("no conflict for %s", path)
+	}
+
+	info, err := os.Stat(filepath.Join(cfg.Dir, filepath.FromSlash(path)))
+	if err != nil {
+		return WatchedFile{}, fmt.Errorf("cannot stat %s: %w", path, err)
+	}
+
+	switch keep {
+	case WatchKeepFile:
+		imp, siteID, err := svc.newMarkdownImport(ctx)
+		if err != nil {
+			return WatchedFile{}, err
+		}
+		var report ImportReport
+		item := svc.importWatchedFile(ctx, imp, siteID, cfg, &state, path, info.ModTime(), true, &report)
+		if item.Action == ImportFailed {
+			return WatchedFile{}, fmt.Errorf("cannot import %s: %s", path, item.Message)
+		}
+
+	case WatchKeepAdmin:
+		content, err := svc.GetContent(ctx, prev.ContentID)
+		if err != nil {
+			return WatchedFile{}, fmt.Errorf("cannot get content: %w", err)
+		}
+		state.Files[path] = WatchedFile{
+			Path:             path,
+			ModTime:          info.ModTime(),
+			Status:           WatchKeptAdmin,
+			ContentID:        content.ID,
+			ContentUpdatedAt: content.UpdatedAt,
+			CheckedAt:        time.Now(),
+		}
+
+	default:
+		return WatchedFile{}, fmt.Errorf("invalid conflict resolution %q", keep)
+	}
+
+	if err := writeWatchState(cfg.StatePath, state); err != nil {
+		return WatchedFile{}, err
+	}
+	return state.Files[path], nil
+}
+
+// newMarkdownImport loads the contents and sections of the site in ctx to
+// import files into.
+func (svc *BaseService) newMarkdownImport(ctx context.Context) (*markdownImport, uuid.UUID, error) {
+	siteID, err := RequireSiteID(ctx)
+	if err != nil {
+		return nil, uuid.Nil, err
+	}
+
+	contents, err := svc.GetAllContentWithMeta(ctx)
+	if err != nil {
+		return nil, uuid.Nil, fmt.Errorf("cannot get all content with meta: %w", err)
+	}
+
+	sections, err := svc.GetSections(ctx)
+	if err != nil {
+		return nil, uuid.Nil, fmt.Errorf("cannot get sections: %w", err)
+	}
+
+	return newMarkdownImport(contents, sections), siteID, nil
+}
+
+// importWatchedFile imports one watched file and records the outcome in
+// state. force skips the conflict check.
+func (svc *BaseService) importWatchedFile(ctx context.Context, imp *markdownImport, siteID uuid.UUID, cfg watchConfig, state *WatchState, rel string, modTime time.Time, force bool, report *ImportReport) ImportItem {
+	prev, known := state.Files[rel]
+
+	opts := importOptions{NewAsDraft: true, MatchSlug: force}
+	if !force {
+		opts.Conflict = func(existing Content) string {
+			return watchConflict(prev, known, existing, modTime)
+		}
+	}
+
+	item, content, err := svc.importMarkdownFile(ctx, imp, siteID, cfg.Dir, rel, opts, report)
+	record := WatchedFile{
+		Path:             rel,
+		ModTime:          modTime,
+		ContentID:        prev.ContentID,
+		ContentUpdatedAt: prev.ContentUpdatedAt,
+		CheckedAt:        time.Now(),
+	}
+
+	switch {
+	case err != nil:
+		item.Action = ImportFailed
+		item.Message = err.Error()
+		record.Status = WatchFailed
+		record.Message = item.Message
+		reportProgress(ctx, "Cannot import %s: %v", rel, err)
+
+	case item.Action == ImportConflict:
+		// Keep the last import as the reference until the conflict is resolved
+		record.Status = WatchConflict
+		record.Message = item.Message
+		if record.ContentID == uuid.Nil {
+			record.ContentID = item.ContentID
+		}
+		reportProgress(ctx, "Conflict in %s: %s", rel, item.Message)
+
+	default:
+		record.Status = WatchImported
+		record.ContentID = content.ID
+		record.ContentUpdatedAt = content.UpdatedAt
+		if cfg.ProcessedDir != "" {
+			if err := moveProcessed(cfg.Dir, cfg.ProcessedDir, rel); err != nil {
+				record.Message = err.Error()
+				reportProgress(ctx, "%v", err)
+			}
+		}
+	}
+
+	state.Files[rel] = record
+	return item
+}
+
+// importSection returns the section for the directory of an imported content,
+// creating it if the site does not have it yet.
+func (svc *BaseService) importSection(ctx context.Context, imp *markdownImport, siteID uuid.UUID, content Content, report *ImportReport) (Section, error) {
+	if section, ok := imp.section(content.SectionPath); ok {
+		return section, nil
+	}
+
+	name := content.SectionName
+	if name == "" {
+		name = sectionNameFromPath(content.SectionPath)
+	}
+
+	section := NewSection(name, "", content.SectionPath, imp.defaultLayoutID())
+	section.SiteID = siteID
+	section.GenCreateValues()
+	if err := svc.CreateSection(ctx, section); err != nil {
+		return Section{}, fmt.Errorf("cannot create section %s: %w", content.SectionPath, err)
+	}
+
+	imp.addSection(section)
+	report.SectionsCreated = append(report.SectionsCreated, section.Path)
+	reportProgress(ctx, "Created section %s", section.Path)
+	return section, nil
+}
+
+// syncContentTags makes tags the tags of a content, adding and removing only
+// what differs.
+func (svc *BaseService) syncContentTags(ctx context.Context, contentID uuid.UUID, tags []Tag) error {
+	current, err := svc.GetTagsForContent(ctx, contentID)
+	if err != nil {
+		return fmt.Errorf("cannot get tags: %w", err)
+	}
+
+	wanted := map[string]bool{}
+	for _, tag := range tags {
+		wanted[strings.ToLower(tag.Name)] = true
+	}
+
+	have := map[string]bool{}
+	for _, tag := range current {
+		if wanted[strings.ToLower(tag.Name)] {
+			have[strings.ToLower(tag.Name)] = true
+			continue
+		}
+		if err := svc.RemoveTagFromContent(ctx, contentID, tag.ID); err != nil {
+			return fmt.Errorf("cannot remove tag %s: %w", tag.Name, err)
+		}
+	}
+
+	for _, tag := range tags {
+		if have[strings.ToLower(tag.Name)] {
+			continue
+		}
+		if err := svc.AddTagToContent(ctx, contentID, tag.Name); err != nil {
+			return fmt.Errorf("cannot add tag %s: %w", tag.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// GenerateHTMLFromContent generates HTML files from the content in the database.
+func (svc *BaseService) GenerateHTMLFromContent(ctx context.Context) error {
+	svc.Log().Info("Service starting HTML generation")
+
+	if err := svc.pm.Preload(ctx); err != nil {
+		svc.Log().Errorf("Cannot preload params: %v", err)
+	}
+
+	repo, err := svc.getRepo(ctx)
+	if err != nil {
+		return fmt.Errorf("repo not available: %w", err)
+	}
+
+	contents, err := repo.GetAllContentWithMeta(ctx)
+	if err != nil {
+		return fmt.Errorf("cannot get all content with meta: %w", err)
+	}
+
+	// Set placeholder for content without image
+	for range contents {
+		// TODO: Handle placeholder image via relationships
+		// if contents[i].Image == "" {
+		//	contents[i].Image = "/static/img/placeholder.png"
+		// }
+	}
+
+	sections, err := repo.GetSections(ctx)
+	if err != nil {
+		return fmt.Errorf("cannot get sections: %w", err)
+	}
+
+	// Get site mode to determine UI behavior
+	siteMode := svc.pm.GetSiteMode(ctx)
+	svc.Log().Infof("Site mode: %s", siteMode)
+
+	// In blog mode, hide section menu (only root exists, no need to show sections)
+	var menuSections []Section
+	if siteMode == "structured" {
+		for _, s := range sections {
+			if s.Name != "root" {
+				menuSections = append(menuSections, s)
+			}
+		}
+	}
+
+	layoutPath := svc.Cfg().StrValOrDef(SSGKey.LayoutPath, "assets/ssg/layout/layout.html")
+	tmpl, err := template.ParseFS(svc.assetsFS,
+		layoutPath,
+		"assets/ssg/partial/list.tmpl",
+		"assets/ssg/partial/blocks.tmpl",
+		"assets/ssg/partial/article-blocks.tmpl",
+		"assets/ssg/partial/blog-blocks.tmpl",
+		"assets/ssg/partial/series-blocks.tmpl",
+		"assets/ssg/partial/pagination.tmpl",
+		"assets/ssg/partial/google-search.tmpl",
+	)
+	if err != nil {
+		return fmt.Errorf("cannot parse template from embedded fs: %w", err)
+	}
+
+	siteSlug, ok := GetSiteSlugFromContext(ctx)
+	if !ok {
+		return fmt.Errorf("site slug not found in context")
+	}
+
+	sitesBasePath := svc.Cfg().StrValOrDef(SSGKey.SitesBasePath, "_workspace/sites")
+	htmlPath := GetSiteHTMLPath(sitesBasePath, siteSlug)
+	build := BuildReport{GeneratedAt: time.Now()}
+
+	reportProgress(ctx, "Copying static assets, images and attachments")
+	if err := CopyStaticAssets(svc.assetsFS, htmlPath); err != nil {
+		return fmt.Errorf("cannot copy static assets: %w", err)
+	}
+
+	// Copy dynamic images from assets/images to html/static/images
+	docsDir := GetSiteDocsPath(sitesBasePath, siteSlug)
+	svc.Log().Info("Copying dynamic images", "from", filepath.Join(docsDir, "assets", "images"), "to", filepath.Join(htmlPath, "static", "images"))
+	if err := CopyDynamicImages(docsDir, htmlPath); err != nil {
+		svc.Log().Error("Failed to copy dynamic images", "error", err)
+		return fmt.Errorf("cannot copy dynamic images: %w", err)
+	}
+	svc.Log().Info("Dynamic images copied successfully")
+
+	if err := CopyDynamicAttachments(docsDir, htmlPath); err != nil {
+		return fmt.Errorf("cannot copy attachments: %w", err)
+	}
+
+	if err := WritePagesFiles(htmlPath, svc.pagesOptions(ctx)); err != nil {
+		return fmt.Errorf("cannot write pages files: %w", err)
+	}
+
+	attachmentMeta, err := svc.attachmentMetadata(ctx)
+	if err != nil {
+		return fmt.Errorf("cannot get attachments: %w", err)
+	}
+
+	headerStyle := svc.pm.Setting(ctx, SSGKey.HeaderStyle)
+	imageExtensions := []string{".png", ".jpg", ".jpeg", ".webp"}
+
+	// Prepare SearchData
+	searchData := SearchData{
+		Provider: "google", // O el proveedor que corresponda
+		Enabled:  svc.pm.SettingBool(ctx, SSGKey.SearchGoogleEnabled),
+		ID:       svc.pm.Setting(ctx, SSGKey.SearchGoogleID),
+	}
+	svc.Log().Infof("SearchData: enabled=%v, id=%s", searchData.Enabled, searchData.ID)
+
+	reportProgress(ctx, "Rendering %d contents", len(contents))
+	for _, content := range contents {
+		svc.Log().Debug("Processing content for HTML generation", "slug", content.Slug(), "section_path", content.SectionPath)
+		if content.Draft {
+			svc.Log().Debug("Skipping draft content", "slug", content.Slug())
+			continue
+		}
+
+		headerImagePath := ""
+
+		if content.HeaderImageURL != "" {
+			headerImagePath = content.HeaderImageURL
+		} else {
+			// Use path helper to get correct content directory based on mode
+			contentDir := filepath.Dir(GetContentFilePath(htmlPath, content, siteMode))
+			contentImgDir := filepath.Join(contentDir, "img")
+
+			foundSpecificHeader := false
+			for _, ext := range imageExtensions {
+				checkPath := filepath.Join("assets", "content", content.SectionPath, content.Slug(), "img", "header"+ext)
+				if f, err := svc.assetsFS.Open(checkPath); err == nil {
+					f.Close()
+					if err := os.MkdirAll(contentImgDir, 0755); err != nil {
+						return fmt.Errorf("cannot create img directory: %w", err)
+					}
+					dst := filepath.Join(contentImgDir, "header"+ext)
+					if err := copyFile(svc.assetsFS, checkPath, dst); err != nil {
+						return fmt.Errorf("cannot copy specific header: %w", err)
+					}
+					headerImagePath = "img/header" + ext
+					foundSpecificHeader = true
+					break
+				}
+			}
+
+			if !foundSpecificHeader {
+				headerImagePath = "/static/img/header.png"
+			}
+		}
+
+		assetPath := "/"
+
+		contentImages, err := svc.GetContentImages(ctx, content.ID)
+		if err != nil {
+			svc.Log().Debug("Failed to load content images", "contentID", content.ID, "error", err)
+			contentImages = []ImageWithMeta{}
+		}
+
+		imageContext := &ImageContext{
+			Images: make(map[string]ImageMetadata),
+		}
+
+		for _, img := range contentImages {
+			svc.Log().Debug("Adding image to context", "filePath", img.FilePath, "altText", img.AltText)
+			imageContext.Images[img.FilePath] = ImageMetadata{
+				AltText: img.AltText,
+				Title:   img.Title,
+			}
+		}
+
+		processor := NewMarkdownProcessor()
+
+		htmlBody, err := processor.ToHTMLWithImageContext([]byte(content.Body), imageContext)
+		if err != nil {
+			svc.Log().Error("Error converting markdown to HTML", "slug", content.Slug(), "error", err)
+			reportProgress(ctx, "Cannot convert %s: %v", content.Heading, err)
+			build.add(&content, "", "Cannot convert markdown: %v", err)
+			continue
+		}
+		htmlBody = enhanceAttachmentLinksInHTML(htmlBody, attachmentMeta)
+
+		if headerStyle == "boxed" || headerStyle == "overlay" {
+			htmlBody = svc.removeFirstH1(htmlBody)
+		}
+
+		pageContent := PageContent{
+			Heading:            content.Heading,
+			HeaderImage:        headerImagePath,
+			HeaderImageAlt:     content.HeaderImageAlt,
+			HeaderImageCaption: content.HeaderImageCaption,
+			Body:               template.HTML(htmlBody),
+			Kind:               content.Kind,
+		}
+
+		blocks := BuildBlocks(content, contents, svc.pm.SettingInt(ctx, SSGKey.BlocksMaxItems))
+
+		data := PageData{
+			HeaderStyle: headerStyle,
+			AssetPath:   assetPath,
+			Menu:        menuSections,
+			Content:     pageContent,
+			Blocks:      blocks,
+			Search:      searchData,
+		}
+
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, data); err != nil {
+			svc.Log().Error("Error executing template for content", "slug", content.Slug(), "error", err)
+			reportProgress(ctx, "Cannot render %s: %v", content.Heading, err)
+			build.add(&content, "", "Cannot render template: %v", err)
+			continue
+		}
+
+		// Use path helper to get correct output path based on mode
+		outputPath := GetContentFilePath(htmlPath, content, siteMode)
+
+		if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+			svc.Log().Error("Error creating directory for HTML file", "path", outputPath, "error", err)
+			build.add(&content, outputPath, "Cannot create directory: %v", err)
+			continue
+		}
+
+		if err := os.WriteFile(outputPath, buf.Bytes(), 0644); err != nil {
+			svc.Log().Error("Error writing index HTML file", "path", outputPath, "error", err)
+			build.add(&content, outputPath, "Cannot write page: %v", err)
+			continue
+		}
+	}
+
+	// Generate index pages
+	indexes := BuildIndexes(contents, sections, siteMode)
+	svc.Log().Infof("Built %d indexes (mode: %s)", len(indexes), siteMode)
+	reportProgress(ctx, "Generating %d index pages", len(indexes))
+	for _, idx := range indexes {
+		svc.Log().Infof("  Index: path=%s, type=%s, content_count=%d", idx.Path, idx.Type, len(idx.Content))
+	}
+
+	// Create a lookup map for manual index pages
+	manualIndexPages := make(map[string]bool)
+	for _, c := range contents {
+		if strings.ToLower(c.Kind) == "page" && c.Slug() == "index" {
+			manualIndexPages[c.SectionPath] = true
+		}
+	}
+
+	postsPerPage := svc.pm.SettingInt(ctx, SSGKey.IndexMaxItems)
+
+	for _, index := range indexes {
+		svc.Log().Infof("Processing index: path=%s, content_count=%d", index.Path, len(index.Content))
+
+		// Check if a manual index page exists for this path
+		if manualIndexPages[index.Path] {
+			svc.Log().Info(fmt.Sprintf("Skipping index generation for '%s': manual index page found.", index.Path))
+			continue
+		}
+
+		// Get section header image for this index
+		var sectionHeaderImage string
+		for _, section := range sections {
+			if section.Path == index.Path {
+				headerPath, err := svc.GetSectionHeaderImage(ctx, section.ID)
+				if err == nil && headerPath != "" {
+					sectionHeaderImage = "/static/images/" + strings.TrimPrefix(headerPath, "/")
+				}
+				break
+			}
+		}
+
+		// Paginate the content
+		totalContent := len(index.Content)
+		// In blog mode, always generate root index even if empty (it's the homepage)
+		// In structured mode, skip empty indexes
+		if totalContent == 0 && !(siteMode == "blog" && index.Path == "/") {
+			svc.Log().Info("Skipping empty index", "path", index.Path)
+			continue
+		}
+		totalPages := (totalContent + postsPerPage - 1) / postsPerPage
+		if totalPages == 0 {
+			totalPages = 1 // At least one page for empty blog mode index
+		}
+
+		for page := 1; page <= totalPages; page++ {
+			start := (page - 1) * postsPerPage
+			end := start + postsPerPage
+			if end > totalContent {
+				end = totalContent
+			}
+			pageContent := index.Content[start:end]
+
+			// Determine output path for the index page using path helper
+			outputPath := GetPaginationFilePath(htmlPath, index.Path, page)
+			svc.Log().Infof("Generating index page: path=%s, page=%d, output=%s", index.Path, page, outputPath)
+
+			assetPath := "/"
+
+			// Prepare pagination data using path helpers
+			pagination := &PaginationData{
+				CurrentPage: page,
+				TotalPages:  totalPages,
+			}
+			if page > 1 {
+				pagination.PrevPageURL = GetPaginationPath(index.Path, page-1, siteMode)
+			}
+			if page < totalPages {
+				pagination.NextPageURL = GetPaginationPath(index.Path, page+1, siteMode)
+			}
+
+			data := PageData{
+				HeaderStyle:        headerStyle,
+				AssetPath:          assetPath,
+				Menu:               menuSections,
+				IsIndex:            true,
+				ListPageContent:    pageContent,
+				Pagination:         pagination,
+				Search:             searchData,
+				SectionHeaderImage: sectionHeaderImage,
+			}
+
+			var buf bytes.Buffer
+			if err := tmpl.Execute(&buf, data); err != nil {
+				svc.Log().Error("Error executing template for index", "path", index.Path, "error", err)
+				build.add(nil, index.Path, "Cannot render index template: %v", err)
+				continue
+			}
+
+			if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+				svc.Log().Error("Error creating directory for index file", "path", outputPath, "error", err)
+				build.add(nil, index.Path, "Cannot create directory: %v", err)
+				continue
+			}
+
+			if err := os.WriteFile(outputPath, buf.Bytes(), 0644); err != nil {
+				svc.Log().Error("Error writing index HTML file", "path", outputPath, "error", err)
+				build.add(nil, index.Path, "Cannot write index page: %v", err)
+				continue
+			}
+		}
+	}
+
+	if err := svc.writeRedirects(ctx, htmlPath, contents, siteMode, &build); err != nil {
+		return err
+	}
+
+	if err := writeBuildReport(GetSiteBuildReportPath(sitesBasePath, siteSlug), build); err != nil {
+		return err
+	}
+
+	reportProgress(ctx, "HTML generated")
+	svc.Log().Info("Service HTML generation finished")
+	return nil
+}
+
+// Redirect related
+
+// ListRedirects returns the redirects of the site in context.
+func (svc *BaseService) ListRedirects(ctx context.Context) ([]Redirect, error) {
+	siteID, err := RequireSiteID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	redirects, err := svc.repo.ListRedirects(ctx, siteID)
+	if err != nil {
+		return nil, fmt.Errorf("cannot list redirects: %w", err)
+	}
+	return redirects, nil
+}
+
+// CreateRedirect sends the old URL from to a content or, without one, to
+// toPath. A redirect from the same path is replaced.
+func (svc *BaseService) CreateRedirect(ctx context.Context, from string, contentID uuid.UUID, toPath string) (Redirect, error) {
+	return svc.saveRedirect(ctx, from, contentID, toPath, RedirectManual)
+}
+
+func (svc *BaseService) saveRedirect(ctx context.Context, from string, contentID uuid.UUID, toPath, source string) (Redirect, error) {
+	siteID, err := RequireSiteID(ctx)
+	if err != nil {
+		return Redirect{}, err
+	}
+	if contentID == uuid.Nil && toPath == "" {
+		return Redirect{}, fmt.Errorf("a redirect needs a content or a path to go to")
+	}
+
+	redirect, err := NewRedirect(siteID, from, source)
+	if err != nil {
+		return Redirect{}, err
+	}
+	redirect.ContentID = contentID
+	redirect.ToPath = toPath
+
+	if err := svc.repo.SaveRedirect(ctx, redirect); err != nil {
+		return Redirect{}, fmt.Errorf("cannot save redirect: %w", err)
+	}
+	return *redirect, nil
+}
+
+// DeleteRedirect removes the redirect id of the site in ctx. Redirects of
+// other sites are not found.
+func (svc *BaseService) DeleteRedirect(ctx context.Context, id uuid.UUID) error {
+	siteID, err := RequireSiteID(ctx)
+	if err != nil {
+		return err
+	}
+	return svc.repo.DeleteRedirect(ctx, siteID, id)
+}
+
+// writeRedirects writes a page for each redirect of the site sending visitors
+// to the current URL of its content or to its path. Redirects to drafts or
+// removed contents, and paths used by generated pages, are skipped.
+func (svc *BaseService) writeRedirects(ctx context.Context, htmlPath string, contents []Content, siteMode string, build *BuildReport) error {
+	redirects, err := svc.ListRedirects(ctx)
+	if err != nil {
+		return err
+	}
+	if len(redirects) == 0 {
+		return nil
+	}
+
+	byID := make(map[uuid.UUID]Content, len(contents))
+	for _, c := range contents {
+		byID[c.ID] = c
+	}
+
+	reportProgress(ctx, "Writing %d redirects", len(redirects))
+	for _, r := range redirects {
+		target := r.ToPath
+		if r.ContentID != uuid.Nil {
+			c, ok := byID[r.ContentID]
+			if !ok || c.Draft {
+				continue
+			}
+			target = GetContentPath(c, siteMode) + "/"
+		}
+		if target == "" || target == r.FromPath {
+			continue
+		}
+
+		written, err := writeRedirectPage(htmlPath, r.FromPath, target)
+		if err != nil {
+			build.add(nil, r.FromPath, "Cannot write redirect: %v", err)
+			continue
+		}
+		if !written {
+			svc.Log().Debug("Redirect path used by a generated page", "path", r.FromPath)
+		}
+	}
+
+	return nil
+}
+
+// Content related
+
+func (svc *BaseService) CreateContent(ctx context.Context, content *Content) error {
+	repo, err := svc.getRepo(ctx)
+	if err != nil {
+		return err
+	}
+	return repo.CreateContent(ctx, content)
+}
+
+func (svc *BaseService) GetContent(ctx context.Context, id uuid.UUID) (Content, error) {
+	repo, err := svc.getRepo(ctx)
+	if err != nil {
+		return Content{}, err
+	}
+	return repo.GetContent(ctx, id)
+}
+
+func (svc *BaseService) UpdateContent(ctx context.Context, content *Content) error {
+	repo, err := svc.getRepo(ctx)
+	if err != nil {
+		return err
+	}
+	return repo.UpdateContent(ctx, content)
+}
+
+func (svc *BaseService) DeleteContent(ctx context.Context, id uuid.UUID) error {
+	repo, err := svc.getRepo(ctx)
+	if err != nil {
+		return err
 	}
 	return repo.DeleteContent(ctx, id)
 }
 
-func (svc *BaseService) GetAllContentWithMeta(ctx context.Context) ([]Content, error) {
+func (svc *BaseService) GetAllContentWithMeta(ctx context.Context) ([]Content, error) {
+	repo, err := svc.getRepo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return repo.GetAllContentWithMeta(ctx)
+}
+
+func (svc *BaseService) GetContentWithPaginationAndSearch(ctx context.Context, offset, limit int, searchQuery string) ([]Content, int, error) {
+	repo, err := svc.getRepo(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	return repo.GetContentWithPaginationAndSearch(ctx, offset, limit, searchQuery)
+}
+
+// Section related
+func (svc *BaseService) CreateSection(ctx context.Context, section Section) error {
+	repo, err := svc.getRepo(ctx)
+	if err != nil {
+		return err
+	}
+	return repo.CreateSection(ctx, section)
+}
+
+func (svc *BaseService) GetSection(ctx context.Context, id uuid.UUID) (Section, error) {
+	repo, err := svc.getRepo(ctx)
+	if err != nil {
+		return Section{}, err
+	}
+	return repo.GetSection(ctx, id)
+}
+
+func (svc *BaseService) GetSections(ctx context.Context) ([]Section, error) {
+	repo, err := svc.getRepo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return repo.GetSections(ctx)
+}
+
+func (svc *BaseService) UpdateSection(ctx context.Context, section Section) error {
+	repo, err := svc.getRepo(ctx)
+	if err != nil {
+		return err
+	}
+	return repo.UpdateSection(ctx, section)
+}
+
+func (svc *BaseService) DeleteSection(ctx context.Context, id uuid.UUID) error {
+	repo, err := svc.getRepo(ctx)
+	if err != nil {
+		return err
+	}
+	return repo.DeleteSection(ctx, id)
+}
+
+// Layout related
+func (svc *BaseService) CreateLayout(ctx context.Context, layout Layout) error {
+	repo, err := svc.getRepo(ctx)
+	if err != nil {
+		return err
+	}
+	return repo.CreateLayout(ctx, layout)
+}
+
+func (svc *BaseService) GetLayout(ctx context.Context, id uuid.UUID) (Layout, error) {
+	repo, err := svc.getRepo(ctx)
+	if err != nil {
+		return Layout{}, err
+	}
+	return repo.GetLayout(ctx, id)
+}
+
+func (svc *BaseService) GetAllLayouts(ctx context.Context) ([]Layout, error) {
+	repo, err := svc.getRepo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return repo.GetAllLayouts(ctx)
+}
+
+func (svc *BaseService) UpdateLayout(ctx context.Context, layout Layout) error {
+	repo, err := svc.getRepo(ctx)
+	if err != nil {
+		return err
+	}
+	return repo.UpdateLayout(ctx, layout)
+}
+
+func (svc *BaseService) DeleteLayout(ctx context.Context, id uuid.UUID) error {
+	repo, err := svc.getRepo(ctx)
+	if err != nil {
+		return err
+	}
+	return repo.DeleteLayout(ctx, id)
+}
+
+// Tag related
+func (svc *BaseService) CreateTag(ctx context.Context, tag Tag) error {
+	repo, err := svc.getRepo(ctx)
+	if err != nil {
+		return err
+	}
+	return repo.CreateTag(ctx, tag)
+}
+
+func (svc *BaseService) GetTag(ctx context.Context, id uuid.UUID) (Tag, error) {
+	repo, err := svc.getRepo(ctx)
+	if err != nil {
+		return Tag{}, err
+	}
+	return repo.GetTag(ctx, id)
+}
+
+func (svc *BaseService) GetTagByName(ctx context.Context, name string) (Tag, error) {
+	repo, err := svc.getRepo(ctx)
+	if err != nil {
+		return Tag{}, err
+	}
+	return repo.GetTagByName(ctx, name)
+}
+
+func (svc *BaseService) GetAllTags(ctx context.Context) ([]Tag, error) {
+	repo, err := svc.getRepo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return repo.GetAllTags(ctx)
+}
+
+func (svc *BaseService) UpdateTag(ctx context.Context, tag Tag) error {
+	repo, err := svc.getRepo(ctx)
+	if err != nil {
+		return err
+	}
+	return repo.UpdateTag(ctx, tag)
+}
+
+func (svc *BaseService) DeleteTag(ctx context.Context, id uuid.UUID) error {
+	repo, err := svc.getRepo(ctx)
+	if err != nil {
+		return err
+	}
+	return repo.DeleteTag(ctx, id)
+}
+
+// Param related
+func (svc *BaseService) CreateParam(ctx context.Context, param *Param) error {
+	if _, err := svc.getRepo(ctx); err != nil {
+		return err
+	}
+	if param.SiteID == uuid.Nil {
+		siteID, err := RequireSiteID(ctx)
+		if err != nil {
+			return err
+		}
+		param.SiteID = siteID
+	}
+	return svc.pm.CreateParam(ctx, param)
+}
+
+func (svc *BaseService) GetParam(ctx context.Context, id uuid.UUID) (Param, error) {
+	repo, err := svc.getRepo(ctx)
+	if err != nil {
+		return Param{}, err
+	}
+	return repo.GetParam(ctx, id)
+}
+
+func (svc *BaseService) GetParamByName(ctx context.Context, name string) (Param, error) {
+	repo, err := svc.getRepo(ctx)
+	if err != nil {
+		return Param{}, err
+	}
+	return repo.GetParamByName(ctx, name)
+}
+
+func (svc *BaseService) GetParamByRefKey(ctx context.Context, refKey string) (Param, error) {
+	repo, err := svc.getRepo(ctx)
+	if err != nil {
+		return Param{}, err
+	}
+	return repo.GetParamByRefKey(ctx, refKey)
+}
+
+func (svc *BaseService) ListParams(ctx context.Context) ([]Param, error) {
+	repo, err := svc.getRepo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return repo.ListParams(ctx)
+}
+
+func (svc *BaseService) UpdateParam(ctx context.Context, param *Param) error {
+	if _, err := svc.getRepo(ctx); err != nil {
+		return err
+	}
+	if param.SiteID == uuid.Nil {
+		siteID, err := RequireSiteID(ctx)
+		if err != nil {
+			return err
+		}
+		param.SiteID = siteID
+	}
+	return svc.pm.UpdateParam(ctx, param)
+}
+
+func (svc *BaseService) DeleteParam(ctx context.Context, id uuid.UUID) error {
+	if _, err := svc.getRepo(ctx); err != nil {
+		return err
+	}
+	return svc.pm.DeleteParam(ctx, id)
+}
+
+// InvalidateParams drops the cached params of a site changed outside the
+// service, such as a purged one.
+func (svc *BaseService) InvalidateParams(siteID uuid.UUID) {
+	svc.pm.InvalidateSite(siteID)
+}
+
+// Image related
+func (svc *BaseService) CreateImage(ctx context.Context, image *Image) error {
+	repo, err := svc.getRepo(ctx)
+	if err != nil {
+		return err
+	}
+	return repo.CreateImage(ctx, image)
+}
+
+func (svc *BaseService) GetImage(ctx context.Context, id uuid.UUID) (Image, error) {
+	repo, err := svc.getRepo(ctx)
+	if err != nil {
+		return Image{}, err
+	}
+	return repo.GetImage(ctx, id)
+}
+
+func (svc *BaseService) GetImageByShortID(ctx context.Context, shortID string) (Image, error) {
+	repo, err := svc.getRepo(ctx)
+	if err != nil {
+		return Image{}, err
+	}
+	return repo.GetImageByShortID(ctx, shortID)
+}
+
+func (svc *BaseService) ListImages(ctx context.Context) ([]Image, error) {
+	repo, err := svc.getRepo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return repo.ListImages(ctx)
+}
+
+func (svc *BaseService) UpdateImage(ctx context.Context, image *Image) error {
+	repo, err := svc.getRepo(ctx)
+	if err != nil {
+		return err
+	}
+	return repo.UpdateImage(ctx, image)
+}
+
+func (svc *BaseService) DeleteImage(ctx context.Context, id uuid.UUID) error {
+	repo, err := svc.getRepo(ctx)
+	if err != nil {
+		return err
+	}
+	return repo.DeleteImage(ctx, id)
+}
+
+// ImageVariant related
+func (svc *BaseService) CreateImageVariant(ctx context.Context, variant *ImageVariant) error {
+	repo, err := svc.getRepo(ctx)
+	if err != nil {
+		return err
+	}
+	return repo.CreateImageVariant(ctx, variant)
+}
+
+func (svc *BaseService) GetImageVariant(ctx context.Context, id uuid.UUID) (ImageVariant, error) {
+	repo, err := svc.getRepo(ctx)
+	if err != nil {
+		return ImageVariant{}, err
+	}
+	return repo.GetImageVariant(ctx, id)
+}
+
+func (svc *BaseService) ListImageVariantsByImageID(ctx context.Context, imageID uuid.UUID) ([]ImageVariant, error) {
+	repo, err := svc.getRepo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return repo.ListImageVariantsByImageID(ctx, imageID)
+}
+
+func (svc *BaseService) UpdateImageVariant(ctx context.Context, variant *ImageVariant) error {
+	repo, err := svc.getRepo(ctx)
+	if err != nil {
+		return err
+	}
+	return repo.UpdateImageVariant(ctx, variant)
+}
+
+func (svc *BaseService) DeleteImageVariant(ctx context.Context, id uuid.UUID) error {
+	repo, err := svc.getRepo(ctx)
+	if err != nil {
+		return err
+	}
+	return repo.DeleteImageVariant(ctx, id)
+}
+
+// ContentTag related
+func (svc *BaseService) AddTagToContent(ctx context.Context, contentID uuid.UUID, tagName string) error {
+	repo, err := svc.getRepo(ctx)
+	if err != nil {
+		return err
+	}
+
+	tag, err := repo.GetTagByName(ctx, tagName)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("error getting tag by name: %w", err)
+	}
+
+	if tag.IsZero() {
+		newTag := NewTag(tagName)
+		newTag.GenCreateValues()
+		err = repo.CreateTag(ctx, newTag)
+		if err != nil {
+			return fmt.Errorf("error creating tag: %w", err)
+		}
+		tag = newTag
+	}
+
+	return repo.AddTagToContent(ctx, contentID, tag.ID)
+}
+
+func (svc *BaseService) RemoveTagFromContent(ctx context.Context, contentID, tagID uuid.UUID) error {
+	repo, err := svc.getRepo(ctx)
+	if err != nil {
+		return err
+	}
+	return repo.RemoveTagFromContent(ctx, contentID, tagID)
+}
+
+func (svc *BaseService) GetTagsForContent(ctx context.Context, contentID uuid.UUID) ([]Tag, error) {
+	repo, err := svc.getRepo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return repo.GetTagsForContent(ctx, contentID)
+}
+
+func (svc *BaseService) GetContentForTag(ctx context.Context, tagID uuid.UUID) ([]Content, error) {
+	repo, err := svc.getRepo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return repo.GetContentForTag(ctx, tagID)
+}
+
+var firstH1Regex = regexp.MustCompile(`(?i)<h1[^>]*>.*?</h1>`)
+
+// removeFirstH1 removes the first <h1>...</h1> tag from an HTML string.
+func (svc *BaseService) removeFirstH1(htmlContent string) string {
+	return firstH1Regex.ReplaceAllStringFunc(htmlContent, func(match string) string {
+		// Only replace the first occurrence
+		if strings.HasPrefix(htmlContent, match) {
+			return ""
+		}
+		return match
+	})
+}
+
+// Content Image Management
+
+// UploadContentImage handles uploading images for content (header or content images)
+func (svc *BaseService) UploadContentImage(ctx context.Context, contentID uuid.UUID, file multipart.File, header *multipart.FileHeader, imageType ImageType, altText, caption string) (*ImageProcessResult, error) {
+	svc.Log().Debugf("Uploading content image: contentID=%s, type=%s", contentID, imageType)
+
+	content, err := svc.repo.GetContent(ctx, contentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get content: %w", err)
+	}
+
+	var section *Section
+	if content.SectionID != uuid.Nil {
+		s, err := svc.repo.GetSection(ctx, content.SectionID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get section: %w", err)
+		}
+		section = &s
+	}
+
+	result, err := svc.im.ProcessUpload(ctx, file, header, &content, section, imageType, altText, caption)
+	if err != nil {
+		return nil, fmt.Errorf("failed to process upload: %w", err)
+	}
+
+	// Create Image record with accessibility metadata - always create new record
+	siteID, _ := GetSiteIDFromContext(ctx)
+	image := Image{
+		SiteID:   siteID,
+		FileName: result.Filename,
+		Title:    caption,
+		FilePath: result.RelativePath,
+		Width:    result.Width,
+		Height:   result.Height,
+		AltText:  altText,
+	}
+	image.GenCreateValues()
+
+	if err := svc.repo.CreateImage(ctx, &image); err != nil {
+		svc.im.DeleteImage(ctx, result.RelativePath)
+		return nil, fmt.Errorf("failed to create image record: %w", err)
+	}
+
+	isHeader := imageType == ImageTypeHeader
+	contentImage := NewContentImage(contentID, image.GetID(), isHeader)
+
+	if err := svc.repo.CreateContentImage(ctx, contentImage); err != nil {
+		svc.im.DeleteImage(ctx, result.RelativePath)
+		svc.repo.DeleteImage(ctx, image.GetID())
+		return nil, fmt.Errorf("failed to create content-image relationship: %w", err)
+	}
+
+	// TODO: Remove direct field update when we complete migration
+	// if imageType == ImageTypeHeader {
+	//	content.Image = result.RelativePath
+	//	if err := svc.repo.UpdateContent(ctx, &content); err != nil {
+	//		return nil, fmt.Errorf("failed to update content with header image: %w", err)
+	//	}
+	// }
+
+	return result, nil
+}
+
+// ImageWithMeta combines Image with ContentImage metadata for API responses
+type ImageWithMeta struct {
+	ID         uuid.UUID `json:"id"`
+	SiteID     uuid.UUID `json:"site_id"`
+	FileName   string    `json:"file_name"`
+	FilePath   string    `json:"file_path"`
+	Width      int       `json:"width"`
+	Height     int       `json:"height"`
+	Title      string    `json:"title"`
+	AltText    string    `json:"alt_text"`
+	IsHeader   bool      `json:"is_header"`
+	IsFeatured bool      `json:"is_featured"`
+	OrderNum   int       `json:"order_num"`
+}
+
+// GetContentImages returns all images for a specific content via relationships
+func (svc *BaseService) GetContentImages(ctx context.Context, contentID uuid.UUID) ([]ImageWithMeta, error) {
+	svc.Log().Debugf("Getting content images: contentID=%s", contentID)
+
 	repo, err := svc.getRepo(ctx)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("repo not available: %w", err)
 	}
-	return repo.GetAllContentWithMeta(ctx)
-}
 
-func (svc *BaseService) GetContentWithPaginationAndSearch(ctx context.Context, offset, limit int, searchQuery string) ([]Content, int, error) {
-	repo, err := svc.getRepo(ctx)
+	contentImages, err := repo.GetContentImagesByContentID(ctx, contentID)
 	if err != nil {
-		return nil, 0, err
+		return nil, fmt.Errorf("failed to get content images: %w", err)
 	}
-	return repo.GetContentWithPaginationAndSearch(ctx, offset, limit, searchQuery)
+
+	var images []ImageWithMeta
+	for _, ci := range contentImages {
+		image, err := repo.GetImage(ctx, ci.ImageID)
+		if err != nil {
+			svc.Log().Info("Failed to get image %s: %v", ci.ImageID, err)
+			continue
+		}
+
+		imageWithMeta := ImageWithMeta{
+			ID:         image.ID,
+			SiteID:     image.SiteID,
+			FileName:   image.FileName,
+			FilePath:   image.FilePath,
+			Width:      image.Width,
+			Height:     image.Height,
+			Title:      image.Title,
+			AltText:    image.AltText,
+			IsHeader:   ci.IsHeader,
+			IsFeatured: ci.IsFeatured,
+			OrderNum:   ci.OrderNum,
+		}
+		images = append(images, imageWithMeta)
+	}
+
+	return images, nil
 }
 
-// Section related
-func (svc *BaseService) CreateSection(ctx context.Context, section Section) error {
-	repo, err := svc.getRepo(ctx)
+// GetContentHeaderImage returns the header image for a specific content
+func (svc *BaseService) GetContentHeaderImage(ctx context.Context, contentID uuid.UUID) (string, error) {
+	contentImages, err := svc.repo.GetContentImagesByContentID(ctx, contentID)
 	if err != nil {
-		return err
+		return "", fmt.Errorf("failed to get content images: %w", err)
 	}
-	return repo.CreateSection(ctx, section)
+
+	for _, ci := range contentImages {
+		if ci.IsHeader {
+			image, err := svc.repo.GetImage(ctx, ci.ImageID)
+			if err != nil {
+				svc.Log().Info("Failed to get header image %s: %v", ci.ImageID, err)
+				continue
+			}
+
+			return image.FilePath, nil
+		}
+	}
+
+	return "", nil // No header image found
 }
 
-func (svc *BaseService) GetSection(ctx context.Context, id uuid.UUID) (Section, error) {
-	repo, err := svc.getRepo(ctx)
+// GetSectionHeaderImage returns the header image for a specific section
+func (svc *BaseService) GetSectionHeaderImage(ctx context.Context, sectionID uuid.UUID) (string, error) {
+	sectionImages, err := svc.repo.GetSectionImagesBySectionID(ctx, sectionID)
 	if err != nil {
-		return Section{}, err
+		return "", fmt.Errorf("failed to get layout images: %w", err)
 	}
-	return repo.GetSection(ctx, id)
+
+	for _, si := range sectionImages {
+		if si.IsHeader {
+			image, err := svc.repo.GetImage(ctx, si.ImageID)
+			if err != nil {
+				svc.Log().Info("Failed to get section header image %s: %v", si.ImageID, err)
+				continue
+			}
+
+			return image.FilePath, nil
+		}
+	}
+
+	return "", nil // No header image found
 }
 
-func (svc *BaseService) GetSections(ctx context.Context) ([]Section, error) {
-	repo, err := svc.getRepo(ctx)
+// GetSectionBlogHeaderImage returns the blog header image for a specific section
+func (svc *BaseService) GetSectionBlogHeaderImage(ctx context.Context, sectionID uuid.UUID) (string, error) {
+	sectionImages, err := svc.repo.GetSectionImagesBySectionID(ctx, sectionID)
 	if err != nil {
-		return nil, err
+		return "", fmt.Errorf("failed to get layout images: %w", err)
 	}
-	return repo.GetSections(ctx)
+
+	for _, si := range sectionImages {
+		if si.IsHeader {
+			image, err := svc.repo.GetImage(ctx, si.ImageID)
+			if err != nil {
+				svc.Log().Info("Failed to get section blog header image %s: %v", si.ImageID, err)
+				continue
+			}
+
+			return image.FilePath, nil
+		}
+	}
+
+	return "", nil // No blog header image found
 }
 
-func (svc *BaseService) UpdateSection(ctx context.Context, section Section) error {
-	repo, err := svc.getRepo(ctx)
+// DeleteContentImage deletes a content image by path
+func (svc *BaseService) DeleteContentImage(ctx context.Context, contentID uuid.UUID, imagePath string) error {
+	svc.Log().Infof("Deleting content image: contentID=%s, imagePath=%s", contentID, imagePath)
+
+	_, err := svc.repo.GetContent(ctx, contentID)
 	if err != nil {
-		return err
+		svc.Log().Errorf("Failed to get content: %v", err)
+		return fmt.Errorf("failed to get content: %w", err)
 	}
-	return repo.UpdateSection(ctx, section)
-}
 
-func (svc *BaseService) DeleteSection(ctx context.Context, id uuid.UUID) error {
-	repo, err := svc.getRepo(ctx)
+	contentImages, err := svc.repo.GetContentImagesByContentID(ctx, contentID)
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to get content images: %w", err)
 	}
-	return repo.DeleteSection(ctx, id)
+
+	var imageToDelete *Image
+	var relationshipToDelete *ContentImage
+	for _, ci := range contentImages {
+		image, err := svc.repo.GetImage(ctx, ci.ImageID)
+		if err != nil {
+			svc.Log().Info("Failed to get image %s: %v", ci.ImageID, err)
+			continue
+		}
+		if image.FilePath == imagePath {
+			imageToDelete = &image
+			relationshipToDelete = &ci
+			break
+		}
+	}
+
+	if imageToDelete == nil {
+		svc.Log().Info("Image not found in database for path: %s", imagePath)
+		if err := svc.im.DeleteImage(ctx, imagePath); err != nil {
+			return fmt.Errorf("failed to delete image file: %w", err)
+		}
+		return nil
+	}
+
+	if err := svc.repo.DeleteContentImage(ctx, relationshipToDelete.ID); err != nil {
+		return fmt.Errorf("failed to delete content image relationship: %w", err)
+	}
+
+	if err := svc.repo.DeleteImage(ctx, imageToDelete.ID); err != nil {
+		return fmt.Errorf("failed to delete image record: %w", err)
+	}
+
+	if err := svc.im.DeleteImage(ctx, imagePath); err != nil {
+		return fmt.Errorf("failed to delete image file: %w", err)
+	}
+
+	return nil
 }
 
-// Layout related
-func (svc *BaseService) CreateLayout(ctx context.Context, layout Layout) error {
-	repo, err := svc.getRepo(ctx)
+// Section Image Management
+
+// UploadSectionImage handles uploading images for sections (section header or blog header)
+func (svc *BaseService) UploadSectionImage(ctx context.Context, sectionID uuid.UUID, file multipart.File, header *multipart.FileHeader, imageType ImageType, altText, caption string) (*ImageProcessResult, error) {
+	section, err := svc.repo.GetSection(ctx, sectionID)
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("failed to get section: %w", err)
 	}
-	return repo.CreateLayout(ctx, layout)
+
+	result, err := svc.im.ProcessUpload(ctx, file, header, nil, &section, imageType, altText, caption)
+	if err != nil {
+		return nil, fmt.Errorf("failed to process upload: %w", err)
+	}
+
+	// Create Image record with accessibility metadata - always create new record
+	siteID, _ := GetSiteIDFromContext(ctx)
+	image := Image{
+		SiteID:   siteID,
+		FileName: result.Filename,
+		Title:    caption,
+		FilePath: result.RelativePath,
+		Width:    result.Width,
+		Height:   result.Height,
+		AltText:  altText,
+	}
+	image.GenCreateValues()
+
+	if err := svc.repo.CreateImage(ctx, &image); err != nil {
+		svc.im.DeleteImage(ctx, result.RelativePath)
+		return nil, fmt.Errorf("failed to create image record: %w", err)
+	}
+
+	isHeader := imageType == ImageTypeSectionHeader || imageType == ImageTypeBlogHeader
+	sectionImage := NewSectionImage(sectionID, image.GetID(), isHeader)
+
+	if err := svc.repo.CreateSectionImage(ctx, sectionImage); err != nil {
+		svc.im.DeleteImage(ctx, result.RelativePath)
+		svc.repo.DeleteImage(ctx, image.GetID())
+		return nil, fmt.Errorf("failed to create section-image relationship: %w", err)
+	}
+
+	return result, nil
 }
 
-func (svc *BaseService) GetLayout(ctx context.Context, id uuid.UUID) (Layout, error) {
-	repo, err := svc.getRepo(ctx)
+func (svc *BaseService) DeleteSectionImage(ctx context.Context, sectionID uuid.UUID, imageType ImageType) error {
+	_, err := svc.repo.GetSection(ctx, sectionID)
 	if err != nil {
-		return Layout{}, err
+		return fmt.Errorf("failed to get section: %w", err)
+	}
+
+	sectionImages, err := svc.repo.GetSectionImagesBySectionID(ctx, sectionID)
+	if err != nil {
+		return fmt.Errorf("failed to get layout images: %w", err)
+	}
+
+	var imageToDelete *Image
+	var relationshipToDelete *SectionImage
+
+	for _, si := range sectionImages {
+		if si.IsHeader {
+			image, err := svc.repo.GetImage(ctx, si.ImageID)
+			if err != nil {
+				svc.Log().Info("Failed to get image %s: %v", si.ImageID, err)
+				continue
+			}
+			imageToDelete = &image
+			relationshipToDelete = &si
+			break
+		}
+	}
+
+	if imageToDelete == nil {
+		svc.Log().Info("No %s image found for section %s", imageType, sectionID)
+		return nil // Nothing to delete
+	}
+
+	if err := svc.repo.DeleteSectionImage(ctx, relationshipToDelete.ID); err != nil {
+		return fmt.Errorf("failed to delete layout image relationship: %w", err)
+	}
+
+	if err := svc.repo.DeleteImage(ctx, imageToDelete.ID); err != nil {
+		return fmt.Errorf("failed to delete image record: %w", err)
+	}
+
+	if err := svc.im.DeleteImage(ctx, imageToDelete.FilePath); err != nil {
+		return fmt.Errorf("failed to delete image file: %w", err)
 	}
-	return repo.GetLayout(ctx, id)
+
+	return nil
 }
 
-func (svc *BaseService) GetAllLayouts(ctx context.Context) ([]Layout, error) {
-	repo, err := svc.getRepo(ctx)
+// Image maintenance
+
+// CleanupOrphanedImages detects image files without references and image records
+// without files for the site in context. With purge set, orphan files are
+// deleted along with the records (and their relationships) whose files are gone.
+// Records of the site that nothing links or references are only reported.
+func (svc *BaseService) CleanupOrphanedImages(ctx context.Context, purge bool) (OrphanReport, error) {
+	inv, err := svc.loadImageInventory(ctx)
 	if err != nil {
-		return nil, err
+		return OrphanReport{}, err
 	}
-	return repo.GetAllLayouts(ctx)
-}
 
-func (svc *BaseService) UpdateLayout(ctx context.Context, layout Layout) error {
-	repo, err := svc.getRepo(ctx)
-	if err != nil {
-		return err
+	var usage ImageUsage
+	usage.Images = inv.siteImages()
+	usage.Linked = inv.linked
+	for _, img := range inv.images {
+		if img.SiteID == uuid.Nil && !inv.linked[img.ID] {
+			usage.Shared = append(usage.Shared, img.FilePath)
+		}
+	}
+	for _, content := range inv.contents {
+		usage.BodyRefs = append(usage.BodyRefs, ExtractImageRefs(content.Body)...)
 	}
-	return repo.UpdateLayout(ctx, layout)
-}
 
-func (svc *BaseService) DeleteLayout(ctx context.Context, id uuid.UUID) error {
-	repo, err := svc.getRepo(ctx)
+	report, err := svc.im.CleanupOrphanedImages(ctx, usage, purge)
 	if err != nil {
-		return err
+		return report, err
 	}
-	return repo.DeleteLayout(ctx, id)
-}
 
-// Tag related
-func (svc *BaseService) CreateTag(ctx context.Context, tag Tag) error {
-	repo, err := svc.getRepo(ctx)
-	if err != nil {
-		return err
+	if !purge {
+		return report, nil
 	}
-	return repo.CreateTag(ctx, tag)
-}
 
-func (svc *BaseService) GetTag(ctx context.Context, id uuid.UUID) (Tag, error) {
-	repo, err := svc.getRepo(ctx)
-	if err != nil {
-		return Tag{}, err
+	for _, img := range report.MissingFiles {
+		for _, ci := range inv.contentImages {
+			if ci.ImageID != img.ID {
+				continue
+			}
+			if err := inv.repo.DeleteContentImage(ctx, ci.ID); err != nil {
+				return report, fmt.Errorf("failed to delete content image relationship: %w", err)
+			}
+		}
+		for _, si := range inv.sectionImages {
+			if si.ImageID != img.ID {
+				continue
+			}
+			if err := inv.repo.DeleteSectionImage(ctx, si.ID); err != nil {
+				return report, fmt.Errorf("failed to delete section image relationship: %w", err)
+			}
+		}
+		if err := inv.repo.DeleteImage(ctx, img.ID); err != nil {
+			return report, fmt.Errorf("failed to delete image record: %w", err)
+		}
+		svc.Log().Infof("Removed image record without file: %s", img.FilePath)
 	}
-	return repo.GetTag(ctx, id)
+
+	return report, nil
 }
 
-func (svc *BaseService) GetTagByName(ctx context.Context, name string) (Tag, error) {
-	repo, err := svc.getRepo(ctx)
-	if err != nil {
-		return Tag{}, err
-	}
-	return repo.GetTagByName(ctx, name)
+// imageInventory holds the image records of the site in context together with
+// everything that can reference them.
+type imageInventory struct {
+	repo          Repo
+	siteID        uuid.UUID
+	contents      []Content
+	sections      []Section
+	contentImages []ContentImage
+	sectionImages []SectionImage
+	images        []Image
+	linked        map[uuid.UUID]bool
 }
 
-func (svc *BaseService) GetAllTags(ctx context.Context) ([]Tag, error) {
-	repo, err := svc.getRepo(ctx)
+func (svc *BaseService) loadImageInventory(ctx context.Context) (imageInventory, error) {
+	inv, err := svc.loadImageRefs(ctx)
 	if err != nil {
-		return nil, err
+		return inv, err
 	}
-	return repo.GetAllTags(ctx)
-}
 
-func (svc *BaseService) UpdateTag(ctx context.Context, tag Tag) error {
-	repo, err := svc.getRepo(ctx)
+	inv.images, err = inv.repo.ListImages(ctx)
 	if err != nil {
-		return err
+		return inv, fmt.Errorf("failed to list images: %w", err)
 	}
-	return repo.UpdateTag(ctx, tag)
+
+	return inv, nil
 }
 
-func (svc *BaseService) DeleteTag(ctx context.Context, id uuid.UUID) error {
-	repo, err := svc.getRepo(ctx)
+// loadImageRefs loads everything that can reference an image of the site in
+// context, leaving the image records out.
+func (svc *BaseService) loadImageRefs(ctx context.Context) (imageInventory, error) {
+	siteID, err := RequireSiteID(ctx)
 	if err != nil {
-		return err
+		return imageInventory{}, err
 	}
-	return repo.DeleteTag(ctx, id)
-}
 
-// Param related
-func (svc *BaseService) CreateParam(ctx context.Context, param *Param) error {
 	repo, err := svc.getRepo(ctx)
 	if err != nil {
-		return err
+		return imageInventory{}, fmt.Errorf("repo not available: %w", err)
 	}
-	return repo.CreateParam(ctx, param)
-}
 
-func (svc *BaseService) GetParam(ctx context.Context, id uuid.UUID) (Param, error) {
-	repo, err := svc.getRepo(ctx)
+	inv := imageInventory{repo: repo, siteID: siteID, linked: make(map[uuid.UUID]bool)}
+
+	inv.contents, err = repo.GetAllContentWithMeta(ctx)
 	if err != nil {
-		return Param{}, err
+		return inv, fmt.Errorf("failed to get contents: %w", err)
 	}
-	return repo.GetParam(ctx, id)
-}
 
-func (svc *BaseService) GetParamByName(ctx context.Context, name string) (Param, error) {
-	repo, err := svc.getRepo(ctx)
+	inv.sections, err = repo.GetSections(ctx)
 	if err != nil {
-		return Param{}, err
+		return inv, fmt.Errorf("failed to get sections: %w", err)
 	}
-	return repo.GetParamByName(ctx, name)
-}
 
-func (svc *BaseService) GetParamByRefKey(ctx context.Context, refKey string) (Param, error) {
-	repo, err := svc.getRepo(ctx)
+	inv.contentImages, err = repo.GetAllContentImages(ctx)
 	if err != nil {
-		return Param{}, err
+		return inv, fmt.Errorf("failed to get content images: %w", err)
 	}
-	return repo.GetParamByRefKey(ctx, refKey)
-}
 
-func (svc *BaseService) ListParams(ctx context.Context) ([]Param, error) {
-	repo, err := svc.getRepo(ctx)
+	inv.sectionImages, err = repo.GetAllSectionImages(ctx)
 	if err != nil {
-		return nil, err
+		return inv, fmt.Errorf("failed to get section images: %w", err)
 	}
-	return repo.ListParams(ctx)
-}
 
-func (svc *BaseService) UpdateParam(ctx context.Context, param *Param) error {
-	repo, err := svc.getRepo(ctx)
-	if err != nil {
-		return err
+	for _, ci := range inv.contentImages {
+		inv.linked[ci.ImageID] = true
+	}
+	for _, si := range inv.sectionImages {
+		inv.linked[si.ImageID] = true
 	}
-	return repo.UpdateParam(ctx, param)
+
+	return inv, nil
 }
 
-func (svc *BaseService) DeleteParam(ctx context.Context, id uuid.UUID) error {
-	repo, err := svc.getRepo(ctx)
-	if err != nil {
-		return err
+// siteImages returns the images owned by or linked from the site.
+func (inv imageInventory) siteImages() []Image {
+	var images []Image
+	for _, img := range inv.images {
+		if img.SiteID == inv.siteID || inv.linked[img.ID] {
+			images = append(images, img)
+		}
 	}
-	return repo.DeleteParam(ctx, id)
+	return images
 }
 
-// Image related
-func (svc *BaseService) CreateImage(ctx context.Context, image *Image) error {
-	repo, err := svc.getRepo(ctx)
-	if err != nil {
-		return err
+// usage lists the places where img is used: relationships and Markdown
+// references in content bodies.
+func (inv imageInventory) usage(img Image) []ImageRef {
+	refs := []ImageRef{}
+
+	contentTitles := make(map[uuid.UUID]string, len(inv.contents))
+	for _, c := range inv.contents {
+		contentTitles[c.ID] = c.Heading
 	}
-	return repo.CreateImage(ctx, image)
-}
 
-func (svc *BaseService) GetImage(ctx context.Context, id uuid.UUID) (Image, error) {
-	repo, err := svc.getRepo(ctx)
-	if err != nil {
-		return Image{}, err
+	for _, ci := range inv.contentImages {
+		if ci.ImageID != img.ID {
+			continue
+		}
+		kind := ImageRefContent
+		if ci.IsHeader {
+			kind = ImageRefHeader
+		}
+		refs = append(refs, ImageRef{Kind: kind, ContentID: ci.ContentID, Title: contentTitles[ci.ContentID]})
 	}
-	return repo.GetImage(ctx, id)
-}
 
-func (svc *BaseService) GetImageByShortID(ctx context.Context, shortID string) (Image, error) {
-	repo, err := svc.getRepo(ctx)
-	if err != nil {
-		return Image{}, err
+	for _, si := range inv.sectionImages {
+		if si.ImageID != img.ID {
+			continue
+		}
+		ref := ImageRef{Kind: ImageRefSection, SectionID: si.SectionID}
+		for _, s := range inv.sections {
+			if s.ID == si.SectionID {
+				ref.Title = s.Name
+				break
+			}
+		}
+		refs = append(refs, ref)
 	}
-	return repo.GetImageByShortID(ctx, shortID)
-}
 
-func (svc *BaseService) ListImages(ctx context.Context) ([]Image, error) {
-	repo, err := svc.getRepo(ctx)
-	if err != nil {
-		return nil, err
+	for _, c := range inv.contents {
+		for _, path := range ExtractImageRefs(c.Body) {
+			if path == img.FilePath {
+				refs = append(refs, ImageRef{Kind: ImageRefBody, ContentID: c.ID, Title: c.Heading})
+				break
+			}
+		}
 	}
-	return repo.ListImages(ctx)
+
+	return refs
 }
 
-func (svc *BaseService) UpdateImage(ctx context.Context, image *Image) error {
-	repo, err := svc.getRepo(ctx)
-	if err != nil {
-		return err
-	}
-	return repo.UpdateImage(ctx, image)
+// Media Library
+
+// Image usage kinds.
+const (
+	ImageRefHeader  = "header"
+	ImageRefContent = "content"
+	ImageRefSection = "section"
+	ImageRefBody    = "body"
+)
+
+// ImageRef describes a place where an image is used.
+type ImageRef struct {
+	Kind      string    `json:"kind"`
+	ContentID uuid.UUID `json:"content_id"`
+	SectionID uuid.UUID `json:"section_id"`
+	Title     string    `json:"title"`
 }
 
-func (svc *BaseService) DeleteImage(ctx context.Context, id uuid.UUID) error {
-	repo, err := svc.getRepo(ctx)
-	if err != nil {
-		return err
-	}
-	return repo.DeleteImage(ctx, id)
+// LibraryImage is an image as listed in the media library.
+type LibraryImage struct {
+	Image
+	Usage []ImageRef `json:"usage"`
 }
 
-// ImageVariant related
-func (svc *BaseService) CreateImageVariant(ctx context.Context, variant *ImageVariant) error {
-	repo, err := svc.getRepo(ctx)
+// UploadLibraryImage stores an image in the media library without attaching it to any content.
+func (svc *BaseService) UploadLibraryImage(ctx context.Context, file multipart.File, header *multipart.FileHeader, altText, title string) (Image, error) {
+	svc.Log().Debugf("Uploading library image: %s", header.Filename)
+
+	siteID, err := RequireSiteID(ctx)
 	if err != nil {
-		return err
+		return Image{}, err
 	}
-	return repo.CreateImageVariant(ctx, variant)
-}
 
-func (svc *BaseService) GetImageVariant(ctx context.Context, id uuid.UUID) (ImageVariant, error) {
-	repo, err := svc.getRepo(ctx)
+	result, err := svc.im.ProcessUpload(ctx, file, header, nil, nil, ImageTypeLibrary, altText, title)
 	if err != nil {
-		return ImageVariant{}, err
+		return Image{}, fmt.Errorf("failed to process upload: %w", err)
 	}
-	return repo.GetImageVariant(ctx, id)
-}
 
-func (svc *BaseService) ListImageVariantsByImageID(ctx context.Context, imageID uuid.UUID) ([]ImageVariant, error) {
-	repo, err := svc.getRepo(ctx)
-	if err != nil {
-		return nil, err
+	image := Image{
+		SiteID:   siteID,
+		FileName: result.Filename,
+		Title:    title,
+		FilePath: result.RelativePath,
+		Width:    result.Width,
+		Height:   result.Height,
+		AltText:  altText,
 	}
-	return repo.ListImageVariantsByImageID(ctx, imageID)
-}
+	image.GenCreateValues()
 
-func (svc *BaseService) UpdateImageVariant(ctx context.Context, variant *ImageVariant) error {
-	repo, err := svc.getRepo(ctx)
-	if err != nil {
-		return err
+	if err := svc.repo.CreateImage(ctx, &image); err != nil {
+		svc.im.DeleteImage(ctx, result.RelativePath)
+		return Image{}, fmt.Errorf("failed to create image record: %w", err)
 	}
-	return repo.UpdateImageVariant(ctx, variant)
+
+	return image, nil
 }
 
-func (svc *BaseService) DeleteImageVariant(ctx context.Context, id uuid.UUID) error {
+// ListLibraryImages returns a page of the site images, newest first, filtered by search.
+func (svc *BaseService) ListLibraryImages(ctx context.Context, offset, limit int, search string) ([]LibraryImage, int, error) {
 	repo, err := svc.getRepo(ctx)
 	if err != nil {
-		return err
+		return nil, 0, fmt.Errorf("repo not available: %w", err)
 	}
-	return repo.DeleteImageVariant(ctx, id)
-}
 
-// ContentTag related
-func (svc *BaseService) AddTagToContent(ctx context.Context, contentID uuid.UUID, tagName string) error {
-	repo, err := svc.getRepo(ctx)
+	images, total, err := repo.ListSiteImages(ctx, offset, limit, strings.TrimSpace(search))
 	if err != nil {
-		return err
+		return nil, 0, fmt.Errorf("failed to list library images: %w", err)
 	}
 
-	tag, err := repo.GetTagByName(ctx, tagName)
-	if err != nil && !errors.Is(err, sql.ErrNoRows) {
-		return fmt.Errorf("error getting tag by name: %w", err)
+	inv, err := svc.loadImageRefs(ctx)
+	if err != nil {
+		return nil, 0, err
 	}
 
-	if tag.IsZero() {
-		newTag := NewTag(tagName)
-		newTag.GenCreateValues()
-		err = repo.CreateTag(ctx, newTag)
-		if err != nil {
-			return fmt.Errorf("error creating tag: %w", err)
-		}
-		tag = newTag
+	library := make([]LibraryImage, 0, len(images))
+	for _, img := range images {
+		library = append(library, LibraryImage{Image: img, Usage: inv.usage(img)})
 	}
 
-	return repo.AddTagToContent(ctx, contentID, tag.ID)
+	return library, total, nil
 }
 
-func (svc *BaseService) RemoveTagFromContent(ctx context.Context, contentID, tagID uuid.UUID) error {
-	repo, err := svc.getRepo(ctx)
+// GetLibraryImage returns an image along with the places where it is used.
+func (svc *BaseService) GetLibraryImage(ctx context.Context, id uuid.UUID) (LibraryImage, error) {
+	inv, err := svc.loadImageInventory(ctx)
 	if err != nil {
-		return err
+		return LibraryImage{}, err
 	}
-	return repo.RemoveTagFromContent(ctx, contentID, tagID)
-}
 
-func (svc *BaseService) GetTagsForContent(ctx context.Context, contentID uuid.UUID) ([]Tag, error) {
-	repo, err := svc.getRepo(ctx)
-	if err != nil {
-		return nil, err
+	for _, img := range inv.siteImages() {
+		if img.ID == id {
+			return LibraryImage{Image: img, Usage: inv.usage(img)}, nil
+		}
 	}
-	return repo.GetTagsForContent(ctx, contentID)
+
+	return LibraryImage{}, fmt.Errorf("image %s not found in site library", id)
 }
 
-func (svc *BaseService) GetContentForTag(ctx context.Context, tagID uuid.UUID) ([]Content, error) {
-	repo, err := svc.getRepo(ctx)
+// UpdateImageMetadata changes the title and alt text of an image, leaving the file untouched.
+func (svc *BaseService) UpdateImageMetadata(ctx context.Context, id uuid.UUID, title, altText string) (Image, error) {
+	image, err := svc.siteImage(ctx, id)
 	if err != nil {
-		return nil, err
+		return Image{}, err
 	}
-	return repo.GetContentForTag(ctx, tagID)
-}
-
-var firstH1Regex = regexp.MustCompile(`(?i)<h1[^>]*>.*?</h1>`)
 
-// removeFirstH1 removes the first <h1>...</h1> tag from an HTML string.
-func (svc *BaseService) removeFirstH1(htmlContent string) string {
-	return firstH1Regex.ReplaceAllStringFunc(htmlContent, func(match string) string {
-		// Only replace the first occurrence
-		if strings.HasPrefix(htmlContent, match) {
-			return ""
-		}
-		return match
-	})
-}
+	image.Title = title
+	image.AltText = altText
+	image.GenUpdateValues()
 
-// Content Image Management
+	if err := svc.repo.UpdateImage(ctx, &image); err != nil {
+		return Image{}, fmt.Errorf("failed to update image: %w", err)
+	}
 
-// UploadContentImage handles uploading images for content (header or content images)
-func (svc *BaseService) UploadContentImage(ctx context.Context, contentID uuid.UUID, file multipart.File, header *multipart.FileHeader, imageType ImageType, altText, caption string) (*ImageProcessResult, error) {
-	svc.Log().Debugf("Uploading content image: contentID=%s, type=%s", contentID, imageType)
+	return image, nil
+}
 
-	content, err := svc.repo.GetContent(ctx, contentID)
+// AddLibraryImageToContent links a library image to a content and returns the
+// Markdown needed to show it in the content body.
+func (svc *BaseService) AddLibraryImageToContent(ctx context.Context, contentID, imageID uuid.UUID) (string, error) {
+	image, err := svc.siteImage(ctx, imageID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get content: %w", err)
-	}
-
-	var section *Section
-	if content.SectionID != uuid.Nil {
-		s, err := svc.repo.GetSection(ctx, content.SectionID)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get section: %w", err)
-		}
-		section = &s
+		return "", err
 	}
 
-	result, err := svc.im.ProcessUpload(ctx, file, header, &content, section, imageType, altText, caption)
+	contentImages, err := svc.repo.GetContentImagesByContentID(ctx, contentID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to process upload: %w", err)
+		return "", fmt.Errorf("failed to get content images: %w", err)
 	}
 
-	// Create Image record with accessibility metadata - always create new record
-	image := Image{
-		Title:    caption,
-		FilePath: result.RelativePath,
-		AltText:  altText,
+	linked := false
+	for _, ci := range contentImages {
+		if ci.ImageID == imageID {
+			linked = true
+			break
+		}
 	}
-	image.GenCreateValues()
 
-	if err := svc.repo.CreateImage(ctx, &image); err != nil {
-		svc.im.DeleteImage(ctx, result.RelativePath)
-		return nil, fmt.Errorf("failed to create image record: %w", err)
+	if !linked {
+		if err := svc.repo.CreateContentImage(ctx, NewContentImage(contentID, imageID, false)); err != nil {
+			return "", fmt.Errorf("failed to create content-image relationship: %w", err)
+		}
 	}
 
-	isHeader := imageType == ImageTypeHeader
-	contentImage := NewContentImage(contentID, image.GetID(), isHeader)
+	return fmt.Sprintf("![%s](%s%s)", image.AltText, imageURLPrefix, image.FilePath), nil
+}
 
-	if err := svc.repo.CreateContentImage(ctx, contentImage); err != nil {
-		svc.im.DeleteImage(ctx, result.RelativePath)
-		svc.repo.DeleteImage(ctx, image.GetID())
-		return nil, fmt.Errorf("failed to create content-image relationship: %w", err)
+// siteImage returns the image with id if it belongs to the site in context.
+func (svc *BaseService) siteImage(ctx context.Context, id uuid.UUID) (Image, error) {
+	siteID, err := RequireSiteID(ctx)
+	if err != nil {
+		return Image{}, err
 	}
 
-	// TODO: Remove direct field update when we complete migration
-	// if imageType == ImageTypeHeader {
-	//	content.Image = result.RelativePath
-	//	if err := svc.repo.UpdateContent(ctx, &content); err != nil {
-	//		return nil, fmt.Errorf("failed to update content with header image: %w", err)
-	//	}
-	// }
+	image, err := svc.repo.GetImage(ctx, id)
+	if err != nil {
+		return Image{}, fmt.Errorf("failed to get image: %w", err)
+	}
+	if image.SiteID != siteID {
+		return Image{}, fmt.Errorf("image %s not found in site library", id)
+	}
 
-	return result, nil
+	return image, nil
 }
 
-// ImageWithMeta combines Image with ContentImage metadata for API responses
-type ImageWithMeta struct {
-	ID         uuid.UUID `json:"id"`
-	SiteID     uuid.UUID `json:"site_id"`
-	FileName   string    `json:"file_name"`
-	FilePath   string    `json:"file_path"`
-	Width      int       `json:"width"`
-	Height     int       `json:"height"`
-	Title      string    `json:"title"`
-	AltText    string    `json:"alt_text"`
-	IsHeader   bool      `json:"is_header"`
-	IsFeatured bool      `json:"is_featured"`
-	OrderNum   int       `json:"order_num"`
+// Image file replacement
+
+// ImageReplacement describes the outcome of replacing the file of an image.
+type ImageReplacement struct {
+	Image           Image           `json:"image"`
+	Revision        *ImageRevision  `json:"revision"`
+	UpdatedContents []uuid.UUID     `json:"updated_contents"`
+	Stripped        *MetadataReport `json:"stripped"`
 }
 
-// GetContentImages returns all images for a specific content via relationships
-func (svc *BaseService) GetContentImages(ctx context.Context, contentID uuid.UUID) ([]ImageWithMeta, error) {
-	svc.Log().Debugf("Getting content images: contentID=%s", contentID)
+// ReplaceImageFile swaps the file of an image of the site in context keeping
+// its ID. When the new file has a different extension the path changes and
+// every content body that references the old path is rewritten. The previous
+// file is recorded as a revision so the replacement can be rolled back, and
+// the variants of the previous file are dropped. The previous file is put
+// back if the replacement cannot be recorded.
+func (svc *BaseService) ReplaceImageFile(ctx context.Context, id uuid.UUID, file multipart.File, header *multipart.FileHeader) (ImageReplacement, error) {
+	svc.Log().Debugf("Replacing image file: imageID=%s, file=%s", id, header.Filename)
 
-	repo, err := svc.getRepo(ctx)
+	image, err := svc.siteImage(ctx, id)
 	if err != nil {
-		return nil, fmt.Errorf("repo not available: %w", err)
+		return ImageReplacement{}, err
 	}
+	previous := image
 
-	contentImages, err := repo.GetContentImagesByContentID(ctx, contentID)
+	result, err := svc.im.ReplaceFile(ctx, image, file, header)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get content images: %w", err)
+		return ImageReplacement{}, fmt.Errorf("failed to replace file: %w", err)
 	}
 
-	var images []ImageWithMeta
-	for _, ci := range contentImages {
-		image, err := repo.GetImage(ctx, ci.ImageID)
-		if err != nil {
-			svc.Log().Info("Failed to get image %s: %v", ci.ImageID, err)
-			continue
+	undo := func() {
+		if err := svc.im.UndoReplace(ctx, previous, result); err != nil {
+			svc.Log().Error("Cannot put back the previous image file", "image", id, "path", previous.FilePath, "error", err)
 		}
+	}
 
-		imageWithMeta := ImageWithMeta{
-			ID:         image.ID,
-			SiteID:     image.SiteID,
-			FileName:   image.FileName,
-			FilePath:   image.FilePath,
-			Width:      image.Width,
-			Height:     image.Height,
-			Title:      image.Title,
-			AltText:    image.AltText,
-			IsHeader:   ci.IsHeader,
-			IsFeatured: ci.IsFeatured,
-			OrderNum:   ci.OrderNum,
+	replacement := ImageReplacement{Stripped: result.Stripped, UpdatedContents: []uuid.UUID{}}
+
+	if result.BackupPath != "" {
+		revision := NewImageRevision(image, result.BackupPath)
+		if err := svc.repo.CreateImageRevision(ctx, revision); err != nil {
+			undo()
+			return ImageReplacement{}, fmt.Errorf("failed to record image revision: %w", err)
 		}
-		images = append(images, imageWithMeta)
+		replacement.Revision = revision
 	}
 
-	return images, nil
-}
+	oldPath := image.FilePath
+	image.FileName = result.Filename
+	image.FilePath = result.RelativePath
+	image.Width = result.Width
+	image.Height = result.Height
+	image.GenUpdateValues()
 
-// GetContentHeaderImage returns the header image for a specific content
-func (svc *BaseService) GetContentHeaderImage(ctx context.Context, contentID uuid.UUID) (string, error) {
-	contentImages, err := svc.repo.GetContentImagesByContentID(ctx, contentID)
-	if err != nil {
-		return "", fmt.Errorf("failed to get content images: %w", err)
+	if err := svc.repo.UpdateImage(ctx, &image); err != nil {
+		if replacement.Revision != nil {
+			if rerr := svc.repo.DeleteImageRevision(ctx, replacement.Revision.ID); rerr != nil {
+				svc.Log().Error("Cannot delete image revision", "revision", replacement.Revision.ID, "error", rerr)
+			}
+		}
+		undo()
+		return ImageReplacement{}, fmt.Errorf("failed to update image: %w", err)
 	}
+	replacement.Image = image
 
-	for _, ci := range contentImages {
-		if ci.IsHeader {
-			image, err := svc.repo.GetImage(ctx, ci.ImageID)
-			if err != nil {
-				svc.Log().Info("Failed to get header image %s: %v", ci.ImageID, err)
-				continue
-			}
+	if err := svc.deleteImageVariants(ctx, id); err != nil {
+		return replacement, err
+	}
 
-			return image.FilePath, nil
+	if oldPath != image.FilePath {
+		updated, err := svc.rewriteContentImageRefs(ctx, oldPath, image.FilePath)
+		replacement.UpdatedContents = updated
+		if err != nil {
+			return replacement, err
 		}
 	}
 
-	return "", nil // No header image found
+	return replacement, nil
 }
 
-// GetSectionHeaderImage returns the header image for a specific section
-func (svc *BaseService) GetSectionHeaderImage(ctx context.Context, sectionID uuid.UUID) (string, error) {
-	sectionImages, err := svc.repo.GetSectionImagesBySectionID(ctx, sectionID)
+// ListImageRevisions returns the previous files of an image of the site in
+// context, newest first.
+func (svc *BaseService) ListImageRevisions(ctx context.Context, id uuid.UUID) ([]ImageRevision, error) {
+	if _, err := svc.siteImage(ctx, id); err != nil {
+		return nil, err
+	}
+	return svc.repo.GetImageRevisionsByImageID(ctx, id)
+}
+
+// RollbackImageFile restores the most recent previous file of an image of the
+// site in context, undoing its last replacement. The variants of the
+// replaced file are dropped.
+func (svc *BaseService) RollbackImageFile(ctx context.Context, id uuid.UUID) (ImageReplacement, error) {
+	svc.Log().Debugf("Rolling back image file: imageID=%s", id)
+
+	image, err := svc.siteImage(ctx, id)
 	if err != nil {
-		return "", fmt.Errorf("failed to get layout images: %w", err)
+		return ImageReplacement{}, err
 	}
 
-	for _, si := range sectionImages {
-		if si.IsHeader {
-			image, err := svc.repo.GetImage(ctx, si.ImageID)
-			if err != nil {
-				svc.Log().Info("Failed to get section header image %s: %v", si.ImageID, err)
-				continue
-			}
+	revisions, err := svc.repo.GetImageRevisionsByImageID(ctx, id)
+	if err != nil {
+		return ImageReplacement{}, fmt.Errorf("failed to get image revisions: %w", err)
+	}
+	if len(revisions) == 0 {
+		return ImageReplacement{}, fmt.Errorf("image %s has no previous file to restore", id)
+	}
+	revision := revisions[0]
 
-			return image.FilePath, nil
-		}
+	if err := svc.im.RestoreFile(ctx, image.FilePath, revision); err != nil {
+		return ImageReplacement{}, fmt.Errorf("failed to restore file: %w", err)
 	}
 
-	return "", nil // No header image found
-}
+	if err := svc.repo.DeleteImageRevision(ctx, revision.ID); err != nil {
+		return ImageReplacement{}, fmt.Errorf("failed to delete image revision: %w", err)
+	}
 
-// GetSectionBlogHeaderImage returns the blog header image for a specific section
-func (svc *BaseService) GetSectionBlogHeaderImage(ctx context.Context, sectionID uuid.UUID) (string, error) {
-	sectionImages, err := svc.repo.GetSectionImagesBySectionID(ctx, sectionID)
-	if err != nil {
-		return "", fmt.Errorf("failed to get layout images: %w", err)
+	replacement := ImageReplacement{Revision: &revision, UpdatedContents: []uuid.UUID{}}
+
+	currentPath := image.FilePath
+	image.FileName = revision.FileName
+	image.FilePath = revision.FilePath
+	image.Width = revision.Width
+	image.Height = revision.Height
+	image.GenUpdateValues()
+
+	if err := svc.repo.UpdateImage(ctx, &image); err != nil {
+		return replacement, fmt.Errorf("failed to update image: %w", err)
 	}
+	replacement.Image = image
 
-	for _, si := range sectionImages {
-		if si.IsHeader {
-			image, err := svc.repo.GetImage(ctx, si.ImageID)
-			if err != nil {
-				svc.Log().Info("Failed to get section blog header image %s: %v", si.ImageID, err)
-				continue
-			}
+	if err := svc.deleteImageVariants(ctx, id); err != nil {
+		return replacement, err
+	}
 
-			return image.FilePath, nil
+	if currentPath != image.FilePath {
+		updated, err := svc.rewriteContentImageRefs(ctx, currentPath, image.FilePath)
+		replacement.UpdatedContents = updated
+		if err != nil {
+			return replacement, err
 		}
 	}
 
-	return "", nil // No blog header image found
+	return replacement, nil
 }
 
-// DeleteContentImage deletes a content image by path
-func (svc *BaseService) DeleteContentImage(ctx context.Context, contentID uuid.UUID, imagePath string) error {
-	svc.Log().Infof("Deleting content image: contentID=%s, imagePath=%s", contentID, imagePath)
-
-	_, err := svc.repo.GetContent(ctx, contentID)
+// deleteImageVariants drops the variants of an image, made from a file the
+// image no longer has.
+func (svc *BaseService) deleteImageVariants(ctx context.Context, imageID uuid.UUID) error {
+	variants, err := svc.repo.ListImageVariantsByImageID(ctx, imageID)
 	if err != nil {
-		svc.Log().Errorf("Failed to get content: %v", err)
-		return fmt.Errorf("failed to get content: %w", err)
+		return fmt.Errorf("failed to get image variants: %w", err)
+	}
+	for _, v := range variants {
+		if err := svc.repo.DeleteImageVariant(ctx, v.ID); err != nil {
+			return fmt.Errorf("failed to delete image variant %s: %w", v.ID, err)
+		}
 	}
+	return nil
+}
 
-	contentImages, err := svc.repo.GetContentImagesByContentID(ctx, contentID)
+// rewriteContentImageRefs points the content bodies of the site that reference
+// oldPath to newPath and returns the IDs of the updated contents.
+func (svc *BaseService) rewriteContentImageRefs(ctx context.Context, oldPath, newPath string) ([]uuid.UUID, error) {
+	updated := []uuid.UUID{}
+
+	contents, err := svc.repo.GetAllContentWithMeta(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to get content images: %w", err)
+		return updated, fmt.Errorf("failed to get contents: %w", err)
 	}
 
-	var imageToDelete *Image
-	var relationshipToDelete *ContentImage
-	for _, ci := range contentImages {
-		image, err := svc.repo.GetImage(ctx, ci.ImageID)
-		if err != nil {
-			svc.Log().Info("Failed to get image %s: %v", ci.ImageID, err)
+	for _, content := range contents {
+		body, changed := RewriteImageRefs(content.Body, oldPath, newPath)
+		if !changed {
 			continue
 		}
-		if image.FilePath == imagePath {
-			imageToDelete = &image
-			relationshipToDelete = &ci
-			break
-		}
-	}
 
-	if imageToDelete == nil {
-		svc.Log().Info("Image not found in database for path: %s", imagePath)
-		if err := svc.im.DeleteImage(ctx, imagePath); err != nil {
-			return fmt.Errorf("failed to delete image file: %w", err)
+		content.Body = body
+		content.GenUpdateValues()
+		if err := svc.repo.UpdateContent(ctx, &content); err != nil {
+			return updated, fmt.Errorf("failed to update content %s: %w", content.ID, err)
 		}
-		return nil
+		updated = append(updated, content.ID)
 	}
 
-	if err := svc.repo.DeleteContentImage(ctx, relationshipToDelete.ID); err != nil {
-		return fmt.Errorf("failed to delete content image relationship: %w", err)
+	return updated, nil
+}
+
+// Attachments
+
+// UploadAttachment stores a downloadable file for the site in context.
+func (svc *BaseService) UploadAttachment(ctx context.Context, file multipart.File, header *multipart.FileHeader, title string) (Attachment, error) {
+	svc.Log().Debugf("Uploading attachment: %s", header.Filename)
+
+	siteID, err := RequireSiteID(ctx)
+	if err != nil {
+		return Attachment{}, err
 	}
 
-	if err := svc.repo.DeleteImage(ctx, imageToDelete.ID); err != nil {
-		return fmt.Errorf("failed to delete image record: %w", err)
+	stored, err := svc.am.Save(ctx, file, header)
+	if err != nil {
+		return Attachment{}, fmt.Errorf("failed to store attachment: %w", err)
 	}
 
-	if err := svc.im.DeleteImage(ctx, imagePath); err != nil {
-		return fmt.Errorf("failed to delete image file: %w", err)
+	attachment := NewAttachment()
+	attachment.SiteID = siteID
+	attachment.FileName = filepath.Base(header.Filename)
+	attachment.FilePath = stored.RelativePath
+	attachment.MimeType = stored.MimeType
+	attachment.SizeBytes = stored.SizeBytes
+	attachment.Title = title
+	attachment.GenShortID()
+	attachment.GenCreateValues()
+
+	if err := svc.repo.CreateAttachment(ctx, &attachment); err != nil {
+		svc.am.Delete(ctx, stored.RelativePath)
+		return Attachment{}, fmt.Errorf("failed to create attachment record: %w", err)
 	}
 
-	return nil
+	return attachment, nil
 }
 
-// Section Image Management
+// ListAttachments returns the attachments of the site in context, newest first.
+func (svc *BaseService) ListAttachments(ctx context.Context) ([]Attachment, error) {
+	return svc.repo.ListAttachments(ctx)
+}
 
-// UploadSectionImage handles uploading images for sections (section header or blog header)
-func (svc *BaseService) UploadSectionImage(ctx context.Context, sectionID uuid.UUID, file multipart.File, header *multipart.FileHeader, imageType ImageType, altText, caption string) (*ImageProcessResult, error) {
-	section, err := svc.repo.GetSection(ctx, sectionID)
+// GetAttachment returns an attachment of the site in context.
+func (svc *BaseService) GetAttachment(ctx context.Context, id uuid.UUID) (Attachment, error) {
+	siteID, err := RequireSiteID(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get section: %w", err)
+		return Attachment{}, err
 	}
 
-	result, err := svc.im.ProcessUpload(ctx, file, header, nil, &section, imageType, altText, caption)
+	attachment, err := svc.repo.GetAttachment(ctx, id)
 	if err != nil {
-		return nil, fmt.Errorf("failed to process upload: %w", err)
+		return Attachment{}, err
 	}
 
-	// Create Image record with accessibility metadata - always create new record
-	image := Image{
-		Title:    caption,
-		FilePath: result.RelativePath,
-		AltText:  altText,
+	if attachment.SiteID != siteID {
+		return Attachment{}, fmt.Errorf("attachment %s not found in site", id)
 	}
-	image.GenCreateValues()
 
-	if err := svc.repo.CreateImage(ctx, &image); err != nil {
-		svc.im.DeleteImage(ctx, result.RelativePath)
-		return nil, fmt.Errorf("failed to create image record: %w", err)
+	return attachment, nil
+}
+
+// DeleteAttachment removes an attachment record, its content links and its file.
+func (svc *BaseService) DeleteAttachment(ctx context.Context, id uuid.UUID) error {
+	attachment, err := svc.GetAttachment(ctx, id)
+	if err != nil {
+		return err
 	}
 
-	isHeader := imageType == ImageTypeSectionHeader || imageType == ImageTypeBlogHeader
-	sectionImage := NewSectionImage(sectionID, image.GetID(), isHeader)
+	if err := svc.repo.DeleteAttachment(ctx, id); err != nil {
+		return fmt.Errorf("failed to delete attachment record: %w", err)
+	}
 
-	if err := svc.repo.CreateSectionImage(ctx, sectionImage); err != nil {
-		svc.im.DeleteImage(ctx, result.RelativePath)
-		svc.repo.DeleteImage(ctx, image.GetID())
-		return nil, fmt.Errorf("failed to create section-image relationship: %w", err)
+	if err := svc.am.Delete(ctx, attachment.FilePath); err != nil {
+		svc.Log().Errorf("Failed to delete attachment file %s: %v", attachment.FilePath, err)
 	}
 
-	return result, nil
+	return nil
 }
 
-func (svc *BaseService) DeleteSectionImage(ctx context.Context, sectionID uuid.UUID, imageType ImageType) error {
-	_, err := svc.repo.GetSection(ctx, sectionID)
+// attachmentMetadata returns the type and size of the site attachments keyed
+// by their path, as used to decorate links in generated pages.
+func (svc *BaseService) attachmentMetadata(ctx context.Context) (map[string]AttachmentMetadata, error) {
+	attachments, err := svc.repo.ListAttachments(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to get section: %w", err)
+		return nil, err
 	}
 
-	sectionImages, err := svc.repo.GetSectionImagesBySectionID(ctx, sectionID)
-	if err != nil {
-		return fmt.Errorf("failed to get layout images: %w", err)
+	meta := make(map[string]AttachmentMetadata, len(attachments))
+	for _, a := range attachments {
+		meta[a.FilePath] = AttachmentMetadata{Kind: a.Kind(), Size: a.Size()}
 	}
+	return meta, nil
+}
 
-	var imageToDelete *Image
-	var relationshipToDelete *SectionImage
+// GetContentAttachments returns the attachments linked to a content.
+func (svc *BaseService) GetContentAttachments(ctx context.Context, contentID uuid.UUID) ([]Attachment, error) {
+	links, err := svc.repo.GetContentAttachmentsByContentID(ctx, contentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get content attachments: %w", err)
+	}
 
-	for _, si := range sectionImages {
-		if si.IsHeader {
-			image, err := svc.repo.GetImage(ctx, si.ImageID)
-			if err != nil {
-				svc.Log().Info("Failed to get image %s: %v", si.ImageID, err)
-				continue
-			}
-			imageToDelete = &image
-			relationshipToDelete = &si
-			break
+	attachments := []Attachment{}
+	for _, link := range links {
+		attachment, err := svc.repo.GetAttachment(ctx, link.AttachmentID)
+		if err != nil {
+			svc.Log().Errorf("Failed to get attachment %s: %v", link.AttachmentID, err)
+			continue
 		}
+		attachments = append(attachments, attachment)
 	}
 
-	if imageToDelete == nil {
-		svc.Log().Info("No %s image found for section %s", imageType, sectionID)
-		return nil // Nothing to delete
-	}
+	return attachments, nil
+}
 
-	if err := svc.repo.DeleteSectionImage(ctx, relationshipToDelete.ID); err != nil {
-		return fmt.Errorf("failed to delete layout image relationship: %w", err)
+// AddAttachmentToContent links an attachment to a content and returns the
+// Markdown link to insert in the content body.
+func (svc *BaseService) AddAttachmentToContent(ctx context.Context, contentID, attachmentID uuid.UUID) (string, error) {
+	attachment, err := svc.GetAttachment(ctx, attachmentID)
+	if err != nil {
+		return "", err
 	}
 
-	if err := svc.repo.DeleteImage(ctx, imageToDelete.ID); err != nil {
-		return fmt.Errorf("failed to delete image record: %w", err)
+	if err := svc.repo.CreateContentAttachment(ctx, NewContentAttachment(contentID, attachmentID)); err != nil {
+		return "", fmt.Errorf("failed to create content-attachment relationship: %w", err)
 	}
 
-	if err := svc.im.DeleteImage(ctx, imageToDelete.FilePath); err != nil {
-		return fmt.Errorf("failed to delete image file: %w", err)
-	}
+	return attachment.Markdown(), nil
+}
 
-	return nil
+// RemoveAttachmentFromContent unlinks an attachment from a content. The
+// attachment itself is kept.
+func (svc *BaseService) RemoveAttachmentFromContent(ctx context.Context, contentID, attachmentID uuid.UUID) error {
+	return svc.repo.DeleteContentAttachment(ctx, contentID, attachmentID)
 }
 
 // calculateFileHash calculates SHA-256 hash of a multipart file
@@ -0,0 +1,328 @@
+package ssg
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/hermesgen/hm"
+)
+
+type fakeImageRepo struct {
+	Repo
+	contents      []Content
+	contentImages []ContentImage
+	images        []Image
+}
+
+func (f *fakeImageRepo) GetAllContentWithMeta(ctx context.Context) ([]Content, error) {
+	return f.contents, nil
+}
+
+func (f *fakeImageRepo) GetSections(ctx context.Context) ([]Section, error) {
+	return nil, nil
+}
+
+func (f *fakeImageRepo) GetAllContentImages(ctx context.Context) ([]ContentImage, error) {
+	return f.contentImages, nil
+}
+
+func (f *fakeImageRepo) GetAllSectionImages(ctx context.Context) ([]SectionImage, error) {
+	return nil, nil
+}
+
+func (f *fakeImageRepo) ListImages(ctx context.Context) ([]Image, error) {
+	return f.images, nil
+}
+
+func (f *fakeImageRepo) GetImage(ctx context.Context, id uuid.UUID) (Image, error) {
+	for _, img := range f.images {
+		if img.ID == id {
+			return img, nil
+		}
+	}
+	return Image{}, errors.New("image not found")
+}
+
+func (f *fakeImageRepo) UpdateImage(ctx context.Context, image *Image) error {
+	for i := range f.images {
+		if f.images[i].ID == image.ID {
+			f.images[i] = *image
+		}
+	}
+	return nil
+}
+
+func (f *fakeImageRepo) GetContentImagesByContentID(ctx context.Context, contentID uuid.UUID) ([]ContentImage, error) {
+	return nil, nil
+}
+
+func (f *fakeImageRepo) CreateContentImage(ctx context.Context, contentImage *ContentImage) error {
+	f.contentImages = append(f.contentImages, *contentImage)
+	return nil
+}
+
+func TestImageInventoryUsage(t *testing.T) {
+	siteID := uuid.New()
+	post := Content{ID: uuid.New(), Heading: "First post", Body: "![cat](/static/images/library/cat.jpg)"}
+	other := Content{ID: uuid.New(), Heading: "Other post", Body: "No images here"}
+	section := Section{ID: uuid.New(), Name: "Blog"}
+
+	cat := Image{ID: uuid.New(), SiteID: siteID, FilePath: "library/cat.jpg"}
+	header := Image{ID: uuid.New(), SiteID: uuid.Nil, FilePath: "blog/header.jpg"}
+	foreign := Image{ID: uuid.New(), SiteID: uuid.New(), FilePath: "elsewhere.jpg"}
+
+	inv := imageInventory{
+		siteID:   siteID,
+		contents: []Content{post, other},
+		sections: []Section{section},
+		contentImages: []ContentImage{
+			{ContentID: other.ID, ImageID: header.ID, IsHeader: true},
+			{ContentID: post.ID, ImageID: cat.ID},
+		},
+		sectionImages: []SectionImage{{SectionID: section.ID, ImageID: header.ID}},
+		images:        []Image{cat, header, foreign},
+		linked:        map[uuid.UUID]bool{cat.ID: true, header.ID: true},
+	}
+
+	var siteImages []string
+	for _, img := range inv.siteImages() {
+		siteImages = append(siteImages, img.FilePath)
+	}
+	if !equalStrings(siteImages, []string{"library/cat.jpg", "blog/header.jpg"}) {
+		t.Errorf("siteImages() = %v", siteImages)
+	}
+
+	tests := []struct {
+		name  string
+		image Image
+		want  []ImageRef
+	}{
+		{
+			name:  "linked and referenced from body",
+			image: cat,
+			want: []ImageRef{
+				{Kind: ImageRefContent, ContentID: post.ID, Title: "First post"},
+				{Kind: ImageRefBody, ContentID: post.ID, Title: "First post"},
+			},
+		},
+		{
+			name:  "content header and section",
+			image: header,
+			want: []ImageRef{
+				{Kind: ImageRefHeader, ContentID: other.ID, Title: "Other post"},
+				{Kind: ImageRefSection, SectionID: section.ID, Title: "Blog"},
+			},
+		},
+		{
+			name:  "unused",
+			image: foreign,
+			want:  []ImageRef{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := inv.usage(tt.image)
+			if len(got) != len(tt.want) {
+				t.Fatalf("usage() = %+v, want %+v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("usage()[%d] = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestLibraryImageOfOtherSite(t *testing.T) {
+	siteID := uuid.New()
+	foreign := Image{ID: uuid.New(), SiteID: uuid.New(), FilePath: "library/foreign.jpg", Title: "Theirs"}
+	repo := &fakeImageRepo{images: []Image{foreign}}
+	params := hm.XParams{Cfg: hm.NewConfig(), Log: hm.NewLogger("error")}
+	svc := &BaseService{Service: hm.NewService("ssg-svc", params), repo: repo}
+	ctx := context.WithValue(context.Background(), siteIDKey, siteID)
+
+	if _, err := svc.UpdateImageMetadata(ctx, foreign.ID, "Mine", "alt"); err == nil {
+		t.Error("UpdateImageMetadata() error = nil, want image of another site rejected")
+	}
+	if repo.images[0].Title != "Theirs" {
+		t.Errorf("image of another site was updated: %+v", repo.images[0])
+	}
+
+	if _, err := svc.AddLibraryImageToContent(ctx, uuid.New(), foreign.ID); err == nil {
+		t.Error("AddLibraryImageToContent() error = nil, want image of another site rejected")
+	}
+	if len(repo.contentImages) != 0 {
+		t.Errorf("image of another site was linked: %+v", repo.contentImages)
+	}
+}
+
+func TestCleanupOrphanedImagesUnused(t *testing.T) {
+	siteID := uuid.New()
+	linked := Image{ID: uuid.New(), SiteID: siteID, FilePath: "blog/linked.jpg"}
+	inBody := Image{ID: uuid.New(), SiteID: siteID, FilePath: "library/body.jpg"}
+	unused := Image{ID: uuid.New(), SiteID: siteID, FilePath: "library/unused.jpg"}
+	foreign := Image{ID: uuid.New(), SiteID: uuid.New(), FilePath: "library/foreign.jpg"}
+	post := Content{ID: uuid.New(), Body: "![body](/static/images/library/body.jpg)"}
+
+	sitesBase := t.TempDir()
+	imagesPath := GetSiteImagesPath(sitesBase, "test")
+	for _, img := range []Image{linked, inBody, unused, foreign} {
+		path := filepath.Join(imagesPath, img.FilePath)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("cannot create dir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte("img"), 0644); err != nil {
+			t.Fatalf("cannot write file: %v", err)
+		}
+	}
+
+	cfg := hm.NewConfig()
+	cfg.Set(SSGKey.SitesBasePath, sitesBase)
+	params := hm.XParams{Cfg: cfg, Log: hm.NewLogger("error")}
+	repo := &fakeImageRepo{
+		contents:      []Content{post},
+		contentImages: []ContentImage{{ContentID: post.ID, ImageID: linked.ID}},
+		images:        []Image{linked, inBody, unused, foreign},
+	}
+	svc := &BaseService{Service: hm.NewService("ssg-svc", params), repo: repo, im: NewImageManager(nil, params)}
+
+	ctx := context.WithValue(context.Background(), siteIDKey, siteID)
+	ctx = context.WithValue(ctx, siteSlugKey, "test")
+	report, err := svc.CleanupOrphanedImages(ctx, false)
+	if err != nil {
+		t.Fatalf("CleanupOrphanedImages() error = %v", err)
+	}
+
+	if len(report.UnusedImages) != 1 || report.UnusedImages[0].ID != unused.ID {
+		t.Errorf("UnusedImages = %v, want only %s", report.UnusedImages, unused.FilePath)
+	}
+}
+
+type fakeReplaceRepo struct {
+	fakeImageRepo
+	revisions  []ImageRevision
+	variants   []ImageVariant
+	failUpdate bool
+}
+
+func (f *fakeReplaceRepo) UpdateImage(ctx context.Context, image *Image) error {
+	if f.failUpdate {
+		return errors.New("disk full")
+	}
+	return f.fakeImageRepo.UpdateImage(ctx, image)
+}
+
+func (f *fakeReplaceRepo) CreateImageRevision(ctx context.Context, revision *ImageRevision) error {
+	f.revisions = append(f.revisions, *revision)
+	return nil
+}
+
+func (f *fakeReplaceRepo) DeleteImageRevision(ctx context.Context, id uuid.UUID) error {
+	for i, r := range f.revisions {
+		if r.ID == id {
+			f.revisions = append(f.revisions[:i], f.revisions[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+func (f *fakeReplaceRepo) ListImageVariantsByImageID(ctx context.Context, imageID uuid.UUID) ([]ImageVariant, error) {
+	return f.variants, nil
+}
+
+func (f *fakeReplaceRepo) DeleteImageVariant(ctx context.Context, id uuid.UUID) error {
+	for i, v := range f.variants {
+		if v.ID == id {
+			f.variants = append(f.variants[:i], f.variants[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+func TestReplaceImageFile(t *testing.T) {
+	siteID := uuid.New()
+	cat := Image{ID: uuid.New(), SiteID: siteID, FileName: "cat.gif", FilePath: "library/cat.gif"}
+	foreign := Image{ID: uuid.New(), SiteID: uuid.New(), FileName: "dog.gif", FilePath: "library/dog.gif"}
+
+	setup := func(t *testing.T) (*BaseService, *fakeReplaceRepo, string, context.Context) {
+		t.Helper()
+		sitesBase := t.TempDir()
+		imagesPath := GetSiteImagesPath(sitesBase, "test")
+		if err := os.MkdirAll(filepath.Join(imagesPath, "library"), 0755); err != nil {
+			t.Fatalf("cannot create dir: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(imagesPath, cat.FilePath), []byte("old"), 0644); err != nil {
+			t.Fatalf("cannot write file: %v", err)
+		}
+
+		cfg := hm.NewConfig()
+		cfg.Set(SSGKey.SitesBasePath, sitesBase)
+		params := hm.XParams{Cfg: cfg, Log: hm.NewLogger("error")}
+		repo := &fakeReplaceRepo{
+			fakeImageRepo: fakeImageRepo{images: []Image{cat, foreign}},
+			variants:      []ImageVariant{{ID: uuid.New(), ImageID: cat.ID, Kind: "thumb"}},
+		}
+		svc := &BaseService{Service: hm.NewService("ssg-svc", params), repo: repo, im: NewImageManager(nil, params)}
+
+		ctx := context.WithValue(context.Background(), siteIDKey, siteID)
+		ctx = context.WithValue(ctx, siteSlugKey, "test")
+		return svc, repo, imagesPath, ctx
+	}
+
+	t.Run("image of other site", func(t *testing.T) {
+		svc, _, imagesPath, ctx := setup(t)
+		file, header := testMultipartFile(t, "dog.gif", []byte("new"))
+
+		if _, err := svc.ReplaceImageFile(ctx, foreign.ID, file, header); err == nil {
+			t.Error("ReplaceImageFile() error = nil, want image of another site rejected")
+		}
+		if _, err := os.Stat(filepath.Join(imagesPath, foreign.FilePath)); !os.IsNotExist(err) {
+			t.Errorf("file of another site was written: %v", err)
+		}
+		if _, err := svc.ListImageRevisions(ctx, foreign.ID); err == nil {
+			t.Error("ListImageRevisions() error = nil, want image of another site rejected")
+		}
+		if _, err := svc.RollbackImageFile(ctx, foreign.ID); err == nil {
+			t.Error("RollbackImageFile() error = nil, want image of another site rejected")
+		}
+	})
+
+	t.Run("update fails", func(t *testing.T) {
+		svc, repo, imagesPath, ctx := setup(t)
+		repo.failUpdate = true
+		file, header := testMultipartFile(t, "cat.gif", []byte("new"))
+
+		if _, err := svc.ReplaceImageFile(ctx, cat.ID, file, header); err == nil {
+			t.Fatal("ReplaceImageFile() error = nil, want the update error")
+		}
+		if got := readTestFile(t, filepath.Join(imagesPath, cat.FilePath)); got != "old" {
+			t.Errorf("file = %q, want the previous file put back", got)
+		}
+		if len(repo.revisions) != 0 {
+			t.Errorf("revisions = %v, want none recorded", repo.revisions)
+		}
+	})
+
+	t.Run("drops variants", func(t *testing.T) {
+		svc, repo, imagesPath, ctx := setup(t)
+		file, header := testMultipartFile(t, "cat.gif", []byte("new"))
+
+		if _, err := svc.ReplaceImageFile(ctx, cat.ID, file, header); err != nil {
+			t.Fatalf("ReplaceImageFile() error = %v", err)
+		}
+		if got := readTestFile(t, filepath.Join(imagesPath, cat.FilePath)); got != "new" {
+			t.Errorf("file = %q, want the new file", got)
+		}
+		if len(repo.revisions) != 1 || len(repo.variants) != 0 {
+			t.Errorf("revisions = %d, variants = %d, want 1 and 0", len(repo.revisions), len(repo.variants))
+		}
+	})
+}
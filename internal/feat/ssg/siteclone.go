@@ -0,0 +1,176 @@
+package ssg
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CloneOptions selects what a clone copies besides the structure of the site:
+// its sections, layouts, params and tags.
+type CloneOptions struct {
+	// Content copies the contents with their meta, tags and redirects.
+	Content bool
+	// Images copies the image and attachment library with its files.
+	Images bool
+}
+
+// cloneContentTables and cloneImageTables are copied only when the matching
+// option is set; the links between both only when both are.
+var (
+	cloneContentTables = []string{"content", "meta", "content_tag", "redirect"}
+	cloneImageTables   = []string{"image", "image_variant", "image_revision", "section_images", "attachment"}
+	cloneLinkTables    = []string{"content_images", "content_attachments"}
+)
+
+// cloneResetParams are the params that bind a site to where it is published
+// or imported from. Clones keep them with an empty value, so a new site never
+// publishes over its template.
+var cloneResetParams = []string{
+	SSGKey.PublishRepoURL,
+	SSGKey.PublishAuthToken,
+	SSGKey.PublishAuthSSHKey,
+	SSGKey.PublishCustomDomain,
+	SSGKey.PublishTargetPath,
+	SSGKey.ContentRepoURL,
+	SSGKey.WatchDir,
+}
+
+// cloneTables keeps the tables of a site that a clone copies under opts.
+// Publish history is never copied and layouts lose header images not copied.
+func cloneTables(tables []SiteTable, opts CloneOptions) []SiteTable {
+	skip := map[string]bool{"publish_record": true}
+	for _, name := range cloneContentTables {
+		skip[name] = !opts.Content
+	}
+	for _, name := range cloneImageTables {
+		skip[name] = !opts.Images
+	}
+	for _, name := range cloneLinkTables {
+		skip[name] = !opts.Content || !opts.Images
+	}
+
+	reset := map[string]bool{}
+	for _, key := range cloneResetParams {
+		reset[key] = true
+	}
+
+	var cloned []SiteTable
+	for _, t := range tables {
+		if skip[t.Name] {
+			continue
+		}
+		for _, row := range t.Rows {
+			switch t.Name {
+			case "layout":
+				if !opts.Images {
+					row["header_image_id"] = nil
+				}
+			case "param":
+				if key, ok := row["ref_key"].(string); ok && reset[key] {
+					row["value"] = ""
+				}
+			}
+		}
+		cloned = append(cloned, t)
+	}
+	return cloned
+}
+
+// CloneSite creates the site slug from the site sourceID, copying its
+// sections, layouts, params and tags and, as opts selects, its contents and
+// images. Every row gets a new ID. Contents cloned without images keep their
+// image references, which stay broken until the images are uploaded again.
+func (sm *SiteManager) CloneSite(ctx context.Context, sourceID uuid.UUID, name, slug string, opts CloneOptions, userID uuid.UUID) (Site, error) {
+	source, err := sm.siteRepo.GetSite(ctx, sourceID)
+	if err != nil {
+		return Site{}, fmt.Errorf("site not found: %w", err)
+	}
+
+	slug = NormalizeSlug(slug)
+	if slug == "" {
+		return Site{}, fmt.Errorf("invalid slug")
+	}
+	if name == "" {
+		name = source.Name
+	}
+
+	sm.Log().Info("Cloning site", "from", source.Slug(), "slug", slug, "content", opts.Content, "images", opts.Images)
+
+	if existing, err := sm.siteRepo.GetSiteBySlug(ctx, slug); err == nil && !existing.IsZero() {
+		return Site{}, fmt.Errorf("site with slug '%s' already exists", slug)
+	}
+	sitesBasePath := sm.Cfg().StrValOrDef(SSGKey.SitesBasePath, "_workspace/sites")
+	siteDir := GetSiteBasePath(sitesBasePath, slug)
+	if _, err := os.Stat(siteDir); err == nil {
+		return Site{}, fmt.Errorf("directory for site '%s' already exists: %s", slug, siteDir)
+	}
+
+	tables, err := sm.repo.ExportSiteTables(ctx, source.ID)
+	if err != nil {
+		return Site{}, fmt.Errorf("cannot read site data: %w", err)
+	}
+	adoptSiteImages(tables, source.ID)
+	tables = cloneTables(tables, opts)
+
+	site := NewSite(name, slug, source.Mode)
+	site.GenID()
+	site.GenShortID()
+	site.GenCreateValues(userID)
+	remapSiteIDs(tables, source.ID, site.ID)
+
+	now := time.Now()
+	for _, t := range tables {
+		if t.Name != "site" {
+			continue
+		}
+		for _, row := range t.Rows {
+			row["short_id"] = site.ShortID
+			row["name"] = name
+			row["slug"] = slug
+			row["active"] = int64(1)
+			row["created_by"] = userID.String()
+			row["updated_by"] = userID.String()
+			row["created_at"] = now
+			row["updated_at"] = now
+		}
+	}
+
+	if err := sm.createSiteDirectories(slug); err != nil {
+		sm.deleteSiteDirectories(slug)
+		return Site{}, fmt.Errorf("failed to create directories: %w", err)
+	}
+
+	if opts.Images {
+		media := map[string]string{
+			GetSiteImagesPath(sitesBasePath, source.Slug()):      GetSiteImagesPath(sitesBasePath, slug),
+			GetSiteAttachmentsPath(sitesBasePath, source.Slug()): GetSiteAttachmentsPath(sitesBasePath, slug),
+		}
+		for src, dst := range media {
+			if _, err := os.Stat(src); os.IsNotExist(err) {
+				continue
+			}
+			if err := copyDir(src, dst); err != nil {
+				sm.deleteSiteDirectories(slug)
+				return Site{}, fmt.Errorf("cannot copy %s: %w", src, err)
+			}
+		}
+	}
+
+	if err := sm.repo.ImportSiteTables(ctx, tables); err != nil {
+		sm.deleteSiteDirectories(slug)
+		return Site{}, fmt.Errorf("cannot copy site data: %w", err)
+	}
+
+	cloned, err := sm.siteRepo.GetSite(ctx, site.ID)
+	if err != nil {
+		return Site{}, err
+	}
+	sm.writeSiteMarker(cloned)
+
+	sm.Log().Info("Site cloned", "id", cloned.ID, "slug", slug, "from", source.Slug())
+	return cloned, nil
+}
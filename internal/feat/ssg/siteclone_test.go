@@ -0,0 +1,70 @@
+package ssg
+
+import (
+	"testing"
+)
+
+func TestCloneTables(t *testing.T) {
+	site := func() []SiteTable {
+		return []SiteTable{
+			{Name: "site", Rows: []map[string]interface{}{{"id": "s"}}},
+			{Name: "image", Rows: []map[string]interface{}{{"id": "i"}}},
+			{Name: "layout", Rows: []map[string]interface{}{{"id": "l", "header_image_id": "i"}}},
+			{Name: "section", Rows: []map[string]interface{}{{"id": "se"}}},
+			{Name: "content", Rows: []map[string]interface{}{{"id": "c"}}},
+			{Name: "param", Rows: []map[string]interface{}{
+				{"id": "p1", "ref_key": SSGKey.PublishRepoURL, "value": "git@example.com:me/site.git"},
+				{"id": "p2", "ref_key": SSGKey.IndexMaxItems, "value": "5"},
+			}},
+			{Name: "content_images", Rows: []map[string]interface{}{{"id": "ci"}}},
+			{Name: "publish_record", Rows: []map[string]interface{}{{"id": "pr"}}},
+		}
+	}
+
+	names := func(tables []SiteTable) []string {
+		var n []string
+		for _, t := range tables {
+			n = append(n, t.Name)
+		}
+		return n
+	}
+
+	tests := []struct {
+		name string
+		opts CloneOptions
+		want []string
+	}{
+		{name: "structure only", want: []string{"site", "layout", "section", "param"}},
+		{name: "content", opts: CloneOptions{Content: true}, want: []string{"site", "layout", "section", "content", "param"}},
+		{name: "images", opts: CloneOptions{Images: true}, want: []string{"site", "image", "layout", "section", "param"}},
+		{name: "everything", opts: CloneOptions{Content: true, Images: true}, want: []string{"site", "image", "layout", "section", "content", "param", "content_images"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tables := cloneTables(site(), tt.opts)
+			got := names(tables)
+			if len(got) != len(tt.want) {
+				t.Fatalf("tables = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("tables = %v, want %v", got, tt.want)
+				}
+			}
+
+			for _, tb := range tables {
+				switch tb.Name {
+				case "layout":
+					if header := tb.Rows[0]["header_image_id"]; (header == nil) == tt.opts.Images {
+						t.Errorf("layout header image = %v with images %t", header, tt.opts.Images)
+					}
+				case "param":
+					if tb.Rows[0]["value"] != "" || tb.Rows[1]["value"] != "5" {
+						t.Errorf("params = %v, want the publish repo emptied only", tb.Rows)
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,275 @@
+package ssg
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OrphanedSite is a directory in the sites base path with no site record. ID
+// is the site the directory belonged to, or uuid.Nil when unknown.
+type OrphanedSite struct {
+	Slug string    `json:"slug"`
+	ID   uuid.UUID `json:"id"`
+	Name string    `json:"name"`
+	Mode string    `json:"mode"`
+}
+
+// ArchiveSite makes the site inactive: it is hidden from the site switcher and
+// its drafts are no longer watched, but all of its data is kept.
+func (sm *SiteManager) ArchiveSite(ctx context.Context, id, userID uuid.UUID) (Site, error) {
+	return sm.setSiteActive(ctx, id, userID, false)
+}
+
+// UnarchiveSite makes an archived site active again.
+func (sm *SiteManager) UnarchiveSite(ctx context.Context, id, userID uuid.UUID) (Site, error) {
+	return sm.setSiteActive(ctx, id, userID, true)
+}
+
+func (sm *SiteManager) setSiteActive(ctx context.Context, id, userID uuid.UUID, active bool) (Site, error) {
+	site, err := sm.siteRepo.GetSite(ctx, id)
+	if err != nil {
+		return Site{}, err
+	}
+
+	site.Active = 0
+	if active {
+		site.Active = 1
+	}
+	site.UpdatedBy = userID
+	site.UpdatedAt = time.Now()
+	if err := sm.siteRepo.UpdateSite(ctx, &site); err != nil {
+		return Site{}, err
+	}
+
+	sm.Log().Info("Site active state changed", "slug", site.Slug(), "active", active)
+	return site, nil
+}
+
+// RenameSite changes the name and the slug of the site id. A new slug moves
+// the site directory, and with it the preview of the site, in a single rename;
+// no job can run for the site meanwhile. It fails if the slug is taken by
+// another site or directory. An empty name keeps the current one.
+func (sm *SiteManager) RenameSite(ctx context.Context, id uuid.UUID, name, slug string, userID uuid.UUID) (Site, error) {
+	site, err := sm.siteRepo.GetSite(ctx, id)
+	if err != nil {
+		return Site{}, err
+	}
+
+	oldSlug := site.Slug()
+	slug = NormalizeSlug(slug)
+	if slug == "" {
+		return Site{}, fmt.Errorf("invalid slug")
+	}
+	if name == "" {
+		name = site.Name
+	}
+	if slug == oldSlug && name == site.Name {
+		return site, nil
+	}
+
+	site.Name = name
+	site.SlugValue = slug
+	site.UpdatedBy = userID
+	site.UpdatedAt = time.Now()
+
+	if slug == oldSlug {
+		if err := sm.siteRepo.UpdateSite(ctx, &site); err != nil {
+			return Site{}, err
+		}
+		sm.writeSiteMarker(site)
+		sm.Log().Info("Site renamed", "slug", slug, "name", name)
+		return site, nil
+	}
+
+	if existing, err := sm.siteRepo.GetSiteBySlug(ctx, slug); err == nil && !existing.IsZero() {
+		return Site{}, fmt.Errorf("site with slug '%s' already exists", slug)
+	}
+	sitesBasePath := sm.Cfg().StrValOrDef(SSGKey.SitesBasePath, "_workspace/sites")
+	oldDir := GetSiteBasePath(sitesBasePath, oldSlug)
+	newDir := GetSiteBasePath(sitesBasePath, slug)
+	if _, err := os.Stat(newDir); err == nil {
+		return Site{}, fmt.Errorf("directory for site '%s' already exists: %s", slug, newDir)
+	}
+
+	unlock, err := sm.jobs.LockSite(oldSlug)
+	if err != nil {
+		return Site{}, fmt.Errorf("cannot rename site '%s': %w", oldSlug, err)
+	}
+	defer unlock()
+
+	moved := false
+	if _, err := os.Stat(oldDir); err == nil {
+		if err := os.Rename(oldDir, newDir); err != nil {
+			return Site{}, fmt.Errorf("cannot move site directory: %w", err)
+		}
+		moved = true
+	}
+
+	if err := sm.siteRepo.UpdateSite(ctx, &site); err != nil {
+		if moved {
+			if rerr := os.Rename(newDir, oldDir); rerr != nil {
+				sm.Log().Error("Cannot move site directory back", "from", newDir, "to", oldDir, "error", rerr)
+			}
+		}
+		return Site{}, fmt.Errorf("failed to update site: %w", err)
+	}
+
+	if err := sm.createSiteDirectories(slug); err != nil {
+		sm.Log().Error("Cannot create site directories", "slug", slug, "error", err)
+	}
+	sm.writeSiteMarker(site)
+
+	sm.Log().Info("Site renamed", "from", oldSlug, "slug", slug, "name", name)
+	return site, nil
+}
+
+// PurgeSite removes the archived site id for good: its rows in every table and
+// its directory. confirm must be the slug of the site. It fails while a job
+// runs for the site.
+func (sm *SiteManager) PurgeSite(ctx context.Context, id uuid.UUID, confirm string) (string, error) {
+	site, err := sm.siteRepo.GetSite(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	if site.Active != 0 {
+		return "", fmt.Errorf("site '%s' must be archived before it is purged", site.Slug())
+	}
+	if confirm != site.Slug() {
+		return "", fmt.Errorf("confirmation does not match the site slug")
+	}
+
+	unlock, err := sm.jobs.LockSite(site.Slug())
+	if err != nil {
+		return "", fmt.Errorf("cannot purge site '%s': %w", site.Slug(), err)
+	}
+	defer unlock()
+
+	if err := sm.repo.PurgeSiteData(ctx, id); err != nil {
+		return "", fmt.Errorf("cannot delete site data: %w", err)
+	}
+	sm.deleteSiteDirectories(site.Slug())
+
+	sm.Log().Info("Site purged", "slug", site.Slug(), "id", id)
+	return site.Slug(), nil
+}
+
+// OrphanedSites lists the directories of the sites base path that have no
+// site record, such as those of sites deleted by older versions.
+func (sm *SiteManager) OrphanedSites(ctx context.Context) ([]OrphanedSite, error) {
+	sitesBasePath := sm.Cfg().StrValOrDef(SSGKey.SitesBasePath, "_workspace/sites")
+	entries, err := os.ReadDir(sitesBasePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot read sites dir: %w", err)
+	}
+
+	sites, err := sm.siteRepo.ListSites(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	known := map[string]bool{}
+	for _, site := range sites {
+		known[site.Slug()] = true
+	}
+
+	var orphans []OrphanedSite
+	for _, e := range entries {
+		if !e.IsDir() || strings.HasPrefix(e.Name(), ".") || known[e.Name()] {
+			continue
+		}
+		orphan := OrphanedSite{Slug: e.Name(), Name: e.Name()}
+		if marker, err := readSiteMarker(GetSiteMarkerPath(sitesBasePath, e.Name())); err == nil {
+			orphan.ID, orphan.Name, orphan.Mode = marker.ID, marker.Name, marker.Mode
+		}
+		orphans = append(orphans, orphan)
+	}
+
+	sort.Slice(orphans, func(i, j int) bool { return orphans[i].Slug < orphans[j].Slug })
+	return orphans, nil
+}
+
+// AttachSite creates the record of the orphaned site directory slug. The site
+// gets back its ID when the directory records it and it is free, so rows left
+// behind by a deleted record are its data again.
+func (sm *SiteManager) AttachSite(ctx context.Context, slug string, userID uuid.UUID) (Site, error) {
+	orphans, err := sm.OrphanedSites(ctx)
+	if err != nil {
+		return Site{}, err
+	}
+
+	var orphan *OrphanedSite
+	for i := range orphans {
+		if orphans[i].Slug == slug {
+			orphan = &orphans[i]
+			break
+		}
+	}
+	if orphan == nil {
+		return Site{}, fmt.Errorf("no orphaned site directory '%s'", slug)
+	}
+	if NormalizeSlug(slug) != slug {
+		return Site{}, fmt.Errorf("directory name '%s' is not a valid slug", slug)
+	}
+
+	mode := orphan.Mode
+	if mode != "structured" && mode != "blog" {
+		mode = "structured"
+	}
+
+	site := NewSite(orphan.Name, slug, mode)
+	site.GenID()
+	if orphan.ID != uuid.Nil {
+		if existing, err := sm.siteRepo.GetSite(ctx, orphan.ID); err != nil || existing.IsZero() {
+			site.SetID(orphan.ID, true)
+		}
+	}
+	site.GenShortID()
+	site.GenCreateValues(userID)
+
+	if err := sm.siteRepo.CreateSite(ctx, &site); err != nil {
+		return Site{}, fmt.Errorf("failed to create site record: %w", err)
+	}
+	if err := sm.createSiteDirectories(slug); err != nil {
+		return Site{}, fmt.Errorf("failed to create directories: %w", err)
+	}
+	sm.writeSiteMarker(site)
+
+	sm.Log().Info("Orphaned site directory attached", "slug", slug, "id", site.ID, "same_id", site.ID == orphan.ID)
+	return site, nil
+}
+
+// writeSiteMarker records in the directory of site which site it belongs to.
+// Failing to write it is logged, not returned: the marker only helps
+// attaching the directory again later.
+func (sm *SiteManager) writeSiteMarker(site Site) {
+	sitesBasePath := sm.Cfg().StrValOrDef(SSGKey.SitesBasePath, "_workspace/sites")
+	marker := BundleSite{ID: site.ID, Name: site.Name, Slug: site.Slug(), Mode: site.Mode}
+
+	data, err := json.MarshalIndent(marker, "", "  ")
+	if err == nil {
+		err = os.WriteFile(GetSiteMarkerPath(sitesBasePath, site.Slug()), data, 0644)
+	}
+	if err != nil {
+		sm.Log().Error("Cannot write site marker", "slug", site.Slug(), "error", err)
+	}
+}
+
+func readSiteMarker(path string) (BundleSite, error) {
+	var marker BundleSite
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return marker, err
+	}
+	err = json.Unmarshal(data, &marker)
+	return marker, err
+}
@@ -0,0 +1,252 @@
+package ssg
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/hermesgen/hm"
+)
+
+type fakeSiteRepo struct {
+	sites map[uuid.UUID]Site
+}
+
+func (f *fakeSiteRepo) CreateSite(ctx context.Context, site *Site) error {
+	f.sites[site.ID] = *site
+	return nil
+}
+
+func (f *fakeSiteRepo) GetSite(ctx context.Context, id uuid.UUID) (Site, error) {
+	site, ok := f.sites[id]
+	if !ok {
+		return Site{}, errors.New("site not found")
+	}
+	return site, nil
+}
+
+func (f *fakeSiteRepo) GetSiteBySlug(ctx context.Context, slug string) (Site, error) {
+	for _, site := range f.sites {
+		if site.Slug() == slug {
+			return site, nil
+		}
+	}
+	return Site{}, errors.New("site not found")
+}
+
+func (f *fakeSiteRepo) ListSites(ctx context.Context, activeOnly bool) ([]Site, error) {
+	var sites []Site
+	for _, site := range f.sites {
+		if !activeOnly || site.Active != 0 {
+			sites = append(sites, site)
+		}
+	}
+	return sites, nil
+}
+
+func (f *fakeSiteRepo) UpdateSite(ctx context.Context, site *Site) error {
+	f.sites[site.ID] = *site
+	return nil
+}
+
+func (f *fakeSiteRepo) DeleteSite(ctx context.Context, id uuid.UUID) error {
+	delete(f.sites, id)
+	return nil
+}
+
+type fakePurgeRepo struct {
+	Repo
+	purged []uuid.UUID
+}
+
+func (f *fakePurgeRepo) PurgeSiteData(ctx context.Context, siteID uuid.UUID) error {
+	f.purged = append(f.purged, siteID)
+	return nil
+}
+
+func newTestSiteManager(t *testing.T, sites ...Site) (*SiteManager, *fakeSiteRepo, *fakePurgeRepo, string) {
+	t.Helper()
+	base := t.TempDir()
+	cfg := hm.NewConfig()
+	cfg.Set(SSGKey.SitesBasePath, base)
+
+	siteRepo := &fakeSiteRepo{sites: map[uuid.UUID]Site{}}
+	for _, site := range sites {
+		siteRepo.sites[site.ID] = site
+	}
+	repo := &fakePurgeRepo{}
+	params := hm.XParams{Cfg: cfg, Log: hm.NewLogger("error")}
+	sm := NewSiteManager(repo, NewJobRunner(params), embed.FS{}, "sqlite", params)
+	sm.siteRepo = siteRepo
+	return sm, siteRepo, repo, base
+}
+
+func TestSiteArchiveAndPurge(t *testing.T) {
+	ctx := context.Background()
+	site := NewSite("Blog", "blog", "blog")
+	site.GenID()
+	sm, siteRepo, repo, base := newTestSiteManager(t, site)
+	writeSiteFiles(t, base, map[string]string{"blog/documents/markdown/post.md": "# Post"})
+
+	if _, err := sm.PurgeSite(ctx, site.ID, "blog"); err == nil {
+		t.Fatal("PurgeSite() of an active site must fail")
+	}
+
+	archived, err := sm.ArchiveSite(ctx, site.ID, uuid.New())
+	if err != nil || archived.Active != 0 {
+		t.Fatalf("ArchiveSite() = %+v, %v", archived, err)
+	}
+	if active, _ := siteRepo.ListSites(ctx, true); len(active) != 0 {
+		t.Errorf("active sites = %v, want the archived site hidden", active)
+	}
+
+	if _, err := sm.PurgeSite(ctx, site.ID, "Blog"); err == nil {
+		t.Error("PurgeSite() with a wrong confirmation must fail")
+	}
+	if len(repo.purged) != 0 {
+		t.Fatalf("purged = %v before confirmation", repo.purged)
+	}
+
+	unlock, err := sm.jobs.LockSite("blog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sm.PurgeSite(ctx, site.ID, "blog"); !errors.Is(err, ErrSiteBusy) {
+		t.Errorf("PurgeSite() of a busy site error = %v, want ErrSiteBusy", err)
+	}
+	unlock()
+	if len(repo.purged) != 0 {
+		t.Fatalf("purged = %v while a job runs", repo.purged)
+	}
+
+	slug, err := sm.PurgeSite(ctx, site.ID, "blog")
+	if err != nil || slug != "blog" {
+		t.Fatalf("PurgeSite() = %q, %v", slug, err)
+	}
+	if len(repo.purged) != 1 || repo.purged[0] != site.ID {
+		t.Errorf("purged = %v, want the site data", repo.purged)
+	}
+	if _, err := os.Stat(filepath.Join(base, "blog")); !os.IsNotExist(err) {
+		t.Errorf("site directory not removed: %v", err)
+	}
+}
+
+func TestSiteUnarchive(t *testing.T) {
+	ctx := context.Background()
+	site := NewSite("Blog", "blog", "blog")
+	site.GenID()
+	site.Active = 0
+	sm, _, _, _ := newTestSiteManager(t, site)
+
+	restored, err := sm.UnarchiveSite(ctx, site.ID, uuid.New())
+	if err != nil || restored.Active != 1 {
+		t.Errorf("UnarchiveSite() = %+v, %v", restored, err)
+	}
+}
+
+func TestRenameSite(t *testing.T) {
+	ctx := context.Background()
+	site := NewSite("Blog", "blog", "blog")
+	site.GenID()
+	other := NewSite("Docs", "docs", "structured")
+	other.GenID()
+	sm, siteRepo, _, base := newTestSiteManager(t, site, other)
+	writeSiteFiles(t, base, map[string]string{
+		"blog/documents/markdown/post.md": "# Post",
+		"taken/documents/index.html":      "x",
+	})
+
+	if _, err := sm.RenameSite(ctx, site.ID, "", "docs", uuid.New()); err == nil {
+		t.Error("RenameSite() to the slug of another site must fail")
+	}
+	if _, err := sm.RenameSite(ctx, site.ID, "", "taken", uuid.New()); err == nil {
+		t.Error("RenameSite() to an existing directory must fail")
+	}
+
+	unlock, err := sm.jobs.LockSite("blog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sm.RenameSite(ctx, site.ID, "", "journal", uuid.New()); !errors.Is(err, ErrSiteBusy) {
+		t.Errorf("RenameSite() of a busy site error = %v, want ErrSiteBusy", err)
+	}
+	unlock()
+
+	renamed, err := sm.RenameSite(ctx, site.ID, "Journal", "My Journal", uuid.New())
+	if err != nil {
+		t.Fatalf("RenameSite() error = %v", err)
+	}
+	if stored := siteRepo.sites[site.ID]; renamed.Slug() != "my-journal" || renamed.Name != "Journal" || stored.Slug() != "my-journal" {
+		t.Errorf("renamed site = %+v", renamed)
+	}
+	if _, err := os.Stat(filepath.Join(base, "my-journal", "documents", "markdown", "post.md")); err != nil {
+		t.Errorf("site files not moved: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(base, "blog")); !os.IsNotExist(err) {
+		t.Errorf("old site directory still there: %v", err)
+	}
+	if marker, err := readSiteMarker(GetSiteMarkerPath(base, "my-journal")); err != nil || marker.Slug != "my-journal" {
+		t.Errorf("marker = %+v, %v", marker, err)
+	}
+}
+
+func TestOrphanedSitesAttach(t *testing.T) {
+	ctx := context.Background()
+	known := NewSite("Known", "known", "structured")
+	known.GenID()
+	sm, siteRepo, _, base := newTestSiteManager(t, known)
+
+	oldID := uuid.New()
+	writeSiteFiles(t, base, map[string]string{
+		"known/documents/markdown/a.md":   "a",
+		"old/site.json":                   `{"id": "` + oldID.String() + `", "name": "Old Blog", "slug": "old", "mode": "blog"}`,
+		"legacy/documents/markdown/b.md":  "b",
+		".restore-123/manifest.json":      "{}",
+		"Not A Slug/documents/index.html": "x",
+	})
+
+	orphans, err := sm.OrphanedSites(ctx)
+	if err != nil {
+		t.Fatalf("OrphanedSites() error = %v", err)
+	}
+	if len(orphans) != 3 || orphans[0].Slug != "Not A Slug" || orphans[1].Slug != "legacy" || orphans[2].Slug != "old" {
+		t.Fatalf("orphans = %+v", orphans)
+	}
+	if orphans[2].ID != oldID || orphans[2].Name != "Old Blog" || orphans[1].ID != uuid.Nil {
+		t.Errorf("orphans = %+v, want the marker read", orphans)
+	}
+
+	site, err := sm.AttachSite(ctx, "old", uuid.New())
+	if err != nil {
+		t.Fatalf("AttachSite() error = %v", err)
+	}
+	if site.ID != oldID || site.Name != "Old Blog" || site.Mode != "blog" {
+		t.Errorf("attached site = %+v, want the site of the marker", site)
+	}
+
+	site, err = sm.AttachSite(ctx, "legacy", uuid.New())
+	if err != nil {
+		t.Fatalf("AttachSite() error = %v", err)
+	}
+	if site.ID == uuid.Nil || site.Mode != "structured" {
+		t.Errorf("attached site = %+v", site)
+	}
+	marker, err := readSiteMarker(GetSiteMarkerPath(base, "legacy"))
+	if err != nil || marker.ID != site.ID {
+		t.Errorf("marker = %+v, %v", marker, err)
+	}
+
+	if _, err := sm.AttachSite(ctx, "Not A Slug", uuid.New()); err == nil {
+		t.Error("AttachSite() of a directory that is not a slug must fail")
+	}
+	if _, err := sm.AttachSite(ctx, "known", uuid.New()); err == nil {
+		t.Error("AttachSite() of a site with a record must fail")
+	}
+	if len(siteRepo.sites) != 3 {
+		t.Errorf("sites = %d, want 3", len(siteRepo.sites))
+	}
+}
@@ -19,15 +19,17 @@ type SiteManager struct {
 	hm.Core
 	siteRepo SiteRepo
 	repo     Repo
+	jobs     *JobRunner
 	assetsFS embed.FS
 	engine   string
 }
 
 // NewSiteManager creates a new site manager.
-func NewSiteManager(repo Repo, assetsFS embed.FS, engine string, params hm.XParams) *SiteManager {
+func NewSiteManager(repo Repo, jobs *JobRunner, assetsFS embed.FS, engine string, params hm.XParams) *SiteManager {
 	return &SiteManager{
 		Core:     hm.NewCore("site-manager", params),
 		repo:     repo,
+		jobs:     jobs,
 		assetsFS: assetsFS,
 		engine:   engine,
 	}
@@ -87,6 +89,7 @@ func (sm *SiteManager) CreateSite(ctx context.Context, name, slug, mode string,
 	}
 
 	sm.Log().Info("Site directories created", "slug", slug)
+	sm.writeSiteMarker(site)
 
 	// Initialize site database
 	if err := sm.initializeSiteDatabase(ctx, slug, userID); err != nil {
@@ -111,6 +114,7 @@ func (sm *SiteManager) createSiteDirectories(slug string) error {
 		GetSiteMarkdownPath(sitesBasePath, slug),
 		GetSiteHTMLPath(sitesBasePath, slug),
 		GetSiteImagesPath(sitesBasePath, slug),
+		GetSiteAttachmentsPath(sitesBasePath, slug),
 	}
 
 	for _, dir := range dirs {
@@ -174,31 +178,27 @@ func (sm *SiteManager) initializeSiteDatabase(ctx context.Context, slug string,
 	return nil
 }
 
-// ListSites returns all sites (optionally only active ones).
-// Auto-deletes sites whose database files don't exist.
+// ListSites returns all sites (optionally only active ones). Records whose
+// directory is missing are kept: the directory is created again when the site
+// is used. Directories without a record are listed by OrphanedSites.
 func (sm *SiteManager) ListSites(ctx context.Context, activeOnly bool) ([]Site, error) {
 	sites, err := sm.siteRepo.ListSites(ctx, activeOnly)
 	if err != nil {
 		return nil, err
 	}
 
+	// Sites created before directories were marked get their marker here.
 	sitesBasePath := sm.Cfg().StrValOrDef(SSGKey.SitesBasePath, "_workspace/sites")
-	validSites := make([]Site, 0, len(sites))
-
 	for _, site := range sites {
-		// Check if site directory exists (not just DB file, since DB is created on first access)
-		siteDir := GetSiteBasePath(sitesBasePath, site.Slug())
-		if _, err := os.Stat(siteDir); err == nil {
-			validSites = append(validSites, site)
-		} else {
-			sm.Log().Info("Site directory not found, auto-deleting orphaned record", "slug", site.Slug(), "path", siteDir)
-			if delErr := sm.siteRepo.DeleteSite(ctx, site.ID); delErr != nil {
-				sm.Log().Error("Failed to auto-delete orphaned site", "slug", site.Slug(), "error", delErr)
-			}
+		if _, err := os.Stat(GetSiteBasePath(sitesBasePath, site.Slug())); err != nil {
+			continue
+		}
+		if _, err := os.Stat(GetSiteMarkerPath(sitesBasePath, site.Slug())); os.IsNotExist(err) {
+			sm.writeSiteMarker(site)
 		}
 	}
 
-	return validSites, nil
+	return sites, nil
 }
 
 // GetSiteBySlug retrieves a site by its slug.
@@ -210,19 +210,3 @@ func (sm *SiteManager) GetSiteBySlug(ctx context.Context, slug string) (Site, er
 func (sm *SiteManager) GetSite(ctx context.Context, id uuid.UUID) (Site, error) {
 	return sm.siteRepo.GetSite(ctx, id)
 }
-
-// DeleteSite removes a site from the database (files remain for backup).
-func (sm *SiteManager) DeleteSite(ctx context.Context, id uuid.UUID) (string, error) {
-	site, err := sm.siteRepo.GetSite(ctx, id)
-	if err != nil {
-		return "", fmt.Errorf("site not found: %w", err)
-	}
-
-	if err := sm.siteRepo.DeleteSite(ctx, id); err != nil {
-		return "", fmt.Errorf("failed to delete site: %w", err)
-	}
-
-	sm.Log().Info("Site deleted from database, files preserved", "slug", site.Slug(), "id", id)
-
-	return site.Slug(), nil
-}
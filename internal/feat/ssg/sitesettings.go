@@ -0,0 +1,274 @@
+package ssg
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/hermesgen/hm"
+)
+
+// siteModeRefKey is the param holding the site mode. It changes only through
+// SwitchMode, which takes care of the URLs that move.
+const siteModeRefKey = "site.mode"
+
+// Setting types, they decide how a value is validated and edited.
+const (
+	SettingString = "string"
+	SettingSecret = "secret"
+	SettingBool   = "bool"
+	SettingInt    = "int"
+	SettingChoice = "choice"
+)
+
+// Sources a setting value is resolved from, in order of precedence.
+const (
+	SourceSite    = "site"
+	SourceConfig  = "config"
+	SourceDefault = "default"
+)
+
+// Setting describes a per-site setting. Its value is read from the param of
+// the site with the same ref key, then from the global configuration and
+// finally from Default.
+type Setting struct {
+	Key         string   `json:"key"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Group       string   `json:"group"`
+	Type        string   `json:"type"`
+	Default     string   `json:"default"`
+	Choices     []string `json:"choices,omitempty"`
+	Min         int      `json:"min,omitempty"`
+}
+
+// SettingValue is a setting with its value for a site and where the value
+// comes from. Secret values are never sent out, only whether they are set.
+type SettingValue struct {
+	Setting
+	Value  string `json:"value"`
+	Source string `json:"source"`
+	IsSet  bool   `json:"is_set"`
+}
+
+// SiteSettings is the schema of the per-site settings, in the order they are
+// shown.
+var SiteSettings = siteSettingsSchema()
+
+func siteSettingsSchema() []Setting {
+	settings := []Setting{
+		{Key: SSGKey.HeaderStyle, Name: "Header style", Group: "Generation", Type: SettingChoice, Default: "boxed",
+			Choices:     []string{"boxed", "overlay", "text-only"},
+			Description: "How the header image and title of a page are laid out."},
+		{Key: SSGKey.BlocksMaxItems, Name: "Related items", Group: "Generation", Type: SettingInt, Default: "5",
+			Description: "Maximum number of items in each block of related contents."},
+		{Key: SSGKey.IndexMaxItems, Name: "Index page size", Group: "Generation", Type: SettingInt, Default: "9", Min: 1,
+			Description: "Number of contents in each page of an index."},
+		{Key: SSGKey.ImagesStripMetadata, Name: "Strip image metadata", Group: "Generation", Type: SettingBool, Default: "true",
+			Description: "Remove EXIF, GPS and other metadata from uploaded images."},
+
+		{Key: SSGKey.SearchGoogleEnabled, Name: "Google search", Group: "Search", Type: SettingBool, Default: "false",
+			Description: "Add a Google Programmable Search box to the generated pages."},
+		{Key: SSGKey.SearchGoogleID, Name: "Google search engine ID", Group: "Search", Type: SettingString,
+			Description: "ID of the Programmable Search engine."},
+
+		{Key: SSGKey.PublishTarget, Name: "Target", Group: "Publishing", Type: SettingChoice, Default: PublishTargetGitHub,
+			Choices:     []string{PublishTargetGitHub, PublishTargetGit, PublishTargetLocal, PublishTargetArchive},
+			Description: "Where the generated site is published."},
+		{Key: SSGKey.PublishTargetPath, Name: "Target path", Group: "Publishing", Type: SettingString,
+			Description: "Directory the local and archive targets write to. The local target only uses an empty directory or one it published to before."},
+		{Key: SSGKey.PublishArchiveFormat, Name: "Archive format", Group: "Publishing", Type: SettingChoice, Default: ArchiveFormatTarGz,
+			Choices:     []string{ArchiveFormatTarGz, ArchiveFormatZip},
+			Description: "Format of the archive target."},
+		{Key: SSGKey.PublishRepoURL, Name: "Repository URL", Group: "Publishing", Type: SettingString,
+			Description: "Repository the github and git targets push to."},
+		{Key: SSGKey.PublishBranch, Name: "Branch", Group: "Publishing", Type: SettingString,
+			Description: "Branch the github and git targets push to."},
+		{Key: SSGKey.PublishPagesSubdir, Name: "Pages subdirectory", Group: "Publishing", Type: SettingString,
+			Description: "Directory of the repository the site is published under."},
+		{Key: SSGKey.PublishAuthMethod, Name: "Auth method", Group: "Publishing", Type: SettingChoice,
+			Choices:     []string{"", string(hm.AuthToken), string(hm.AuthSSH)},
+			Description: "How to authenticate the push. Empty lets git use its own configuration."},
+		{Key: SSGKey.PublishAuthToken, Name: "Auth token", Group: "Publishing", Type: SettingSecret,
+			Description: "Token for the token auth method."},
+		{Key: SSGKey.PublishAuthSSHKey, Name: "SSH key path", Group: "Publishing", Type: SettingString,
+			Description: "Private key for the ssh auth method."},
+		{Key: SSGKey.PublishCommitUserName, Name: "Commit author name", Group: "Publishing", Type: SettingString,
+			Description: "Author name of the publish commits."},
+		{Key: SSGKey.PublishCommitUserEmail, Name: "Commit author email", Group: "Publishing", Type: SettingString,
+			Description: "Author email of the publish commits."},
+		{Key: SSGKey.PublishCommitMessage, Name: "Commit message", Group: "Publishing", Type: SettingString,
+			Description: "Default message of the publish commits."},
+		{Key: SSGKey.PublishHistorySnapshots, Name: "History snapshots", Group: "Publishing", Type: SettingInt, Default: "10", Min: 1,
+			Description: "Number of publishes whose output is kept for rollback."},
+		{Key: SSGKey.PublishCustomDomain, Name: "Custom domain", Group: "Publishing", Type: SettingString,
+			Description: "Domain written to the CNAME file of GitHub Pages."},
+		{Key: SSGKey.PublishNoJekyll, Name: "Disable Jekyll", Group: "Publishing", Type: SettingBool, Default: "true",
+			Description: "Add a .nojekyll file so GitHub Pages serves the output as is."},
+		{Key: SSGKey.PublishPreserve, Name: "Preserve", Group: "Publishing", Type: SettingString,
+			Description: "Comma separated paths of the target kept between publishes."},
+
+		{Key: SSGKey.ContentVersioning, Name: "Versioning", Group: "Content", Type: SettingBool, Default: "false",
+			Description: "Keep the Markdown export of the site in git."},
+		{Key: SSGKey.ContentRepoURL, Name: "Repository URL", Group: "Content", Type: SettingString,
+			Description: "Optional remote of the Markdown repository."},
+		{Key: SSGKey.ContentBranch, Name: "Branch", Group: "Content", Type: SettingString, Default: "main",
+			Description: "Branch of the Markdown repository."},
+
+		{Key: SSGKey.WatchEnabled, Name: "Watch drafts", Group: "Watch", Type: SettingBool, Default: "false",
+			Description: "Import Markdown files dropped in the watched directory."},
+		{Key: SSGKey.WatchDir, Name: "Watched directory", Group: "Watch", Type: SettingString,
+			Description: "Directory to watch, relative to the site directory. Defaults to its drafts directory."},
+		{Key: SSGKey.WatchProcessedDir, Name: "Processed directory", Group: "Watch", Type: SettingString,
+			Description: "Directory imported files are moved to, relative to the watched one. Empty leaves them in place."},
+	}
+
+	for _, qc := range QualityChecks {
+		settings = append(settings, Setting{
+			Key:         SSGKey.QualityPrefix + "." + qc.Name,
+			Name:        strings.ReplaceAll(qc.Name, "-", " "),
+			Group:       "Quality",
+			Type:        SettingChoice,
+			Default:     qc.Severity,
+			Choices:     []string{SeverityError, SeverityWarning, SeverityOff},
+			Description: "Severity of the " + qc.Name + " check.",
+		})
+	}
+
+	return settings
+}
+
+// FindSetting returns the setting with key from the schema.
+func FindSetting(key string) (Setting, bool) {
+	for _, s := range SiteSettings {
+		if s.Key == key {
+			return s, true
+		}
+	}
+	return Setting{}, false
+}
+
+// Validate reports whether value is valid for the setting. An empty value is
+// always valid, it means the setting is inherited.
+func (s Setting) Validate(value string) error {
+	if value == "" {
+		return nil
+	}
+
+	switch s.Type {
+	case SettingBool:
+		if _, err := strconv.ParseBool(value); err != nil {
+			return fmt.Errorf("%s must be true or false", s.Key)
+		}
+	case SettingInt:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%s must be a number", s.Key)
+		}
+		if n < s.Min {
+			return fmt.Errorf("%s must be at least %d", s.Key, s.Min)
+		}
+	case SettingChoice:
+		if !slices.Contains(s.Choices, value) {
+			return fmt.Errorf("%s must be one of %s", s.Key, strings.Join(s.Choices, ", "))
+		}
+	}
+	return nil
+}
+
+// resolve returns the first valid value among the site value, the config
+// value and the default, along with its source. Empty values are skipped.
+func (s Setting) resolve(siteVal, cfgVal string) (value, source string) {
+	if siteVal != "" && s.Validate(siteVal) == nil {
+		return siteVal, SourceSite
+	}
+	if cfgVal != "" && s.Validate(cfgVal) == nil {
+		return cfgVal, SourceConfig
+	}
+	return s.Default, SourceDefault
+}
+
+// ListSettings returns the settings of the site in context with their
+// resolved values.
+func (svc *BaseService) ListSettings(ctx context.Context) ([]SettingValue, error) {
+	return svc.pm.SettingValues(ctx)
+}
+
+// UpdateSettings sets the values of the params of the site in context by ref
+// key and returns the updated params. Keys in the settings schema are
+// validated and their param created if the site has none; any other key must
+// belong to an existing param. Nothing is updated if a key is rejected.
+func (svc *BaseService) UpdateSettings(ctx context.Context, values map[string]string) ([]Param, error) {
+	siteID, err := RequireSiteID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	params := make([]Param, 0, len(keys))
+	for _, key := range keys {
+		if key == siteModeRefKey {
+			return nil, fmt.Errorf("%s is changed by switching the site mode", key)
+		}
+
+		setting, known := FindSetting(key)
+		if known {
+			if err := setting.Validate(values[key]); err != nil {
+				return nil, err
+			}
+		}
+
+		param, err := svc.pm.FindParamByRef(ctx, key)
+		if err != nil || param.IsZero() {
+			if !known {
+				return nil, fmt.Errorf("unknown setting: %s", key)
+			}
+			param = NewParam(setting.Name, "")
+			param.SiteID = siteID
+			param.Description = setting.Description
+			param.RefKey = key
+			param.System = 1
+		}
+		param.Value = values[key]
+		params = append(params, param)
+	}
+
+	for i := range params {
+		if params[i].ID == uuid.Nil {
+			params[i].GenCreateValues()
+			err = svc.pm.CreateParam(ctx, &params[i])
+		} else {
+			params[i].GenUpdateValues()
+			err = svc.pm.UpdateParam(ctx, &params[i])
+		}
+		if err != nil {
+			return nil, fmt.Errorf("cannot update %s: %w", params[i].RefKey, err)
+		}
+	}
+
+	return params, nil
+}
+
+// ResetSetting removes the value of a setting from the site in context, so it
+// is inherited from the configuration again.
+func (svc *BaseService) ResetSetting(ctx context.Context, key string) error {
+	if _, ok := FindSetting(key); !ok {
+		return fmt.Errorf("unknown setting: %s", key)
+	}
+
+	param, err := svc.pm.FindParamByRef(ctx, key)
+	if err != nil || param.IsZero() {
+		return nil
+	}
+
+	return svc.pm.DeleteParam(ctx, param.ID)
+}
@@ -0,0 +1,180 @@
+package ssg
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/hermesgen/hm"
+)
+
+type fakeParamRepo struct {
+	Repo
+	params map[string]Param
+}
+
+func (f *fakeParamRepo) GetParamByRefKey(ctx context.Context, refKey string) (Param, error) {
+	return f.params[refKey], nil
+}
+
+func (f *fakeParamRepo) ListParams(ctx context.Context) ([]Param, error) {
+	var params []Param
+	for _, p := range f.params {
+		params = append(params, p)
+	}
+	return params, nil
+}
+
+func (f *fakeParamRepo) CreateParam(ctx context.Context, p *Param) error {
+	p.ID = uuid.New()
+	f.params[p.RefKey] = *p
+	return nil
+}
+
+func (f *fakeParamRepo) UpdateParam(ctx context.Context, p *Param) error {
+	f.params[p.RefKey] = *p
+	return nil
+}
+
+func TestSettingValidate(t *testing.T) {
+	tests := []struct {
+		key     string
+		value   string
+		wantErr bool
+	}{
+		{key: SSGKey.HeaderStyle, value: "overlay"},
+		{key: SSGKey.HeaderStyle, value: "fancy", wantErr: true},
+		{key: SSGKey.IndexMaxItems, value: "12"},
+		{key: SSGKey.IndexMaxItems, value: "0", wantErr: true},
+		{key: SSGKey.IndexMaxItems, value: "many", wantErr: true},
+		{key: SSGKey.BlocksMaxItems, value: "0"},
+		{key: SSGKey.SearchGoogleEnabled, value: "true"},
+		{key: SSGKey.SearchGoogleEnabled, value: "yes", wantErr: true},
+		{key: SSGKey.PublishAuthToken, value: "secret"},
+		{key: SSGKey.PublishTarget, value: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key+"="+tt.value, func(t *testing.T) {
+			setting, ok := FindSetting(tt.key)
+			if !ok {
+				t.Fatalf("setting %s not in schema", tt.key)
+			}
+			if err := setting.Validate(tt.value); (err != nil) != tt.wantErr {
+				t.Errorf("Validate(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSiteSettingsSchema(t *testing.T) {
+	seen := map[string]bool{}
+	for _, s := range SiteSettings {
+		if seen[s.Key] {
+			t.Errorf("setting %s is duplicated", s.Key)
+		}
+		seen[s.Key] = true
+		if s.Name == "" || s.Group == "" {
+			t.Errorf("setting %s has no name or group", s.Key)
+		}
+		if err := s.Validate(s.Default); err != nil {
+			t.Errorf("default of %s is invalid: %v", s.Key, err)
+		}
+	}
+	if seen[siteModeRefKey] {
+		t.Errorf("%s is changed by switching the site mode, not as a setting", siteModeRefKey)
+	}
+}
+
+func TestParamManagerSetting(t *testing.T) {
+	cfg := hm.NewConfig()
+	cfg.Set(SSGKey.IndexMaxItems, "20")
+	cfg.Set(SSGKey.BlocksMaxItems, "7")
+	cfg.Set(SSGKey.HeaderStyle, "stacked")
+
+	repo := &fakeParamRepo{params: map[string]Param{
+		SSGKey.IndexMaxItems:    {ID: uuid.New(), RefKey: SSGKey.IndexMaxItems, Value: "12"},
+		SSGKey.BlocksMaxItems:   {ID: uuid.New(), RefKey: SSGKey.BlocksMaxItems, Value: ""},
+		SSGKey.PublishTarget:    {ID: uuid.New(), RefKey: SSGKey.PublishTarget, Value: "ftp"},
+		SSGKey.PublishAuthToken: {ID: uuid.New(), RefKey: SSGKey.PublishAuthToken, Value: "secret"},
+	}}
+	pm := NewParamManager(repo, hm.XParams{Cfg: cfg, Log: hm.NewLogger("error")})
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		key  string
+		want string
+	}{
+		{name: "site value", key: SSGKey.IndexMaxItems, want: "12"},
+		{name: "empty site value inherits config", key: SSGKey.BlocksMaxItems, want: "7"},
+		{name: "invalid site value", key: SSGKey.PublishTarget, want: PublishTargetGitHub},
+		{name: "invalid config value", key: SSGKey.HeaderStyle, want: "boxed"},
+		{name: "default", key: SSGKey.PublishNoJekyll, want: "true"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := pm.Setting(ctx, tt.key); got != tt.want {
+				t.Errorf("Setting(%s) = %q, want %q", tt.key, got, tt.want)
+			}
+		})
+	}
+
+	if got := pm.SettingInt(ctx, SSGKey.IndexMaxItems); got != 12 {
+		t.Errorf("SettingInt() = %d, want 12", got)
+	}
+
+	values, err := pm.SettingValues(ctx)
+	if err != nil {
+		t.Fatalf("SettingValues() error = %v", err)
+	}
+	for _, v := range values {
+		switch v.Key {
+		case SSGKey.IndexMaxItems:
+			if v.Source != SourceSite {
+				t.Errorf("source of %s = %s, want %s", v.Key, v.Source, SourceSite)
+			}
+		case SSGKey.BlocksMaxItems:
+			if v.Source != SourceConfig {
+				t.Errorf("source of %s = %s, want %s", v.Key, v.Source, SourceConfig)
+			}
+		case SSGKey.PublishAuthToken:
+			if v.Value != "" || !v.IsSet {
+				t.Errorf("secret = %q set %v, want hidden and set", v.Value, v.IsSet)
+			}
+		}
+	}
+}
+
+func TestUpdateSettings(t *testing.T) {
+	siteID := uuid.New()
+	ctx := context.WithValue(context.Background(), siteIDKey, siteID)
+	params := hm.XParams{Cfg: hm.NewConfig(), Log: hm.NewLogger("error")}
+
+	t.Run("creates missing params", func(t *testing.T) {
+		repo := &fakeParamRepo{params: map[string]Param{}}
+		svc := &BaseService{Service: hm.NewService("ssg-svc", params), repo: repo, pm: NewParamManager(repo, params)}
+
+		if _, err := svc.UpdateSettings(ctx, map[string]string{SSGKey.HeaderStyle: "overlay"}); err != nil {
+			t.Fatalf("UpdateSettings() error = %v", err)
+		}
+		p := repo.params[SSGKey.HeaderStyle]
+		if p.Value != "overlay" || p.SiteID != siteID {
+			t.Errorf("param = %q for site %s, want overlay for site %s", p.Value, p.SiteID, siteID)
+		}
+	})
+
+	t.Run("rejects invalid values", func(t *testing.T) {
+		repo := &fakeParamRepo{params: map[string]Param{}}
+		svc := &BaseService{Service: hm.NewService("ssg-svc", params), repo: repo, pm: NewParamManager(repo, params)}
+
+		values := map[string]string{SSGKey.HeaderStyle: "overlay", SSGKey.IndexMaxItems: "-1"}
+		if _, err := svc.UpdateSettings(ctx, values); err == nil {
+			t.Fatal("UpdateSettings() error = nil, want invalid value error")
+		}
+		if len(repo.params) != 0 {
+			t.Errorf("params were saved: %v", repo.params)
+		}
+	})
+}
@@ -0,0 +1,344 @@
+package ssg
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hermesgen/hm"
+)
+
+// Source change statuses, as listed in the commit message.
+const (
+	SourceAdded    = "Added"
+	SourceModified = "Modified"
+	SourceRemoved  = "Removed"
+	SourceRenamed  = "Renamed"
+)
+
+// ErrSourceDiverged is returned when the remote branch of the Markdown
+// repository has commits the local one does not have. Nothing is pushed, the
+// histories have to be reconciled by hand.
+var ErrSourceDiverged = errors.New("remote has diverged from the local markdown history")
+
+// SourceChange is a Markdown file changed by a generation.
+type SourceChange struct {
+	Status  string
+	Path    string
+	OldPath string // Previous path of a renamed file
+	Heading string // Heading of the content the file belongs to, if any
+}
+
+// SourceCommit is the result of versioning a Markdown export.
+type SourceCommit struct {
+	Hash    string
+	Message string
+	Changes []SourceChange
+	Pushed  bool
+}
+
+// SourceRepo keeps the Markdown export of a site in a local git repository,
+// committing every generation that changes it and optionally pushing it to a
+// remote branch. Remote credentials work as for publishing: cfg.RepoURL,
+// cfg.Branch, cfg.Auth and cfg.SSHKeyPath describe the remote.
+type SourceRepo struct {
+	hm.Core
+	gitClient        hm.GitClient
+	credentialHelper string
+}
+
+// NewSourceRepo creates a SourceRepo.
+func NewSourceRepo(gitClient hm.GitClient, params hm.XParams) *SourceRepo {
+	return &SourceRepo{
+		Core:             hm.NewCore("ssg-source-repo", params),
+		gitClient:        gitClient,
+		credentialHelper: gitCredentialHelper(),
+	}
+}
+
+// Commit records the current state of the Markdown export in dir as one
+// commit. Markdown files written for contents that no longer exist are
+// removed first so deleted and renamed contents show up in the history. An
+// empty SourceCommit is returned when nothing changed.
+func (s *SourceRepo) Commit(ctx context.Context, cfg PublisherConfig, dir string, contents []Content) (commit SourceCommit, err error) {
+	defer func() { err = redactError(err, cfg.Auth.Token) }()
+
+	authEnv, err := gitAuthEnv(cfg, s.credentialHelper)
+	if err != nil {
+		return SourceCommit{}, err
+	}
+	env := append(os.Environ(), authEnv...)
+
+	if err := s.init(ctx, dir, cfg.Branch, env); err != nil {
+		return SourceCommit{}, err
+	}
+
+	files := sourceFiles(contents)
+	if err := removeStaleMarkdown(dir, files); err != nil {
+		return SourceCommit{}, fmt.Errorf("cannot remove stale markdown: %w", err)
+	}
+
+	if err := s.gitClient.Add(ctx, dir, "--all", env); err != nil {
+		return SourceCommit{}, fmt.Errorf("cannot stage markdown: %w", err)
+	}
+
+	status, err := runGit(ctx, dir, env, "status", "--porcelain", "-z", "--no-renames")
+	if err != nil {
+		return SourceCommit{}, fmt.Errorf("cannot get markdown changes: %w", err)
+	}
+
+	changes := parseSourceStatus(status, files)
+	if len(changes) > 0 {
+		author := cfg.CommitAuthor
+		author.Message = sourceCommitMessage(changes)
+
+		hash, err := s.gitClient.Commit(ctx, dir, author, env)
+		if err != nil {
+			return SourceCommit{}, fmt.Errorf("cannot commit markdown: %w", err)
+		}
+
+		commit = SourceCommit{Hash: hash, Message: author.Message, Changes: changes}
+		s.Log().Info("Markdown changes committed", "hash", hash, "changes", len(changes))
+	}
+
+	if cfg.RepoURL == "" {
+		return commit, nil
+	}
+
+	// Push on every run, a previous push may have failed
+	if err := s.push(ctx, dir, cfg.RepoURL, cfg.Branch, env); err != nil {
+		return commit, fmt.Errorf("cannot push markdown to %s: %w", redact(cfg.RepoURL), err)
+	}
+	commit.Pushed = true
+	s.Log().Info("Markdown pushed", "branch", cfg.Branch)
+
+	return commit, nil
+}
+
+// push sends the local branch to the remote one without forcing it. When the
+// remote branch has commits that are not in the local history it returns
+// ErrSourceDiverged instead of overwriting them.
+func (s *SourceRepo) push(ctx context.Context, dir, repoURL, branch string, env []string) error {
+	heads, err := runGit(ctx, dir, env, "ls-remote", "--heads", repoURL, branch)
+	if err != nil {
+		return err
+	}
+
+	if strings.TrimSpace(heads) != "" {
+		if _, err := runGit(ctx, dir, env, "fetch", repoURL, branch); err != nil {
+			return err
+		}
+		if _, err := runGit(ctx, dir, env, "merge-base", "--is-ancestor", "FETCH_HEAD", "HEAD"); err != nil {
+			var exitErr *exec.ExitError
+			if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+				return ErrSourceDiverged
+			}
+			return err
+		}
+	}
+
+	_, err = runGit(ctx, dir, env, "push", repoURL, "HEAD:refs/heads/"+branch)
+	return err
+}
+
+// init creates the repository in dir on branch unless it already exists.
+func (s *SourceRepo) init(ctx context.Context, dir, branch string, env []string) error {
+	if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+		return nil
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("cannot create markdown dir: %w", err)
+	}
+
+	if _, err := runGit(ctx, dir, env, "init", "--initial-branch="+branch); err != nil {
+		return fmt.Errorf("cannot init markdown repo: %w", err)
+	}
+
+	s.Log().Info("Markdown repo created", "path", dir, "branch", branch)
+	return nil
+}
+
+// sourceFiles maps the Markdown file of each content, relative to the export
+// root, to its content. It mirrors the layout written by Generator.Generate.
+func sourceFiles(contents []Content) map[string]Content {
+	files := make(map[string]Content, len(contents))
+	for _, content := range contents {
+		rel := filepath.ToSlash(filepath.Join(content.SectionPath, content.Slug()+".md"))
+		files[strings.TrimPrefix(rel, "/")] = content
+	}
+	return files
+}
+
+// removeStaleMarkdown deletes the Markdown files under dir that were written
+// for a content and are not in files. Other files, such as a README, are left
+// alone.
+func removeStaleMarkdown(dir string, files map[string]Content) error {
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if filepath.Ext(path) != ".md" {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		if _, ok := files[filepath.ToSlash(rel)]; ok {
+			return nil
+		}
+		if !generatedMarkdown(path) {
+			return nil
+		}
+		return os.Remove(path)
+	})
+	if err != nil {
+		return err
+	}
+
+	return removeEmptyDirs(dir)
+}
+
+// generatedMarkdown reports whether the Markdown file at path was written by
+// Generator.Generate, whose front matter slug always matches the file name.
+func generatedMarkdown(path string) bool {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+
+	doc, err := parseMarkdownDoc(data)
+	if err != nil {
+		return false
+	}
+	return doc.Front.Slug != "" && doc.Front.Slug == strings.TrimSuffix(filepath.Base(path), ".md")
+}
+
+// parseSourceStatus reads the output of git status --porcelain -z
+// --no-renames after staging everything and names the contents behind each
+// change. Renames are paired up by the short ID that ends every file name;
+// git's own rename detection is unreliable for files that mostly share the
+// same front matter.
+func parseSourceStatus(status string, files map[string]Content) []SourceChange {
+	var changes []SourceChange
+	removed := map[string]int{}
+
+	for _, entry := range strings.Split(status, "\x00") {
+		if len(entry) < 4 {
+			continue
+		}
+
+		change := SourceChange{Path: entry[3:]}
+		switch entry[0] {
+		case 'A':
+			change.Status = SourceAdded
+		case 'M', 'T':
+			change.Status = SourceModified
+		case 'D':
+			change.Status = SourceRemoved
+			removed[sourceShortID(change.Path)] = len(changes)
+		default:
+			continue
+		}
+
+		if content, ok := files[change.Path]; ok {
+			change.Heading = content.Heading
+		}
+		changes = append(changes, change)
+	}
+
+	renamed := map[int]bool{}
+	for j, change := range changes {
+		if change.Status != SourceAdded {
+			continue
+		}
+		if i, ok := removed[sourceShortID(change.Path)]; ok && !renamed[i] {
+			renamed[i] = true
+			changes[j].Status = SourceRenamed
+			changes[j].OldPath = changes[i].Path
+		}
+	}
+
+	var result []SourceChange
+	for i, change := range changes {
+		if !renamed[i] {
+			result = append(result, change)
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool { return result[i].Path < result[j].Path })
+	return result
+}
+
+// sourceShortID returns the content short ID at the end of a Markdown file
+// name, see Content.Slug.
+func sourceShortID(p string) string {
+	name := strings.TrimSuffix(filepath.Base(p), ".md")
+	if i := strings.LastIndex(name, "-"); i >= 0 {
+		return name[i+1:]
+	}
+	return name
+}
+
+// sourceCommitMessage summarizes changes in a subject line and lists each
+// touched content in the body.
+func sourceCommitMessage(changes []SourceChange) string {
+	var b strings.Builder
+
+	if len(changes) == 1 {
+		fmt.Fprintf(&b, "%s %s\n\n", changes[0].Status, changeName(changes[0]))
+	} else {
+		fmt.Fprintf(&b, "Update %d content items\n\n", len(changes))
+	}
+
+	for _, c := range changes {
+		switch {
+		case c.OldPath != "":
+			fmt.Fprintf(&b, "- %s: %s (%s, was %s)\n", c.Status, changeName(c), c.Path, c.OldPath)
+		case c.Heading != "":
+			fmt.Fprintf(&b, "- %s: %s (%s)\n", c.Status, c.Heading, c.Path)
+		default:
+			fmt.Fprintf(&b, "- %s: %s\n", c.Status, c.Path)
+		}
+	}
+
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+func changeName(c SourceChange) string {
+	if c.Heading != "" {
+		return c.Heading
+	}
+	return c.Path
+}
+
+// runGit runs a git command the hm git client does not cover and returns its
+// standard output.
+func runGit(ctx context.Context, dir string, env []string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Env = env
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("git %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
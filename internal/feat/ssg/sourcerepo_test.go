@@ -0,0 +1,182 @@
+package ssg_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hermesgen/clio/internal/feat/ssg"
+	"github.com/hermesgen/hm"
+	"github.com/hermesgen/hm/github"
+)
+
+func TestSourceRepoCommitsMarkdownChanges(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	remote := filepath.Join(t.TempDir(), "source.git")
+	if out, err := exec.Command("git", "init", "--bare", remote).CombinedOutput(); err != nil {
+		t.Fatalf("cannot create bare repo: %v: %s", err, out)
+	}
+
+	sitesDir := t.TempDir()
+	params := hm.XParams{Cfg: hm.NewConfig(), Log: hm.NewLogger("error")}
+	params.Cfg.Set(ssg.SSGKey.SitesBasePath, sitesDir)
+
+	gen := ssg.NewGenerator(params)
+	src := ssg.NewSourceRepo(github.NewClient(params), params)
+	dir := ssg.GetSiteMarkdownPath(sitesDir, "blog")
+	cfg := ssg.PublisherConfig{
+		RepoURL: "file://" + remote,
+		Branch:  "main",
+		CommitAuthor: hm.GitCommit{
+			UserName:  "Test",
+			UserEmail: "test@example.com",
+		},
+	}
+	ctx := context.Background()
+
+	post := ssg.Content{Heading: "First Post", SectionPath: "notes", ShortID: "a1b2c3", Body: "Hello"}
+	about := ssg.Content{Heading: "About", ShortID: "d4e5f6", Body: "About me"}
+
+	generate := func(contents ...ssg.Content) ssg.SourceCommit {
+		t.Helper()
+		if err := gen.Generate(ctx, "blog", contents); err != nil {
+			t.Fatalf("Generate() error = %v", err)
+		}
+		commit, err := src.Commit(ctx, cfg, dir, contents)
+		if err != nil {
+			t.Fatalf("Commit() error = %v", err)
+		}
+		return commit
+	}
+
+	readme := filepath.Join(dir, "README.md")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(readme, []byte("# Blog\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	first := generate(post, about)
+	if first.Hash == "" || !first.Pushed {
+		t.Fatalf("first commit = %+v, want a pushed commit", first)
+	}
+	if len(first.Changes) != 3 {
+		t.Fatalf("Changes = %+v, want 3", first.Changes)
+	}
+	for _, want := range []string{"Update 3 content items", "- Added: About (about-d4e5f6.md)", "- Added: First Post (notes/first-post-a1b2c3.md)"} {
+		if !strings.Contains(first.Message, want) {
+			t.Errorf("message %q does not contain %q", first.Message, want)
+		}
+	}
+
+	if again := generate(post, about); again.Hash != "" {
+		t.Errorf("commit without changes = %+v, want none", again)
+	}
+
+	post.Heading = "First Post Renamed"
+	post.Body = "Hello again"
+	third := generate(post)
+
+	got := map[string]ssg.SourceChange{}
+	for _, c := range third.Changes {
+		got[c.Status] = c
+	}
+	if c := got[ssg.SourceRemoved]; c.Path != "about-d4e5f6.md" {
+		t.Errorf("removed change = %+v", c)
+	}
+	if c := got[ssg.SourceRenamed]; c.Path != "notes/first-post-renamed-a1b2c3.md" || c.OldPath != "notes/first-post-a1b2c3.md" || c.Heading != "First Post Renamed" {
+		t.Errorf("renamed change = %+v", c)
+	}
+
+	if _, err := os.Stat(readme); err != nil {
+		t.Errorf("README.md was removed: %v", err)
+	}
+
+	out, err := exec.Command("git", "--git-dir", remote, "rev-list", "--count", "main").CombinedOutput()
+	if err != nil {
+		t.Fatalf("cannot read remote history: %v: %s", err, out)
+	}
+	if strings.TrimSpace(string(out)) != "2" {
+		t.Errorf("remote has %s commits, want 2", out)
+	}
+}
+
+func TestSourceRepoDoesNotOverwriteDivergedRemote(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	remote := filepath.Join(t.TempDir(), "source.git")
+	if out, err := exec.Command("git", "init", "--bare", remote).CombinedOutput(); err != nil {
+		t.Fatalf("cannot create bare repo: %v: %s", err, out)
+	}
+
+	sitesDir := t.TempDir()
+	params := hm.XParams{Cfg: hm.NewConfig(), Log: hm.NewLogger("error")}
+	params.Cfg.Set(ssg.SSGKey.SitesBasePath, sitesDir)
+
+	gen := ssg.NewGenerator(params)
+	src := ssg.NewSourceRepo(github.NewClient(params), params)
+	dir := ssg.GetSiteMarkdownPath(sitesDir, "blog")
+	cfg := ssg.PublisherConfig{
+		RepoURL: "file://" + remote,
+		Branch:  "main",
+		CommitAuthor: hm.GitCommit{
+			UserName:  "Test",
+			UserEmail: "test@example.com",
+		},
+	}
+	ctx := context.Background()
+
+	post := ssg.Content{Heading: "First Post", ShortID: "a1b2c3", Body: "Hello"}
+	if err := gen.Generate(ctx, "blog", []ssg.Content{post}); err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if _, err := src.Commit(ctx, cfg, dir, []ssg.Content{post}); err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+
+	clone := filepath.Join(t.TempDir(), "clone")
+	git := func(args ...string) string {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=Other", "GIT_AUTHOR_EMAIL=other@example.com",
+			"GIT_COMMITTER_NAME=Other", "GIT_COMMITTER_EMAIL=other@example.com")
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %s: %v: %s", args[0], err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	git("clone", "--branch", "main", remote, clone)
+	if err := os.WriteFile(filepath.Join(clone, "notes.md"), []byte("Edited elsewhere\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	git("-C", clone, "add", "notes.md")
+	git("-C", clone, "commit", "-m", "Edit elsewhere")
+	git("-C", clone, "push", "origin", "main")
+	remoteHead := git("--git-dir", remote, "rev-parse", "main")
+
+	post.Body = "Hello again"
+	if err := gen.Generate(ctx, "blog", []ssg.Content{post}); err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	commit, err := src.Commit(ctx, cfg, dir, []ssg.Content{post})
+	if !errors.Is(err, ssg.ErrSourceDiverged) {
+		t.Fatalf("Commit() error = %v, want %v", err, ssg.ErrSourceDiverged)
+	}
+	if commit.Hash == "" || commit.Pushed {
+		t.Errorf("commit = %+v, want a local commit that was not pushed", commit)
+	}
+	if got := git("--git-dir", remote, "rev-parse", "main"); got != remoteHead {
+		t.Errorf("remote head = %s, want %s", got, remoteHead)
+	}
+}
@@ -0,0 +1,191 @@
+package ssg
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Statuses of a file of the watched drafts directory.
+const (
+	WatchImported  = "imported"
+	WatchConflict  = "conflict"
+	WatchFailed    = "failed"
+	WatchKeptAdmin = "kept-admin"
+)
+
+// Ways to resolve a watch conflict.
+const (
+	WatchKeepFile  = "file"
+	WatchKeepAdmin = "admin"
+)
+
+// WatchedFile is what the watcher knows about a file of the watched drafts
+// directory since it last looked at it.
+type WatchedFile struct {
+	Path    string    `json:"path"`
+	ModTime time.Time `json:"mod_time"`
+	Status  string    `json:"status"`
+	Message string    `json:"message,omitempty"`
+
+	// The content the file was last imported into and its update time right
+	// after the import; a later update time means it was edited in the admin.
+	ContentID        uuid.UUID `json:"content_id,omitempty"`
+	ContentUpdatedAt time.Time `json:"content_updated_at"`
+
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// WatchState is the import state of the watched drafts directory of a site.
+type WatchState struct {
+	LastRun time.Time              `json:"last_run"`
+	Files   map[string]WatchedFile `json:"files"`
+}
+
+// WatchStatus is the watch configuration of a site along with the files
+// waiting to be imported and the conflicts to resolve.
+type WatchStatus struct {
+	Enabled      bool          `json:"enabled"`
+	Dir          string        `json:"dir"`
+	ProcessedDir string        `json:"processed_dir,omitempty"`
+	LastRun      time.Time     `json:"last_run"`
+	Pending      []string      `json:"pending"`
+	Conflicts    []WatchedFile `json:"conflicts"`
+	Files        []WatchedFile `json:"files"`
+}
+
+// watchConfig is where and how the drafts of a site are watched.
+type watchConfig struct {
+	Enabled      bool
+	Dir          string
+	ProcessedDir string
+	StatePath    string
+}
+
+func readWatchState(p string) (WatchState, error) {
+	state := WatchState{Files: map[string]WatchedFile{}}
+
+	data, err := os.ReadFile(p)
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return state, fmt.Errorf("cannot read watch state: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &state); err != nil {
+		return state, fmt.Errorf("cannot decode watch state: %w", err)
+	}
+	if state.Files == nil {
+		state.Files = map[string]WatchedFile{}
+	}
+	return state, nil
+}
+
+func writeWatchState(p string, state WatchState) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("cannot encode watch state: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return fmt.Errorf("cannot create watch state dir: %w", err)
+	}
+	if err := os.WriteFile(p, data, 0644); err != nil {
+		return fmt.Errorf("cannot write watch state: %w", err)
+	}
+	return nil
+}
+
+// sortedFiles returns the files of the state ordered by path, only those with
+// one of statuses if any are given.
+func (s WatchState) sortedFiles(statuses ...string) []WatchedFile {
+	files := []WatchedFile{}
+	for _, f := range s.Files {
+		if len(statuses) > 0 && !slices.Contains(statuses, f.Status) {
+			continue
+		}
+		files = append(files, f)
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return files
+}
+
+// scanWatchDir returns the modification time of the Markdown files in dir and
+// the ones that are new or changed since they were last looked at. Files under
+// processedDir are left out. A missing dir has no files.
+func scanWatchDir(dir, processedDir string, state WatchState) (pending []string, modTimes map[string]time.Time, err error) {
+	modTimes = map[string]time.Time{}
+	pending = []string{}
+
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return pending, modTimes, nil
+	}
+
+	files, err := markdownFiles(dir)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot list watched files: %w", err)
+	}
+
+	skip := ""
+	if processedDir != "" {
+		if rel, err := filepath.Rel(dir, processedDir); err == nil && rel != "." && !strings.HasPrefix(rel, "..") {
+			skip = filepath.ToSlash(rel) + "/"
+		}
+	}
+
+	for _, rel := range files {
+		if skip != "" && strings.HasPrefix(rel, skip) {
+			continue
+		}
+
+		info, err := os.Stat(filepath.Join(dir, filepath.FromSlash(rel)))
+		if err != nil {
+			return nil, nil, fmt.Errorf("cannot stat %s: %w", rel, err)
+		}
+		modTimes[rel] = info.ModTime()
+
+		if prev, ok := state.Files[rel]; !ok || !prev.ModTime.Equal(info.ModTime()) {
+			pending = append(pending, rel)
+		}
+	}
+
+	return pending, modTimes, nil
+}
+
+// watchConflict returns why updating existing from a file modified at modTime
+// would overwrite edits made in the admin, or an empty string if it would not.
+// prev is the last known state of the file, if known.
+func watchConflict(prev WatchedFile, known bool, existing Content, modTime time.Time) string {
+	if known && prev.ContentID == existing.ID && !prev.ContentUpdatedAt.IsZero() {
+		if existing.UpdatedAt.Equal(prev.ContentUpdatedAt) {
+			return ""
+		}
+		return "The content was edited in the admin after the file was last imported"
+	}
+
+	// Never imported into this content: the newest side wins
+	if existing.UpdatedAt.After(modTime) {
+		return "The content was edited in the admin after the file was last modified"
+	}
+	return ""
+}
+
+// moveProcessed moves the imported file rel from dir to the same relative path
+// under processedDir.
+func moveProcessed(dir, processedDir, rel string) error {
+	dst := filepath.Join(processedDir, filepath.FromSlash(rel))
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return fmt.Errorf("cannot create processed dir: %w", err)
+	}
+	if err := os.Rename(filepath.Join(dir, filepath.FromSlash(rel)), dst); err != nil {
+		return fmt.Errorf("cannot move %s to the processed dir: %w", rel, err)
+	}
+	return nil
+}
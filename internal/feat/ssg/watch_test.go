@@ -0,0 +1,236 @@
+package ssg
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hermesgen/hm"
+)
+
+func writeWatchedFile(t *testing.T, dir, rel string, modTime time.Time) {
+	t.Helper()
+	p := filepath.Join(dir, filepath.FromSlash(rel))
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(p, []byte("---\ntitle: Post\n---\nBody\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(p, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestScanWatchDir(t *testing.T) {
+	dir := t.TempDir()
+	old := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	writeWatchedFile(t, dir, "same.md", old)
+	writeWatchedFile(t, dir, "changed.md", old.Add(time.Hour))
+	writeWatchedFile(t, dir, "new.md", old)
+	writeWatchedFile(t, dir, "processed/done.md", old)
+
+	state := WatchState{Files: map[string]WatchedFile{
+		"same.md":    {Path: "same.md", ModTime: old},
+		"changed.md": {Path: "changed.md", ModTime: old},
+	}}
+
+	pending, modTimes, err := scanWatchDir(dir, filepath.Join(dir, "processed"), state)
+	if err != nil {
+		t.Fatalf("scanWatchDir() error = %v", err)
+	}
+	if len(pending) != 2 || pending[0] != "changed.md" || pending[1] != "new.md" {
+		t.Errorf("pending = %v, want [changed.md new.md]", pending)
+	}
+	if _, ok := modTimes["processed/done.md"]; ok {
+		t.Errorf("files in the processed dir were scanned")
+	}
+	if !modTimes["same.md"].Equal(old) {
+		t.Errorf("mod time of same.md = %v", modTimes["same.md"])
+	}
+
+	pending, _, err = scanWatchDir(filepath.Join(dir, "missing"), "", state)
+	if err != nil || len(pending) != 0 {
+		t.Errorf("scanWatchDir() of a missing dir = %v, %v", pending, err)
+	}
+}
+
+func TestWatchConflict(t *testing.T) {
+	id := uuid.New()
+	imported := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	modTime := imported.Add(time.Hour)
+
+	tests := []struct {
+		name     string
+		prev     WatchedFile
+		known    bool
+		updated  time.Time
+		conflict bool
+	}{
+		{
+			name:    "untouched since the last import",
+			prev:    WatchedFile{ContentID: id, ContentUpdatedAt: imported},
+			known:   true,
+			updated: imported,
+		},
+		{
+			name:     "edited in the admin since the last import",
+			prev:     WatchedFile{ContentID: id, ContentUpdatedAt: imported},
+			known:    true,
+			updated:  imported.Add(time.Minute),
+			conflict: true,
+		},
+		{
+			name:    "never imported, file is newer",
+			updated: modTime.Add(-time.Minute),
+		},
+		{
+			name:     "never imported, content is newer",
+			updated:  modTime.Add(time.Minute),
+			conflict: true,
+		},
+		{
+			name:     "last imported into another content",
+			prev:     WatchedFile{ContentID: uuid.New(), ContentUpdatedAt: imported},
+			known:    true,
+			updated:  modTime.Add(time.Minute),
+			conflict: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			existing := Content{ID: id, UpdatedAt: tt.updated}
+			got := watchConflict(tt.prev, tt.known, existing, modTime)
+			if (got != "") != tt.conflict {
+				t.Errorf("watchConflict() = %q, want conflict %t", got, tt.conflict)
+			}
+		})
+	}
+}
+
+func TestWatchStateRoundTrip(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "documents", "watch-state.json")
+
+	state, err := readWatchState(p)
+	if err != nil || len(state.Files) != 0 {
+		t.Fatalf("readWatchState() of a missing file = %+v, %v", state, err)
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	state.LastRun = now
+	state.Files["b.md"] = WatchedFile{Path: "b.md", Status: WatchConflict, ContentID: uuid.New(), ModTime: now}
+	state.Files["a.md"] = WatchedFile{Path: "a.md", Status: WatchImported, ModTime: now}
+
+	if err := writeWatchState(p, state); err != nil {
+		t.Fatalf("writeWatchState() error = %v", err)
+	}
+	got, err := readWatchState(p)
+	if err != nil {
+		t.Fatalf("readWatchState() error = %v", err)
+	}
+	if !got.LastRun.Equal(now) || got.Files["b.md"] != state.Files["b.md"] {
+		t.Errorf("readWatchState() = %+v", got)
+	}
+
+	if files := got.sortedFiles(); len(files) != 2 || files[0].Path != "a.md" {
+		t.Errorf("sortedFiles() = %+v", files)
+	}
+	if conflicts := got.sortedFiles(WatchConflict); len(conflicts) != 1 || conflicts[0].Path != "b.md" {
+		t.Errorf("sortedFiles(conflict) = %+v", conflicts)
+	}
+}
+
+func TestMoveProcessed(t *testing.T) {
+	dir := t.TempDir()
+	writeWatchedFile(t, dir, "tech/post.md", time.Now())
+	processed := filepath.Join(dir, "processed")
+
+	if err := moveProcessed(dir, processed, "tech/post.md"); err != nil {
+		t.Fatalf("moveProcessed() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(processed, "tech", "post.md")); err != nil {
+		t.Errorf("file not moved: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "tech", "post.md")); !os.IsNotExist(err) {
+		t.Errorf("file still in the watched dir")
+	}
+}
+
+type fakeSiteLister struct {
+	sites []Site
+}
+
+func (f fakeSiteLister) ListSites(ctx context.Context, activeOnly bool) ([]Site, error) {
+	return f.sites, nil
+}
+
+type fakeWatchService struct {
+	Service
+	mu       sync.Mutex
+	statuses map[string]WatchStatus
+	imported chan string
+}
+
+func (f *fakeWatchService) WatchStatus(ctx context.Context) (WatchStatus, error) {
+	slug, _ := GetSiteSlugFromContext(ctx)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.statuses[slug], nil
+}
+
+func (f *fakeWatchService) ImportWatched(ctx context.Context) (ImportReport, error) {
+	slug, _ := GetSiteSlugFromContext(ctx)
+	f.imported <- slug
+	return ImportReport{Summary: "1 created"}, nil
+}
+
+func TestDraftWatcherPoll(t *testing.T) {
+	svc := &fakeWatchService{
+		statuses: map[string]WatchStatus{
+			"pending":  {Enabled: true, Pending: []string{"post.md"}},
+			"idle":     {Enabled: true, Pending: []string{}},
+			"disabled": {Enabled: false, Pending: []string{"post.md"}},
+		},
+		imported: make(chan string, 3),
+	}
+	sites := fakeSiteLister{sites: []Site{
+		NewSite("Pending", "pending", "blog"),
+		NewSite("Idle", "idle", "blog"),
+		NewSite("Disabled", "disabled", "blog"),
+	}}
+	jobs := newTestJobRunner()
+	w := NewDraftWatcher(svc, sites, jobs, hm.XParams{Cfg: hm.NewConfig(), Log: hm.NewLogger("error")})
+
+	w.Poll(context.Background())
+
+	select {
+	case slug := <-svc.imported:
+		if slug != "pending" {
+			t.Errorf("imported site = %q, want pending", slug)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no import job was run")
+	}
+
+	list := jobs.List("pending")
+	if len(list) != 1 || list[0].Kind != JobImportWatched {
+		t.Fatalf("jobs = %+v, want one %s job", list, JobImportWatched)
+	}
+	waitJob(t, jobs, list[0])
+	for _, slug := range []string{"idle", "disabled"} {
+		if got := jobs.List(slug); len(got) != 0 {
+			t.Errorf("jobs of %s = %+v, want none", slug, got)
+		}
+	}
+
+	select {
+	case slug := <-svc.imported:
+		t.Errorf("unexpected import for %q", slug)
+	default:
+	}
+}
@@ -0,0 +1,580 @@
+package ssg
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"html"
+	"io"
+	"net/url"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hermesgen/hm"
+)
+
+// How the categories of a WordPress export are imported.
+const (
+	WordPressCategoriesAsTags     = "tags"
+	WordPressCategoriesAsSections = "sections"
+)
+
+// wordPressUploadsPath is where WordPress serves uploaded media from.
+const wordPressUploadsPath = "/wp-content/uploads/"
+
+// wordPressNoDate is how WordPress writes the date of never published posts.
+const wordPressNoDate = "0000-00-00 00:00:00"
+
+var (
+	// wordPressSizeRe matches the size suffix of the resized copies WordPress
+	// makes of uploaded images: photo-300x200.jpg.
+	wordPressSizeRe = regexp.MustCompile(`-\d+x\d+(\.[A-Za-z0-9]+)$`)
+	// wordPressCaptionRe matches the caption shortcodes of the classic editor.
+	wordPressCaptionRe = regexp.MustCompile(`\[/?caption[^\]]*\]`)
+	htmlCommentRe      = regexp.MustCompile(`(?s)<!--.*?-->`)
+	// anyTagRe and anyAttrRe match every HTML tag and attribute.
+	anyTagRe     = regexp.MustCompile(`<(/?)([a-zA-Z][a-zA-Z0-9]*)((?:[^>"']|"[^"]*"|'[^']*')*?)/?>`)
+	anyAttrRe    = regexp.MustCompile(`([a-zA-Z_:][-a-zA-Z0-9_:.]*)\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s"'>]+))`)
+	blankLinesRe = regexp.MustCompile(`\n{3,}`)
+)
+
+// wxrExport is the part of a WordPress eXtended RSS export the import reads.
+type wxrExport struct {
+	Items []wxrItem `xml:"channel>item"`
+}
+
+// wxrItem is a post, page, attachment or any other post type of an export.
+type wxrItem struct {
+	Title         string        `xml:"title"`
+	Link          string        `xml:"link"`
+	Encoded       []wxrEncoded  `xml:"encoded"`
+	PostID        string        `xml:"post_id"`
+	PostDate      string        `xml:"post_date"`
+	PostDateGMT   string        `xml:"post_date_gmt"`
+	PostName      string        `xml:"post_name"`
+	Status        string        `xml:"status"`
+	PostParent    string        `xml:"post_parent"`
+	PostType      string        `xml:"post_type"`
+	AttachmentURL string        `xml:"attachment_url"`
+	Categories    []wxrCategory `xml:"category"`
+	PostMeta      []wxrPostMeta `xml:"postmeta"`
+}
+
+// wxrEncoded is the content or the excerpt of an item, told apart by their
+// namespace.
+type wxrEncoded struct {
+	XMLName xml.Name
+	Value   string `xml:",chardata"`
+}
+
+type wxrCategory struct {
+	Domain   string `xml:"domain,attr"`
+	Nicename string `xml:"nicename,attr"`
+	Name     string `xml:",chardata"`
+}
+
+type wxrPostMeta struct {
+	Key   string `xml:"meta_key"`
+	Value string `xml:"meta_value"`
+}
+
+// parseWXR reads a WordPress export. Exports are often not strict XML, so
+// HTML entities and unclosed tags are accepted.
+func parseWXR(r io.Reader) (wxrExport, error) {
+	dec := xml.NewDecoder(r)
+	dec.Strict = false
+	dec.Entity = xml.HTMLEntity
+
+	var export wxrExport
+	if err := dec.Decode(&export); err != nil {
+		return wxrExport{}, fmt.Errorf("cannot read WordPress export: %w", err)
+	}
+	return export, nil
+}
+
+// posts returns the posts and pages of the export, skipping trashed ones and
+// the other post types such as menu items or revisions.
+func (e wxrExport) posts() []wxrItem {
+	var posts []wxrItem
+	for _, item := range e.Items {
+		if item.Status == "trash" || item.Status == "auto-draft" {
+			continue
+		}
+		if item.PostType == "post" || item.PostType == "page" {
+			posts = append(posts, item)
+		}
+	}
+	return posts
+}
+
+func (item wxrItem) content() string {
+	return item.encoded(false)
+}
+
+func (item wxrItem) excerpt() string {
+	return item.encoded(true)
+}
+
+func (item wxrItem) encoded(excerpt bool) string {
+	for _, e := range item.Encoded {
+		if strings.Contains(e.XMLName.Space, "excerpt") == excerpt {
+			return e.Value
+		}
+	}
+	return ""
+}
+
+func (item wxrItem) meta(key string) string {
+	for _, m := range item.PostMeta {
+		if m.Key == key {
+			return strings.TrimSpace(m.Value)
+		}
+	}
+	return ""
+}
+
+// date returns when the item was published, preferring the UTC date. Items
+// never published have none.
+func (item wxrItem) date() (*time.Time, error) {
+	for _, s := range []string{item.PostDateGMT, item.PostDate} {
+		s = strings.TrimSpace(s)
+		if s == "" || s == wordPressNoDate {
+			continue
+		}
+		t, err := time.Parse("2006-01-02 15:04:05", s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid date %q", s)
+		}
+		return &t, nil
+	}
+	return nil, nil
+}
+
+// toContent maps a post or page to a content. Posts are blog posts and pages
+// are pages; only published items are published, scheduled ones are imported
+// as drafts keeping their date. Categories become tags or, with
+// WordPressCategoriesAsSections, the first one becomes the section. The
+// permalink is kept as a redirect.
+func (item wxrItem) toContent(categoriesAs string) (migratedContent, error) {
+	mc := migratedContent{}
+	ic := &mc.importedContent
+	c := &ic.Content
+
+	slug := strings.TrimSpace(item.PostName)
+	if unescaped, err := url.PathUnescape(slug); err == nil {
+		slug = unescaped
+	}
+	if slug == "" {
+		slug = hm.Normalize(item.Title)
+	}
+	if slug == "" {
+		slug = "post-" + strings.TrimSpace(item.PostID)
+	}
+	ic.Slug = hm.Normalize(slug)
+
+	c.Heading = strings.TrimSpace(item.Title)
+	if c.Heading == "" {
+		c.Heading = headingFromSlug(slug)
+	}
+	c.Body = htmlToMarkdown(item.content())
+	c.SectionPath = "/"
+	c.Kind = "blog"
+	if item.PostType == "page" {
+		c.Kind = "page"
+	}
+
+	date, err := item.date()
+	if err != nil {
+		return migratedContent{}, err
+	}
+	ic.CreatedAt = date
+
+	switch item.Status {
+	case "publish":
+		c.PublishedAt = date
+	case "future":
+		c.Draft = true
+		c.PublishedAt = date
+		mc.Notes = append(mc.Notes, "Scheduled post imported as a draft")
+	default:
+		c.Draft = true
+	}
+
+	if excerpt := htmlToMarkdown(item.excerpt()); excerpt != "" {
+		c.Summary = excerpt
+		c.Meta.Description = excerpt
+	}
+
+	seen := map[string]bool{}
+	addTag := func(name string) {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[strings.ToLower(name)] {
+			return
+		}
+		seen[strings.ToLower(name)] = true
+		c.Tags = append(c.Tags, Tag{Name: name})
+	}
+	for _, cat := range item.Categories {
+		switch cat.Domain {
+		case "post_tag":
+			addTag(cat.Name)
+		case "category":
+			if cat.Nicename == "uncategorized" {
+				continue
+			}
+			if categoriesAs == WordPressCategoriesAsSections && c.SectionPath == "/" {
+				nicename := cat.Nicename
+				if nicename == "" {
+					nicename = hm.Normalize(cat.Name)
+				}
+				c.SectionPath = "/" + nicename
+				c.SectionName = strings.TrimSpace(cat.Name)
+				continue
+			}
+			addTag(cat.Name)
+		}
+	}
+
+	if u, err := url.Parse(strings.TrimSpace(item.Link)); err == nil && u.RawQuery == "" && strings.Trim(u.Path, "/") != "" {
+		mc.Redirects = append(mc.Redirects, u.Path)
+	}
+
+	return mc, nil
+}
+
+// wordPressMedia is the uploaded media of an export found in a local copy of
+// the uploads directory.
+type wordPressMedia struct {
+	src      func(ref string) (file, shared string)
+	urls     map[string]string // Attachment URLs by post ID
+	byTarget map[string]migrationImage
+	byParent map[string][]migrationImage
+	parents  []string // Of the images, in export order
+	images   []migrationImage
+}
+
+// newWordPressMedia finds the attachments of the export under uploadsDir.
+func newWordPressMedia(e wxrExport, uploadsDir string) *wordPressMedia {
+	m := &wordPressMedia{
+		src:      wordPressImageSource(uploadsDir),
+		urls:     map[string]string{},
+		byTarget: map[string]migrationImage{},
+		byParent: map[string][]migrationImage{},
+	}
+
+	for _, item := range e.Items {
+		if item.PostType != "attachment" {
+			continue
+		}
+		m.urls[strings.TrimSpace(item.PostID)] = item.AttachmentURL
+
+		file, shared := m.src(item.AttachmentURL)
+		if file == "" {
+			continue
+		}
+		mi := migrationImage{
+			Source:  file,
+			Target:  sharedImageTarget(shared),
+			Title:   strings.TrimSpace(item.Title),
+			AltText: item.meta("_wp_attachment_image_alt"),
+		}
+		if _, ok := m.byTarget[mi.Target]; ok {
+			continue
+		}
+		parent := strings.TrimSpace(item.PostParent)
+		m.byTarget[mi.Target] = mi
+		m.byParent[parent] = append(m.byParent[parent], mi)
+		m.parents = append(m.parents, parent)
+		m.images = append(m.images, mi)
+	}
+
+	return m
+}
+
+// unattached returns the images not uploaded to any of the posts.
+func (m *wordPressMedia) unattached(posts []wxrItem) []migrationImage {
+	ids := map[string]bool{}
+	for _, item := range posts {
+		ids[strings.TrimSpace(item.PostID)] = true
+	}
+
+	var images []migrationImage
+	for i, mi := range m.images {
+		if !ids[m.parents[i]] {
+			images = append(images, mi)
+		}
+	}
+	return images
+}
+
+// readWordPressItem reads a post or page with its images: those in the body,
+// the featured image and the media uploaded to it.
+func readWordPressItem(item wxrItem, media *wordPressMedia, categoriesAs string) (migratedContent, error) {
+	mc, err := item.toContent(categoriesAs)
+	if err != nil {
+		return migratedContent{}, err
+	}
+
+	mc.migrationImages(media.urls[item.meta("_thumbnail_id")], media.src)
+
+	for i := range mc.Images {
+		if mi, ok := media.byTarget[mc.Images[i].Target]; ok {
+			mc.Images[i].Title = mi.Title
+			if mi.AltText != "" {
+				mc.Images[i].AltText = mi.AltText
+			}
+		}
+	}
+
+	for _, mi := range media.byParent[strings.TrimSpace(item.PostID)] {
+		if !slices.ContainsFunc(mc.Images, func(img migrationImage) bool { return img.Target == mi.Target }) {
+			mc.Images = append(mc.Images, mi)
+		}
+	}
+
+	return mc, nil
+}
+
+// wordPressImageSource resolves the URLs of uploaded images to files in the
+// local copy of wp-content/uploads at uploadsDir. When a resized copy is not
+// there the original is used.
+func wordPressImageSource(uploadsDir string) func(ref string) (file, shared string) {
+	return func(ref string) (string, string) {
+		if uploadsDir == "" {
+			return "", ""
+		}
+		u, err := url.Parse(strings.TrimSpace(ref))
+		if err != nil {
+			return "", ""
+		}
+		i := strings.Index(u.Path, wordPressUploadsPath)
+		if i < 0 {
+			return "", ""
+		}
+		rel := refPath(u.Path[i+len(wordPressUploadsPath):])
+		if !isImageFile(rel) {
+			return "", ""
+		}
+
+		for _, candidate := range []string{rel, wordPressSizeRe.ReplaceAllString(rel, "$1")} {
+			clean := strings.TrimPrefix(path.Clean("/"+candidate), "/")
+			if file := filepath.Join(uploadsDir, filepath.FromSlash(clean)); isFile(file) {
+				return file, path.Join(wordPressUploadsPath, clean)
+			}
+		}
+		return "", ""
+	}
+}
+
+// htmlToMarkdown converts the HTML of WordPress posts to Markdown. It covers
+// what the classic and block editors write: paragraphs, headings, emphasis,
+// links, images, lists, quotes, code and rules. Other tags are dropped keeping
+// their text.
+func htmlToMarkdown(s string) string {
+	s = htmlCommentRe.ReplaceAllString(s, "")
+	s = wordPressCaptionRe.ReplaceAllString(s, "")
+
+	c := &markdownConverter{out: []*bytes.Buffer{{}}}
+	last := 0
+	for _, m := range anyTagRe.FindAllStringSubmatchIndex(s, -1) {
+		c.text(s[last:m[0]])
+		last = m[1]
+		closing := m[3] > m[2]
+		name := strings.ToLower(s[m[4]:m[5]])
+		c.tag(name, closing, tagAttrs(s[m[6]:m[7]]))
+	}
+	c.text(s[last:])
+
+	md := blankLinesRe.ReplaceAllString(c.out[0].String(), "\n\n")
+	return strings.TrimSpace(md)
+}
+
+func tagAttrs(s string) map[string]string {
+	attrs := map[string]string{}
+	for _, m := range anyAttrRe.FindAllStringSubmatch(s, -1) {
+		attrs[strings.ToLower(m[1])] = html.UnescapeString(m[2] + m[3] + m[4])
+	}
+	return attrs
+}
+
+// markdownConverter writes Markdown while walking the tags of an HTML
+// document. Quotes are written to their own buffer and prefixed when closed.
+type markdownConverter struct {
+	out   []*bytes.Buffer
+	lists []int // Next number of each open list, 0 for bullets
+	links []string
+	pre   int
+	skip  int
+}
+
+func (c *markdownConverter) buf() *bytes.Buffer {
+	return c.out[len(c.out)-1]
+}
+
+func (c *markdownConverter) write(s string) {
+	c.buf().WriteString(s)
+}
+
+// newline ends the current line, leaving n line breaks at most.
+func (c *markdownConverter) newline(n int) {
+	b := c.buf().Bytes()
+	if len(b) == 0 {
+		return
+	}
+	trailing := len(b) - len(bytes.TrimRight(b, "\n"))
+	for ; trailing < n; trailing++ {
+		c.write("\n")
+	}
+}
+
+func (c *markdownConverter) block() {
+	if len(c.lists) > 0 {
+		c.newline(1)
+		return
+	}
+	c.newline(2)
+}
+
+func (c *markdownConverter) text(s string) {
+	if c.skip > 0 || s == "" {
+		return
+	}
+	if c.pre > 0 {
+		c.write(html.UnescapeString(s))
+		return
+	}
+	if strings.TrimSpace(s) == "" && strings.Contains(s, "\n") {
+		b := c.buf().Bytes()
+		if len(b) == 0 || b[len(b)-1] == '\n' {
+			return
+		}
+	}
+	if b := c.buf().Bytes(); len(b) == 0 || b[len(b)-1] == '\n' {
+		s = strings.TrimLeft(s, " \t")
+	}
+	c.write(html.UnescapeString(s))
+}
+
+func (c *markdownConverter) tag(name string, closing bool, attrs map[string]string) {
+	if name == "script" || name == "style" {
+		if closing {
+			c.skip = max(c.skip-1, 0)
+		} else {
+			c.skip++
+		}
+		return
+	}
+	if c.skip > 0 {
+		return
+	}
+	if c.pre > 0 && name != "pre" {
+		return
+	}
+
+	switch name {
+	case "p", "div", "figure", "figcaption", "table":
+		c.block()
+	case "br":
+		c.write("  \n")
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		c.newline(2)
+		if !closing {
+			c.write(strings.Repeat("#", int(name[1]-'0')) + " ")
+		}
+	case "strong", "b":
+		c.write("**")
+	case "em", "i":
+		c.write("_")
+	case "code":
+		c.write("`")
+	case "a":
+		if !closing {
+			c.links = append(c.links, attrs["href"])
+			if attrs["href"] != "" {
+				c.write("[")
+			}
+			return
+		}
+		if len(c.links) == 0 {
+			return
+		}
+		href := c.links[len(c.links)-1]
+		c.links = c.links[:len(c.links)-1]
+		if href != "" {
+			c.write("](" + href + ")")
+		}
+	case "img":
+		if attrs["src"] == "" {
+			return
+		}
+		img := "![" + attrs["alt"] + "](" + attrs["src"]
+		if title := attrs["title"]; title != "" {
+			img += ` "` + strings.ReplaceAll(title, `"`, `'`) + `"`
+		}
+		c.write(img + ")")
+	case "ul", "ol":
+		if closing {
+			if len(c.lists) > 0 {
+				c.lists = c.lists[:len(c.lists)-1]
+			}
+			c.block()
+			return
+		}
+		c.block()
+		next := 0
+		if name == "ol" {
+			next = 1
+		}
+		c.lists = append(c.lists, next)
+	case "li":
+		if closing || len(c.lists) == 0 {
+			return
+		}
+		c.newline(1)
+		depth := len(c.lists) - 1
+		marker := "- "
+		if n := c.lists[depth]; n > 0 {
+			marker = strconv.Itoa(n) + ". "
+			c.lists[depth]++
+		}
+		c.write(strings.Repeat("   ", depth) + marker)
+	case "blockquote":
+		if !closing {
+			c.block()
+			c.out = append(c.out, &bytes.Buffer{})
+			return
+		}
+		if len(c.out) == 1 {
+			return
+		}
+		quote := strings.TrimSpace(c.buf().String())
+		c.out = c.out[:len(c.out)-1]
+		lines := strings.Split(blankLinesRe.ReplaceAllString(quote, "\n\n"), "\n")
+		for i, line := range lines {
+			lines[i] = strings.TrimRight("> "+line, " ")
+		}
+		c.block()
+		c.write(strings.Join(lines, "\n"))
+		c.newline(2)
+	case "pre":
+		if !closing {
+			c.newline(2)
+			c.write("```\n")
+			c.pre++
+			return
+		}
+		c.pre = max(c.pre-1, 0)
+		c.newline(1)
+		c.write("```")
+		c.newline(2)
+	case "hr":
+		c.newline(2)
+		c.write("---")
+		c.newline(2)
+	}
+}
@@ -0,0 +1,275 @@
+package ssg
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testWXR = `<?xml version="1.0" encoding="UTF-8" ?>
+<rss version="2.0"
+	xmlns:excerpt="http://wordpress.org/export/1.2/excerpt/"
+	xmlns:content="http://purl.org/rss/1.0/modules/content/"
+	xmlns:wp="http://wordpress.org/export/1.2/">
+<channel>
+	<title>My Blog</title>
+	<wp:base_site_url>https://example.com</wp:base_site_url>
+	<item>
+		<title>Hello &amp; Welcome</title>
+		<link>https://example.com/2021/05/hello-welcome/</link>
+		<content:encoded><![CDATA[<!-- wp:paragraph --><p>First &amp; <strong>bold</strong> <a href="https://go.dev">Go</a>.</p><!-- /wp:paragraph -->
+[caption id="attachment_10"]<img src="https://example.com/wp-content/uploads/2021/05/photo-300x200.jpg" alt="A photo" /> Photo[/caption]]]></content:encoded>
+		<excerpt:encoded><![CDATA[<p>Short intro</p>]]></excerpt:encoded>
+		<wp:post_id>1</wp:post_id>
+		<wp:post_date>2021-05-03 12:00:00</wp:post_date>
+		<wp:post_date_gmt>2021-05-03 10:00:00</wp:post_date_gmt>
+		<wp:post_name>hello-welcome</wp:post_name>
+		<wp:status>publish</wp:status>
+		<wp:post_parent>0</wp:post_parent>
+		<wp:post_type>post</wp:post_type>
+		<category domain="category" nicename="uncategorized"><![CDATA[Uncategorized]]></category>
+		<category domain="category" nicename="travel"><![CDATA[Travel]]></category>
+		<category domain="category" nicename="food"><![CDATA[Food]]></category>
+		<category domain="post_tag" nicename="go"><![CDATA[Go]]></category>
+		<wp:postmeta>
+			<wp:meta_key>_thumbnail_id</wp:meta_key>
+			<wp:meta_value><![CDATA[11]]></wp:meta_value>
+		</wp:postmeta>
+	</item>
+	<item>
+		<title>About</title>
+		<link>https://example.com/?page_id=2</link>
+		<content:encoded><![CDATA[About me]]></content:encoded>
+		<excerpt:encoded><![CDATA[]]></excerpt:encoded>
+		<wp:post_id>2</wp:post_id>
+		<wp:post_date>0000-00-00 00:00:00</wp:post_date>
+		<wp:post_date_gmt>0000-00-00 00:00:00</wp:post_date_gmt>
+		<wp:post_name></wp:post_name>
+		<wp:status>draft</wp:status>
+		<wp:post_type>page</wp:post_type>
+	</item>
+	<item>
+		<title>Coming soon</title>
+		<link>https://example.com/2030/01/coming-soon/</link>
+		<content:encoded><![CDATA[Soon]]></content:encoded>
+		<wp:post_id>3</wp:post_id>
+		<wp:post_date_gmt>2030-01-01 08:00:00</wp:post_date_gmt>
+		<wp:post_name>coming-soon</wp:post_name>
+		<wp:status>future</wp:status>
+		<wp:post_type>post</wp:post_type>
+	</item>
+	<item>
+		<title>Old</title>
+		<wp:post_id>4</wp:post_id>
+		<wp:status>trash</wp:status>
+		<wp:post_type>post</wp:post_type>
+	</item>
+	<item>
+		<title>Menu</title>
+		<wp:post_id>5</wp:post_id>
+		<wp:status>publish</wp:status>
+		<wp:post_type>nav_menu_item</wp:post_type>
+	</item>
+	<item>
+		<title>Photo</title>
+		<wp:post_id>10</wp:post_id>
+		<wp:post_parent>1</wp:post_parent>
+		<wp:post_type>attachment</wp:post_type>
+		<wp:attachment_url>https://example.com/wp-content/uploads/2021/05/photo.jpg</wp:attachment_url>
+		<wp:postmeta>
+			<wp:meta_key>_wp_attachment_image_alt</wp:meta_key>
+			<wp:meta_value>Sunset over the bay</wp:meta_value>
+		</wp:postmeta>
+	</item>
+	<item>
+		<title>Cover</title>
+		<wp:post_id>11</wp:post_id>
+		<wp:post_parent>1</wp:post_parent>
+		<wp:post_type>attachment</wp:post_type>
+		<wp:attachment_url>https://example.com/wp-content/uploads/2021/05/cover.png</wp:attachment_url>
+	</item>
+	<item>
+		<title>Logo</title>
+		<wp:post_id>12</wp:post_id>
+		<wp:post_parent>0</wp:post_parent>
+		<wp:post_type>attachment</wp:post_type>
+		<wp:attachment_url>https://example.com/wp-content/uploads/logo.svg</wp:attachment_url>
+	</item>
+</channel>
+</rss>
+`
+
+func TestParseWXR(t *testing.T) {
+	export, err := parseWXR(strings.NewReader(testWXR))
+	if err != nil {
+		t.Fatalf("parseWXR() error = %v", err)
+	}
+
+	posts := export.posts()
+	if len(posts) != 3 {
+		t.Fatalf("posts() = %d items, want the post, the page and the scheduled post", len(posts))
+	}
+
+	post := posts[0]
+	if post.Title != "Hello & Welcome" || post.PostID != "1" || post.meta("_thumbnail_id") != "11" {
+		t.Errorf("post = %+v", post)
+	}
+	if !strings.Contains(post.content(), "<strong>bold</strong>") || post.excerpt() != "<p>Short intro</p>" {
+		t.Errorf("content = %q, excerpt = %q", post.content(), post.excerpt())
+	}
+}
+
+func TestWordPressToContent(t *testing.T) {
+	export, err := parseWXR(strings.NewReader(testWXR))
+	if err != nil {
+		t.Fatal(err)
+	}
+	posts := export.posts()
+
+	mc, err := posts[0].toContent(WordPressCategoriesAsTags)
+	if err != nil {
+		t.Fatalf("toContent() error = %v", err)
+	}
+	c := mc.Content
+	if c.Kind != "blog" || c.Draft || mc.Slug != "hello-welcome" || c.SectionPath != "/" {
+		t.Errorf("kind, draft, slug, section = %q, %t, %q, %q", c.Kind, c.Draft, mc.Slug, c.SectionPath)
+	}
+	if c.PublishedAt == nil || !c.PublishedAt.Equal(time.Date(2021, 5, 3, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("published at = %v, want the GMT date", c.PublishedAt)
+	}
+	if c.Summary != "Short intro" || c.Meta.Description != "Short intro" {
+		t.Errorf("summary = %q, description = %q", c.Summary, c.Meta.Description)
+	}
+	if len(c.Tags) != 3 || c.Tags[0].Name != "Travel" || c.Tags[1].Name != "Food" || c.Tags[2].Name != "Go" {
+		t.Errorf("tags = %v, want the categories but uncategorized, and the tags", c.Tags)
+	}
+	if len(mc.Redirects) != 1 || mc.Redirects[0] != "/2021/05/hello-welcome/" {
+		t.Errorf("redirects = %v", mc.Redirects)
+	}
+
+	mc, err = posts[0].toContent(WordPressCategoriesAsSections)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mc.Content.SectionPath != "/travel" || mc.Content.SectionName != "Travel" {
+		t.Errorf("section = %q, %q, want the first category", mc.Content.SectionPath, mc.Content.SectionName)
+	}
+	if len(mc.Content.Tags) != 2 || mc.Content.Tags[0].Name != "Food" {
+		t.Errorf("tags = %v, want the other categories and the tags", mc.Content.Tags)
+	}
+
+	mc, err = posts[1].toContent(WordPressCategoriesAsTags)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mc.Content.Kind != "page" || !mc.Content.Draft || mc.Content.PublishedAt != nil || mc.Slug != "about" {
+		t.Errorf("page = %+v, slug %q", mc.Content, mc.Slug)
+	}
+	if len(mc.Redirects) != 0 {
+		t.Errorf("redirects = %v, links with a query can not be redirected", mc.Redirects)
+	}
+
+	mc, err = posts[2].toContent(WordPressCategoriesAsTags)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !mc.Content.Draft || mc.Content.PublishedAt == nil || len(mc.Notes) != 1 {
+		t.Errorf("scheduled post = %+v, notes %v", mc.Content, mc.Notes)
+	}
+}
+
+func TestReadWordPressItemImages(t *testing.T) {
+	uploads := t.TempDir()
+	writeSiteFiles(t, uploads, map[string]string{
+		"2021/05/photo.jpg": "jpg",
+		"2021/05/cover.png": "png",
+		"logo.svg":          "svg",
+	})
+
+	export, err := parseWXR(strings.NewReader(testWXR))
+	if err != nil {
+		t.Fatal(err)
+	}
+	media := newWordPressMedia(export, uploads)
+
+	mc, err := readWordPressItem(export.posts()[0], media, WordPressCategoriesAsTags)
+	if err != nil {
+		t.Fatalf("readWordPressItem() error = %v", err)
+	}
+
+	if !strings.Contains(mc.Content.Body, "![A photo](/static/images/imported/wp-content/uploads/2021/05/photo.jpg)") {
+		t.Errorf("body = %q, want the resized image replaced by the original", mc.Content.Body)
+	}
+	if strings.Contains(mc.Content.Body, "caption") {
+		t.Errorf("body = %q, want the caption shortcode removed", mc.Content.Body)
+	}
+
+	if len(mc.Images) != 2 {
+		t.Fatalf("images = %+v, want the photo and the cover", mc.Images)
+	}
+	photo, cover := mc.Images[0], mc.Images[1]
+	if photo.Source != filepath.Join(uploads, "2021", "05", "photo.jpg") || photo.Title != "Photo" || photo.AltText != "Sunset over the bay" {
+		t.Errorf("photo = %+v", photo)
+	}
+	if cover.Target != "imported/wp-content/uploads/2021/05/cover.png" || !cover.IsHeader {
+		t.Errorf("cover = %+v", cover)
+	}
+
+	unattached := media.unattached(export.posts())
+	if len(unattached) != 1 || unattached[0].Target != "imported/wp-content/uploads/logo.svg" {
+		t.Errorf("unattached = %+v", unattached)
+	}
+}
+
+func TestHTMLToMarkdown(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want string
+	}{
+		{
+			name: "paragraphs and emphasis",
+			html: "<p>Hello <strong>bold</strong> and <em>italic</em></p>\n<p>Second&nbsp;one</p>",
+			want: "Hello **bold** and _italic_\n\nSecond one",
+		},
+		{
+			name: "classic editor text",
+			html: "First paragraph\n\nSecond paragraph",
+			want: "First paragraph\n\nSecond paragraph",
+		},
+		{
+			name: "headings and links",
+			html: `<h2>Title</h2><p>See <a href="https://go.dev/?a=1&amp;b=2">the site</a>.</p>`,
+			want: "## Title\n\nSee [the site](https://go.dev/?a=1&b=2).",
+		},
+		{
+			name: "lists",
+			html: "<ul>\n<li>One</li>\n<li>Two<ol><li>A</li><li>B</li></ol></li>\n</ul>",
+			want: "- One\n- Two\n   1. A\n   2. B",
+		},
+		{
+			name: "quote",
+			html: "<blockquote><p>Quoted</p><p>Twice</p></blockquote><p>After</p>",
+			want: "> Quoted\n>\n> Twice\n\nAfter",
+		},
+		{
+			name: "code",
+			html: "<p>Run <code>go test</code></p><pre><code>if a &lt; b {\n\treturn\n}</code></pre>",
+			want: "Run `go test`\n\n```\nif a < b {\n\treturn\n}\n```",
+		},
+		{
+			name: "image and rule",
+			html: `<img src="/a.png" alt="An image" title="Tip"><hr/><script>alert(1)</script>`,
+			want: "![An image](/a.png \"Tip\")\n\n---",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := htmlToMarkdown(tt.html); got != tt.want {
+				t.Errorf("htmlToMarkdown() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -11,6 +11,9 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/hermesgen/clio/internal/feat/ssg"
+	"github.com/hermesgen/hm"
+	"github.com/jmoiron/sqlx"
+	"github.com/mattn/go-sqlite3"
 )
 
 var (
@@ -23,6 +26,7 @@ var (
 	resParam        = "param"
 	resImage        = "image"
 	resImageVariant = "image_variant"
+	resAttachment   = "attachment"
 )
 
 // sanitizeURLPath sanitizes a file path for safe use in URLs
@@ -104,7 +108,13 @@ func (repo *ClioRepo) GetContent(ctx context.Context, id uuid.UUID) (ssg.Content
 	return ssg.Content{}, errors.New("content not found")
 }
 
+// UpdateContent updates a content and its meta in one transaction, the one in
+// ctx if there is one.
 func (repo *ClioRepo) UpdateContent(ctx context.Context, c *ssg.Content) (err error) {
+	if _, ok := hm.TxFromContext(ctx); ok {
+		return repo.updateContent(ctx, repo.getExec(ctx), c)
+	}
+
 	tx, err := repo.db.BeginTxx(ctx, nil)
 	if err != nil {
 		return fmt.Errorf("cannot begin transaction: %w", err)
@@ -119,12 +129,16 @@ func (repo *ClioRepo) UpdateContent(ctx context.Context, c *ssg.Content) (err er
 		err = tx.Commit()
 	}()
 
+	return repo.updateContent(ctx, tx, c)
+}
+
+func (repo *ClioRepo) updateContent(ctx context.Context, exec sqlx.ExtContext, c *ssg.Content) error {
 	// Update Content
 	contentQuery, err := repo.BaseRepo.Query().Get(featSSG, resContent, "Update")
 	if err != nil {
 		return fmt.Errorf("cannot get update content query: %w", err)
 	}
-	if _, err = tx.NamedExecContext(ctx, contentQuery, c); err != nil {
+	if _, err = sqlx.NamedExecContext(ctx, exec, contentQuery, c); err != nil {
 		return fmt.Errorf("cannot update content: %w", err)
 	}
 
@@ -133,7 +147,7 @@ func (repo *ClioRepo) UpdateContent(ctx context.Context, c *ssg.Content) (err er
 	if err != nil {
 		return fmt.Errorf("cannot get update meta query: %w", err)
 	}
-	if _, err = tx.NamedExecContext(ctx, metaQuery, c.Meta); err != nil {
+	if _, err = sqlx.NamedExecContext(ctx, exec, metaQuery, c.Meta); err != nil {
 		return fmt.Errorf("cannot update meta: %w", err)
 	}
 
@@ -187,6 +201,7 @@ func (repo *ClioRepo) GetAllContentWithMeta(ctx context.Context) ([]ssg.Content,
 
 		err := rows.Scan(
 			&c.ID, &c.UserID, &c.SectionID, &c.Kind, &c.Heading, &c.Body, &c.Draft, &c.Featured, &publishedAt, &c.ShortID,
+			&c.Summary, &c.Series, &c.SeriesOrder,
 			&c.CreatedBy, &c.UpdatedBy, &c.CreatedAt, &c.UpdatedAt,
 			&sectionPath, &sectionName,
 			&metaID, &description, &keywords, &robots, &canonicalURL, &sitemap, &tableOfContents, &share, &comments,
@@ -689,7 +704,7 @@ func (repo *ClioRepo) CreateParam(ctx context.Context, p *ssg.Param) (err error)
 	if err != nil {
 		return fmt.Errorf("cannot get create param query: %w", err)
 	}
-	if _, err = repo.db.NamedExecContext(ctx, query, p); err != nil {
+	if _, err = sqlx.NamedExecContext(ctx, repo.getExec(ctx), query, p); err != nil {
 		return fmt.Errorf("cannot create param: %w", err)
 	}
 	return nil
@@ -712,12 +727,17 @@ func (repo *ClioRepo) GetParam(ctx context.Context, id uuid.UUID) (ssg.Param, er
 }
 
 func (repo *ClioRepo) GetParamByName(ctx context.Context, name string) (ssg.Param, error) {
+	siteID, ok := ssg.GetSiteIDFromContext(ctx)
+	if !ok {
+		return ssg.Param{}, fmt.Errorf("no site ID in context")
+	}
+
 	query, err := repo.BaseRepo.Query().Get(featSSG, resParam, "GetByName")
 	if err != nil {
 		return ssg.Param{}, fmt.Errorf("cannot get get param by name query: %w", err)
 	}
 	var param ssg.Param
-	err = repo.db.GetContext(ctx, &param, query, name)
+	err = repo.db.GetContext(ctx, &param, query, siteID, name)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return ssg.Param{}, errors.New("param not found")
@@ -728,12 +748,17 @@ func (repo *ClioRepo) GetParamByName(ctx context.Context, name string) (ssg.Para
 }
 
 func (repo *ClioRepo) GetParamByRefKey(ctx context.Context, refKey string) (ssg.Param, error) {
+	siteID, ok := ssg.GetSiteIDFromContext(ctx)
+	if !ok {
+		return ssg.Param{}, fmt.Errorf("no site ID in context")
+	}
+
 	query, err := repo.BaseRepo.Query().Get(featSSG, resParam, "GetByRefKey")
 	if err != nil {
 		return ssg.Param{}, fmt.Errorf("cannot get get param by ref key query: %w", err)
 	}
 	var param ssg.Param
-	err = repo.db.GetContext(ctx, &param, query, refKey)
+	err = repo.db.GetContext(ctx, &param, query, siteID, refKey)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return ssg.Param{}, errors.New("param not found")
@@ -744,12 +769,17 @@ func (repo *ClioRepo) GetParamByRefKey(ctx context.Context, refKey string) (ssg.
 }
 
 func (repo *ClioRepo) ListParams(ctx context.Context) ([]ssg.Param, error) {
+	siteID, ok := ssg.GetSiteIDFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("no site ID in context")
+	}
+
 	query, err := repo.BaseRepo.Query().Get(featSSG, resParam, "List")
 	if err != nil {
 		return nil, fmt.Errorf("cannot get list params query: %w", err)
 	}
 	var params []ssg.Param
-	err = repo.db.SelectContext(ctx, &params, query)
+	err = repo.db.SelectContext(ctx, &params, query, siteID)
 	if err != nil {
 		return nil, fmt.Errorf("cannot list params: %w", err)
 	}
@@ -761,7 +791,7 @@ func (repo *ClioRepo) UpdateParam(ctx context.Context, p *ssg.Param) (err error)
 	if err != nil {
 		return fmt.Errorf("cannot get update param query: %w", err)
 	}
-	if _, err = repo.db.NamedExecContext(ctx, query, p); err != nil {
+	if _, err = sqlx.NamedExecContext(ctx, repo.getExec(ctx), query, p); err != nil {
 		return fmt.Errorf("cannot update param: %w", err)
 	}
 	return nil
@@ -876,6 +906,38 @@ func (repo *ClioRepo) ListImages(ctx context.Context) ([]ssg.Image, error) {
 	return images, nil
 }
 
+// ListSiteImages returns a page of the images owned by or linked from the site
+// in context, newest first, along with the total of images matching search.
+func (repo *ClioRepo) ListSiteImages(ctx context.Context, offset, limit int, search string) ([]ssg.Image, int, error) {
+	siteID, ok := ssg.GetSiteIDFromContext(ctx)
+	if !ok {
+		return nil, 0, fmt.Errorf("no site ID in context")
+	}
+
+	countQuery, err := repo.BaseRepo.Query().Get(featSSG, resImage, "CountSiteImages")
+	if err != nil {
+		return nil, 0, fmt.Errorf("cannot get count site images query: %w", err)
+	}
+
+	var total int
+	if err := repo.db.GetContext(ctx, &total, countQuery, siteID, search); err != nil {
+		return nil, 0, fmt.Errorf("cannot count site images: %w", err)
+	}
+
+	query, err := repo.BaseRepo.Query().Get(featSSG, resImage, "ListSiteImages")
+	if err != nil {
+		return nil, 0, fmt.Errorf("cannot get list site images query: %w", err)
+	}
+
+	images := []ssg.Image{}
+	err = repo.db.SelectContext(ctx, &images, query, siteID, search, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("cannot list site images: %w", err)
+	}
+
+	return images, total, nil
+}
+
 func (repo *ClioRepo) UpdateImage(ctx context.Context, img *ssg.Image) (err error) {
 	tx, err := repo.db.BeginTxx(ctx, nil)
 	if err != nil {
@@ -1102,6 +1164,25 @@ func (repo *ClioRepo) GetContentImagesByContentID(ctx context.Context, contentID
 	return contentImages, err
 }
 
+// GetAllContentImages returns the content-image relationships of the site in context.
+func (repo *ClioRepo) GetAllContentImages(ctx context.Context) ([]ssg.ContentImage, error) {
+	siteID, ok := ssg.GetSiteIDFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("no site ID in context")
+	}
+
+	query := `
+		SELECT ci.id, ci.content_id, ci.image_id, ci.is_header, ci.is_featured, ci.order_num, ci.created_at
+		FROM content_images ci
+		JOIN content c ON c.id = ci.content_id
+		WHERE c.site_id = ?
+		ORDER BY ci.content_id, ci.order_num
+	`
+	var contentImages []ssg.ContentImage
+	err := repo.db.SelectContext(ctx, &contentImages, query, siteID)
+	return contentImages, err
+}
+
 // SectionImage relationship methods
 
 func (repo *ClioRepo) CreateSectionImage(ctx context.Context, sectionImage *ssg.SectionImage) error {
@@ -1139,6 +1220,475 @@ func (repo *ClioRepo) GetSectionImagesBySectionID(ctx context.Context, sectionID
 	return sectionImages, err
 }
 
+// GetAllSectionImages returns the section-image relationships of the site in context.
+func (repo *ClioRepo) GetAllSectionImages(ctx context.Context) ([]ssg.SectionImage, error) {
+	siteID, ok := ssg.GetSiteIDFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("no site ID in context")
+	}
+
+	query := `
+		SELECT si.id, si.section_id, si.image_id, si.is_header, si.is_featured, si.order_num, si.created_at
+		FROM section_images si
+		JOIN section s ON s.id = si.section_id
+		WHERE s.site_id = ?
+		ORDER BY si.section_id, si.order_num
+	`
+	var sectionImages []ssg.SectionImage
+	err := repo.db.SelectContext(ctx, &sectionImages, query, siteID)
+	return sectionImages, err
+}
+
+// ImageRevision related
+
+func (repo *ClioRepo) CreateImageRevision(ctx context.Context, revision *ssg.ImageRevision) error {
+	query := `
+		INSERT INTO image_revision (id, image_id, file_name, file_path, backup_path, width, height, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	_, err := repo.db.ExecContext(ctx, query,
+		revision.ID,
+		revision.ImageID,
+		revision.FileName,
+		revision.FilePath,
+		revision.BackupPath,
+		revision.Width,
+		revision.Height,
+		revision.CreatedAt,
+	)
+	return err
+}
+
+// GetImageRevisionsByImageID returns the revisions of an image, newest first.
+func (repo *ClioRepo) GetImageRevisionsByImageID(ctx context.Context, imageID uuid.UUID) ([]ssg.ImageRevision, error) {
+	query := `
+		SELECT id, image_id, file_name, file_path, backup_path, width, height, created_at
+		FROM image_revision
+		WHERE image_id = ?
+		ORDER BY created_at DESC
+	`
+	var revisions []ssg.ImageRevision
+	err := repo.db.SelectContext(ctx, &revisions, query, imageID)
+	return revisions, err
+}
+
+func (repo *ClioRepo) DeleteImageRevision(ctx context.Context, id uuid.UUID) error {
+	query := `DELETE FROM image_revision WHERE id = ?`
+	_, err := repo.db.ExecContext(ctx, query, id)
+	return err
+}
+
+// Attachment related
+
+func (repo *ClioRepo) CreateAttachment(ctx context.Context, attachment *ssg.Attachment) error {
+	query, err := repo.BaseRepo.Query().Get(featSSG, resAttachment, "Create")
+	if err != nil {
+		return fmt.Errorf("cannot get create attachment query: %w", err)
+	}
+	if _, err = repo.db.NamedExecContext(ctx, query, attachment); err != nil {
+		return fmt.Errorf("cannot create attachment: %w", err)
+	}
+	return nil
+}
+
+func (repo *ClioRepo) GetAttachment(ctx context.Context, id uuid.UUID) (ssg.Attachment, error) {
+	query, err := repo.BaseRepo.Query().Get(featSSG, resAttachment, "Get")
+	if err != nil {
+		return ssg.Attachment{}, fmt.Errorf("cannot get attachment query: %w", err)
+	}
+
+	var attachment ssg.Attachment
+	err = repo.db.GetContext(ctx, &attachment, query, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ssg.Attachment{}, errors.New("attachment not found")
+		}
+		return ssg.Attachment{}, fmt.Errorf("cannot get attachment: %w", err)
+	}
+
+	return attachment, nil
+}
+
+func (repo *ClioRepo) UpdateAttachment(ctx context.Context, attachment *ssg.Attachment) error {
+	query, err := repo.BaseRepo.Query().Get(featSSG, resAttachment, "Update")
+	if err != nil {
+		return fmt.Errorf("cannot get update attachment query: %w", err)
+	}
+	if _, err = repo.db.NamedExecContext(ctx, query, attachment); err != nil {
+		return fmt.Errorf("cannot update attachment: %w", err)
+	}
+	return nil
+}
+
+func (repo *ClioRepo) DeleteAttachment(ctx context.Context, id uuid.UUID) error {
+	query, err := repo.BaseRepo.Query().Get(featSSG, resAttachment, "Delete")
+	if err != nil {
+		return fmt.Errorf("cannot get delete attachment query: %w", err)
+	}
+	_, err = repo.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("cannot delete attachment: %w", err)
+	}
+	return nil
+}
+
+// ListAttachments returns the attachments of the site in context, newest first.
+func (repo *ClioRepo) ListAttachments(ctx context.Context) ([]ssg.Attachment, error) {
+	siteID, ok := ssg.GetSiteIDFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("no site ID in context")
+	}
+
+	query, err := repo.BaseRepo.Query().Get(featSSG, resAttachment, "List")
+	if err != nil {
+		return nil, fmt.Errorf("cannot get list attachments query: %w", err)
+	}
+
+	var attachments []ssg.Attachment
+	err = repo.db.SelectContext(ctx, &attachments, query, siteID)
+	if err != nil {
+		return nil, fmt.Errorf("cannot list attachments: %w", err)
+	}
+
+	return attachments, nil
+}
+
+// ContentAttachment relationship methods
+
+func (repo *ClioRepo) CreateContentAttachment(ctx context.Context, contentAttachment *ssg.ContentAttachment) error {
+	query := `
+		INSERT INTO content_attachments (id, content_id, attachment_id, order_num, created_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(content_id, attachment_id) DO NOTHING
+	`
+	_, err := repo.db.ExecContext(ctx, query,
+		contentAttachment.ID,
+		contentAttachment.ContentID,
+		contentAttachment.AttachmentID,
+		contentAttachment.OrderNum,
+		contentAttachment.CreatedAt,
+	)
+	return err
+}
+
+func (repo *ClioRepo) DeleteContentAttachment(ctx context.Context, contentID, attachmentID uuid.UUID) error {
+	query := `DELETE FROM content_attachments WHERE content_id = ? AND attachment_id = ?`
+	_, err := repo.db.ExecContext(ctx, query, contentID, attachmentID)
+	return err
+}
+
+func (repo *ClioRepo) GetContentAttachmentsByContentID(ctx context.Context, contentID uuid.UUID) ([]ssg.ContentAttachment, error) {
+	query := `
+		SELECT id, content_id, attachment_id, order_num, created_at
+		FROM content_attachments
+		WHERE content_id = ?
+		ORDER BY order_num, created_at
+	`
+	var contentAttachments []ssg.ContentAttachment
+	err := repo.db.SelectContext(ctx, &contentAttachments, query, contentID)
+	return contentAttachments, err
+}
+
+// PublishRecord related
+
+const publishRecordColumns = `id, site_id, target, status, reference, commit_hash, summary, error,
+		duration_ms, snapshot_path, rollback_of, published_by, created_at`
+
+func (repo *ClioRepo) CreatePublishRecord(ctx context.Context, record *ssg.PublishRecord) error {
+	query := `
+		INSERT INTO publish_record (` + publishRecordColumns + `)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	_, err := repo.db.ExecContext(ctx, query,
+		record.ID,
+		record.SiteID,
+		record.Target,
+		record.Status,
+		record.Reference,
+		record.CommitHash,
+		record.Summary,
+		record.Error,
+		record.DurationMS,
+		record.SnapshotPath,
+		record.RollbackOf,
+		record.PublishedBy,
+		record.CreatedAt,
+	)
+	return err
+}
+
+func (repo *ClioRepo) GetPublishRecord(ctx context.Context, id uuid.UUID) (ssg.PublishRecord, error) {
+	query := `SELECT ` + publishRecordColumns + ` FROM publish_record WHERE id = ?`
+
+	var record ssg.PublishRecord
+	err := repo.db.GetContext(ctx, &record, query, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ssg.PublishRecord{}, errors.New("publish record not found")
+		}
+		return ssg.PublishRecord{}, fmt.Errorf("cannot get publish record: %w", err)
+	}
+	return record, nil
+}
+
+// ListPublishRecords returns the publish history of a site, newest first.
+func (repo *ClioRepo) ListPublishRecords(ctx context.Context, siteID uuid.UUID) ([]ssg.PublishRecord, error) {
+	query := `
+		SELECT ` + publishRecordColumns + `
+		FROM publish_record
+		WHERE site_id = ?
+		ORDER BY created_at DESC
+	`
+	var records []ssg.PublishRecord
+	err := repo.db.SelectContext(ctx, &records, query, siteID)
+	return records, err
+}
+
+func (repo *ClioRepo) ClearPublishRecordSnapshot(ctx context.Context, id uuid.UUID) error {
+	query := `UPDATE publish_record SET snapshot_path = '' WHERE id = ?`
+	_, err := repo.db.ExecContext(ctx, query, id)
+	return err
+}
+
+// Redirect related
+
+const redirectColumns = `id, site_id, from_path, content_id, to_path, source, created_at`
+
+// SaveRedirect creates a redirect, replacing the target of the one the site
+// may already have from the same path.
+func (repo *ClioRepo) SaveRedirect(ctx context.Context, redirect *ssg.Redirect) error {
+	query := `
+		INSERT INTO redirect (` + redirectColumns + `)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(site_id, from_path) DO UPDATE SET
+			content_id = excluded.content_id,
+			to_path = excluded.to_path,
+			source = excluded.source
+	`
+	_, err := repo.getExec(ctx).ExecContext(ctx, query,
+		redirect.ID,
+		redirect.SiteID,
+		redirect.FromPath,
+		redirect.ContentID,
+		redirect.ToPath,
+		redirect.Source,
+		redirect.CreatedAt,
+	)
+	return err
+}
+
+// ListRedirects returns the redirects of a site ordered by path.
+func (repo *ClioRepo) ListRedirects(ctx context.Context, siteID uuid.UUID) ([]ssg.Redirect, error) {
+	query := `
+		SELECT ` + redirectColumns + `
+		FROM redirect
+		WHERE site_id = ?
+		ORDER BY from_path
+	`
+	var redirects []ssg.Redirect
+	err := repo.db.SelectContext(ctx, &redirects, query, siteID)
+	return redirects, err
+}
+
+// DeleteRedirect removes the redirect id if it belongs to the site.
+func (repo *ClioRepo) DeleteRedirect(ctx context.Context, siteID, id uuid.UUID) error {
+	query := `DELETE FROM redirect WHERE id = ? AND site_id = ?`
+	res, err := repo.db.ExecContext(ctx, query, id, siteID)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return errors.New("redirect not found")
+	}
+	return nil
+}
+
+// Site bundle related
+
+// siteImageIDs selects the images of the site ?1 as the media library lists
+// them: those it owns and those its contents and sections link, including
+// images uploaded before they were given a site.
+const siteImageIDs = `SELECT id FROM image WHERE site_id = ?1
+	UNION SELECT ci.image_id FROM content_images ci JOIN content c ON c.id = ci.content_id WHERE c.site_id = ?1
+	UNION SELECT si.image_id FROM section_images si JOIN section s ON s.id = si.section_id WHERE s.site_id = ?1`
+
+// unownedSiteImageIDs selects the images without a site that only the site
+// ?1 links and whose path the site does not use yet.
+const unownedSiteImageIDs = `SELECT i.id FROM image i
+	WHERE i.site_id IN ('', '00000000-0000-0000-0000-000000000000')
+		AND i.id IN (` + siteImageIDs + `)
+		AND NOT EXISTS (SELECT 1 FROM content_images ci JOIN content c ON c.id = ci.content_id WHERE ci.image_id = i.id AND c.site_id != ?1)
+		AND NOT EXISTS (SELECT 1 FROM section_images si JOIN section s ON s.id = si.section_id WHERE si.image_id = i.id AND s.site_id != ?1)
+		AND NOT EXISTS (SELECT 1 FROM image o WHERE o.site_id = ?1 AND o.file_path = i.file_path)`
+
+// siteTables are the tables holding the data of a site, in the order their rows
+// are restored, with the condition selecting the rows the site owns and, when
+// it exports more than those, the condition selecting the exported rows.
+// Users and their site roles belong to the instance and stay out.
+var siteTables = []struct {
+	name   string
+	where  string
+	export string
+}{
+	{"site", "id = ?", ""},
+	{"image", "site_id = ?", "id IN (" + siteImageIDs + ")"},
+	{"layout", "site_id = ?", ""},
+	{"section", "site_id = ?", ""},
+	{"content", "site_id = ?", ""},
+	{"meta", "site_id = ?", ""},
+	{"tag", "site_id = ?", ""},
+	{"content_tag", "content_id IN (SELECT id FROM content WHERE site_id = ?)", ""},
+	{"param", "site_id = ?", ""},
+	{"image_variant", "image_id IN (SELECT id FROM image WHERE site_id = ?)", "image_id IN (" + siteImageIDs + ")"},
+	{"image_revision", "image_id IN (SELECT id FROM image WHERE site_id = ?)", "image_id IN (" + siteImageIDs + ")"},
+	{"content_images", "content_id IN (SELECT id FROM content WHERE site_id = ?)", ""},
+	{"section_images", "section_id IN (SELECT id FROM section WHERE site_id = ?)", ""},
+	{"attachment", "site_id = ?", ""},
+	{"content_attachments", "content_id IN (SELECT id FROM content WHERE site_id = ?)", ""},
+	{"redirect", "site_id = ?", ""},
+	{"publish_record", "site_id = ?", ""},
+}
+
+// SchemaVersion returns the datetime of the last migration applied.
+func (repo *ClioRepo) SchemaVersion(ctx context.Context) (string, error) {
+	var version sql.NullString
+	err := repo.db.GetContext(ctx, &version, `SELECT MAX(datetime) FROM migrations`)
+	return version.String, err
+}
+
+// ExportSiteTables returns every row of the site in the tables of siteTables,
+// the images it links but does not own included. Text and times are returned
+// as the text SQLite keeps.
+func (repo *ClioRepo) ExportSiteTables(ctx context.Context, siteID uuid.UUID) ([]ssg.SiteTable, error) {
+	var tables []ssg.SiteTable
+	for _, t := range siteTables {
+		where := t.where
+		if t.export != "" {
+			where = t.export
+		}
+		query := `SELECT * FROM ` + t.name + ` WHERE ` + where + ` ORDER BY rowid`
+		rows, err := repo.db.QueryxContext(ctx, query, siteID)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read %s: %w", t.name, err)
+		}
+
+		table := ssg.SiteTable{Name: t.name, Rows: []map[string]interface{}{}}
+		for rows.Next() {
+			row := map[string]interface{}{}
+			if err := rows.MapScan(row); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("cannot read %s: %w", t.name, err)
+			}
+			for col, val := range row {
+				switch v := val.(type) {
+				case []byte:
+					row[col] = string(v)
+				case time.Time:
+					row[col] = v.Format(sqlite3.SQLiteTimestampFormats[0])
+				}
+			}
+			table.Rows = append(table.Rows, row)
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return nil, fmt.Errorf("cannot read %s: %w", t.name, err)
+		}
+
+		tables = append(tables, table)
+	}
+	return tables, nil
+}
+
+// ImportSiteTables inserts the rows of a site in a single transaction. Columns
+// the tables no longer have are dropped and missing ones take their defaults,
+// so bundles of older schemas can be restored.
+func (repo *ClioRepo) ImportSiteTables(ctx context.Context, tables []ssg.SiteTable) error {
+	byName := map[string]ssg.SiteTable{}
+	for _, t := range tables {
+		known := false
+		for _, st := range siteTables {
+			known = known || st.name == t.Name
+		}
+		if !known {
+			return fmt.Errorf("unknown table %q", t.Name)
+		}
+		byName[t.Name] = t
+	}
+
+	tx, err := repo.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, st := range siteTables {
+		table, ok := byName[st.name]
+		if !ok || len(table.Rows) == 0 {
+			continue
+		}
+
+		var columns []string
+		if err := tx.SelectContext(ctx, &columns, `SELECT name FROM pragma_table_info(?)`, st.name); err != nil {
+			return fmt.Errorf("cannot get columns of %s: %w", st.name, err)
+		}
+
+		for _, row := range table.Rows {
+			var names, marks []string
+			var args []interface{}
+			for _, col := range columns {
+				val, ok := row[col]
+				if !ok {
+					continue
+				}
+				names = append(names, col)
+				marks = append(marks, "?")
+				args = append(args, val)
+			}
+
+			query := `INSERT INTO ` + st.name + ` (` + strings.Join(names, ", ") + `) VALUES (` + strings.Join(marks, ", ") + `)`
+			if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+				return fmt.Errorf("cannot insert into %s: %w", st.name, err)
+			}
+		}
+	}
+
+	return tx.Commit()
+}
+
+// PurgeSiteData deletes every row of the site, the site record and the roles
+// of users in it included, in a single transaction. Images without a site
+// that only the site links go with it. Tables are emptied in the reverse order
+// of siteTables so the rows selecting others go first.
+func (repo *ClioRepo) PurgeSiteData(ctx context.Context, siteID uuid.UUID) error {
+	tx, err := repo.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `UPDATE image SET site_id = ?1 WHERE id IN (` + unownedSiteImageIDs + `)`
+	if _, err := tx.ExecContext(ctx, query, siteID); err != nil {
+		return fmt.Errorf("cannot take over images without a site: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM user_site WHERE site_id = ?`, siteID); err != nil {
+		return fmt.Errorf("cannot delete from user_site: %w", err)
+	}
+	for i := len(siteTables) - 1; i >= 0; i-- {
+		t := siteTables[i]
+		if _, err := tx.ExecContext(ctx, `DELETE FROM `+t.name+` WHERE `+t.where, siteID); err != nil {
+			return fmt.Errorf("cannot delete from %s: %w", t.name, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
 // Site related
 
 func (repo *ClioRepo) GetSiteBySlug(ctx context.Context, slug string) (ssg.Site, error) {
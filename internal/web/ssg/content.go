@@ -21,10 +21,13 @@ type Content struct {
 	SectionID   uuid.UUID  `json:"section_id"`
 	Kind        string     `json:"kind"`
 	Heading     string     `json:"heading"`
+	Summary     string     `json:"summary"`
 	Body        string     `json:"body"`
 	Image       string     `json:"image"`
 	Draft       bool       `json:"draft"`
 	Featured    bool       `json:"featured"`
+	Series      string     `json:"series,omitempty"`
+	SeriesOrder int        `json:"series_order,omitempty"`
 	PublishedAt *time.Time `json:"published_at"`
 	Tags        []feat.Tag `json:"tags"`
 	Meta        feat.Meta  `json:"meta"`
@@ -115,10 +118,13 @@ func ToWebContent(featContent feat.Content) Content {
 		SectionID:   featContent.SectionID,
 		Kind:        featContent.Kind,
 		Heading:     featContent.Heading,
+		Summary:     featContent.Summary,
 		Body:        featContent.Body,
 		Image:       "",
 		Draft:       featContent.Draft,
 		Featured:    featContent.Featured,
+		Series:      featContent.Series,
+		SeriesOrder: featContent.SeriesOrder,
 		PublishedAt: featContent.PublishedAt,
 		Tags:        featContent.Tags,
 		Meta:        featContent.Meta,
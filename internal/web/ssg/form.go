@@ -23,10 +23,13 @@ type ContentForm struct {
 	SectionID   string `json:"section_id"`
 	Kind        string `json:"kind"`
 	Heading     string `json:"heading"`
+	Summary     string `json:"summary"`
 	Body        string `json:"body"`
 	Image       string `json:"image"`
 	Draft       bool   `json:"draft"`
 	Featured    bool   `json:"featured"`
+	Series      string `json:"series"`
+	SeriesOrder int    `json:"series_order"`
 	PublishedAt string `json:"published_at"`
 	Tags        string `json:"tags"`
 
@@ -60,11 +63,14 @@ func ContentFormFromRequest(r *http.Request) (ContentForm, error) {
 	form.SectionID = r.Form.Get("section_id")
 	form.Kind = r.Form.Get("kind")
 	form.Heading = r.Form.Get("heading")
+	form.Summary = r.Form.Get("summary")
 	form.Body = r.Form.Get("body")
 	form.Image = r.Form.Get("image")
 	form.Tags = r.Form.Get("tags")
 	form.Draft, _ = strconv.ParseBool(r.Form.Get("draft"))
 	form.Featured, _ = strconv.ParseBool(r.Form.Get("featured"))
+	form.Series = r.Form.Get("series")
+	form.SeriesOrder, _ = strconv.Atoi(r.Form.Get("series_order"))
 	form.PublishedAt = r.Form.Get("published_at")
 
 	// Meta fields
@@ -109,6 +115,9 @@ func ToFeatContent(form ContentForm) feat.Content {
 	// TODO: Handle image via relationship
 	content.Draft = form.Draft
 	content.Featured = form.Featured
+	content.Summary = form.Summary
+	content.Series = form.Series
+	content.SeriesOrder = form.SeriesOrder
 
 	if form.PublishedAt != "" {
 		// Try parsing multiple formats, starting with RFC3339
@@ -0,0 +1,70 @@
+package ssg
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+
+	feat "github.com/hermesgen/clio/internal/feat/ssg"
+	"github.com/hermesgen/hm"
+)
+
+// ListAttachments shows the downloadable files of the site.
+func (h *WebHandler) ListAttachments(w http.ResponseWriter, r *http.Request) {
+	h.Log().Info("List attachments")
+
+	var response struct {
+		Attachments []feat.Attachment `json:"attachments"`
+	}
+	err := h.apiClient.Get(h.addSiteSlugHeader(r), "/ssg/attachments", &response)
+	if err != nil {
+		h.Err(w, err, "Cannot get attachments from API", http.StatusInternalServerError)
+		return
+	}
+
+	page := hm.NewPage(r, response.Attachments)
+	page.Name = "Attachments"
+	page.Form.SetAction("/ssg/delete-attachment")
+	page.SetFlash(h.GetFlash(r))
+
+	tmpl, err := h.Tmpl().Get(ssgFeat, "list-attachments")
+	if err != nil {
+		h.Err(w, err, hm.ErrTemplateNotFound, http.StatusInternalServerError)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err = tmpl.Execute(&buf, page); err != nil {
+		h.Err(w, err, hm.ErrCannotRenderTemplate, http.StatusInternalServerError)
+		return
+	}
+
+	h.OK(w, r, &buf, http.StatusOK)
+}
+
+// DeleteAttachment removes an attachment and its file.
+func (h *WebHandler) DeleteAttachment(w http.ResponseWriter, r *http.Request) {
+	h.Log().Info("Delete attachment")
+
+	if err := r.ParseForm(); err != nil {
+		h.Err(w, err, "Failed to parse form", http.StatusBadRequest)
+		return
+	}
+
+	idStr := r.Form.Get("id")
+	if idStr == "" {
+		h.Err(w, nil, "Missing attachment ID", http.StatusBadRequest)
+		return
+	}
+
+	path := fmt.Sprintf("/ssg/attachments/%s", idStr)
+	err := h.apiClient.Delete(h.addSiteSlugHeader(r), path)
+	if err != nil {
+		h.FlashError(w, r, fmt.Sprintf("Failed to delete attachment: %v", err))
+		h.Redir(w, r, "/ssg/list-attachments", http.StatusSeeOther)
+		return
+	}
+
+	h.FlashInfo(w, r, "Attachment deleted successfully")
+	h.Redir(w, r, "/ssg/list-attachments", http.StatusSeeOther)
+}
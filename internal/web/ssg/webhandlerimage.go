@@ -300,6 +300,69 @@ func (h *WebHandler) DeleteImage(w http.ResponseWriter, r *http.Request) {
 	h.Redir(w, r, hm.ListPath(&Image{}), http.StatusSeeOther)
 }
 
+// ListOrphanImages shows a dry run report of orphaned image files and records.
+func (h *WebHandler) ListOrphanImages(w http.ResponseWriter, r *http.Request) {
+	h.Log().Info("List orphan images")
+
+	var response struct {
+		Report feat.OrphanReport `json:"report"`
+	}
+	err := h.apiClient.Get(h.addSiteSlugHeader(r), "/ssg/images/orphans", &response)
+	if err != nil {
+		h.Err(w, err, "Cannot get orphaned images from API", http.StatusInternalServerError)
+		return
+	}
+
+	page := hm.NewPage(r, response.Report)
+	page.Name = "Orphaned Images"
+	page.Form.SetAction("/ssg/purge-orphan-images")
+
+	tmpl, err := h.Tmpl().Get(ssgFeat, "list-orphan-images")
+	if err != nil {
+		h.Err(w, err, hm.ErrTemplateNotFound, http.StatusInternalServerError)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err = tmpl.Execute(&buf, page); err != nil {
+		h.Err(w, err, hm.ErrCannotRenderTemplate, http.StatusInternalServerError)
+		return
+	}
+
+	h.OK(w, r, &buf, http.StatusOK)
+}
+
+// PurgeOrphanImages removes orphaned image files and records after confirmation.
+func (h *WebHandler) PurgeOrphanImages(w http.ResponseWriter, r *http.Request) {
+	h.Log().Info("Purge orphan images")
+
+	if err := r.ParseForm(); err != nil {
+		h.Err(w, err, "Failed to parse form", http.StatusBadRequest)
+		return
+	}
+
+	if r.Form.Get("confirm") != "yes" {
+		h.FlashError(w, r, "Please confirm the purge before removing orphaned images")
+		h.Redir(w, r, "/ssg/list-orphan-images", http.StatusSeeOther)
+		return
+	}
+
+	var response struct {
+		Report feat.OrphanReport `json:"report"`
+	}
+	req := feat.PurgeOrphanedImagesRequest{Confirm: true}
+	err := h.apiClient.Post(h.addSiteSlugHeader(r), "/ssg/images/orphans/purge", req, &response)
+	if err != nil {
+		h.FlashError(w, r, fmt.Sprintf("Failed to purge orphaned images: %v", err))
+		h.Redir(w, r, "/ssg/list-orphan-images", http.StatusSeeOther)
+		return
+	}
+
+	report := response.Report
+	h.FlashSuccess(w, r, fmt.Sprintf("Removed %d orphan files and %d images without file", len(report.OrphanFiles), len(report.MissingFiles)))
+	h.Redir(w, r, "/ssg/list-orphan-images", http.StatusSeeOther)
+}
+
 func (h *WebHandler) renderImageForm(w http.ResponseWriter, r *http.Request, form ImageForm, image Image, errorMessage string, statusCode int) {
 	page := hm.NewPage(r, image)
 	page.SetForm(&form)
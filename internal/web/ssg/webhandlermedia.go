@@ -0,0 +1,205 @@
+package ssg
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	feat "github.com/hermesgen/clio/internal/feat/ssg"
+	"github.com/hermesgen/hm"
+)
+
+// ListMedia shows the media library, paginated and filtered by search.
+func (h *WebHandler) ListMedia(w http.ResponseWriter, r *http.Request) {
+	h.Log().Info("List media")
+
+	query := r.URL.Query()
+	searchQuery := query.Get("search")
+	pageStr := query.Get("page")
+
+	page := 1
+	if pageStr != "" {
+		if p, err := strconv.Atoi(pageStr); err == nil && p > 0 {
+			page = p
+		}
+	}
+
+	var response struct {
+		Images     []feat.LibraryImage `json:"images"`
+		Page       int                 `json:"page"`
+		TotalPages int                 `json:"total_pages"`
+		TotalCount int                 `json:"total_count"`
+		Search     string              `json:"search"`
+	}
+
+	path := fmt.Sprintf("/ssg/library/images?page=%d", page)
+	if searchQuery != "" {
+		path += "&search=" + url.QueryEscape(searchQuery)
+	}
+
+	err := h.apiClient.Get(h.addSiteSlugHeader(r), path, &response)
+	if err != nil {
+		h.Err(w, err, "Cannot get media library from API", http.StatusInternalServerError)
+		return
+	}
+
+	pageData := struct {
+		hm.Page
+		CurrentPage int    `json:"current_page"`
+		TotalPages  int    `json:"total_pages"`
+		TotalCount  int    `json:"total_count"`
+		SearchQuery string `json:"search_query"`
+		PageNumbers []int  `json:"page_numbers"`
+	}{
+		Page:        *hm.NewPage(r, response.Images),
+		CurrentPage: response.Page,
+		TotalPages:  response.TotalPages,
+		TotalCount:  response.TotalCount,
+		SearchQuery: searchQuery,
+		PageNumbers: generatePageNumbers(response.Page, response.TotalPages),
+	}
+
+	pageData.Name = "Media Library"
+	pageData.Form.SetAction(ssgPath)
+	pageData.SetFlash(h.GetFlash(r))
+
+	tmpl, err := h.Tmpl().Get(ssgFeat, "list-media")
+	if err != nil {
+		h.Err(w, err, hm.ErrTemplateNotFound, http.StatusInternalServerError)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err = tmpl.Execute(&buf, pageData); err != nil {
+		h.Err(w, err, hm.ErrCannotRenderTemplate, http.StatusInternalServerError)
+		return
+	}
+
+	h.OK(w, r, &buf, http.StatusOK)
+}
+
+// ShowMedia shows a library image, where it is used and its metadata form.
+func (h *WebHandler) ShowMedia(w http.ResponseWriter, r *http.Request) {
+	h.Log().Info("Show media")
+
+	idStr := r.URL.Query().Get("id")
+	if idStr == "" {
+		h.Err(w, nil, "Missing image ID", http.StatusBadRequest)
+		return
+	}
+
+	var response struct {
+		Image feat.LibraryImage `json:"image"`
+	}
+	path := fmt.Sprintf("/ssg/images/%s/usage", idStr)
+	err := h.apiClient.Get(h.addSiteSlugHeader(r), path, &response)
+	if err != nil {
+		h.Err(w, err, "Cannot get image usage from API", http.StatusInternalServerError)
+		return
+	}
+
+	var revisionsResponse struct {
+		Revisions []feat.ImageRevision `json:"revisions"`
+	}
+	path = fmt.Sprintf("/ssg/images/%s/revisions", idStr)
+	err = h.apiClient.Get(h.addSiteSlugHeader(r), path, &revisionsResponse)
+	if err != nil {
+		h.Err(w, err, "Cannot get image revisions from API", http.StatusInternalServerError)
+		return
+	}
+
+	data := struct {
+		feat.LibraryImage
+		Revisions []feat.ImageRevision
+	}{
+		LibraryImage: response.Image,
+		Revisions:    revisionsResponse.Revisions,
+	}
+
+	page := hm.NewPage(r, data)
+	page.Name = "Show Media"
+	page.Form.SetAction("/ssg/update-media")
+	page.SetFlash(h.GetFlash(r))
+
+	tmpl, err := h.Tmpl().Get(ssgFeat, "show-media")
+	if err != nil {
+		h.Err(w, err, hm.ErrTemplateNotFound, http.StatusInternalServerError)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err = tmpl.Execute(&buf, page); err != nil {
+		h.Err(w, err, hm.ErrCannotRenderTemplate, http.StatusInternalServerError)
+		return
+	}
+
+	h.OK(w, r, &buf, http.StatusOK)
+}
+
+// UpdateMedia saves the title and alt text of a library image.
+func (h *WebHandler) UpdateMedia(w http.ResponseWriter, r *http.Request) {
+	h.Log().Info("Update media")
+
+	if err := r.ParseForm(); err != nil {
+		h.Err(w, err, "Failed to parse form", http.StatusBadRequest)
+		return
+	}
+
+	idStr := r.Form.Get("id")
+	if idStr == "" {
+		h.Err(w, nil, "Missing image ID", http.StatusBadRequest)
+		return
+	}
+
+	req := feat.UpdateImageMetadataRequest{
+		Title:   r.Form.Get("title"),
+		AltText: r.Form.Get("alt_text"),
+	}
+
+	showPath := "/ssg/show-media?id=" + url.QueryEscape(idStr)
+
+	path := fmt.Sprintf("/ssg/images/%s/metadata", idStr)
+	err := h.apiClient.Put(h.addSiteSlugHeader(r), path, req, nil)
+	if err != nil {
+		h.FlashError(w, r, fmt.Sprintf("Failed to update image: %v", err))
+		h.Redir(w, r, showPath, http.StatusSeeOther)
+		return
+	}
+
+	h.FlashSuccess(w, r, "Image updated successfully")
+	h.Redir(w, r, showPath, http.StatusSeeOther)
+}
+
+// RollbackMedia restores the previous file of a library image.
+func (h *WebHandler) RollbackMedia(w http.ResponseWriter, r *http.Request) {
+	h.Log().Info("Rollback media")
+
+	if err := r.ParseForm(); err != nil {
+		h.Err(w, err, "Failed to parse form", http.StatusBadRequest)
+		return
+	}
+
+	idStr := r.Form.Get("id")
+	if idStr == "" {
+		h.Err(w, nil, "Missing image ID", http.StatusBadRequest)
+		return
+	}
+
+	showPath := "/ssg/show-media?id=" + url.QueryEscape(idStr)
+
+	var response struct {
+		Replacement feat.ImageReplacement `json:"replacement"`
+	}
+	path := fmt.Sprintf("/ssg/images/%s/rollback", idStr)
+	err := h.apiClient.Post(h.addSiteSlugHeader(r), path, nil, &response)
+	if err != nil {
+		h.FlashError(w, r, fmt.Sprintf("Failed to restore previous file: %v", err))
+		h.Redir(w, r, showPath, http.StatusSeeOther)
+		return
+	}
+
+	h.FlashSuccess(w, r, fmt.Sprintf("Previous file restored, %d contents updated", len(response.Replacement.UpdatedContents)))
+	h.Redir(w, r, showPath, http.StatusSeeOther)
+}
@@ -0,0 +1,155 @@
+package ssg
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+
+	feat "github.com/hermesgen/clio/internal/feat/ssg"
+	"github.com/hermesgen/hm"
+)
+
+// ShowPublishPlan shows the changes a publish would make so they can be
+// reviewed before publishing.
+func (h *WebHandler) ShowPublishPlan(w http.ResponseWriter, r *http.Request) {
+	h.Log().Info("Show publish plan")
+
+	var response struct {
+		Plan feat.PlanReport `json:"plan"`
+	}
+	err := h.apiClient.Get(h.addSiteSlugHeader(r), "/ssg/publish/plan", &response)
+	if err != nil {
+		h.FlashError(w, r, fmt.Sprintf("Failed to plan publish: %v", err))
+		h.Redir(w, r, "/ssg/list-content", http.StatusSeeOther)
+		return
+	}
+
+	var quality struct {
+		Report feat.QualityReport `json:"report"`
+	}
+	err = h.apiClient.Get(h.addSiteSlugHeader(r), "/ssg/publish/quality", &quality)
+	if err != nil {
+		h.FlashError(w, r, fmt.Sprintf("Failed to run quality checks: %v", err))
+		h.Redir(w, r, "/ssg/list-content", http.StatusSeeOther)
+		return
+	}
+
+	data := struct {
+		Plan    feat.PlanReport
+		Quality feat.QualityReport
+	}{
+		Plan:    response.Plan,
+		Quality: quality.Report,
+	}
+
+	page := hm.NewPage(r, data)
+	page.Name = "Publish Plan"
+	page.Form.SetAction("/ssg/publish")
+	page.SetFlash(h.GetFlash(r))
+
+	tmpl, err := h.Tmpl().Get(ssgFeat, "show-publish-plan")
+	if err != nil {
+		h.Err(w, err, hm.ErrTemplateNotFound, http.StatusInternalServerError)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err = tmpl.Execute(&buf, page); err != nil {
+		h.Err(w, err, hm.ErrCannotRenderTemplate, http.StatusInternalServerError)
+		return
+	}
+
+	h.OK(w, r, &buf, http.StatusOK)
+}
+
+// Publish publishes the generated site with an optional commit message.
+func (h *WebHandler) Publish(w http.ResponseWriter, r *http.Request) {
+	h.Log().Info("Publish")
+
+	if err := r.ParseForm(); err != nil {
+		h.Err(w, err, "Failed to parse form", http.StatusBadRequest)
+		return
+	}
+
+	req := feat.PublishRequest{
+		Message: r.Form.Get("message"),
+		Force:   r.Form.Get("force") == "on",
+	}
+
+	var response struct {
+		CommitURL string `json:"commitURL"`
+	}
+	err := h.apiClient.Post(h.addSiteSlugHeader(r), "/ssg/publish", req, &response)
+	if err != nil {
+		h.FlashError(w, r, fmt.Sprintf("Failed to publish: %v", err))
+		h.Redir(w, r, "/ssg/publish-plan", http.StatusSeeOther)
+		return
+	}
+
+	h.FlashSuccess(w, r, fmt.Sprintf("Site published: %s", response.CommitURL))
+	h.Redir(w, r, "/ssg/publish-history", http.StatusSeeOther)
+}
+
+// ListPublishHistory shows past publishes of the site and lets a previous
+// one be published again.
+func (h *WebHandler) ListPublishHistory(w http.ResponseWriter, r *http.Request) {
+	h.Log().Info("List publish history")
+
+	var response struct {
+		Records []feat.PublishRecord `json:"records"`
+	}
+	err := h.apiClient.Get(h.addSiteSlugHeader(r), "/ssg/publish/history", &response)
+	if err != nil {
+		h.Err(w, err, "Cannot get publish history from API", http.StatusInternalServerError)
+		return
+	}
+
+	page := hm.NewPage(r, response.Records)
+	page.Name = "Publish History"
+	page.Form.SetAction("/ssg/rollback-publish")
+	page.SetFlash(h.GetFlash(r))
+
+	tmpl, err := h.Tmpl().Get(ssgFeat, "list-publish-history")
+	if err != nil {
+		h.Err(w, err, hm.ErrTemplateNotFound, http.StatusInternalServerError)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err = tmpl.Execute(&buf, page); err != nil {
+		h.Err(w, err, hm.ErrCannotRenderTemplate, http.StatusInternalServerError)
+		return
+	}
+
+	h.OK(w, r, &buf, http.StatusOK)
+}
+
+// RollbackPublish re-publishes the output of a previous publish.
+func (h *WebHandler) RollbackPublish(w http.ResponseWriter, r *http.Request) {
+	h.Log().Info("Rollback publish")
+
+	if err := r.ParseForm(); err != nil {
+		h.Err(w, err, "Failed to parse form", http.StatusBadRequest)
+		return
+	}
+
+	idStr := r.Form.Get("id")
+	if idStr == "" {
+		h.Err(w, nil, "Missing publish record ID", http.StatusBadRequest)
+		return
+	}
+
+	var response struct {
+		Record feat.PublishRecord `json:"record"`
+	}
+	path := fmt.Sprintf("/ssg/publish/history/%s/rollback", idStr)
+	err := h.apiClient.Post(h.addSiteSlugHeader(r), path, nil, &response)
+	if err != nil {
+		h.FlashError(w, r, fmt.Sprintf("Failed to roll back: %v", err))
+		h.Redir(w, r, "/ssg/publish-history", http.StatusSeeOther)
+		return
+	}
+
+	h.FlashSuccess(w, r, fmt.Sprintf("Previous output published again: %s", response.Record.Reference))
+	h.Redir(w, r, "/ssg/publish-history", http.StatusSeeOther)
+}
@@ -10,20 +10,39 @@ import (
 	"github.com/hermesgen/hm"
 )
 
-// NOTE: Site handlers use SiteManager directly instead of API client.
-// TODO: Add API endpoints for sites to support API-only mode (e.g., Neovim plugin).
+// NOTE: Site handlers use SiteManager directly instead of API client, as they
+// work with no site selected. API clients manage sites through the same
+// SiteManager operations under /api/v1/ssg/sites.
 
 func (wh *WebHandler) ListSites(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
-	sites, err := wh.siteManager.ListSites(ctx, true)
+	sites, err := wh.siteManager.ListSites(ctx, false)
 	if err != nil {
 		wh.Log().Error("Failed to get sites", "error", err)
 		wh.Err(w, err, "Cannot get sites", http.StatusInternalServerError)
 		return
 	}
 
-	page := hm.NewPage(r, sites)
+	var active, archived []feat.Site
+	for _, site := range sites {
+		if site.Active != 0 {
+			active = append(active, site)
+		} else {
+			archived = append(archived, site)
+		}
+	}
+
+	orphans, err := wh.siteManager.OrphanedSites(ctx)
+	if err != nil {
+		wh.Log().Error("Failed to get orphaned sites", "error", err)
+	}
+
+	page := hm.NewPage(r, map[string]interface{}{
+		"Sites":    active,
+		"Archived": archived,
+		"Orphans":  orphans,
+	})
 	page.Form.SetAction(ssgPath)
 
 	tmpl, err := wh.Tmpl().Get(ssgFeat, "list-sites")
@@ -44,7 +63,16 @@ func (wh *WebHandler) ListSites(w http.ResponseWriter, r *http.Request) {
 }
 
 func (wh *WebHandler) NewSite(w http.ResponseWriter, r *http.Request) {
-	page := hm.NewPage(r, nil)
+	sites, err := wh.siteManager.ListSites(r.Context(), true)
+	if err != nil {
+		wh.Err(w, err, "Cannot list sites", http.StatusInternalServerError)
+		return
+	}
+
+	page := hm.NewPage(r, map[string]interface{}{
+		"Sites": sites,
+		"From":  r.URL.Query().Get("from"),
+	})
 	page.Form.SetAction(ssgPath)
 
 	tmpl, err := wh.Tmpl().Get(ssgFeat, "new-site")
@@ -76,6 +104,12 @@ func (wh *WebHandler) CreateSite(w http.ResponseWriter, r *http.Request) {
 	name := r.FormValue("name")
 	slug := r.FormValue("slug")
 	mode := r.FormValue("mode")
+	from := r.FormValue("from")
+
+	if from != "" {
+		wh.cloneSite(w, r, from, name, slug)
+		return
+	}
 
 	if name == "" || slug == "" || mode == "" {
 		wh.FlashError(w, r, "Name, slug, and mode are required")
@@ -105,6 +139,41 @@ func (wh *WebHandler) CreateSite(w http.ResponseWriter, r *http.Request) {
 	http.Redirect(w, r, "/ssg/sites", http.StatusSeeOther)
 }
 
+// cloneSite creates the site from the form of NewSite starting from the site
+// with ID from.
+func (wh *WebHandler) cloneSite(w http.ResponseWriter, r *http.Request, from, name, slug string) {
+	sourceID, err := uuid.Parse(from)
+	if err != nil {
+		wh.FlashError(w, r, "Invalid site ID")
+		http.Redirect(w, r, "/ssg/sites/new", http.StatusSeeOther)
+		return
+	}
+
+	if name == "" || feat.NormalizeSlug(slug) == "" {
+		wh.FlashError(w, r, "Name and slug are required")
+		http.Redirect(w, r, "/ssg/sites/new?from="+from, http.StatusSeeOther)
+		return
+	}
+
+	opts := feat.CloneOptions{
+		Content: r.FormValue("content") == "on",
+		Images:  r.FormValue("images") == "on",
+	}
+
+	userID := uuid.New()
+
+	site, err := wh.siteManager.CloneSite(r.Context(), sourceID, name, slug, opts, userID)
+	if err != nil {
+		wh.Log().Error("Failed to clone site", "error", err)
+		wh.FlashError(w, r, "Failed to clone site: "+err.Error())
+		http.Redirect(w, r, "/ssg/sites/new?from="+from, http.StatusSeeOther)
+		return
+	}
+
+	wh.FlashInfo(w, r, fmt.Sprintf("Site '%s' created from a clone", site.Slug()))
+	http.Redirect(w, r, "/ssg/sites", http.StatusSeeOther)
+}
+
 func (wh *WebHandler) SwitchSite(w http.ResponseWriter, r *http.Request) {
 	slug := r.URL.Query().Get("slug")
 	if slug == "" {
@@ -124,38 +193,266 @@ func (wh *WebHandler) SwitchSite(w http.ResponseWriter, r *http.Request) {
 	http.Redirect(w, r, "/ssg/list-content?site="+slug, http.StatusSeeOther)
 }
 
-func (wh *WebHandler) DeleteSite(w http.ResponseWriter, r *http.Request) {
-	ctx := r.Context()
+func (wh *WebHandler) EditSite(w http.ResponseWriter, r *http.Request) {
+	siteID, err := uuid.Parse(r.URL.Query().Get("id"))
+	if err != nil {
+		wh.FlashError(w, r, "Invalid site ID")
+		http.Redirect(w, r, "/ssg/sites", http.StatusSeeOther)
+		return
+	}
 
-	idStr := r.URL.Query().Get("id")
-	if idStr == "" {
-		wh.FlashError(w, r, "Site ID is required")
+	site, err := wh.siteManager.GetSite(r.Context(), siteID)
+	if err != nil {
+		wh.FlashError(w, r, "Site not found")
 		http.Redirect(w, r, "/ssg/sites", http.StatusSeeOther)
 		return
 	}
 
-	siteID, err := uuid.Parse(idStr)
+	page := hm.NewPage(r, &site)
+	page.Form.SetAction(ssgPath)
+
+	tmpl, err := wh.Tmpl().Get(ssgFeat, "edit-site")
+	if err != nil {
+		wh.Err(w, err, hm.ErrTemplateNotFound, http.StatusInternalServerError)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, page); err != nil {
+		wh.Err(w, err, hm.ErrCannotRenderTemplate, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf.Bytes())
+}
+
+// RenameSite changes the name and slug of a site. If the site is the one
+// selected in this browser, the session and last site cookie follow the new
+// slug.
+func (wh *WebHandler) RenameSite(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		wh.FlashError(w, r, "Invalid form data")
+		http.Redirect(w, r, "/ssg/sites", http.StatusSeeOther)
+		return
+	}
+
+	siteID, err := uuid.Parse(r.FormValue("id"))
+	if err != nil {
+		wh.FlashError(w, r, "Invalid site ID")
+		http.Redirect(w, r, "/ssg/sites", http.StatusSeeOther)
+		return
+	}
+	editPath := "/ssg/sites/edit?id=" + siteID.String()
+
+	site, err := wh.siteManager.GetSite(r.Context(), siteID)
+	if err != nil {
+		wh.FlashError(w, r, "Site not found")
+		http.Redirect(w, r, "/ssg/sites", http.StatusSeeOther)
+		return
+	}
+	oldSlug := site.Slug()
+
+	name := r.FormValue("name")
+	slug := r.FormValue("slug")
+	if name == "" || feat.NormalizeSlug(slug) == "" {
+		wh.FlashError(w, r, "Name and slug are required")
+		http.Redirect(w, r, editPath, http.StatusSeeOther)
+		return
+	}
+
+	userID := uuid.New()
+
+	site, err = wh.siteManager.RenameSite(r.Context(), siteID, name, slug, userID)
+	if err != nil {
+		wh.Log().Error("Failed to rename site", "error", err)
+		wh.FlashError(w, r, "Failed to rename site: "+err.Error())
+		http.Redirect(w, r, editPath, http.StatusSeeOther)
+		return
+	}
+
+	if site.Slug() != oldSlug {
+		if _, sessionSlug, err := wh.sessionManager.GetUserSession(r); err == nil && sessionSlug == oldSlug {
+			if err := wh.sessionManager.SetSiteSlug(w, r, site.Slug()); err != nil {
+				wh.Log().Error("Failed to update session", "error", err)
+			}
+		}
+		if cookie, err := r.Cookie("last_site"); err == nil && cookie.Value == oldSlug {
+			feat.SetLastSiteCookie(w, site.Slug())
+		}
+	}
+
+	wh.FlashInfo(w, r, fmt.Sprintf("Site '%s' saved", site.Slug()))
+	http.Redirect(w, r, "/ssg/sites", http.StatusSeeOther)
+}
+
+// ArchiveSite hides a site from the switcher, keeping all of its data.
+func (wh *WebHandler) ArchiveSite(w http.ResponseWriter, r *http.Request) {
+	siteID, err := uuid.Parse(r.URL.Query().Get("id"))
 	if err != nil {
 		wh.FlashError(w, r, "Invalid site ID")
 		http.Redirect(w, r, "/ssg/sites", http.StatusSeeOther)
 		return
 	}
 
-	siteSlug, err := wh.siteManager.DeleteSite(ctx, siteID)
+	userID := uuid.New()
+
+	site, err := wh.siteManager.ArchiveSite(r.Context(), siteID, userID)
 	if err != nil {
-		wh.Log().Error("Failed to delete site", "error", err)
-		wh.FlashError(w, r, "Failed to delete site: "+err.Error())
+		wh.Log().Error("Failed to archive site", "error", err)
+		wh.FlashError(w, r, "Failed to archive site: "+err.Error())
 		http.Redirect(w, r, "/ssg/sites", http.StatusSeeOther)
 		return
 	}
 
 	msg := fmt.Sprintf(
-		"Site '%s' deleted. Your content files have been preserved as backup. <a href='https://github.com/hermesgen/clio/tree/main/docs/guides/site-deletion.md' target='_blank' class='underline'>Learn more</a>",
-		siteSlug,
+		"Site '%s' archived. It can be restored or purged from the archived sites. <a href='https://github.com/hermesgen/clio/tree/main/docs/guides/site-deletion.md' target='_blank' class='underline'>Learn more</a>",
+		site.Slug(),
 	)
 	wh.FlashInfo(w, r, msg)
-	flash := wh.FlashManager().GetFlash(r)
-	wh.FlashManager().SetFlashInCookie(w, flash)
+	http.Redirect(w, r, "/ssg/sites", http.StatusSeeOther)
+}
+
+// UnarchiveSite makes an archived site active again.
+func (wh *WebHandler) UnarchiveSite(w http.ResponseWriter, r *http.Request) {
+	siteID, err := uuid.Parse(r.URL.Query().Get("id"))
+	if err != nil {
+		wh.FlashError(w, r, "Invalid site ID")
+		http.Redirect(w, r, "/ssg/sites", http.StatusSeeOther)
+		return
+	}
+
+	userID := uuid.New()
+
+	site, err := wh.siteManager.UnarchiveSite(r.Context(), siteID, userID)
+	if err != nil {
+		wh.Log().Error("Failed to restore archived site", "error", err)
+		wh.FlashError(w, r, "Failed to restore site: "+err.Error())
+		http.Redirect(w, r, "/ssg/sites", http.StatusSeeOther)
+		return
+	}
+
+	wh.FlashInfo(w, r, fmt.Sprintf("Site '%s' restored", site.Slug()))
+	http.Redirect(w, r, "/ssg/sites", http.StatusSeeOther)
+}
+
+// PurgeSite removes an archived site and its files for good. The form must
+// repeat the slug of the site as confirmation.
+func (wh *WebHandler) PurgeSite(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		wh.FlashError(w, r, "Invalid form data")
+		http.Redirect(w, r, "/ssg/sites", http.StatusSeeOther)
+		return
+	}
+
+	siteID, err := uuid.Parse(r.FormValue("id"))
+	if err != nil {
+		wh.FlashError(w, r, "Invalid site ID")
+		http.Redirect(w, r, "/ssg/sites", http.StatusSeeOther)
+		return
+	}
+
+	slug, err := wh.siteManager.PurgeSite(r.Context(), siteID, r.FormValue("confirm"))
+	if err != nil {
+		wh.Log().Error("Failed to purge site", "error", err)
+		wh.FlashError(w, r, "Failed to purge site: "+err.Error())
+		http.Redirect(w, r, "/ssg/sites", http.StatusSeeOther)
+		return
+	}
+	wh.paramManager.InvalidateSite(siteID)
+
+	wh.FlashInfo(w, r, fmt.Sprintf("Site '%s' and its files were permanently removed", slug))
+	http.Redirect(w, r, "/ssg/sites", http.StatusSeeOther)
+}
+
+// AttachSite creates the site record of an orphaned site directory.
+func (wh *WebHandler) AttachSite(w http.ResponseWriter, r *http.Request) {
+	slug := r.URL.Query().Get("slug")
+	if slug == "" {
+		wh.FlashError(w, r, "Site slug is required")
+		http.Redirect(w, r, "/ssg/sites", http.StatusSeeOther)
+		return
+	}
+
+	userID := uuid.New()
+
+	site, err := wh.siteManager.AttachSite(r.Context(), slug, userID)
+	if err != nil {
+		wh.Log().Error("Failed to attach site", "error", err)
+		wh.FlashError(w, r, "Failed to attach site: "+err.Error())
+		http.Redirect(w, r, "/ssg/sites", http.StatusSeeOther)
+		return
+	}
+
+	wh.FlashInfo(w, r, fmt.Sprintf("Site '%s' attached", site.Slug()))
+	http.Redirect(w, r, "/ssg/sites", http.StatusSeeOther)
+}
+
+// ExportSite downloads the bundle of a site.
+func (wh *WebHandler) ExportSite(w http.ResponseWriter, r *http.Request) {
+	siteID, err := uuid.Parse(r.URL.Query().Get("id"))
+	if err != nil {
+		wh.FlashError(w, r, "Invalid site ID")
+		http.Redirect(w, r, "/ssg/sites", http.StatusSeeOther)
+		return
+	}
+
+	bundle, err := wh.siteManager.ExportSite(r.Context(), siteID)
+	if err != nil {
+		wh.Log().Error("Failed to export site", "error", err)
+		wh.FlashError(w, r, "Failed to export site: "+err.Error())
+		http.Redirect(w, r, "/ssg/sites", http.StatusSeeOther)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", bundle.FileName()))
+	if err := bundle.Write(w); err != nil {
+		wh.Log().Error("Failed to write site bundle", "error", err)
+	}
+}
+
+func (wh *WebHandler) NewRestoreSite(w http.ResponseWriter, r *http.Request) {
+	page := hm.NewPage(r, nil)
+	page.Form.SetAction(ssgPath)
+
+	tmpl, err := wh.Tmpl().Get(ssgFeat, "restore-site")
+	if err != nil {
+		wh.Err(w, err, hm.ErrTemplateNotFound, http.StatusInternalServerError)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, page); err != nil {
+		wh.Err(w, err, hm.ErrCannotRenderTemplate, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf.Bytes())
+}
+
+// RestoreSite creates a site from an uploaded bundle.
+func (wh *WebHandler) RestoreSite(w http.ResponseWriter, r *http.Request) {
+	file, _, err := r.FormFile("bundle")
+	if err != nil {
+		wh.FlashError(w, r, "A site bundle is required")
+		http.Redirect(w, r, "/ssg/sites/restore", http.StatusSeeOther)
+		return
+	}
+	defer file.Close()
+
+	site, err := wh.siteManager.RestoreSite(r.Context(), file, r.FormValue("slug"), r.FormValue("name"))
+	if err != nil {
+		wh.Log().Error("Failed to restore site", "error", err)
+		wh.FlashError(w, r, "Failed to restore site: "+err.Error())
+		http.Redirect(w, r, "/ssg/sites/restore", http.StatusSeeOther)
+		return
+	}
+
+	wh.FlashInfo(w, r, fmt.Sprintf("Site '%s' restored", site.Slug()))
 	http.Redirect(w, r, "/ssg/sites", http.StatusSeeOther)
 }
 
@@ -0,0 +1,121 @@
+package ssg
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/google/uuid"
+	feat "github.com/hermesgen/clio/internal/feat/ssg"
+	"github.com/hermesgen/hm"
+)
+
+// ShowModeSwitch shows what switching the site to another mode would change
+// and the options to keep its contents listed before switching.
+func (h *WebHandler) ShowModeSwitch(w http.ResponseWriter, r *http.Request) {
+	h.Log().Info("Show mode switch")
+
+	current := h.paramManager.GetSiteMode(r.Context())
+	query := r.URL.Query()
+	mode := query.Get("mode")
+	if mode == "" {
+		mode = feat.ModeBlog
+		if current == feat.ModeBlog {
+			mode = feat.ModeStructured
+		}
+	}
+
+	values := url.Values{}
+	values.Set("mode", mode)
+	values.Set("kind", query.Get("kind"))
+	values.Set("section_id", query.Get("section_id"))
+
+	var response struct {
+		Plan feat.ModeSwitchPlan `json:"plan"`
+	}
+	err := h.apiClient.Get(h.addSiteSlugHeader(r), "/ssg/site-mode/plan?"+values.Encode(), &response)
+	if err != nil {
+		h.FlashError(w, r, fmt.Sprintf("Failed to plan mode switch: %v", err))
+		h.Redir(w, r, "/ssg/list-params", http.StatusSeeOther)
+		return
+	}
+
+	var sectionsResponse struct {
+		Sections []Section `json:"sections"`
+	}
+	err = h.apiClient.Get(h.addSiteSlugHeader(r), "/ssg/sections", &sectionsResponse)
+	if err != nil {
+		h.Err(w, err, "Cannot get sections from API", http.StatusInternalServerError)
+		return
+	}
+
+	data := struct {
+		Plan      feat.ModeSwitchPlan
+		Sections  []Section
+		Kind      string
+		SectionID string
+	}{
+		Plan:      response.Plan,
+		Sections:  sectionsResponse.Sections,
+		Kind:      query.Get("kind"),
+		SectionID: query.Get("section_id"),
+	}
+
+	page := hm.NewPage(r, data)
+	page.Name = "Site Mode"
+	page.Form.SetAction("/ssg/switch-site-mode")
+	page.SetFlash(h.GetFlash(r))
+
+	tmpl, err := h.Tmpl().Get(ssgFeat, "show-mode-switch")
+	if err != nil {
+		h.Err(w, err, hm.ErrTemplateNotFound, http.StatusInternalServerError)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err = tmpl.Execute(&buf, page); err != nil {
+		h.Err(w, err, hm.ErrCannotRenderTemplate, http.StatusInternalServerError)
+		return
+	}
+
+	h.OK(w, r, &buf, http.StatusOK)
+}
+
+// SwitchSiteMode switches the site to the mode of the form, reassigning and
+// redirecting its contents as chosen.
+func (h *WebHandler) SwitchSiteMode(w http.ResponseWriter, r *http.Request) {
+	h.Log().Info("Switch site mode")
+
+	if err := r.ParseForm(); err != nil {
+		h.Err(w, err, "Failed to parse form", http.StatusBadRequest)
+		return
+	}
+
+	req := feat.ModeSwitchOptions{
+		Mode:      r.Form.Get("mode"),
+		Kind:      r.Form.Get("kind"),
+		Redirects: r.Form.Get("redirects") == "on",
+	}
+	if s := r.Form.Get("section_id"); s != "" {
+		id, err := uuid.Parse(s)
+		if err != nil {
+			h.Err(w, err, "Invalid section ID", http.StatusBadRequest)
+			return
+		}
+		req.SectionID = id
+	}
+
+	var response struct {
+		Plan feat.ModeSwitchPlan `json:"plan"`
+	}
+	err := h.apiClient.Post(h.addSiteSlugHeader(r), "/ssg/site-mode", req, &response)
+	if err != nil {
+		h.FlashError(w, r, fmt.Sprintf("Failed to switch mode: %v", err))
+		h.Redir(w, r, "/ssg/site-mode?mode="+url.QueryEscape(req.Mode), http.StatusSeeOther)
+		return
+	}
+
+	h.FlashSuccess(w, r, fmt.Sprintf("Site switched to %s mode: %s", response.Plan.To, response.Plan.Summary))
+	h.Redir(w, r, "/ssg/list-content", http.StatusSeeOther)
+}
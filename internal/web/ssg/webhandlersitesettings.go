@@ -0,0 +1,135 @@
+package ssg
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	feat "github.com/hermesgen/clio/internal/feat/ssg"
+	"github.com/hermesgen/hm"
+)
+
+// settingGroup is a group of settings shown together in the settings page.
+type settingGroup struct {
+	Name     string
+	Settings []feat.SettingValue
+}
+
+// ShowSiteSettings shows the settings of the current site, laid out from the
+// settings schema.
+func (h *WebHandler) ShowSiteSettings(w http.ResponseWriter, r *http.Request) {
+	h.Log().Info("Show site settings")
+
+	settings, err := h.siteSettings(r)
+	if err != nil {
+		h.Err(w, err, "Cannot get settings from API", http.StatusInternalServerError)
+		return
+	}
+
+	var groups []settingGroup
+	for _, s := range settings {
+		if len(groups) == 0 || groups[len(groups)-1].Name != s.Group {
+			groups = append(groups, settingGroup{Name: s.Group})
+		}
+		last := &groups[len(groups)-1]
+		last.Settings = append(last.Settings, s)
+	}
+
+	page := hm.NewPage(r, struct{ Groups []settingGroup }{Groups: groups})
+	page.Name = "Site Settings"
+	page.Form.SetAction("/ssg/update-site-settings")
+	page.SetFlash(h.GetFlash(r))
+
+	tmpl, err := h.Tmpl().Get(ssgFeat, "show-site-settings")
+	if err != nil {
+		h.Err(w, err, hm.ErrTemplateNotFound, http.StatusInternalServerError)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err = tmpl.Execute(&buf, page); err != nil {
+		h.Err(w, err, hm.ErrCannotRenderTemplate, http.StatusInternalServerError)
+		return
+	}
+
+	h.OK(w, r, &buf, http.StatusOK)
+}
+
+// UpdateSiteSettings saves the settings of the form that differ from their
+// current value, so untouched settings keep being inherited.
+func (h *WebHandler) UpdateSiteSettings(w http.ResponseWriter, r *http.Request) {
+	h.Log().Info("Update site settings")
+
+	if err := r.ParseForm(); err != nil {
+		h.Err(w, err, "Failed to parse form", http.StatusBadRequest)
+		return
+	}
+
+	settings, err := h.siteSettings(r)
+	if err != nil {
+		h.Err(w, err, "Cannot get settings from API", http.StatusInternalServerError)
+		return
+	}
+
+	values := map[string]string{}
+	for _, s := range settings {
+		value := r.Form.Get(s.Key)
+		switch s.Type {
+		case feat.SettingBool:
+			value = fmt.Sprint(value == "true")
+		case feat.SettingSecret:
+			if value == "" {
+				continue
+			}
+		}
+		if value != s.Value {
+			values[s.Key] = value
+		}
+	}
+
+	if len(values) == 0 {
+		h.FlashSuccess(w, r, "No settings changed")
+		h.Redir(w, r, "/ssg/site-settings", http.StatusSeeOther)
+		return
+	}
+
+	err = h.apiClient.Put(h.addSiteSlugHeader(r), "/ssg/settings", values, nil)
+	if err != nil {
+		h.FlashError(w, r, fmt.Sprintf("Failed to update settings: %v", err))
+		h.Redir(w, r, "/ssg/site-settings", http.StatusSeeOther)
+		return
+	}
+
+	h.FlashSuccess(w, r, fmt.Sprintf("%d settings updated", len(values)))
+	h.Redir(w, r, "/ssg/site-settings", http.StatusSeeOther)
+}
+
+// ResetSiteSetting drops the value the current site has for a setting.
+func (h *WebHandler) ResetSiteSetting(w http.ResponseWriter, r *http.Request) {
+	h.Log().Info("Reset site setting")
+
+	if err := r.ParseForm(); err != nil {
+		h.Err(w, err, "Failed to parse form", http.StatusBadRequest)
+		return
+	}
+
+	key := r.Form.Get("reset")
+	err := h.apiClient.Delete(h.addSiteSlugHeader(r), "/ssg/settings/"+url.PathEscape(key))
+	if err != nil {
+		h.FlashError(w, r, fmt.Sprintf("Failed to reset setting: %v", err))
+		h.Redir(w, r, "/ssg/site-settings", http.StatusSeeOther)
+		return
+	}
+
+	h.FlashSuccess(w, r, fmt.Sprintf("%s is inherited again", key))
+	h.Redir(w, r, "/ssg/site-settings", http.StatusSeeOther)
+}
+
+func (h *WebHandler) siteSettings(r *http.Request) ([]feat.SettingValue, error) {
+	var response struct {
+		Settings []feat.SettingValue `json:"settings"`
+	}
+	err := h.apiClient.Get(h.addSiteSlugHeader(r), "/ssg/settings", &response)
+	return response.Settings, err
+}
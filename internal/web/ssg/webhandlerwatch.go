@@ -0,0 +1,70 @@
+package ssg
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+
+	feat "github.com/hermesgen/clio/internal/feat/ssg"
+	"github.com/hermesgen/hm"
+)
+
+// ListWatchedDrafts shows the watched drafts directory of the site, the files
+// waiting to be imported and the conflicts to resolve.
+func (h *WebHandler) ListWatchedDrafts(w http.ResponseWriter, r *http.Request) {
+	h.Log().Info("List watched drafts")
+
+	var response struct {
+		Status feat.WatchStatus `json:"status"`
+	}
+	err := h.apiClient.Get(h.addSiteSlugHeader(r), "/ssg/watch", &response)
+	if err != nil {
+		h.Err(w, err, "Cannot get watch status from API", http.StatusInternalServerError)
+		return
+	}
+
+	page := hm.NewPage(r, response.Status)
+	page.Name = "Watched Drafts"
+	page.Form.SetAction("/ssg/resolve-watch-conflict")
+	page.SetFlash(h.GetFlash(r))
+
+	tmpl, err := h.Tmpl().Get(ssgFeat, "list-watched-drafts")
+	if err != nil {
+		h.Err(w, err, hm.ErrTemplateNotFound, http.StatusInternalServerError)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err = tmpl.Execute(&buf, page); err != nil {
+		h.Err(w, err, hm.ErrCannotRenderTemplate, http.StatusInternalServerError)
+		return
+	}
+
+	h.OK(w, r, &buf, http.StatusOK)
+}
+
+// ResolveWatchConflict keeps either the watched file or the admin version of a
+// content in conflict.
+func (h *WebHandler) ResolveWatchConflict(w http.ResponseWriter, r *http.Request) {
+	h.Log().Info("Resolve watch conflict")
+
+	if err := r.ParseForm(); err != nil {
+		h.Err(w, err, "Failed to parse form", http.StatusBadRequest)
+		return
+	}
+
+	req := feat.ResolveWatchConflictRequest{
+		Path: r.Form.Get("path"),
+		Keep: r.Form.Get("keep"),
+	}
+
+	err := h.apiClient.Post(h.addSiteSlugHeader(r), "/ssg/watch/resolve", req, nil)
+	if err != nil {
+		h.FlashError(w, r, fmt.Sprintf("Failed to resolve conflict: %v", err))
+		h.Redir(w, r, "/ssg/watched-drafts", http.StatusSeeOther)
+		return
+	}
+
+	h.FlashSuccess(w, r, fmt.Sprintf("Conflict in %s resolved", req.Path))
+	h.Redir(w, r, "/ssg/watched-drafts", http.StatusSeeOther)
+}
@@ -12,7 +12,15 @@ func NewWebRouter(handler *WebHandler, mw []hm.Middleware, params hm.XParams) *h
 	core.Get("/sites/new", handler.NewSite)
 	core.Post("/sites/create", handler.CreateSite)
 	core.Get("/sites/switch", handler.SwitchSite)
-	core.Get("/sites/delete", handler.DeleteSite)
+	core.Get("/sites/edit", handler.EditSite)
+	core.Post("/sites/rename", handler.RenameSite)
+	core.Get("/sites/archive", handler.ArchiveSite)
+	core.Get("/sites/unarchive", handler.UnarchiveSite)
+	core.Post("/sites/purge", handler.PurgeSite)
+	core.Get("/sites/attach", handler.AttachSite)
+	core.Get("/sites/export", handler.ExportSite)
+	core.Get("/sites/restore", handler.NewRestoreSite)
+	core.Post("/sites/restore", handler.RestoreSite)
 
 	core.Get("/new-content", handler.NewContent)
 	core.Post("/create-content", handler.CreateContent)
@@ -23,6 +31,14 @@ func NewWebRouter(handler *WebHandler, mw []hm.Middleware, params hm.XParams) *h
 	core.Get("/show-content", handler.ShowContent)
 	core.Post("/delete-content", handler.DeleteContent)
 	core.Post("/generate-html", handler.GenerateHTML)
+	core.Get("/publish-plan", handler.ShowPublishPlan)
+	core.Post("/publish", handler.Publish)
+	core.Get("/publish-history", handler.ListPublishHistory)
+	core.Post("/rollback-publish", handler.RollbackPublish)
+	core.Get("/watched-drafts", handler.ListWatchedDrafts)
+	core.Post("/resolve-watch-conflict", handler.ResolveWatchConflict)
+	core.Get("/site-mode", handler.ShowModeSwitch)
+	core.Post("/switch-site-mode", handler.SwitchSiteMode)
 
 	// Section routes
 	core.Get("/new-section", handler.NewSection)
@@ -60,6 +76,11 @@ func NewWebRouter(handler *WebHandler, mw []hm.Middleware, params hm.XParams) *h
 	core.Get("/show-param", handler.ShowParam)
 	core.Post("/delete-param", handler.DeleteParam)
 
+	// Site settings routes
+	core.Get("/site-settings", handler.ShowSiteSettings)
+	core.Post("/update-site-settings", handler.UpdateSiteSettings)
+	core.Post("/reset-site-setting", handler.ResetSiteSetting)
+
 	// Image routes
 	core.Get("/new-image", handler.NewImage)
 	core.Post("/create-image", handler.CreateImage)
@@ -68,6 +89,18 @@ func NewWebRouter(handler *WebHandler, mw []hm.Middleware, params hm.XParams) *h
 	core.Get("/list-images", handler.ListImages)
 	core.Get("/show-image", handler.ShowImage)
 	core.Post("/delete-image", handler.DeleteImage)
+	core.Get("/list-orphan-images", handler.ListOrphanImages)
+	core.Post("/purge-orphan-images", handler.PurgeOrphanImages)
+
+	// Media Library routes
+	core.Get("/media-library", handler.ListMedia)
+	core.Get("/show-media", handler.ShowMedia)
+	core.Post("/update-media", handler.UpdateMedia)
+	core.Post("/rollback-media", handler.RollbackMedia)
+
+	// Attachment routes
+	core.Get("/list-attachments", handler.ListAttachments)
+	core.Post("/delete-attachment", handler.DeleteAttachment)
 
 	// Image Variant routes
 	core.Get("/images/:imageID/variants/new", handler.NewImageVariant)
@@ -31,6 +31,11 @@ const (
 var assetsFS embed.FS
 
 func main() {
+	// Git runs clio as its credential helper while publishing
+	if len(os.Args) > 1 && os.Args[1] == ssg.GitCredentialCommand {
+		os.Exit(ssg.RunGitCredentialHelper(os.Args[2:], os.Stdin, os.Stdout))
+	}
+
 	flag.Parse()
 
 	ctx := context.Background()
@@ -51,18 +56,28 @@ func main() {
 	adminFileServer := core.NewAdminFileServer(xparams)
 	apiRouter := hm.NewAPIRouter("api-router", xparams)
 	gitClient := github.NewClient(xparams)
-	ssgPublisher := ssg.NewPublisher(gitClient, xparams)
+	gitPublisher := ssg.NewPublisher(gitClient, xparams)
+	ssgPublisher := ssg.NewTargetPublisher(map[string]ssg.Publisher{
+		ssg.PublishTargetGitHub:  gitPublisher,
+		ssg.PublishTargetGit:     gitPublisher,
+		ssg.PublishTargetLocal:   ssg.NewLocalPublisher(xparams),
+		ssg.PublishTargetArchive: ssg.NewArchivePublisher(xparams),
+	}, xparams)
 	ssgGenerator := ssg.NewGenerator(xparams)
+	sourceRepo := ssg.NewSourceRepo(gitClient, xparams)
 	qm := hm.NewQueryManager(assetsFS, engine, xparams)
 	clioRepo := sqlite.NewClioRepo(qm, xparams)
-	siteManager := ssg.NewSiteManager(clioRepo, assetsFS, engine, xparams)
+	jobRunner := ssg.NewJobRunner(xparams)
+	siteManager := ssg.NewSiteManager(clioRepo, jobRunner, assetsFS, engine, xparams)
 	siteContextMw := ssg.NewSiteContextMw(sessionManager, siteManager, xparams)
 	authSeeder := auth.NewSeeder(assetsFS, engine, clioRepo, xparams)
 	ssgSeeder := ssg.NewSeeder(assetsFS, engine, clioRepo, xparams)
 	paramManager := ssg.NewParamManager(clioRepo, xparams)
-	imageManager := ssg.NewImageManager(xparams)
-	ssgAPIService := ssg.NewService(assetsFS, clioRepo, ssgGenerator, ssgPublisher, paramManager, imageManager, xparams)
-	ssgAPIHandler := ssg.NewAPIHandler("ssg-api-handler", ssgAPIService, siteManager, xparams)
+	imageManager := ssg.NewImageManager(paramManager, xparams)
+	attachmentManager := ssg.NewAttachmentManager(xparams)
+	ssgAPIService := ssg.NewService(assetsFS, clioRepo, ssgGenerator, sourceRepo, ssgPublisher, paramManager, imageManager, attachmentManager, xparams)
+	draftWatcher := ssg.NewDraftWatcher(ssgAPIService, siteManager, jobRunner, xparams)
+	ssgAPIHandler := ssg.NewAPIHandler("ssg-api-handler", ssgAPIService, siteManager, jobRunner, xparams)
 	ssgAPIRouter := ssg.NewAPIRouter(ssgAPIHandler, []hm.Middleware{hm.CORSMw, siteContextMw.APIHandler}, xparams)
 
 	authAPIHandler := auth.NewAPIHandler("auth-api-handler", clioRepo, xparams)
@@ -82,6 +97,9 @@ func main() {
 	app.Add(gitClient)
 	app.Add(ssgPublisher)
 	app.Add(ssgGenerator)
+	app.Add(sourceRepo)
+	app.Add(jobRunner)
+	app.Add(draftWatcher)
 	app.Add(apiRouter)
 	app.Add(authSeeder)
 	app.Add(ssgSeeder)
@@ -106,6 +124,7 @@ func main() {
 	}
 
 	app.Router.HandleFunc("/static/images/*", adminFileServer.Handler())
+	app.Router.HandleFunc("/static/attachments/*", adminFileServer.AttachmentsHandler())
 	app.MountAPI("/api/v1/auth", authAPIRouter)
 	app.MountAPI("/api/v1/ssg", ssgAPIRouter)
 	app.MountWeb("/ssg", ssgWebRouter)